@@ -1,9 +1,8 @@
 package api
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
@@ -13,6 +12,7 @@ import (
 	coreerrors "github.com/suared/core/errors"
 
 	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
 	"github.com/suared/core-apiuser/service"
 )
 
@@ -43,27 +43,93 @@ func SetupAppRoutes(router *mux.Router) {
 	* Get lifeapp life org category model - GET Lifeapp/Categories/myLife;  Returns CategoryUserModel
 	* Add a category  -  PATCH Lifeapp/Categories/myLife   <Category Object w/  Action>; Returns Success/Failure
 	* Delete a category - PATCH Lifeapp/Categories/myLife	<Category Object w/  Action>; Returns Success/Failure
-	* Move a category - PATCH Lifeapp/Categories/myLife 	<Category Object w/  Action>; Returns Success/Failure
+	* Move a category - PATCH Lifeapp/Categories/myLife 	<Category Object w/  Action>; Returns Success/Failure, 400 for a move under its own descendant
+	* Unknown categories - update, delete and move return 404 when the category id is not in the model
+	* Copy a category - PATCH Lifeapp/Categories/myLife 	<Category Object w/  Action>; Returns Success/Failure
+	* List category models - GET Lifeapp/Categories?limit=&cursor=; Returns CategoryModelList, pass the returned cursor for the next page
+	* Live model changes - GET Lifeapp/Categories/{modelID}/events; Server-Sent Events, resumes from Last-Event-ID
+	* Register a webhook - POST Lifeapp/Categories/{modelID}/webhooks   <CategoryWebhookRequest>; Returns CategoryWebhook with its signing secret
+	* List webhooks - GET Lifeapp/Categories/{modelID}/webhooks; Returns []CategoryWebhook
+	* Remove a webhook - DELETE Lifeapp/Categories/{modelID}/webhooks/{webhookID}; Returns Success/Failure
+	* Recent webhook deliveries - GET Lifeapp/Categories/{modelID}/webhooks/deliveries?limit=; Returns []CategoryWebhookDelivery, newest first
+	* Model audit log - GET Lifeapp/Categories/{modelID}/audit?from=&to=&limit=&cursor=; Returns CategoryAuditList, newest first, times are RFC3339
+	* Invite a user to a model - POST Lifeapp/Categories/{modelID}/invites   <CategoryInviteRequest>; Returns CategoryInvite with the token to send
+	* Accept an invite - POST Lifeapp/Categories/invites/accept   <CategoryInviteAccept>; Returns CategorySharedModel and the Location of the model
+	* List who a model is shared with - GET Lifeapp/Categories/{modelID}/shares; Returns []CategoryShare, owner only
+	* Remove a share - DELETE Lifeapp/Categories/{modelID}/shares/{userID}; the owner removes access, or the user leaves with ?owner=
+	* Models shared with me - GET Lifeapp/Categories/shared; Returns []CategorySharedModel
+	* Search a model - GET Lifeapp/Categories/{modelID}/search?q=&match=&caseSensitive=&maxDistance=&limit=; Returns []CategorySearchHit, best first.  match is exact, prefix, substring or fuzzy (default)
+	* Search all my models - GET Lifeapp/Categories/search?q=...; same parameters, Returns []CategorySearchHit with the model of each hit
+	* Shared models - add ?owner=<ownerID> to the lifeapp and {modelID} routes.  Viewers can read, editors can also change categories, other changes return 403
+	* Sibling title policy - PUT Lifeapp/Categories/{modelID}/titlePolicy   <CategoryTitlePolicy>; uniqueness allow, reject (409 on duplicates) or suffix, with ignoreCase and normalize.  Enforced on add, update, move, copy and import
+	* Model size limits - GET Lifeapp/Categories/{modelID}/limits; Returns CategoryLimits in effect.  PUT <CategoryLimits> sets the model's own limits within the deployment limits, changes over a limit return 400
+	* Storage usage - GET Lifeapp/Categories/usage; Returns CategoryUsage with the quotas, 0 is unlimited.  Changes over a quota return 403
+	* Rate limits - changes (not GETs) over PROCESS_CATEGORY_RATE_LIMIT per second return 429 with Retry-After
+	* Safe retries - send an Idempotency-Key header on any change, a retry with the same key and request replays the first response with Idempotent-Replayed: true.  The same key with a different request returns 422, 409 while the first is running.  Server errors and 409s are not replayed
+	* Get any category model - GET Lifeapp/Categories/{modelID}; Returns CategoryUserModel, Accept header or ?format= selects json, markdown, opml or csv
+	* Import a new category model - POST Lifeapp/Categories   <File in Content-Type or ?format= format>; Returns Location of the new model
+	* Merge into a category model - POST Lifeapp/Categories/{modelID}/import   <File in Content-Type or ?format= format>; Returns Success/Failure
+	* List starter templates - GET Lifeapp/Categories/templates; Returns []CategoryTemplate
+	* Create a category model from a template - POST Lifeapp/Categories/templates/{templateName}?name=; Returns Location of the new model
+	* API description - GET /openapi.json; Returns the OpenAPI 3 document for these routes, add new routes to categoryOpenAPIRoutes
+	* GraphQL - POST /graphql   <{query, operationName, variables}>; queries and mutations over models and categories, nested at most PROCESS_CATEGORY_GRAPHQL_MAX_DEPTH deep.  GET /graphql?query= runs queries, without a query Returns the schema
 	 */
 
+	router.Use(categoryCacheMiddleware)
+	router.Use(categoryOwnerMiddleware)
+	router.Use(categoryRateLimitMiddleware)
+	router.Use(categoryIdempotencyMiddleware)
+
 	urlToHandle := relPathCategory + "/lifeapp" //  -->  lifeApp/categories/lifeapp
 	router.HandleFunc(urlToHandle, getLifeCategoryModel).Methods("GET")
 	router.HandleFunc(relPathCategory+"/lifeappList", getLifeCategoryList).Methods("GET")
 	router.HandleFunc(urlToHandle, patchCategoryLifeModel).Methods("PATCH")
+	router.HandleFunc(relPathCategory+"/templates", getCategoryTemplates).Methods("GET")
+	router.HandleFunc(relPathCategory+"/templates/{templateName}", postCategoryTemplateModel).Methods("POST")
+	router.HandleFunc(relPathCategory+"/shared", getSharedCategoryModels).Methods("GET")
+	router.HandleFunc(relPathCategory+"/search", getAllCategorySearch).Methods("GET")
+	router.HandleFunc(relPathCategory+"/usage", getCategoryUsage).Methods("GET")
+	router.HandleFunc(relPathCategory+"/invites/accept", postCategoryInviteAccept).Methods("POST")
+
+	//Generic model routes are registered last so the named lifeapp routes above take precedence
+	router.HandleFunc(relPathCategory, getCategoryModels).Methods("GET")
+	router.HandleFunc(relPathCategory, postCategoryImport).Methods("POST")
+	router.HandleFunc(relPathCategory+"/{modelID}", getCategoryModel).Methods("GET")
+	router.HandleFunc(relPathCategory+"/{modelID}/events", getCategoryModelEvents).Methods("GET")
+	router.HandleFunc(relPathCategory+"/{modelID}/webhooks", postCategoryWebhook).Methods("POST")
+	router.HandleFunc(relPathCategory+"/{modelID}/webhooks", getCategoryWebhooks).Methods("GET")
+	router.HandleFunc(relPathCategory+"/{modelID}/webhooks/deliveries", getCategoryWebhookDeliveries).Methods("GET")
+	router.HandleFunc(relPathCategory+"/{modelID}/webhooks/{webhookID}", deleteCategoryWebhook).Methods("DELETE")
+	router.HandleFunc(relPathCategory+"/{modelID}/audit", getCategoryAudit).Methods("GET")
+	router.HandleFunc(relPathCategory+"/{modelID}/search", getCategorySearch).Methods("GET")
+	router.HandleFunc(relPathCategory+"/{modelID}/titlePolicy", putCategoryTitlePolicy).Methods("PUT")
+	router.HandleFunc(relPathCategory+"/{modelID}/limits", getCategoryLimits).Methods("GET")
+	router.HandleFunc(relPathCategory+"/{modelID}/limits", putCategoryLimits).Methods("PUT")
+	router.HandleFunc(relPathCategory+"/{modelID}/invites", postCategoryInvite).Methods("POST")
+	router.HandleFunc(relPathCategory+"/{modelID}/shares", getCategoryShares).Methods("GET")
+	router.HandleFunc(relPathCategory+"/{modelID}/shares/{userID}", deleteCategoryShare).Methods("DELETE")
+	router.HandleFunc(relPathCategory+"/{modelID}/import", postCategoryMerge).Methods("POST")
+	router.HandleFunc(os.Getenv("PROCESS_RELATIVE_PATH")+"/openapi.json", getCategoryOpenAPI).Methods("GET")
+	router.HandleFunc(categoryGraphQLPath(), serveCategoryGraphQL).Methods("GET", "POST")
 }
 
 //API Request object(s)
 
-//CategoryActions - Defines the patch object expected when interacting with life app category actions
-//Operation is required, one of:  ADD, MOVE, DELETE, UPDATE
-//ParentID - Required for Add and Move.
+//CategoryActions - Defines the patch object expected when interacting with life app category actions, checked by Validate
+//Operation is required, one of:  ADD, MOVE, DELETE, UPDATE, COPY
+//ParentID - Optional for ADD, MOVE and COPY, empty is the top level.  Not allowed for other operations
 //ID - Required for All Actions
-//Title - Required for ADD and UPDATE
+//Title - Required for ADD and UPDATE, not allowed for other operations
+//TargetModelID - Optional for COPY, defaults to this model
+//RenameCopy - Optional for COPY, prefixes the copied title with "Copy of "
+//Unknown fields are refused
 type CategoryActions struct {
-	Operation string `json:"operation"` //Required for All Actions - Add, Move, Delete, Update
-	ParentID  string `json:"parentID"`  //Add = parent ID, Move = New Parent ID
-	ID        string `json:"id"`        //Required for All Actions
-	Title     string `json:"title"`     //Required for Add, Update
+	Operation     string `json:"operation"`               //Required for All Actions - Add, Move, Delete, Update, Copy
+	ParentID      string `json:"parentID"`                //Add = parent ID, Move = New Parent ID, Copy = Target Parent ID
+	ID            string `json:"id"`                      //Required for All Actions
+	Title         string `json:"title"`                   //Required for Add, Update
+	TargetModelID string `json:"targetModelID,omitempty"` //Copy only
+	RenameCopy    bool   `json:"renameCopy,omitempty"`    //Copy only
 }
 
 //repository.CategoryUserModel is the other API object that will be used
@@ -78,7 +144,7 @@ func getLifeCategoryModel(w http.ResponseWriter, r *http.Request) {
 		apiErr := getCategoryError(r, "get", err)
 		coreapi.WriteGetAPIResponse(ctx, w, r, categories, apiErr)
 	} else {
-		coreapi.WriteGetAPIResponse(ctx, w, r, categories, nil)
+		writeCategoryModelResponse(ctx, w, r, categories)
 	}
 }
 
@@ -102,17 +168,10 @@ func getLifeCategoryList(w http.ResponseWriter, r *http.Request) {
 func patchCategoryLifeModel(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	//First - Get the payload action object
-	categoryAction := &CategoryActions{}
-	byteMessage, _ := ioutil.ReadAll(r.Body)
-
-	//log.Printf("TaskMessagePatch Body contains: %v", string(byteMessage))
-
-	err := json.Unmarshal(byteMessage, categoryAction)
-
-	if err != nil || categoryAction.ID == "" {
-		apiErr := coreerrors.NewClientError("Body of message sent does not meet the category action structure (1):" + err.Error())
-		coreapi.WritePatchAPIResponse(ctx, w, r, apiErr)
+	//First - Get the payload action object, validated for its operation
+	categoryAction, err := readCategoryActions(w, r)
+	if err != nil {
+		writeCategoryRequestError(w, r, err)
 		return
 	}
 
@@ -120,28 +179,39 @@ func patchCategoryLifeModel(w http.ResponseWriter, r *http.Request) {
 	if categoryAction.Operation == "UPDATE" {
 		err = categoryService.UpdateCategory(ctx, myLifeCategoryUserModelID, model.Category{ID: categoryAction.ID, Title: categoryAction.Title})
 		if err != nil {
-			apiErr := coreerrors.NewClientError(fmt.Sprintf("Update failed during Category Patch Request: %v", err))
+			apiErr := getCategoryPatchError("Update failed during Category Patch Request: %v", err)
 			coreapi.WritePatchAPIResponse(ctx, w, r, apiErr)
 			return
 		}
 	} else if categoryAction.Operation == "MOVE" {
 		err = categoryService.MoveCategory(ctx, myLifeCategoryUserModelID, categoryAction.ParentID, categoryAction.ID)
 		if err != nil {
-			apiErr := coreerrors.NewClientError(fmt.Sprintf("Update failed during Category Patch Delete Request: %v", err))
+			apiErr := getCategoryPatchError("Update failed during Category Patch Delete Request: %v", err)
 			coreapi.WritePatchAPIResponse(ctx, w, r, apiErr)
 			return
 		}
 	} else if categoryAction.Operation == "ADD" {
 		err = categoryService.AddCategory(ctx, myLifeCategoryUserModelID, categoryAction.ParentID, model.Category{ID: categoryAction.ID, Title: categoryAction.Title})
 		if err != nil {
-			apiErr := coreerrors.NewClientError(fmt.Sprintf("Update failed during Category Patch Delete Request: %v", err))
+			apiErr := getCategoryPatchError("Update failed during Category Patch Delete Request: %v", err)
+			coreapi.WritePatchAPIResponse(ctx, w, r, apiErr)
+			return
+		}
+	} else if categoryAction.Operation == "COPY" {
+		targetModelID := categoryAction.TargetModelID
+		if targetModelID == lifeappModelName {
+			targetModelID = myLifeCategoryUserModelID
+		}
+		_, err = categoryService.CopyCategory(ctx, myLifeCategoryUserModelID, categoryAction.ID, targetModelID, categoryAction.ParentID, categoryAction.RenameCopy)
+		if err != nil {
+			apiErr := getCategoryPatchError("Update failed during Category Patch Copy Request: %v", err)
 			coreapi.WritePatchAPIResponse(ctx, w, r, apiErr)
 			return
 		}
 	} else if categoryAction.Operation == "DELETE" {
 		err = categoryService.DeleteCategory(ctx, myLifeCategoryUserModelID, categoryAction.ID)
 		if err != nil {
-			apiErr := coreerrors.NewClientError(fmt.Sprintf("Update failed during Category Patch Delete Request: %v", err))
+			apiErr := getCategoryPatchError("Update failed during Category Patch Delete Request: %v", err)
 			coreapi.WritePatchAPIResponse(ctx, w, r, apiErr)
 			return
 		}
@@ -153,6 +223,36 @@ func patchCategoryLifeModel(w http.ResponseWriter, r *http.Request) {
 
 }
 
+//getCategoryPatchError - patch failures are reported as client errors, except a role that does not allow the change, an unknown category
+//(404), a duplicate title and a concurrent change (409) and a model too large to store (413)
+func getCategoryPatchError(format string, err error) error {
+	if errors.Is(err, repository.ErrForbidden) {
+		return newForbiddenError(err.Error())
+	}
+	if titleErr := getCategoryTitleError(err); titleErr != nil {
+		return titleErr
+	}
+	if limitErr := getCategoryLimitError(err); limitErr != nil {
+		return limitErr
+	}
+	if errors.Is(err, repository.ErrQuotaExceeded) {
+		return newForbiddenError(err.Error())
+	}
+	if errors.Is(err, model.ErrCategoryNotFound) {
+		return newNotFoundError(err.Error())
+	}
+	if errors.Is(err, model.ErrCategoryMoveCycle) {
+		return coreerrors.NewClientError(err.Error())
+	}
+	if errors.Is(err, repository.ErrCategoryConflict) {
+		return newConflictError(err.Error())
+	}
+	if errors.Is(err, repository.ErrCategoryTooLarge) {
+		return newRequestTooLargeError(err.Error())
+	}
+	return coreerrors.NewClientError(fmt.Sprintf(format, err))
+}
+
 func getCategoryError(r *http.Request, when string, err error) error {
 	//To start will always assume a system error and build up library of user errors over time
 	//If known up front, the appropriate message will be created by api code, if common will add translator here
@@ -160,6 +260,30 @@ func getCategoryError(r *http.Request, when string, err error) error {
 	if ok {
 		return err
 	}
+	if shareErr := getCategoryShareError(err); shareErr != nil {
+		return shareErr
+	}
+	if titleErr := getCategoryTitleError(err); titleErr != nil {
+		return titleErr
+	}
+	if limitErr := getCategoryLimitError(err); limitErr != nil {
+		return limitErr
+	}
+	if errors.Is(err, repository.ErrQuotaExceeded) {
+		return newForbiddenError(err.Error())
+	}
+	if errors.Is(err, model.ErrCategoryNotFound) {
+		return newNotFoundError(err.Error())
+	}
+	if errors.Is(err, model.ErrCategoryMoveCycle) {
+		return coreerrors.NewClientError(err.Error())
+	}
+	if errors.Is(err, repository.ErrCategoryConflict) {
+		return newConflictError(err.Error())
+	}
+	if errors.Is(err, repository.ErrCategoryTooLarge) {
+		return newRequestTooLargeError(err.Error())
+	}
 	apiError := coreerrors.NewError(err)
 	return apiError
 }
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+)
+
+//MaxCategoryActionBytes - larger PATCH bodies are refused with 413, an action is a few hundred bytes
+const MaxCategoryActionBytes = 16 * 1024
+
+//Category action operations
+const (
+	CategoryOperationAdd    = "ADD"
+	CategoryOperationMove   = "MOVE"
+	CategoryOperationDelete = "DELETE"
+	CategoryOperationUpdate = "UPDATE"
+	CategoryOperationCopy   = "COPY"
+)
+
+//categoryActionFields - the optional fields each operation uses, operation and id are always required.  Other fields must be empty
+var categoryActionFields = map[string]map[string]bool{
+	CategoryOperationAdd:    {"parentID": true, "title": true},
+	CategoryOperationMove:   {"parentID": true},
+	CategoryOperationDelete: {},
+	CategoryOperationUpdate: {"title": true},
+	CategoryOperationCopy:   {"parentID": true, "targetModelID": true, "renameCopy": true},
+}
+
+//CategoryFieldError - one invalid field of a request, Field is the JSON name and empty when the body itself is invalid
+type CategoryFieldError struct {
+	Field   string
+	Message string
+}
+
+//CategoryValidationError - a 400 response listing each invalid field.  ErrorType and DeveloperMessage match the core error so it reads like any other error
+type CategoryValidationError struct {
+	ErrorType        int
+	DeveloperMessage string
+	Fields           []CategoryFieldError
+}
+
+func (err *CategoryValidationError) Error() string {
+	return err.DeveloperMessage
+}
+
+func newCategoryValidationError(fields []CategoryFieldError) *CategoryValidationError {
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+		if field.Field != "" {
+			messages[i] = field.Field + " " + field.Message
+		}
+	}
+	return &CategoryValidationError{
+		ErrorType:        http.StatusBadRequest,
+		DeveloperMessage: "Invalid category request: " + strings.Join(messages, ", "),
+		Fields:           fields,
+	}
+}
+
+//newRequestTooLargeError - core errors has no 413 constructor yet
+func newRequestTooLargeError(err string) error {
+	return coreerrors.Error{ErrorType: http.StatusRequestEntityTooLarge,
+		DeveloperMessage: err}
+}
+
+//Validate - the field errors for the operation's rules, empty when the action is valid.  A title only needs to be set,
+//its length is checked against the model's limits by the service
+func (action *CategoryActions) Validate() []CategoryFieldError {
+	var fields []CategoryFieldError
+	allowed, known := categoryActionFields[action.Operation]
+	switch {
+	case action.Operation == "":
+		fields = append(fields, CategoryFieldError{Field: "operation", Message: "is required"})
+	case !known:
+		fields = append(fields, CategoryFieldError{Field: "operation", Message: "must be one of ADD, MOVE, DELETE, UPDATE or COPY"})
+	}
+	if strings.TrimSpace(action.ID) == "" {
+		fields = append(fields, CategoryFieldError{Field: "id", Message: "is required"})
+	}
+	if !known {
+		return fields
+	}
+
+	if allowed["title"] && strings.TrimSpace(action.Title) == "" {
+		fields = append(fields, CategoryFieldError{Field: "title", Message: "is required for " + action.Operation})
+	}
+	if action.ParentID != "" && action.ParentID == action.ID && action.Operation != CategoryOperationCopy {
+		fields = append(fields, CategoryFieldError{Field: "parentID", Message: "must not be the category itself"})
+	}
+	set := map[string]bool{
+		"parentID":      action.ParentID != "",
+		"title":         action.Title != "",
+		"targetModelID": action.TargetModelID != "",
+		"renameCopy":    action.RenameCopy,
+	}
+	for _, name := range []string{"parentID", "title", "targetModelID", "renameCopy"} {
+		if set[name] && !allowed[name] {
+			fields = append(fields, CategoryFieldError{Field: name, Message: "is not used by " + action.Operation})
+		}
+	}
+	return fields
+}
+
+//readCategoryActions - decodes the PATCH body, refusing unknown fields, trailing data and bodies over MaxCategoryActionBytes, then validates it.
+//The error is ready to write with writeCategoryRequestError
+func readCategoryActions(w http.ResponseWriter, r *http.Request) (*CategoryActions, error) {
+	action := &CategoryActions{}
+	err := readCategoryJSON(w, r, action, MaxCategoryActionBytes)
+	if err != nil {
+		return nil, err
+	}
+	if fields := action.Validate(); len(fields) > 0 {
+		return nil, newCategoryValidationError(fields)
+	}
+	return action, nil
+}
+
+//readCategoryJSON - decodes a JSON body into value, refusing unknown fields, trailing data and bodies over maxBytes.  The error is ready
+//to write with writeCategoryRequestError
+func readCategoryJSON(w http.ResponseWriter, r *http.Request, value interface{}, maxBytes int64) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBytes))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(value)
+	if err == nil && decoder.Decode(&json.RawMessage{}) != io.EOF {
+		err = errors.New("body must be a single JSON value")
+	}
+	if err != nil {
+		return categoryDecodeError(err, maxBytes)
+	}
+	return nil
+}
+
+//categoryDecodeError - the field a JSON decode error is about where encoding/json reports it
+func categoryDecodeError(err error, maxBytes int64) error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxErr):
+		return newRequestTooLargeError(fmt.Sprintf("Request body must be at most %v bytes", maxBytes))
+	case err == io.EOF:
+		return newCategoryValidationError([]CategoryFieldError{{Message: "body is required"}})
+	case errors.As(err, &typeErr):
+		return newCategoryValidationError([]CategoryFieldError{{Field: typeErr.Field, Message: "must be a " + typeErr.Type.String()}})
+	case errors.As(err, &syntaxErr):
+		return newCategoryValidationError([]CategoryFieldError{{Message: fmt.Sprintf("body is not valid JSON at offset %v", syntaxErr.Offset)}})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return newCategoryValidationError([]CategoryFieldError{{Field: field, Message: "is not a known field"}})
+	}
+	return newCategoryValidationError([]CategoryFieldError{{Message: err.Error()}})
+}
+
+//writeCategoryRequestError - validation errors keep their field details, core only writes its own error type
+func writeCategoryRequestError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr *CategoryValidationError
+	if !errors.As(err, &validationErr) {
+		coreapi.WritePatchAPIResponse(r.Context(), w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(validationErr.ErrorType)
+	json.NewEncoder(w).Encode(validationErr)
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	coreapi "github.com/suared/core/api"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+func TestCategoryActionsValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		field  string
+	}{
+		{"add", `{"operation":"ADD","id":"a","title":"Work"}`, 0, ""},
+		{"add under a parent", `{"operation":"ADD","parentID":"p","id":"a","title":"Work"}`, 0, ""},
+		{"empty strings are unset", `{"operation":"DELETE","parentID":"","id":"a","title":""}`, 0, ""},
+		{"copy", `{"operation":"COPY","id":"a","targetModelID":"m","renameCopy":true}`, 0, ""},
+		{"empty body", ``, http.StatusBadRequest, ""},
+		{"not json", `{"operation":`, http.StatusBadRequest, ""},
+		{"two actions", `{"operation":"DELETE","id":"a"}{"operation":"DELETE","id":"b"}`, http.StatusBadRequest, ""},
+		{"missing id", `{"operation":"DELETE"}`, http.StatusBadRequest, "id"},
+		{"missing operation", `{"id":"a"}`, http.StatusBadRequest, "operation"},
+		{"unknown operation", `{"operation":"RENAME","id":"a"}`, http.StatusBadRequest, "operation"},
+		{"lower case operation", `{"operation":"add","id":"a","title":"Work"}`, http.StatusBadRequest, "operation"},
+		{"add without title", `{"operation":"ADD","id":"a"}`, http.StatusBadRequest, "title"},
+		{"update with blank title", `{"operation":"UPDATE","id":"a","title":"  "}`, http.StatusBadRequest, "title"},
+		{"title on move", `{"operation":"MOVE","id":"a","parentID":"p","title":"Work"}`, http.StatusBadRequest, "title"},
+		{"move under itself", `{"operation":"MOVE","id":"a","parentID":"a"}`, http.StatusBadRequest, "parentID"},
+		{"copy options on add", `{"operation":"ADD","id":"a","title":"Work","renameCopy":true}`, http.StatusBadRequest, "renameCopy"},
+		{"unknown field", `{"operation":"DELETE","id":"a","parent":"p"}`, http.StatusBadRequest, "parent"},
+		{"wrong type", `{"operation":"DELETE","id":7}`, http.StatusBadRequest, "id"},
+		{"too large", `{"operation":"ADD","id":"a","title":"` + strings.Repeat("x", MaxCategoryActionBytes) + `"}`, http.StatusRequestEntityTooLarge, ""},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("PATCH", "/categories/lifeapp", strings.NewReader(test.body))
+		w := httptest.NewRecorder()
+		action, err := readCategoryActions(w, r)
+		if test.status == 0 {
+			if err != nil || action == nil {
+				t.Errorf("%v: expected a valid action, received: %v", test.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%v: expected an error", test.name)
+			continue
+		}
+		writeCategoryRequestError(w, r, err)
+		if w.Code != test.status {
+			t.Errorf("%v: expected status %v, received: %v %v", test.name, test.status, w.Code, w.Body.String())
+		}
+		var validationErr *CategoryValidationError
+		if test.field != "" && (!errors.As(err, &validationErr) || validationErr.Fields[0].Field != test.field) {
+			t.Errorf("%v: expected an error for %v, received: %v", test.name, test.field, err)
+		}
+		if test.status == http.StatusBadRequest && !strings.Contains(w.Body.String(), `"Fields":[`) {
+			t.Errorf("%v: expected the field details in the response, received: %v", test.name, w.Body.String())
+		}
+	}
+}
+
+func TestCategoryInviteValidation(t *testing.T) {
+	tests := []struct {
+		body  string
+		field string
+	}{
+		{`{"role":"viewer","expiresIn":"24h"}`, ""},
+		{`{"expiresIn":"24h"}`, "role"},
+		{`{"role":"viewer","expiresIn":"-1h"}`, "expiresIn"},
+		{`{"role":"viewer","expires":"24h"}`, "expires"},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/categories/m/invites", strings.NewReader(test.body))
+		_, _, err := readCategoryInvite(httptest.NewRecorder(), r)
+		var validationErr *CategoryValidationError
+		if test.field == "" && err != nil {
+			t.Errorf("%v: expected a valid invite, received: %v", test.body, err)
+		} else if test.field != "" && (!errors.As(err, &validationErr) || validationErr.Fields[0].Field != test.field) {
+			t.Errorf("%v: expected an error for %v, received: %v", test.body, test.field, err)
+		}
+	}
+}
+
+func TestCategoryPatchErrors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{fmt.Errorf("Service Update Category a Failed with: %w", model.ErrCategoryNotFound), http.StatusNotFound},
+		{fmt.Errorf("Service Move Category a under b Failed with: %w", model.ErrCategoryMoveCycle), http.StatusBadRequest},
+		{fmt.Errorf("Category Model move failed with: %w", repository.ErrCategoryConflict), http.StatusConflict},
+		{errors.New("Service Update Category Model not found"), http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("PATCH", "/categories/lifeapp", nil)
+		w := httptest.NewRecorder()
+		coreapi.WritePatchAPIResponse(r.Context(), w, r, getCategoryPatchError("Update failed during Category Patch Request: %v", test.err))
+		if w.Code != test.status {
+			t.Errorf("Expected status %v for %v, received: %v", test.status, test.err, w.Code)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+
+	"github.com/suared/core-apiuser/repository"
+)
+
+//CategoryAuditEntry - one change to the model.  Old values are set when the change replaced them
+type CategoryAuditEntry struct {
+	ID          string    `json:"id"`
+	OccurredAt  time.Time `json:"occurredAt"`
+	ActorID     string    `json:"actorID"`
+	Operation   string    `json:"operation"`
+	ModelID     string    `json:"modelID"`
+	CategoryID  string    `json:"categoryID,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	OldTitle    string    `json:"oldTitle,omitempty"`
+	ParentID    string    `json:"parentID,omitempty"`
+	OldParentID string    `json:"oldParentID,omitempty"`
+}
+
+//CategoryAuditList - one page of the audit log, newest first.  Cursor is omitted on the last page
+type CategoryAuditList struct {
+	Records []CategoryAuditEntry `json:"records"`
+	Cursor  string               `json:"cursor,omitempty"`
+}
+
+//parseAuditTime - an empty value leaves the range open
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+//GET /Lifeapp/Categories/{modelID}/audit?from=&to=&limit=&cursor=
+func getCategoryAudit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	from, err := parseAuditTime(query.Get("from"))
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, coreerrors.NewClientError("from must be an RFC3339 time such as 2021-01-02T15:04:05Z"))
+		return
+	}
+	to, err := parseAuditTime(query.Get("to"))
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, coreerrors.NewClientError("to must be an RFC3339 time such as 2021-01-02T15:04:05Z"))
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, coreerrors.NewClientError("to must not be before from"))
+		return
+	}
+	var limit int
+	if limitParam := query.Get("limit"); limitParam != "" {
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 || limit > repository.MaxPageLimit {
+			apiErr := coreerrors.NewClientError("limit must be a number from 1 to " + strconv.Itoa(repository.MaxPageLimit))
+			coreapi.WriteGetAPIResponse(ctx, w, r, nil, apiErr)
+			return
+		}
+	}
+
+	page, err := categoryService.ListAuditRecords(ctx, getCategoryModelID(r), from, to, limit, query.Get("cursor"))
+	if err != nil {
+		var apiErr error
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			apiErr = coreerrors.NewClientError("cursor is not valid, start again without a cursor")
+		} else {
+			apiErr = getCategoryError(r, "audit", err)
+		}
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, apiErr)
+		return
+	}
+
+	list := CategoryAuditList{Records: []CategoryAuditEntry{}, Cursor: page.Cursor}
+	for _, event := range page.Records {
+		list.Records = append(list.Records, CategoryAuditEntry{
+			ID:          event.ID,
+			OccurredAt:  event.OccurredAt,
+			ActorID:     event.ActorID,
+			Operation:   string(event.Type),
+			ModelID:     event.ModelID,
+			CategoryID:  event.CategoryID,
+			Title:       event.Title,
+			OldTitle:    event.OldTitle,
+			ParentID:    event.ParentID,
+			OldParentID: event.OldParentID,
+		})
+	}
+	coreapi.WriteGetAPIResponse(ctx, w, r, list, nil)
+}
@@ -0,0 +1,12 @@
+package api
+
+import (
+	"net/http"
+)
+
+//categoryCacheMiddleware - gives each request its own model cache when the cache scope is invocation (Lambda), a no-op otherwise
+func categoryCacheMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(categoryService.WithInvocationCache(r.Context())))
+	})
+}
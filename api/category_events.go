@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+
+	"github.com/suared/core-apiuser/model"
+)
+
+//sseHeartbeat - comment lines keep proxies from closing an idle stream, set with PROCESS_CATEGORY_SSE_HEARTBEAT
+var sseHeartbeat = 5 * time.Second
+
+//sseMaxDuration - the core http listener has a 15 second write timeout, so each stream ends before it and the client reconnects with Last-Event-ID.
+//Set with PROCESS_CATEGORY_SSE_MAX_DURATION
+var sseMaxDuration = 14 * time.Second
+
+//sseRetry - reconnect delay sent to the client in milliseconds
+const sseRetry = 1000
+
+//sseResetEvent - sent when the Last-Event-ID is no longer buffered, the client should reload the model
+const sseResetEvent = "reset"
+
+func init() {
+	if heartbeat, err := time.ParseDuration(os.Getenv("PROCESS_CATEGORY_SSE_HEARTBEAT")); err == nil && heartbeat > 0 {
+		sseHeartbeat = heartbeat
+	}
+	if maxDuration, err := time.ParseDuration(os.Getenv("PROCESS_CATEGORY_SSE_MAX_DURATION")); err == nil && maxDuration > 0 {
+		sseMaxDuration = maxDuration
+	}
+}
+
+//writeSSEEvent - writes one category event, the event id is used by the client for Last-Event-ID
+func writeSSEEvent(w http.ResponseWriter, event model.CategoryEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %v\nevent: %v\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+//GET /Lifeapp/Categories/{modelID}/events - Server-Sent Events of changes made to the model through this server.  Resumes from the Last-Event-ID header or ?lastEventId=
+func getCategoryModelEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	modelID := getCategoryModelID(r)
+
+	//confirms the model exists and belongs to the caller before streaming
+	categories, err := categoryService.GetCategoryModel(ctx, modelID)
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, getCategoryError(r, "events", err))
+		return
+	}
+	if categories.ID == "" {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, newNotFoundError("Category model not found: "+modelID))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, coreerrors.NewError(fmt.Errorf("Streaming is not supported by this server")))
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	missed, resumed, events, cancel := categoryService.SubscribeCategoryEvents(ctx, modelID, lastEventID)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %v\n\n", sseRetry)
+	if !resumed {
+		fmt.Fprintf(w, "event: %v\ndata: {\"modelID\":%q}\n\n", sseResetEvent, categories.ID)
+	}
+	for _, event := range missed {
+		if writeSSEEvent(w, event) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	deadline := time.NewTimer(sseMaxDuration)
+	defer deadline.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case event, open := <-events:
+			if !open {
+				//fell behind, the client reconnects and resumes from its last event
+				return
+			}
+			err = writeSSEEvent(w, event)
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+//MaxCategoryImportBytes - larger import bodies are refused with 413, well above the largest model that can be stored
+const MaxCategoryImportBytes = 8 * 1024 * 1024
+
+//lifeappModelName - path alias for the lifeapp model on the generic {modelID} routes
+const lifeappModelName = "lifeapp"
+
+//getCategoryModelID - returns the model id from the route, translating the lifeapp alias
+func getCategoryModelID(r *http.Request) string {
+	modelID := mux.Vars(r)["modelID"]
+	if modelID == lifeappModelName {
+		return myLifeCategoryUserModelID
+	}
+	return modelID
+}
+
+//newNotFoundError - core errors has no not found constructor yet
+func newNotFoundError(err string) error {
+	return coreerrors.Error{ErrorType: coreerrors.StatusNotFound,
+		DeveloperMessage: err}
+}
+
+//GET /Lifeapp/Categories/{modelID}
+func getCategoryModel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	modelID := getCategoryModelID(r)
+	categories, err := categoryService.GetCategoryModel(ctx, modelID)
+	if err != nil {
+		apiErr := getCategoryError(r, "get", err)
+		coreapi.WriteGetAPIResponse(ctx, w, r, categories, apiErr)
+		return
+	}
+	if categories.ID == "" {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, newNotFoundError("Category model not found: "+modelID))
+		return
+	}
+	writeCategoryModelResponse(ctx, w, r, categories)
+}
+
+//POST /Lifeapp/Categories?name=
+func postCategoryImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	imported, err := readCategoryImport(w, r)
+	if err != nil {
+		coreapi.WritePostAPIResponse(ctx, w, r, "", err)
+		return
+	}
+	catModel, err := categoryService.ImportCategoryModel(ctx, r.URL.Query().Get("name"), imported)
+	if err != nil {
+		coreapi.WritePostAPIResponse(ctx, w, r, "", getCategoryError(r, "import", err))
+		return
+	}
+	coreapi.WritePostAPIResponse(ctx, w, r, relPathCategory+"/"+catModel.ID, nil)
+}
+
+//POST /Lifeapp/Categories/{modelID}/import
+func postCategoryMerge(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	imported, err := readCategoryImport(w, r)
+	if err != nil {
+		coreapi.WritePostAPIResponse(ctx, w, r, "", err)
+		return
+	}
+	err = categoryService.MergeCategoryModel(ctx, getCategoryModelID(r), imported)
+	if err != nil {
+		apiErr := getCategoryPatchError("Merge failed during Category Import Request: %v", err)
+		coreapi.WritePostAPIResponse(ctx, w, r, "", apiErr)
+		return
+	}
+	coreapi.WritePostAPIResponse(ctx, w, r, relPathCategory+"/"+mux.Vars(r)["modelID"], nil)
+}
+
+//negotiateCategoryFormat - ?format= wins, otherwise the first supported Accept entry.  Defaults to json
+func negotiateCategoryFormat(r *http.Request) model.CategoryFormat {
+	if format, ok := model.CategoryFormatFromName(r.URL.Query().Get("format")); ok {
+		return format
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if format, ok := model.CategoryFormatFromMediaType(strings.TrimSpace(accept)); ok {
+			return format
+		}
+	}
+	return model.FormatJSON
+}
+
+//writeCategoryModelResponse - writes the model in the negotiated format, json uses the standard response writer
+func writeCategoryModelResponse(ctx context.Context, w http.ResponseWriter, r *http.Request, categories *repository.CategoryUserModel) {
+	format := negotiateCategoryFormat(r)
+	if format == model.FormatJSON {
+		coreapi.WriteGetAPIResponse(ctx, w, r, categories, nil)
+		return
+	}
+	data, err := model.ExportCategoryRoot(&categories.CategoryRoot, format)
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, getCategoryError(r, "export", err))
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Write(data)
+}
+
+//readCategoryImport - reads the request body in the ?format= or Content-Type format, errors are client errors ready to write
+func readCategoryImport(w http.ResponseWriter, r *http.Request) (*model.CategoryRoot, error) {
+	format, ok := model.CategoryFormatFromName(r.URL.Query().Get("format"))
+	if !ok {
+		format, ok = model.CategoryFormatFromMediaType(r.Header.Get("Content-Type"))
+	}
+	if !ok {
+		return nil, coreerrors.NewClientError("Unsupported import format, use json, markdown, opml or csv via Content-Type or ?format=")
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxCategoryImportBytes))
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return nil, newRequestTooLargeError(fmt.Sprintf("Import body must be at most %v bytes", MaxCategoryImportBytes))
+	}
+	if err != nil {
+		return nil, coreerrors.NewClientError(fmt.Sprintf("Unable to read import body: %v", err))
+	}
+	imported, err := model.ImportCategoryRoot(data, format)
+	if err != nil {
+		return nil, coreerrors.NewClientError(fmt.Sprintf("Import body is not valid %v: %v", format, err))
+	}
+	return imported, nil
+}
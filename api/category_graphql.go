@@ -0,0 +1,493 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+/*
+GraphQL over the category service, so a client can fetch the slice of a tree it needs in one request, e.g. only two levels of titles
+or one subtree with its ancestors.  Resolvers call the same service methods as the REST routes, so sharing, limits, title policies,
+quotas and events work the same.  The schema is built with graphql-go, which supports introspection.  Queries are refused before anything
+is read when they nest deeper than categoryGraphQLMaxDepth, use Model.descendants(maxDepth:) for deep trees rather than nesting children.
+GET /graphql without a query returns the schema in SDL, see category_graphql_http.go for the route
+*/
+
+var categoryGraphQLSchema = newCategoryGraphQLSchema()
+
+//categoryGraphModel - a model with the owner argument it was read with, nested fields read the same owner's model
+type categoryGraphModel struct {
+	owner string
+	model *repository.CategoryUserModel
+}
+
+//categoryGraphCategory - a category with the categories above it, top level first
+type categoryGraphCategory struct {
+	model     *categoryGraphModel
+	category  *model.Category
+	ancestors []*model.Category
+}
+
+//categoryGraphHit - a search result, the model is read when the hit's category is selected
+type categoryGraphHit struct {
+	modelID   string
+	modelName string
+	owner     string
+	result    model.CategorySearchResult
+	model     *categoryGraphModel
+}
+
+//graphStringArg - the argument, empty when not given
+func graphStringArg(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+	return value
+}
+
+//graphIntArg - the argument, 0 when not given
+func graphIntArg(args map[string]interface{}, name string) int {
+	value, _ := args[name].(int)
+	return value
+}
+
+//graphBoolArg - the argument, false when not given
+func graphBoolArg(args map[string]interface{}, name string) bool {
+	value, _ := args[name].(bool)
+	return value
+}
+
+//categoryGraphModelID - lifeapp is the alias of the lifeapp model as on the REST routes
+func categoryGraphModelID(modelID string) string {
+	if modelID == lifeappModelName {
+		return myLifeCategoryUserModelID
+	}
+	return modelID
+}
+
+//categoryGraphNode - the category with its ancestors found by walking the tree, nil when the model has no such category
+func (node *categoryGraphModel) categoryGraphNode(id string) *categoryGraphCategory {
+	parents := map[*model.Category]*model.Category{}
+	stack := append([]*model.Category{}, node.model.Children...)
+	for len(stack) > 0 {
+		category := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if category.ID == id {
+			var ancestors []*model.Category
+			for parent := parents[category]; parent != nil; parent = parents[parent] {
+				ancestors = append([]*model.Category{parent}, ancestors...)
+			}
+			return &categoryGraphCategory{model: node, category: category, ancestors: ancestors}
+		}
+		for _, child := range category.Children {
+			parents[child] = category
+			stack = append(stack, child)
+		}
+	}
+	return nil
+}
+
+func (node *categoryGraphModel) children(children []*model.Category, ancestors []*model.Category) []*categoryGraphCategory {
+	nodes := make([]*categoryGraphCategory, len(children))
+	for i, child := range children {
+		nodes[i] = &categoryGraphCategory{model: node, category: child, ancestors: ancestors}
+	}
+	return nodes
+}
+
+//descendants - the categories below, depth first in tree order, down to maxDepth levels with 0 for all
+func (node *categoryGraphModel) descendants(children []*model.Category, ancestors []*model.Category, maxDepth int) []*categoryGraphCategory {
+	var nodes []*categoryGraphCategory
+	stack := node.children(children, ancestors)
+	for len(stack) > 0 {
+		next := stack[0]
+		stack = stack[1:]
+		nodes = append(nodes, next)
+		if maxDepth > 0 && len(next.ancestors)-len(ancestors)+1 >= maxDepth {
+			continue
+		}
+		below := append(append([]*model.Category{}, next.ancestors...), next.category)
+		stack = append(node.children(next.category.Children, below), stack...)
+	}
+	return nodes
+}
+
+func (node *categoryGraphCategory) path() []string {
+	titles := make([]string, 0, len(node.ancestors)+1)
+	for _, ancestor := range node.ancestors {
+		titles = append(titles, ancestor.Title)
+	}
+	return append(titles, node.category.Title)
+}
+
+//readCategoryGraphModel - nil when the model does not exist, the lifeapp model is created on first read as on the REST routes
+func readCategoryGraphModel(p graphql.ResolveParams, modelID string, owner string) (*categoryGraphModel, error) {
+	ctx := categoryService.WithCategoryOwner(p.Context, owner)
+	catModel, err := categoryService.GetCategoryModel(ctx, categoryGraphModelID(modelID))
+	if err != nil || catModel.ID == "" {
+		return nil, err
+	}
+	return &categoryGraphModel{owner: owner, model: catModel}, nil
+}
+
+//readChangedCategory - the category as stored after a change, titles can differ from the request under the suffix title policy
+func readChangedCategory(p graphql.ResolveParams, modelID string, id string, owner string) (interface{}, error) {
+	node, err := readCategoryGraphModel(p, modelID, owner)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	if changed := node.categoryGraphNode(id); changed != nil {
+		return changed, nil
+	}
+	return nil, nil
+}
+
+//categoryGraphSearchArgs - the search arguments with the same checks as the search routes
+func categoryGraphSearchArgs(args map[string]interface{}) (model.CategorySearchOptions, error) {
+	opts := model.CategorySearchOptions{Limit: repository.DefaultPageLimit, CaseSensitive: graphBoolArg(args, "caseSensitive"), MaxDistance: graphIntArg(args, "maxDistance")}
+	match, ok := model.ParseCategoryMatchType(graphStringArg(args, "match"))
+	if !ok {
+		return opts, errors.New("match must be exact, prefix, substring or fuzzy")
+	}
+	opts.Match = match
+	if opts.MaxDistance < 0 || opts.MaxDistance > 3 {
+		return opts, errors.New("maxDistance must be a number from 0 to 3, 0 uses a distance based on the query length")
+	}
+	if limit, given := args["limit"].(int); given {
+		if limit <= 0 || limit > repository.MaxPageLimit {
+			return opts, errors.New("limit must be a number from 1 to " + strconv.Itoa(repository.MaxPageLimit))
+		}
+		opts.Limit = limit
+	}
+	return opts, nil
+}
+
+func newCategoryGraphQLSchema() *graphql.Schema {
+	ownerArg := &graphql.ArgumentConfig{Type: graphql.String}
+	searchArgs := graphql.FieldConfigArgument{
+		"query":         {Type: graphql.NewNonNull(graphql.String)},
+		"match":         {Type: graphql.String},
+		"caseSensitive": {Type: graphql.Boolean},
+		"maxDistance":   {Type: graphql.Int},
+		"limit":         {Type: graphql.Int},
+	}
+
+	limitsType := graphql.NewObject(graphql.ObjectConfig{Name: "Limits", Description: "Size limits in effect for a model, 0 is unlimited", Fields: graphql.Fields{
+		"maxDepth": {Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(model.CategoryLimits).MaxDepth, nil
+		}},
+		"maxChildren": {Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(model.CategoryLimits).MaxChildren, nil
+		}},
+		"maxCategories": {Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(model.CategoryLimits).MaxCategories, nil
+		}},
+		"maxTitleLength": {Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(model.CategoryLimits).MaxTitleLength, nil
+		}},
+	}})
+	//the types refer to each other, their fields are read once the schema is built
+	var modelFields, categoryFields, hitFields graphql.Fields
+	modelType := graphql.NewObject(graphql.ObjectConfig{Name: "Model", Description: "A category model, a named tree of categories",
+		Fields: graphql.FieldsThunk(func() graphql.Fields { return modelFields })})
+	categoryType := graphql.NewObject(graphql.ObjectConfig{Name: "Category", Description: "A category, depth is 1 for the top level",
+		Fields: graphql.FieldsThunk(func() graphql.Fields { return categoryFields })})
+	hitType := graphql.NewObject(graphql.ObjectConfig{Name: "SearchHit", Description: "A category matching a search, best matches first",
+		Fields: graphql.FieldsThunk(func() graphql.Fields { return hitFields })})
+	categoryList := graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(categoryType)))
+
+	modelFields = graphql.Fields{
+		"id": {Type: graphql.NewNonNull(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*categoryGraphModel).model.ID, nil
+		}},
+		"name": {Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*categoryGraphModel).model.Name, nil
+		}},
+		"titlePolicy": {Type: graphql.NewNonNull(graphql.String), Description: "The sibling title policy, e.g. reject,ignoreCase", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*categoryGraphModel).model.TitlePolicy.String(), nil
+		}},
+		"limits": {Type: graphql.NewNonNull(limitsType), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			node := p.Source.(*categoryGraphModel)
+			return categoryService.GetCategoryLimits(categoryService.WithCategoryOwner(p.Context, node.owner), node.model.ID)
+		}},
+		"categories": {Type: categoryList, Description: "The top level categories", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			node := p.Source.(*categoryGraphModel)
+			return node.children(node.model.Children, nil), nil
+		}},
+		"category": {Type: categoryType, Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if found := p.Source.(*categoryGraphModel).categoryGraphNode(graphStringArg(p.Args, "id")); found != nil {
+				return found, nil
+			}
+			return nil, nil
+		}},
+		"descendants": {Type: categoryList, Description: "Every category depth first, down to maxDepth levels when set", Args: graphql.FieldConfigArgument{"maxDepth": {Type: graphql.Int}}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			node := p.Source.(*categoryGraphModel)
+			return node.descendants(node.model.Children, nil, graphIntArg(p.Args, "maxDepth")), nil
+		}},
+		"search": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(hitType))), Args: searchArgs, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			node := p.Source.(*categoryGraphModel)
+			opts, err := categoryGraphSearchArgs(p.Args)
+			if err != nil {
+				return nil, err
+			}
+			hits := []*categoryGraphHit{}
+			for _, result := range node.model.Search(graphStringArg(p.Args, "query"), opts) {
+				hits = append(hits, &categoryGraphHit{modelID: node.model.ID, modelName: node.model.Name, owner: node.owner, result: result, model: node})
+			}
+			return hits, nil
+		}},
+	}
+
+	categoryFields = graphql.Fields{
+		"id": {Type: graphql.NewNonNull(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*categoryGraphCategory).category.ID, nil
+		}},
+		"title": {Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*categoryGraphCategory).category.Title, nil
+		}},
+		"depth": {Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return len(p.Source.(*categoryGraphCategory).ancestors) + 1, nil
+		}},
+		"path": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), Description: "Titles from the top level down to this category", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*categoryGraphCategory).path(), nil
+		}},
+		"parent": {Type: categoryType, Description: "Null for the top level", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			node := p.Source.(*categoryGraphCategory)
+			if len(node.ancestors) == 0 {
+				return nil, nil
+			}
+			last := len(node.ancestors) - 1
+			return &categoryGraphCategory{model: node.model, category: node.ancestors[last], ancestors: node.ancestors[:last]}, nil
+		}},
+		"ancestors": {Type: categoryList, Description: "The categories above, top level first", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			node := p.Source.(*categoryGraphCategory)
+			ancestors := make([]*categoryGraphCategory, len(node.ancestors))
+			for i, ancestor := range node.ancestors {
+				ancestors[i] = &categoryGraphCategory{model: node.model, category: ancestor, ancestors: node.ancestors[:i]}
+			}
+			return ancestors, nil
+		}},
+		"children": {Type: categoryList, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			node := p.Source.(*categoryGraphCategory)
+			return node.model.children(node.category.Children, append(append([]*model.Category{}, node.ancestors...), node.category)), nil
+		}},
+		"childCount": {Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return len(p.Source.(*categoryGraphCategory).category.Children), nil
+		}},
+		"descendants": {Type: categoryList, Description: "The categories below depth first, down to maxDepth levels when set", Args: graphql.FieldConfigArgument{"maxDepth": {Type: graphql.Int}}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			node := p.Source.(*categoryGraphCategory)
+			return node.model.descendants(node.category.Children, append(append([]*model.Category{}, node.ancestors...), node.category), graphIntArg(p.Args, "maxDepth")), nil
+		}},
+		"model": {Type: graphql.NewNonNull(modelType), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*categoryGraphCategory).model, nil
+		}},
+	}
+
+	hitFields = graphql.Fields{
+		"modelID":   {Type: graphql.NewNonNull(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*categoryGraphHit).modelID, nil }},
+		"modelName": {Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) { return p.Source.(*categoryGraphHit).modelName, nil }},
+		"match": {Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return string(p.Source.(*categoryGraphHit).result.Match), nil
+		}},
+		"distance": {Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*categoryGraphHit).result.Distance, nil
+		}},
+		"path": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*categoryGraphHit).result.Path, nil
+		}},
+		"category": {Type: categoryType, Description: "Null when the category changed since the search", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			hit := p.Source.(*categoryGraphHit)
+			if hit.model == nil {
+				node, err := readCategoryGraphModel(p, hit.modelID, hit.owner)
+				if err != nil || node == nil {
+					return nil, err
+				}
+				hit.model = node
+			}
+			if found := hit.model.categoryGraphNode(hit.result.Category.ID); found != nil {
+				return found, nil
+			}
+			return nil, nil
+		}},
+	}
+
+	sharedType := graphql.NewObject(graphql.ObjectConfig{Name: "SharedModel", Description: "A model another user shared with the caller", Fields: graphql.Fields{
+		"modelID": {Type: graphql.NewNonNull(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*repository.CategorySharedDAO).ModelID, nil
+		}},
+		"ownerID": {Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*repository.CategorySharedDAO).OwnerID, nil
+		}},
+		"role": {Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return string(p.Source.(*repository.CategorySharedDAO).Role), nil
+		}},
+		"model": {Type: modelType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			shared := p.Source.(*repository.CategorySharedDAO)
+			return readCategoryGraphModel(p, shared.ModelID, shared.OwnerID)
+		}},
+	}})
+	pageType := graphql.NewObject(graphql.ObjectConfig{Name: "ModelPage", Description: "One page of the caller's models, pass cursor for the next page", Fields: graphql.Fields{
+		"models": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(modelType))), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			page := p.Source.(*repository.CategoryPage)
+			models := make([]*categoryGraphModel, len(page.Models))
+			for i := range page.Models {
+				models[i] = &categoryGraphModel{model: &page.Models[i]}
+			}
+			return models, nil
+		}},
+		"cursor": {Type: graphql.String, Description: "Null on the last page", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if cursor := p.Source.(*repository.CategoryPage).Cursor; cursor != "" {
+				return cursor, nil
+			}
+			return nil, nil
+		}},
+	}})
+
+	query := graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: graphql.Fields{
+		"models": {Type: pageType, Args: graphql.FieldConfigArgument{"limit": {Type: graphql.Int}, "cursor": {Type: graphql.String}}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			limit := graphIntArg(p.Args, "limit")
+			if _, given := p.Args["limit"].(int); given && (limit <= 0 || limit > repository.MaxPageLimit) {
+				return nil, errors.New("limit must be a number from 1 to " + strconv.Itoa(repository.MaxPageLimit))
+			}
+			page, err := categoryService.ListCategoryModels(p.Context, limit, graphStringArg(p.Args, "cursor"))
+			if errors.Is(err, repository.ErrInvalidCursor) {
+				return nil, errors.New("cursor is not valid, start again without a cursor")
+			}
+			return page, err
+		}},
+		"model": {Type: modelType, Description: "Null when the model does not exist, lifeapp is the lifeapp model", Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}, "owner": ownerArg}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return readCategoryGraphModel(p, graphStringArg(p.Args, "id"), graphStringArg(p.Args, "owner"))
+		}},
+		"category": {Type: categoryType, Args: graphql.FieldConfigArgument{"modelID": {Type: graphql.NewNonNull(graphql.ID)}, "id": {Type: graphql.NewNonNull(graphql.ID)}, "owner": ownerArg}, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return readChangedCategory(p, graphStringArg(p.Args, "modelID"), graphStringArg(p.Args, "id"), graphStringArg(p.Args, "owner"))
+		}},
+		"search": {Type: graphql.NewList(graphql.NewNonNull(hitType)), Description: "Matching categories across the caller's models", Args: searchArgs, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			opts, err := categoryGraphSearchArgs(p.Args)
+			if err != nil {
+				return nil, err
+			}
+			results, err := categoryService.SearchAllCategoryModels(p.Context, graphStringArg(p.Args, "query"), opts)
+			if err != nil {
+				return nil, err
+			}
+			hits := make([]*categoryGraphHit, len(results))
+			for i, result := range results {
+				hits[i] = &categoryGraphHit{modelID: result.ModelID, modelName: result.ModelName, result: result.CategorySearchResult}
+			}
+			return hits, nil
+		}},
+		"sharedModels": {Type: graphql.NewList(graphql.NewNonNull(sharedType)), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return categoryService.ListSharedModels(p.Context)
+		}},
+	}})
+
+	modelArgs := func(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+		args["modelID"] = &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}
+		args["owner"] = ownerArg
+		return args
+	}
+	//ownerContext - the model and context a mutation changes
+	ownerContext := func(p graphql.ResolveParams) (context.Context, string, string) {
+		owner := graphStringArg(p.Args, "owner")
+		return categoryService.WithCategoryOwner(p.Context, owner), categoryGraphModelID(graphStringArg(p.Args, "modelID")), owner
+	}
+	mutation := graphql.NewObject(graphql.ObjectConfig{Name: "Mutation", Fields: graphql.Fields{
+		"addCategory": {Type: categoryType, Description: "Adds a category, at the top level without a parentID", Args: modelArgs(graphql.FieldConfigArgument{"parentID": {Type: graphql.ID}, "id": {Type: graphql.NewNonNull(graphql.ID)}, "title": {Type: graphql.NewNonNull(graphql.String)}}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				ctx, modelID, owner := ownerContext(p)
+				id := graphStringArg(p.Args, "id")
+				err := categoryService.AddCategory(ctx, modelID, graphStringArg(p.Args, "parentID"), model.Category{ID: id, Title: graphStringArg(p.Args, "title")})
+				if err != nil {
+					return nil, err
+				}
+				return readChangedCategory(p, modelID, id, owner)
+			}},
+		"updateCategory": {Type: categoryType, Description: "Changes a category's title", Args: modelArgs(graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}, "title": {Type: graphql.NewNonNull(graphql.String)}}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				ctx, modelID, owner := ownerContext(p)
+				id := graphStringArg(p.Args, "id")
+				err := categoryService.UpdateCategory(ctx, modelID, model.Category{ID: id, Title: graphStringArg(p.Args, "title")})
+				if err != nil {
+					return nil, err
+				}
+				return readChangedCategory(p, modelID, id, owner)
+			}},
+		"moveCategory": {Type: categoryType, Description: "Moves a category and its children, to the top level without a parentID", Args: modelArgs(graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}, "parentID": {Type: graphql.ID}}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				ctx, modelID, owner := ownerContext(p)
+				id := graphStringArg(p.Args, "id")
+				err := categoryService.MoveCategory(ctx, modelID, graphStringArg(p.Args, "parentID"), id)
+				if err != nil {
+					return nil, err
+				}
+				return readChangedCategory(p, modelID, id, owner)
+			}},
+		"copyCategory": {Type: categoryType, Description: "Copies a category and its children with new ids, returns the copy", Args: modelArgs(graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}, "targetModelID": {Type: graphql.ID}, "parentID": {Type: graphql.ID}, "renameCopy": {Type: graphql.Boolean}}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				ctx, modelID, owner := ownerContext(p)
+				targetModelID := categoryGraphModelID(graphStringArg(p.Args, "targetModelID"))
+				copied, err := categoryService.CopyCategory(ctx, modelID, graphStringArg(p.Args, "id"), targetModelID, graphStringArg(p.Args, "parentID"), graphBoolArg(p.Args, "renameCopy"))
+				if err != nil {
+					return nil, err
+				}
+				if targetModelID == "" {
+					targetModelID = modelID
+				}
+				return readChangedCategory(p, targetModelID, copied.ID, owner)
+			}},
+		"deleteCategory": {Type: modelType, Description: "Deletes a category and its children, returns the model", Args: modelArgs(graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				ctx, modelID, owner := ownerContext(p)
+				err := categoryService.DeleteCategory(ctx, modelID, graphStringArg(p.Args, "id"))
+				if err != nil {
+					return nil, err
+				}
+				return readCategoryGraphModel(p, modelID, owner)
+			}},
+		"setTitlePolicy": {Type: modelType, Description: "Sets the sibling title policy in the titlePolicy form, e.g. suffix,ignoreCase", Args: modelArgs(graphql.FieldConfigArgument{"policy": {Type: graphql.NewNonNull(graphql.String)}}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				ctx, modelID, owner := ownerContext(p)
+				policy, err := model.ParseCategoryTitlePolicy(graphStringArg(p.Args, "policy"))
+				if err == nil {
+					err = categoryService.SetTitlePolicy(ctx, modelID, policy)
+				}
+				if err != nil {
+					return nil, err
+				}
+				return readCategoryGraphModel(p, modelID, owner)
+			}},
+		"setLimits": {Type: modelType, Description: "Sets the model's own limits within the deployment limits, 0 is unlimited", Args: modelArgs(graphql.FieldConfigArgument{"maxDepth": {Type: graphql.Int}, "maxChildren": {Type: graphql.Int}, "maxCategories": {Type: graphql.Int}, "maxTitleLength": {Type: graphql.Int}}),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				ctx, modelID, owner := ownerContext(p)
+				limits := model.CategoryLimits{MaxDepth: graphIntArg(p.Args, "maxDepth"), MaxChildren: graphIntArg(p.Args, "maxChildren"),
+					MaxCategories: graphIntArg(p.Args, "maxCategories"), MaxTitleLength: graphIntArg(p.Args, "maxTitleLength")}
+				err := categoryService.SetCategoryLimits(ctx, modelID, limits)
+				if err != nil {
+					return nil, err
+				}
+				return readCategoryGraphModel(p, modelID, owner)
+			}},
+		"createModelFromTemplate": {Type: modelType, Description: "Creates a model for the caller from a starter template, named after the template without a name", Args: graphql.FieldConfigArgument{"template": {Type: graphql.NewNonNull(graphql.String)}, "name": {Type: graphql.String}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				created, err := categoryService.CreateCategoryModelFromTemplate(p.Context, graphStringArg(p.Args, "template"), graphStringArg(p.Args, "name"))
+				if err != nil {
+					return nil, err
+				}
+				return &categoryGraphModel{model: created}, nil
+			}},
+		"deleteModel": {Type: graphql.Boolean, Description: "Deletes one of the caller's models", Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				err := categoryService.DeleteCategoryModel(p.Context, categoryGraphModelID(graphStringArg(p.Args, "id")))
+				return err == nil, err
+			}},
+	}})
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+	if err != nil {
+		panic("Unable to build the category GraphQL schema: " + err.Error())
+	}
+	return &schema
+}
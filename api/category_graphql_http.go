@@ -0,0 +1,276 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+)
+
+//MaxCategoryGraphQLBytes - larger request bodies are refused with 413
+const MaxCategoryGraphQLBytes = 64 * 1024
+
+//DefaultCategoryGraphQLMaxDepth - the field nesting allowed when PROCESS_CATEGORY_GRAPHQL_MAX_DEPTH is not set
+const DefaultCategoryGraphQLMaxDepth = 10
+
+var categoryGraphQLMaxDepth = DefaultCategoryGraphQLMaxDepth
+
+func init() {
+	if value := os.Getenv("PROCESS_CATEGORY_GRAPHQL_MAX_DEPTH"); value != "" {
+		depth, err := strconv.Atoi(value)
+		if err != nil || depth < 0 {
+			panic("PROCESS_CATEGORY_GRAPHQL_MAX_DEPTH must be a number, 0 for no limit, received: " + value)
+		}
+		categoryGraphQLMaxDepth = depth
+	}
+}
+
+//CategoryGraphQLRequest - POST body of /graphql, GETs send the same fields as query parameters with variables as JSON
+type CategoryGraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+//categoryGraphQLPath - outside the categories path like the OpenAPI document
+func categoryGraphQLPath() string {
+	return os.Getenv("PROCESS_RELATIVE_PATH") + "/graphql"
+}
+
+//readCategoryGraphQLRequest - a POST body, or the query, operationName and variables parameters of a GET
+func readCategoryGraphQLRequest(w http.ResponseWriter, r *http.Request) (CategoryGraphQLRequest, error) {
+	request := CategoryGraphQLRequest{}
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		request.Query = query.Get("query")
+		request.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				return request, coreerrors.NewClientError("variables must be a JSON object")
+			}
+		}
+		return request, nil
+	}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxCategoryGraphQLBytes)).Decode(&request)
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxErr):
+		return request, newRequestTooLargeError("GraphQL request must be at most " + strconv.Itoa(MaxCategoryGraphQLBytes) + " bytes")
+	case err != nil:
+		return request, coreerrors.NewClientError("GraphQL request must be JSON with a query")
+	case request.Query == "":
+		return request, coreerrors.NewClientError("query is required")
+	}
+	return request, nil
+}
+
+//categoryGraphQLOperation - the operation the request runs, nil when the name matches none or several are sent without one
+func categoryGraphQLOperation(doc *ast.Document, name string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if found != nil {
+				return nil
+			}
+			found = operation
+		} else if operation.Name != nil && operation.Name.Value == name {
+			return operation
+		}
+	}
+	return found
+}
+
+//checkCategoryGraphQLDepth - top level fields are depth 1, fragments count where they are spread and introspection fields are not limited as they read no data
+func checkCategoryGraphQLDepth(doc *ast.Document, operation *ast.OperationDefinition, maxDepth int) error {
+	if maxDepth == 0 {
+		return nil
+	}
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok && fragment.Name != nil {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+	spreading := map[string]bool{}
+	var check func(set *ast.SelectionSet, depth int) error
+	check = func(set *ast.SelectionSet, depth int) error {
+		if set == nil {
+			return nil
+		}
+		for _, selection := range set.Selections {
+			switch sel := selection.(type) {
+			case *ast.Field:
+				if strings.HasPrefix(sel.Name.Value, "__") {
+					continue
+				}
+				if depth > maxDepth {
+					return fmt.Errorf("Query is deeper than the limit of %v at field %v", maxDepth, sel.Name.Value)
+				}
+				if err := check(sel.SelectionSet, depth+1); err != nil {
+					return err
+				}
+			case *ast.InlineFragment:
+				if err := check(sel.SelectionSet, depth); err != nil {
+					return err
+				}
+			case *ast.FragmentSpread:
+				fragment := fragments[sel.Name.Value]
+				//unknown and cyclic spreads are reported by validation
+				if fragment == nil || spreading[sel.Name.Value] {
+					continue
+				}
+				spreading[sel.Name.Value] = true
+				err := check(fragment.SelectionSet, depth)
+				spreading[sel.Name.Value] = false
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return check(operation.SelectionSet, 1)
+}
+
+//categoryGraphQLSDL - the schema in SDL, types in the order they are reached from Query then Mutation and fields sorted by name
+func categoryGraphQLSDL(schema *graphql.Schema) string {
+	var objects []*graphql.Object
+	seen := map[string]bool{}
+	var visit func(t graphql.Type)
+	visit = func(t graphql.Type) {
+		object, ok := graphql.GetNamed(t).(*graphql.Object)
+		if !ok || object == nil || seen[object.Name()] {
+			return
+		}
+		seen[object.Name()] = true
+		objects = append(objects, object)
+		fields := object.Fields()
+		for _, name := range sortedGraphQLFields(fields) {
+			visit(fields[name].Type)
+		}
+	}
+	visit(schema.QueryType())
+	if schema.MutationType() != nil {
+		visit(schema.MutationType())
+	}
+
+	var sdl strings.Builder
+	sdl.WriteString("schema {\n  query: " + schema.QueryType().Name() + "\n")
+	if schema.MutationType() != nil {
+		sdl.WriteString("  mutation: " + schema.MutationType().Name() + "\n")
+	}
+	sdl.WriteString("}\n")
+	for _, object := range objects {
+		sdl.WriteString("\n")
+		writeGraphQLDescription(&sdl, "", object.Description())
+		sdl.WriteString("type " + object.Name() + " {\n")
+		fields := object.Fields()
+		for _, name := range sortedGraphQLFields(fields) {
+			field := fields[name]
+			writeGraphQLDescription(&sdl, "  ", field.Description)
+			sdl.WriteString("  " + name)
+			if len(field.Args) > 0 {
+				args := make([]string, 0, len(field.Args))
+				for _, arg := range field.Args {
+					text := arg.Name() + ": " + arg.Type.String()
+					if arg.DefaultValue != nil {
+						text += " = " + graphQLLiteral(arg.DefaultValue)
+					}
+					args = append(args, text)
+				}
+				sort.Strings(args)
+				sdl.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			sdl.WriteString(": " + field.Type.String() + "\n")
+		}
+		sdl.WriteString("}\n")
+	}
+	return sdl.String()
+}
+
+func sortedGraphQLFields(fields graphql.FieldDefinitionMap) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeGraphQLDescription(sdl *strings.Builder, indent string, description string) {
+	if description != "" {
+		sdl.WriteString(indent + strconv.Quote(description) + "\n")
+	}
+}
+
+func graphQLLiteral(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return strconv.Quote(text)
+	}
+	return fmt.Sprint(value)
+}
+
+func writeCategoryGraphQLResult(w http.ResponseWriter, result *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+//GET, POST /graphql
+func serveCategoryGraphQL(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method == http.MethodGet && r.URL.Query().Get("query") == "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(categoryGraphQLSDL(categoryGraphQLSchema)))
+		return
+	}
+	request, err := readCategoryGraphQLRequest(w, r)
+	if err != nil {
+		coreapi.WritePostAPIResponse(ctx, w, r, "", err)
+		return
+	}
+
+	//documents that do not parse are left for graphql.Do to report
+	if doc, err := parser.Parse(parser.ParseParams{Source: request.Query}); err == nil {
+		if operation := categoryGraphQLOperation(doc, request.OperationName); operation != nil {
+			//mutations are changes, GETs may only query and POSTed mutations count against the rate limit like other changes
+			if operation.Operation == ast.OperationTypeMutation {
+				if r.Method == http.MethodGet {
+					w.Header().Set("Allow", http.MethodPost)
+					coreapi.WriteGetAPIResponse(ctx, w, r, nil, coreerrors.Error{ErrorType: http.StatusMethodNotAllowed, DeveloperMessage: "Mutations must be sent with POST"})
+					return
+				}
+				if !allowCategoryChange(w, r) {
+					return
+				}
+			}
+			if err := checkCategoryGraphQLDepth(doc, operation, categoryGraphQLMaxDepth); err != nil {
+				writeCategoryGraphQLResult(w, &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(err.Error())}})
+				return
+			}
+		}
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         *categoryGraphQLSchema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        ctx,
+	})
+	writeCategoryGraphQLResult(w, result)
+}
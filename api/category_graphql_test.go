@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+func TestCategoryGraphQLTree(t *testing.T) {
+	catModel := &repository.CategoryUserModel{}
+	catModel.ID = "m1"
+	catModel.Name = "Tree"
+	catModel.Children = []*model.Category{
+		{ID: "work", Title: "Work", Children: []*model.Category{
+			{ID: "projects", Title: "Projects", Children: []*model.Category{{ID: "launch", Title: "Launch"}}},
+		}},
+		{ID: "home", Title: "Home"},
+	}
+	node := &categoryGraphModel{model: catModel}
+
+	found := node.categoryGraphNode("launch")
+	if found == nil || strings.Join(found.path(), "/") != "Work/Projects/Launch" {
+		t.Fatalf("Expected the category with its ancestors, received: %+v", found)
+	}
+	if node.categoryGraphNode("missing") != nil {
+		t.Errorf("Expected no node for a missing category")
+	}
+
+	var ids []string
+	for _, descendant := range node.descendants(catModel.Children, nil, 2) {
+		ids = append(ids, descendant.category.ID)
+	}
+	if strings.Join(ids, ",") != "work,projects,home" {
+		t.Errorf("Expected two levels depth first, received: %v", ids)
+	}
+	ids = nil
+	work := node.categoryGraphNode("work")
+	for _, descendant := range node.descendants(work.category.Children, []*model.Category{work.category}, 0) {
+		ids = append(ids, descendant.category.ID)
+		if descendant.category.ID == "launch" && len(descendant.ancestors) != 2 {
+			t.Errorf("Expected descendants to keep their ancestors, received: %v", len(descendant.ancestors))
+		}
+	}
+	if strings.Join(ids, ",") != "projects,launch" {
+		t.Errorf("Expected every level below the category, received: %v", ids)
+	}
+
+	//nested fields resolve from the model without reading it again
+	category := categoryGraphQLSchema.QueryType().Fields()["category"]
+	resolve := category.Resolve
+	category.Resolve = func(p graphql.ResolveParams) (interface{}, error) {
+		return node.categoryGraphNode(graphStringArg(p.Args, "id")), nil
+	}
+	defer func() { category.Resolve = resolve }()
+	result := graphql.Do(graphql.Params{Schema: *categoryGraphQLSchema, Context: context.TODO(), RequestString: `{ category(modelID: "m1", id: "projects") {
+		title depth path parent { id parent { id } } ancestors { id } children { id childCount } model { name descendants(maxDepth: 1) { id } }
+	} }`})
+	body, _ := json.Marshal(result)
+	expected := `{"data":{"category":{"title":"Projects","depth":2,"path":["Work","Projects"],"parent":{"id":"work","parent":null},"ancestors":[{"id":"work"}],` +
+		`"children":[{"id":"launch","childCount":0}],"model":{"name":"Tree","descendants":[{"id":"work"},{"id":"home"}]}}}}`
+	//graphql-go returns objects as maps so the keys are compared rather than their order
+	var received, wanted interface{}
+	json.Unmarshal(body, &received)
+	json.Unmarshal([]byte(expected), &wanted)
+	if !reflect.DeepEqual(received, wanted) {
+		t.Errorf("Expected the subtree with its ancestors, received: %s", body)
+	}
+}
+
+func TestCategoryGraphQLHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	serveCategoryGraphQL(w, httptest.NewRequest("GET", "/graphql", nil))
+	if !strings.Contains(w.Body.String(), "type Category {") || !strings.Contains(w.Body.String(), "mutation: Mutation") {
+		t.Errorf("Expected the schema without a query, received: %v", w.Body.String())
+	}
+
+	//refused before anything is read
+	deep := `{"query":"{ model(id: \"lifeapp\") { categories { children { children { children { children { children { children { children { children { id } } } } } } } } } } }"}`
+	w = httptest.NewRecorder()
+	serveCategoryGraphQL(w, httptest.NewRequest("POST", "/graphql", strings.NewReader(deep)))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "deeper than the limit of 10") {
+		t.Errorf("Expected the depth limit error, received: %v %v", w.Code, w.Body.String())
+	}
+
+	//introspection reads no data so it is not held to the depth limit
+	introspection := `{"query":"{ __schema { queryType { name } types { name fields { type { ofType { ofType { ofType { ofType { ofType { ofType { ofType { name } } } } } } } } } } } }"}`
+	w = httptest.NewRecorder()
+	serveCategoryGraphQL(w, httptest.NewRequest("POST", "/graphql", strings.NewReader(introspection)))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), `"queryType":{"name":"Query"}`) || strings.Contains(w.Body.String(), `"errors"`) {
+		t.Errorf("Expected the schema from introspection, received: %v %v", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	serveCategoryGraphQL(w, httptest.NewRequest("GET", `/graphql?query=mutation+%7B+deleteModel(id:"m1")+%7D`, nil))
+	if w.Code != 405 {
+		t.Errorf("Expected mutations over GET to be refused, received: %v %v", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	serveCategoryGraphQL(w, httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"variables":{}}`)))
+	if w.Code != 400 {
+		t.Errorf("Expected a request without a query to be refused, received: %v %v", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+	"github.com/suared/core/security"
+
+	"github.com/suared/core-apiuser/repository"
+	"github.com/suared/core-apiuser/service"
+)
+
+//categoryIdempotencyHeader - set by clients on changes they may retry, e.g. after a timeout
+const categoryIdempotencyHeader = "Idempotency-Key"
+
+//categoryReplayedHeader - marks a response replayed from an earlier request with the same key
+const categoryReplayedHeader = "Idempotent-Replayed"
+
+//categoryReplayHeaders - the response headers kept with the response, the rest are set again by the server
+var categoryReplayHeaders = []string{"Content-Type", "Location"}
+
+//newUnprocessableError - core errors has no 422 constructor yet
+func newUnprocessableError(err string) error {
+	return coreerrors.Error{ErrorType: http.StatusUnprocessableEntity,
+		DeveloperMessage: err}
+}
+
+//categoryResponseRecorder - passes the response through while keeping a copy to store with the key
+type categoryResponseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *categoryResponseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *categoryResponseRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
+
+//categoryRequestHash - the method, path, query and body identify the request a key was used with
+func categoryRequestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+//categoryBodyLimit - the largest body the route accepts, the middleware reads the body before the handler applies its own limit
+func categoryBodyLimit(r *http.Request) int64 {
+	if r.URL.Path == categoryGraphQLPath() {
+		return MaxCategoryGraphQLBytes
+	}
+	if route := mux.CurrentRoute(r); route != nil {
+		template, _ := route.GetPathTemplate()
+		if template == relPathCategory || template == relPathCategory+"/{modelID}/import" {
+			return MaxCategoryImportBytes
+		}
+	}
+	return MaxCategoryActionBytes
+}
+
+//categoryIdempotencyMiddleware - changes sent with an Idempotency-Key are made once per user, retries replay the first response until the key expires.
+//A key reused with a different request returns 422, a retry while the first request is running returns 409.  Server errors and conflicts
+//release the key so the retry makes the change
+func categoryIdempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(categoryIdempotencyHeader)
+		ctx := r.Context()
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions || security.IsAnonymous(ctx) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		limit := categoryBodyLimit(r)
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			coreapi.WritePatchAPIResponse(ctx, w, r, newRequestTooLargeError(fmt.Sprintf("Request body must be at most %v bytes", limit)))
+			return
+		}
+		if err != nil {
+			coreapi.WritePatchAPIResponse(ctx, w, r, coreerrors.NewClientError("Unable to read the request body"))
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		requestHash := categoryRequestHash(r, body)
+
+		change, err := categoryService.BeginIdempotentChange(ctx, key, requestHash)
+		switch {
+		case errors.Is(err, repository.ErrIdempotencyKeyReused):
+			coreapi.WritePatchAPIResponse(ctx, w, r, newUnprocessableError(repository.ErrIdempotencyKeyReused.Error()))
+			return
+		case errors.Is(err, repository.ErrIdempotencyInProgress):
+			coreapi.WritePatchAPIResponse(ctx, w, r, newConflictError(repository.ErrIdempotencyInProgress.Error()))
+			return
+		case errors.Is(err, repository.ErrInvalidIdempotencyKey):
+			coreapi.WritePatchAPIResponse(ctx, w, r, coreerrors.NewClientError(repository.ErrInvalidIdempotencyKey.Error()))
+			return
+		case err != nil:
+			coreapi.WritePatchAPIResponse(ctx, w, r, getCategoryError(r, "idempotency", err))
+			return
+		case change.Replay != nil:
+			for name, value := range change.Replay.Header {
+				w.Header().Set(name, value)
+			}
+			w.Header().Set(categoryReplayedHeader, "true")
+			w.WriteHeader(change.Replay.Status)
+			w.Write(change.Replay.Body)
+			return
+		}
+
+		rec := &categoryResponseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			//e.g. a POST that only sets Location, the server sends 200
+			rec.status = http.StatusOK
+		}
+		//a conflict such as a concurrent change to the model is temporary, replaying it would fail every retry
+		if rec.status >= http.StatusInternalServerError || rec.status == http.StatusConflict {
+			err = categoryService.AbandonIdempotentChange(ctx, key)
+		} else {
+			header := map[string]string{}
+			for _, name := range categoryReplayHeaders {
+				if value := w.Header().Get(name); value != "" {
+					header[name] = value
+				}
+			}
+			err = categoryService.CompleteIdempotentChange(ctx, change, service.CategoryIdempotentResponse{Status: rec.status, Header: header, Body: rec.body.Bytes()})
+		}
+		if err != nil {
+			//the change was made, a retry within the window may make it again
+			log.Printf("Category idempotency key %v was not stored: %v", key, err)
+		}
+	})
+}
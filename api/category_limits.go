@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+
+	"github.com/suared/core-apiuser/model"
+)
+
+//GET /Lifeapp/Categories/{modelID}/limits
+func getCategoryLimits(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	limits, err := categoryService.GetCategoryLimits(ctx, getCategoryModelID(r))
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, getCategoryError(r, "limits", err))
+		return
+	}
+	coreapi.WriteGetAPIResponse(ctx, w, r, limits, nil)
+}
+
+//PUT /Lifeapp/Categories/{modelID}/limits   <model.CategoryLimits>
+func putCategoryLimits(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	limits := model.CategoryLimits{}
+	err := json.NewDecoder(r.Body).Decode(&limits)
+	if err != nil || !limits.Valid() {
+		coreapi.WritePutAPIResponse(ctx, w, r, coreerrors.NewClientError("Limits must be JSON with maxDepth, maxChildren, maxCategories and maxTitleLength, 0 or missing for the deployment limit"))
+		return
+	}
+	err = categoryService.SetCategoryLimits(ctx, getCategoryModelID(r), limits)
+	if err != nil {
+		err = getCategoryError(r, "limits", err)
+	}
+	coreapi.WritePutAPIResponse(ctx, w, r, err)
+}
+
+//getCategoryLimitError - a change over a limit is a client error, nil if err is not one
+func getCategoryLimitError(err error) error {
+	var limitErr *model.CategoryLimitError
+	if errors.As(err, &limitErr) {
+		return coreerrors.NewClientError(limitErr.Error())
+	}
+	return nil
+}
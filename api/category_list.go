@@ -0,0 +1,59 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+
+	"github.com/suared/core-apiuser/repository"
+)
+
+//CategoryModelSummary - list entry for a model, GET the model by id for the categories
+type CategoryModelSummary struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+//CategoryModelList - one page of the user's models, Cursor is omitted on the last page
+type CategoryModelList struct {
+	Models []CategoryModelSummary `json:"models"`
+	Cursor string                 `json:"cursor,omitempty"`
+}
+
+//GET /Lifeapp/Categories?limit=&cursor=
+func getCategoryModels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	var limit int
+	if limitParam := query.Get("limit"); limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 || limit > repository.MaxPageLimit {
+			apiErr := coreerrors.NewClientError("limit must be a number from 1 to " + strconv.Itoa(repository.MaxPageLimit))
+			coreapi.WriteGetAPIResponse(ctx, w, r, nil, apiErr)
+			return
+		}
+	}
+
+	page, err := categoryService.ListCategoryModels(ctx, limit, query.Get("cursor"))
+	if err != nil {
+		var apiErr error
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			apiErr = coreerrors.NewClientError("cursor is not valid, start again without a cursor")
+		} else {
+			apiErr = getCategoryError(r, "list", err)
+		}
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, apiErr)
+		return
+	}
+
+	list := CategoryModelList{Models: []CategoryModelSummary{}, Cursor: page.Cursor}
+	for i := range page.Models {
+		list.Models = append(list.Models, CategoryModelSummary{ID: page.Models[i].ID, Name: page.Models[i].Name})
+	}
+	coreapi.WriteGetAPIResponse(ctx, w, r, list, nil)
+}
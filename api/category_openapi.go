@@ -0,0 +1,320 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+
+	"github.com/graphql-go/graphql"
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+	"github.com/suared/core-apiuser/service"
+)
+
+//categoryOpenAPIRoute - one operation of the document, Path is relative to the category path.  Request and Response are zero values of the JSON
+//bodies, their schemas are read from the Go types so the document follows the structs
+type categoryOpenAPIRoute struct {
+	Method string
+	Path   string
+	//Root - Path is relative to PROCESS_RELATIVE_PATH vs. the category path
+	Root    bool
+	Summary string
+	//Query - parameter name to description
+	Query    map[string]string
+	Request  interface{}
+	Response interface{}
+	//RequestTypes, ResponseTypes - other media types accepted or returned, e.g. the import formats
+	RequestTypes  []string
+	ResponseTypes []string
+	//Location - the response Location header is set to the created or changed resource
+	Location bool
+	//Validated - invalid bodies return a CategoryValidationError listing the fields
+	Validated bool
+	//Created - success is a 201 vs. 200
+	Created bool
+}
+
+var categoryOwnerQuery = map[string]string{categoryOwnerParam: "Owner of a model shared with the caller, omit for the caller's own models"}
+
+var categorySearchQuery = map[string]string{
+	"q":             "Title to search for",
+	"match":         "Loosest match returned: exact, prefix, substring or fuzzy (default)",
+	"caseSensitive": "true to compare case",
+	"maxDistance":   "Edit distance allowed for fuzzy matches",
+	"limit":         "Number of hits, all when omitted",
+}
+
+//categoryFormatTypes - the media types of the model formats besides JSON
+func categoryFormatTypes() []string {
+	return []string{model.FormatMarkdown.ContentType(), model.FormatOPML.ContentType(), model.FormatCSV.ContentType()}
+}
+
+//categoryOpenAPIRoutes - every route registered in SetupAppRoutes, TestCategoryOpenAPICoversRoutes fails when one is missing
+func categoryOpenAPIRoutes() []categoryOpenAPIRoute {
+	return []categoryOpenAPIRoute{
+		{Method: "GET", Path: "/lifeapp", Summary: "Get the lifeapp model", Query: mergeQuery(categoryOwnerQuery, map[string]string{"format": "json (default), markdown, opml or csv, the Accept header is used when omitted"}),
+			Response: repository.CategoryUserModel{}, ResponseTypes: categoryFormatTypes()},
+		{Method: "GET", Path: "/lifeappList", Summary: "Get every category of the lifeapp model as a flat list", Query: categoryOwnerQuery, Response: []*model.Category{}},
+		{Method: "PATCH", Path: "/lifeapp", Summary: "Add, update, move, copy or delete a category of the lifeapp model", Query: categoryOwnerQuery, Request: CategoryActions{},
+			Validated: true},
+		{Method: "GET", Path: "/templates", Summary: "List starter templates", Response: []*model.CategoryTemplate{}},
+		{Method: "POST", Path: "/templates/{templateName}", Summary: "Create a model from a template", Query: map[string]string{"name": "Name of the new model, the template title when omitted"}, Location: true},
+		{Method: "GET", Path: "/shared", Summary: "Models shared with the caller", Response: []CategorySharedModel{}},
+		{Method: "GET", Path: "/search", Summary: "Search the titles of all the caller's models, best first", Query: categorySearchQuery, Response: []CategorySearchHit{}},
+		{Method: "GET", Path: "/usage", Summary: "Models and storage used against the quotas", Response: service.CategoryUsageReport{}},
+		{Method: "POST", Path: "/invites/accept", Summary: "Accept an invite to another user's model", Request: CategoryInviteAccept{}, Response: CategorySharedModel{}, Location: true,
+			Validated: true},
+		{Method: "GET", Path: "", Summary: "List the caller's models", Query: map[string]string{"limit": "Models per page", "cursor": "Cursor from the previous page"}, Response: CategoryModelList{}},
+		{Method: "POST", Path: "", Summary: "Import a new model", Query: map[string]string{"name": "Name of the new model", "format": "Format of the body when the Content-Type does not say"},
+			Request: repository.CategoryUserModel{}, RequestTypes: categoryFormatTypes(), Location: true},
+		{Method: "GET", Path: "/{modelID}", Summary: "Get a model", Query: mergeQuery(categoryOwnerQuery, map[string]string{"format": "json (default), markdown, opml or csv, the Accept header is used when omitted"}),
+			Response: repository.CategoryUserModel{}, ResponseTypes: categoryFormatTypes()},
+		{Method: "GET", Path: "/{modelID}/events", Summary: "Live model changes as Server-Sent Events", Query: mergeQuery(categoryOwnerQuery, map[string]string{"lastEventId": "Resume after this event, the Last-Event-ID header is used when omitted"}),
+			ResponseTypes: []string{"text/event-stream"}},
+		{Method: "POST", Path: "/{modelID}/webhooks", Summary: "Register a webhook", Request: CategoryWebhookRequest{}, Response: CategoryWebhook{}, Location: true},
+		{Method: "GET", Path: "/{modelID}/webhooks", Summary: "List webhooks", Response: []CategoryWebhook{}},
+		{Method: "GET", Path: "/{modelID}/webhooks/deliveries", Summary: "Recent webhook deliveries, newest first", Query: map[string]string{"limit": "Number of deliveries"}, Response: []CategoryWebhookDelivery{}},
+		{Method: "DELETE", Path: "/{modelID}/webhooks/{webhookID}", Summary: "Remove a webhook"},
+		{Method: "GET", Path: "/{modelID}/audit", Summary: "Model audit log, newest first", Query: mergeQuery(categoryOwnerQuery, map[string]string{"from": "RFC3339 start time", "to": "RFC3339 end time", "limit": "Records per page", "cursor": "Cursor from the previous page"}),
+			Response: CategoryAuditList{}},
+		{Method: "GET", Path: "/{modelID}/search", Summary: "Search a model's titles, best first", Query: mergeQuery(categoryOwnerQuery, categorySearchQuery), Response: []CategorySearchHit{}},
+		{Method: "PUT", Path: "/{modelID}/titlePolicy", Summary: "Set the sibling title policy", Query: categoryOwnerQuery, Request: model.CategoryTitlePolicy{}},
+		{Method: "GET", Path: "/{modelID}/limits", Summary: "Limits in effect for the model", Query: categoryOwnerQuery, Response: model.CategoryLimits{}},
+		{Method: "PUT", Path: "/{modelID}/limits", Summary: "Set the model's own limits within the deployment limits", Query: categoryOwnerQuery, Request: model.CategoryLimits{}},
+		{Method: "POST", Path: "/{modelID}/invites", Summary: "Invite a user to the model", Request: CategoryInviteRequest{}, Response: CategoryInvite{},
+			Created: true, Validated: true},
+		{Method: "GET", Path: "/{modelID}/shares", Summary: "Users the model is shared with", Response: []CategoryShare{}},
+		{Method: "DELETE", Path: "/{modelID}/shares/{userID}", Summary: "Remove a share, or leave a shared model with ?owner=", Query: categoryOwnerQuery},
+		{Method: "POST", Path: "/{modelID}/import", Summary: "Merge a file into the model", Query: mergeQuery(categoryOwnerQuery, map[string]string{"format": "Format of the body when the Content-Type does not say"}),
+			Request: repository.CategoryUserModel{}, RequestTypes: categoryFormatTypes(), Location: true},
+		{Method: "GET", Path: "/openapi.json", Root: true, Summary: "This document", Response: map[string]interface{}{}},
+		{Method: "GET", Path: "/graphql", Root: true, Summary: "Run a GraphQL query, without a query returns the schema in SDL",
+			Query:    map[string]string{"query": "The GraphQL document, mutations must be POSTed", "operationName": "The operation to run when the document has several", "variables": "JSON object of variable values"},
+			Response: graphql.Result{}, ResponseTypes: []string{"text/plain"}},
+		{Method: "POST", Path: "/graphql", Root: true, Summary: "Run a GraphQL query or mutation", Request: CategoryGraphQLRequest{}, Response: graphql.Result{}},
+	}
+}
+
+func mergeQuery(queries ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, query := range queries {
+		for name, description := range query {
+			merged[name] = description
+		}
+	}
+	return merged
+}
+
+var categoryPathParams = regexp.MustCompile(`{([^}]+)}`)
+
+//categoryOpenAPISchemas - component schemas by name, built from the Go types as they are referenced
+type categoryOpenAPISchemas struct {
+	schemas map[string]interface{}
+	types   map[string]reflect.Type
+}
+
+//ref - the schema of the type, structs are added to the components and referenced so recursive types such as Category work
+func (s *categoryOpenAPISchemas) ref(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return s.ref(t.Elem())
+	case reflect.Struct:
+		name := t.Name()
+		if existing, found := s.types[name]; found && existing != t {
+			//same name in two packages
+			name = strings.Title(t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]) + name
+		}
+		if _, found := s.types[name]; !found {
+			s.types[name] = t
+			s.schemas[name] = s.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.ref(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.ref(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+	return map[string]interface{}{}
+}
+
+//object - the properties encoding/json writes for the struct, embedded structs without a name are flattened
+func (s *categoryOpenAPISchemas) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	s.properties(t, properties)
+	for name, values := range categoryOpenAPIEnums[t.Name()] {
+		properties[name].(map[string]interface{})["enum"] = values
+	}
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if t == reflect.TypeOf(CategoryActions{}) {
+		//unknown fields are refused
+		schema["additionalProperties"] = false
+	}
+	return schema
+}
+
+//categoryOpenAPIEnums - the allowed values of string fields, by schema and property
+var categoryOpenAPIEnums = map[string]map[string][]string{
+	"CategoryActions": {"operation": {CategoryOperationAdd, CategoryOperationMove, CategoryOperationDelete, CategoryOperationUpdate, CategoryOperationCopy}},
+}
+
+func (s *categoryOpenAPISchemas) properties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		switch {
+		case tag == "-" || (field.PkgPath != "" && !field.Anonymous):
+			continue
+		case field.Anonymous && name == "":
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.properties(embedded, properties)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.ref(field.Type)
+	}
+}
+
+//categoryOpenAPIDocument - the OpenAPI 3 document for the category routes
+func categoryOpenAPIDocument() map[string]interface{} {
+	schemas := &categoryOpenAPISchemas{schemas: map[string]interface{}{}, types: map[string]reflect.Type{}}
+	errorResponse := map[string]interface{}{
+		"description": "Error, ErrorType is the HTTP status",
+		"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": schemas.ref(reflect.TypeOf(coreerrors.Error{}))}},
+	}
+
+	paths := map[string]interface{}{}
+	for _, route := range categoryOpenAPIRoutes() {
+		path := relPathCategory + route.Path
+		if route.Root {
+			path = os.Getenv("PROCESS_RELATIVE_PATH") + route.Path
+		}
+		operation := map[string]interface{}{
+			"summary":     route.Summary,
+			"operationId": strings.ToLower(route.Method) + categoryOperationName(route.Path),
+		}
+
+		var parameters []interface{}
+		for _, match := range categoryPathParams.FindAllStringSubmatch(route.Path, -1) {
+			parameters = append(parameters, map[string]interface{}{"name": match[1], "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"}})
+		}
+		names := make([]string, 0, len(route.Query))
+		for name := range route.Query {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			parameters = append(parameters, map[string]interface{}{"name": name, "in": "query", "description": route.Query[name], "schema": map[string]interface{}{"type": "string"}})
+		}
+		if route.Method != http.MethodGet {
+			parameters = append(parameters, map[string]interface{}{"name": categoryIdempotencyHeader, "in": "header", "description": "Retries with the same key replay the first response", "schema": map[string]interface{}{"type": "string"}})
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if route.Request != nil || len(route.RequestTypes) > 0 {
+			content := map[string]interface{}{}
+			if route.Request != nil {
+				content["application/json"] = map[string]interface{}{"schema": schemas.ref(reflect.TypeOf(route.Request))}
+			}
+			for _, mediaType := range route.RequestTypes {
+				content[mediaType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+			}
+			operation["requestBody"] = map[string]interface{}{"required": true, "content": content}
+		}
+
+		success := map[string]interface{}{"description": "Success"}
+		content := map[string]interface{}{}
+		if route.Response != nil {
+			content["application/json"] = map[string]interface{}{"schema": schemas.ref(reflect.TypeOf(route.Response))}
+		}
+		for _, mediaType := range route.ResponseTypes {
+			content[mediaType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+		}
+		if len(content) > 0 {
+			success["content"] = content
+		}
+		if route.Location {
+			success["headers"] = map[string]interface{}{"Location": map[string]interface{}{"description": "The created or changed resource", "schema": map[string]interface{}{"type": "string"}}}
+		}
+		status := "200"
+		if route.Created {
+			status = "201"
+		}
+		responses := map[string]interface{}{status: success, "default": errorResponse}
+		if route.Validated {
+			responses["400"] = map[string]interface{}{
+				"description": "Invalid request, Fields lists each problem",
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": schemas.ref(reflect.TypeOf(CategoryValidationError{}))}},
+			}
+		}
+		operation["responses"] = responses
+
+		item, found := paths[path].(map[string]interface{})
+		if !found {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Category API",
+			"version":     "1.0.0",
+			"description": "Category models and their changes.  Changes over the rate limit return 429 with Retry-After",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas.schemas},
+	}
+}
+
+//categoryOperationName - e.g. /{modelID}/webhooks/deliveries is ModelWebhooksDeliveries
+func categoryOperationName(path string) string {
+	name := "Categories"
+	for _, part := range strings.Split(strings.TrimSuffix(path, ".json"), "/") {
+		part = strings.TrimSuffix(strings.TrimPrefix(part, "{"), "}")
+		part = strings.TrimSuffix(part, "ID")
+		if part != "" {
+			name += strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return name
+}
+
+//GET /openapi.json
+func getCategoryOpenAPI(w http.ResponseWriter, r *http.Request) {
+	coreapi.WriteGetAPIResponse(r.Context(), w, r, categoryOpenAPIDocument(), nil)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+//TestCategoryOpenAPICoversRoutes - every registered route needs an entry in categoryOpenAPIRoutes and every entry a route
+func TestCategoryOpenAPICoversRoutes(t *testing.T) {
+	router := mux.NewRouter()
+	SetupAppRoutes(router)
+	document := categoryOpenAPIDocument()
+	paths := document["paths"].(map[string]interface{})
+
+	registered := map[string]bool{}
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			method = strings.ToLower(method)
+			registered[method+" "+path] = true
+			item, _ := paths[path].(map[string]interface{})
+			if _, found := item[method]; !found {
+				t.Errorf("Route %v %v has no OpenAPI entry, add it to categoryOpenAPIRoutes", method, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walking the routes failed with: %v", err)
+	}
+	for path, item := range paths {
+		for method := range item.(map[string]interface{}) {
+			if !registered[method+" "+path] {
+				t.Errorf("OpenAPI entry %v %v has no route", method, path)
+			}
+		}
+	}
+
+	//the document is valid JSON and the schemas follow the Go structs
+	data, err := json.Marshal(document)
+	if err != nil {
+		t.Fatalf("Unable to encode the document: %v", err)
+	}
+	for _, expected := range []string{`"CategoryActions"`, `"operation"`, `"targetModelID"`, `"CategoryUserModel"`, `"titlePolicy"`, `"#/components/schemas/Category"`} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected the document to contain %v", expected)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+	"github.com/suared/core/security"
+
+	"github.com/suared/core-apiuser/ratelimit"
+)
+
+//categoryLimiter - per user limit on changes, nil when PROCESS_CATEGORY_RATE_LIMIT is not set.  Reads are not limited
+var categoryLimiter *ratelimit.Limiter
+
+func init() {
+	rate, burst, err := categoryRateConfig(os.Getenv("PROCESS_CATEGORY_RATE_LIMIT"), os.Getenv("PROCESS_CATEGORY_RATE_BURST"))
+	if err != nil {
+		panic("Unable to setup the category rate limit: " + err.Error())
+	}
+	if rate == 0 {
+		return
+	}
+	store, err := ratelimit.NewStore(os.Getenv("PROCESS_CATEGORY_RATE_STORE"))
+	if err != nil {
+		panic("Unable to setup the category rate limit: " + err.Error())
+	}
+	categoryLimiter = ratelimit.NewLimiter(store, rate, burst)
+}
+
+//categoryRateConfig - the rate and burst from their settings, a rate of 0 when the limit is not set.  A value that is set must be a positive
+//number so a typo fails startup vs. silently turning the limit off
+func categoryRateConfig(rateValue string, burstValue string) (float64, int, error) {
+	burst := 0
+	if burstValue != "" {
+		var err error
+		burst, err = strconv.Atoi(burstValue)
+		if err != nil || burst <= 0 {
+			return 0, 0, fmt.Errorf("PROCESS_CATEGORY_RATE_BURST must be a positive whole number, received: %q", burstValue)
+		}
+	}
+	if rateValue == "" {
+		return 0, 0, nil
+	}
+	rate, err := strconv.ParseFloat(rateValue, 64)
+	if err != nil || rate <= 0 {
+		return 0, 0, fmt.Errorf("PROCESS_CATEGORY_RATE_LIMIT must be a positive number of changes per second, received: %q", rateValue)
+	}
+	if burst == 0 {
+		burst = int(rate) + 1
+	}
+	return rate, burst, nil
+}
+
+//SetCategoryLimiter - replaces the configured limiter, e.g. with a shared store.  nil stops limiting
+func SetCategoryLimiter(limiter *ratelimit.Limiter) {
+	categoryLimiter = limiter
+}
+
+//newTooManyRequestsError - core errors has no 429 constructor yet
+func newTooManyRequestsError(err string) error {
+	return coreerrors.Error{ErrorType: http.StatusTooManyRequests,
+		DeveloperMessage: err}
+}
+
+//categoryRateLimitMiddleware - changes beyond the user's rate are refused with 429 and Retry-After.  GraphQL requests are limited by the
+//handler, only mutations are changes
+func categoryRateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions || r.URL.Path == categoryGraphQLPath() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if allowCategoryChange(w, r) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+//allowCategoryChange - false when the change is over the caller's rate, the 429 is written.  A failing store allows the change
+func allowCategoryChange(w http.ResponseWriter, r *http.Request) bool {
+	if categoryLimiter == nil {
+		return true
+	}
+	ctx := r.Context()
+	key := r.RemoteAddr
+	if !security.IsAnonymous(ctx) {
+		key = security.GetAuth(ctx).GetUser()
+	}
+	wait, err := categoryLimiter.Allow(ctx, key)
+	if err != nil {
+		log.Printf("Category rate limit store failed, allowing the request: %v", err)
+	} else if wait > 0 {
+		w.Header().Set("Retry-After", ratelimit.RetryAfter(wait))
+		coreapi.WritePatchAPIResponse(ctx, w, r, newTooManyRequestsError("Too many category changes, retry after "+ratelimit.RetryAfter(wait)+" seconds"))
+		return false
+	}
+	return true
+}
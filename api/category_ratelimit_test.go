@@ -0,0 +1,29 @@
+package api
+
+import "testing"
+
+func TestCategoryRateConfig(t *testing.T) {
+	tests := []struct {
+		rate, burst   string
+		expectedRate  float64
+		expectedBurst int
+		fails         bool
+	}{
+		{"", "", 0, 0, false},
+		{"", "5", 0, 0, false},
+		{"", "abc", 0, 0, true},
+		{"2.5", "", 2.5, 3, false},
+		{"2", "10", 2, 10, false},
+		{"abc", "", 0, 0, true},
+		{"0", "", 0, 0, true},
+		{"-1", "", 0, 0, true},
+		{"2", "1.5", 0, 0, true},
+		{"2", "0", 0, 0, true},
+	}
+	for _, test := range tests {
+		rate, burst, err := categoryRateConfig(test.rate, test.burst)
+		if (err != nil) != test.fails || rate != test.expectedRate || burst != test.expectedBurst {
+			t.Errorf("%q, %q: expected %v, %v, error %v, received: %v, %v, %v", test.rate, test.burst, test.expectedRate, test.expectedBurst, test.fails, rate, burst, err)
+		}
+	}
+}
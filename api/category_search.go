@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+//CategorySearchHit - one matching category, best matches are returned first.  Path is the titles from the top level category down to the match
+type CategorySearchHit struct {
+	ModelID   string   `json:"modelID"`
+	ModelName string   `json:"modelName,omitempty"`
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	ParentID  string   `json:"parentID,omitempty"`
+	Path      []string `json:"path"`
+	Match     string   `json:"match"`
+	Distance  int      `json:"distance,omitempty"`
+}
+
+func newCategorySearchHit(modelID string, modelName string, result model.CategorySearchResult) CategorySearchHit {
+	return CategorySearchHit{
+		ModelID:   modelID,
+		ModelName: modelName,
+		ID:        result.Category.ID,
+		Title:     result.Category.Title,
+		ParentID:  result.ParentID,
+		Path:      result.Path,
+		Match:     string(result.Match),
+		Distance:  result.Distance,
+	}
+}
+
+//readCategorySearch - q is required; match (exact, prefix, substring or fuzzy), caseSensitive, maxDistance and limit are optional
+func readCategorySearch(r *http.Request) (string, model.CategorySearchOptions, error) {
+	query := r.URL.Query()
+	opts := model.CategorySearchOptions{Limit: repository.DefaultPageLimit}
+	q := query.Get("q")
+	if q == "" {
+		return "", opts, coreerrors.NewClientError("q is required")
+	}
+	match, ok := model.ParseCategoryMatchType(query.Get("match"))
+	if !ok {
+		return "", opts, coreerrors.NewClientError("match must be exact, prefix, substring or fuzzy")
+	}
+	opts.Match = match
+	if caseParam := query.Get("caseSensitive"); caseParam != "" {
+		caseSensitive, err := strconv.ParseBool(caseParam)
+		if err != nil {
+			return "", opts, coreerrors.NewClientError("caseSensitive must be true or false")
+		}
+		opts.CaseSensitive = caseSensitive
+	}
+	if distanceParam := query.Get("maxDistance"); distanceParam != "" {
+		maxDistance, err := strconv.Atoi(distanceParam)
+		if err != nil || maxDistance < 0 || maxDistance > 3 {
+			return "", opts, coreerrors.NewClientError("maxDistance must be a number from 0 to 3, 0 uses a distance based on the query length")
+		}
+		opts.MaxDistance = maxDistance
+	}
+	if limitParam := query.Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 || limit > repository.MaxPageLimit {
+			return "", opts, coreerrors.NewClientError("limit must be a number from 1 to " + strconv.Itoa(repository.MaxPageLimit))
+		}
+		opts.Limit = limit
+	}
+	return q, opts, nil
+}
+
+//GET /Lifeapp/Categories/{modelID}/search?q=&match=&caseSensitive=&maxDistance=&limit=
+func getCategorySearch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q, opts, err := readCategorySearch(r)
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, err)
+		return
+	}
+	modelID := getCategoryModelID(r)
+	categories, err := categoryService.GetCategoryModel(ctx, modelID)
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, getCategoryError(r, "search", err))
+		return
+	}
+	if categories.ID == "" {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, newNotFoundError("Category model not found: "+modelID))
+		return
+	}
+	hits := []CategorySearchHit{}
+	for _, result := range categories.Search(q, opts) {
+		hits = append(hits, newCategorySearchHit(categories.ID, "", result))
+	}
+	coreapi.WriteGetAPIResponse(ctx, w, r, hits, nil)
+}
+
+//GET /Lifeapp/Categories/search?q=&match=&caseSensitive=&maxDistance=&limit= - all of the caller's models
+func getAllCategorySearch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q, opts, err := readCategorySearch(r)
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, err)
+		return
+	}
+	results, err := categoryService.SearchAllCategoryModels(ctx, q, opts)
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, getCategoryError(r, "search", err))
+		return
+	}
+	hits := []CategorySearchHit{}
+	for _, result := range results {
+		hits = append(hits, newCategorySearchHit(result.ModelID, result.ModelName, result.CategorySearchResult))
+	}
+	coreapi.WriteGetAPIResponse(ctx, w, r, hits, nil)
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+
+	"github.com/suared/core-apiuser/repository"
+)
+
+//categoryOwnerParam - query parameter selecting another user's model on the model routes, the caller needs a grant from the owner
+const categoryOwnerParam = "owner"
+
+//maxCategoryShareBytes - larger invite and accept bodies are refused with 413, both are a few short fields
+const maxCategoryShareBytes = 4 * 1024
+
+//CategoryInviteRequest - POST body to invite a user to a model.  Role is editor or viewer, ExpiresIn is a duration such as 24h and defaults to 7 days
+type CategoryInviteRequest struct {
+	Role      string `json:"role"`
+	ExpiresIn string `json:"expiresIn,omitempty"`
+}
+
+//CategoryInvite - send the token to the invitee, it is accepted with POST Lifeapp/Categories/invites/accept
+type CategoryInvite struct {
+	Token     string    `json:"token"`
+	ModelID   string    `json:"modelID"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+//CategoryInviteAccept - POST body to accept an invite
+type CategoryInviteAccept struct {
+	Token string `json:"token"`
+}
+
+//CategoryShare - a user with access to a model
+type CategoryShare struct {
+	UserID    string    `json:"userID"`
+	Role      string    `json:"role"`
+	GrantedAt time.Time `json:"grantedAt"`
+}
+
+//CategorySharedModel - a model shared with the caller, pass OwnerID as ?owner= on the model routes
+type CategorySharedModel struct {
+	ModelID  string    `json:"modelID"`
+	OwnerID  string    `json:"ownerID"`
+	Role     string    `json:"role"`
+	SharedAt time.Time `json:"sharedAt"`
+}
+
+//newForbiddenError - core errors has no forbidden constructor yet
+func newForbiddenError(err string) error {
+	return coreerrors.Error{ErrorType: coreerrors.StatusForbidden,
+		DeveloperMessage: err}
+}
+
+//categoryOwnerMiddleware - ?owner= makes the request work on that user's model
+func categoryOwnerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ownerID := r.URL.Query().Get(categoryOwnerParam)
+		next.ServeHTTP(w, r.WithContext(categoryService.WithCategoryOwner(r.Context(), ownerID)))
+	})
+}
+
+//readCategoryInvite - the invite body with its fields checked, the role is checked by the repository
+func readCategoryInvite(w http.ResponseWriter, r *http.Request) (CategoryInviteRequest, time.Duration, error) {
+	request := CategoryInviteRequest{}
+	err := readCategoryJSON(w, r, &request, maxCategoryShareBytes)
+	if err != nil {
+		return request, 0, err
+	}
+	var fields []CategoryFieldError
+	if request.Role == "" {
+		fields = append(fields, CategoryFieldError{Field: "role", Message: "is required"})
+	}
+	var ttl time.Duration
+	if request.ExpiresIn != "" {
+		ttl, err = time.ParseDuration(request.ExpiresIn)
+		if err != nil || ttl <= 0 {
+			fields = append(fields, CategoryFieldError{Field: "expiresIn", Message: "must be a positive duration such as 24h"})
+		}
+	}
+	if len(fields) > 0 {
+		return request, 0, newCategoryValidationError(fields)
+	}
+	return request, ttl, nil
+}
+
+//POST /Lifeapp/Categories/{modelID}/invites   <CategoryInviteRequest>
+func postCategoryInvite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	request, ttl, err := readCategoryInvite(w, r)
+	if err != nil {
+		writeCategoryRequestError(w, r, err)
+		return
+	}
+	invite, err := categoryService.CreateInvite(ctx, getCategoryModelID(r), request.Role, ttl)
+	if err != nil {
+		coreapi.WritePostAPIResponse(ctx, w, r, "", getCategoryError(r, "invite", err))
+		return
+	}
+	//the token is only available here, so the new invite is returned with the 201
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CategoryInvite{Token: invite.Token(), ModelID: invite.ModelID, Role: string(invite.Role), ExpiresAt: invite.ExpiresAt})
+}
+
+//POST /Lifeapp/Categories/invites/accept   <CategoryInviteAccept>
+func postCategoryInviteAccept(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	request := CategoryInviteAccept{}
+	err := readCategoryJSON(w, r, &request, maxCategoryShareBytes)
+	if err == nil && request.Token == "" {
+		err = newCategoryValidationError([]CategoryFieldError{{Field: "token", Message: "is required"}})
+	}
+	if err != nil {
+		writeCategoryRequestError(w, r, err)
+		return
+	}
+	shared, err := categoryService.AcceptInvite(ctx, request.Token)
+	if err != nil {
+		coreapi.WritePostAPIResponse(ctx, w, r, "", getCategoryError(r, "accept", err))
+		return
+	}
+	w.Header().Set("Location", relPathCategory+"/"+shared.ModelID+"?"+categoryOwnerParam+"="+shared.OwnerID)
+	coreapi.WriteGetAPIResponse(ctx, w, r, CategorySharedModel{ModelID: shared.ModelID, OwnerID: shared.OwnerID, Role: string(shared.Role), SharedAt: shared.SharedAt}, nil)
+}
+
+//GET /Lifeapp/Categories/{modelID}/shares
+func getCategoryShares(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	grants, err := categoryService.ListShares(ctx, getCategoryModelID(r))
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, getCategoryError(r, "shares", err))
+		return
+	}
+	list := []CategoryShare{}
+	for _, grant := range grants {
+		list = append(list, CategoryShare{UserID: grant.GranteeID, Role: string(grant.Role), GrantedAt: grant.GrantedAt})
+	}
+	coreapi.WriteGetAPIResponse(ctx, w, r, list, nil)
+}
+
+//DELETE /Lifeapp/Categories/{modelID}/shares/{userID} - the owner removes access, or the user leaves with ?owner=
+func deleteCategoryShare(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	err := categoryService.RemoveShare(ctx, getCategoryModelID(r), mux.Vars(r)["userID"])
+	if err != nil {
+		err = getCategoryError(r, "share", err)
+	}
+	coreapi.WriteDeleteAPIResponse(ctx, w, r, err)
+}
+
+//GET /Lifeapp/Categories/shared
+func getSharedCategoryModels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	shares, err := categoryService.ListSharedModels(ctx)
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, getCategoryError(r, "shared", err))
+		return
+	}
+	list := []CategorySharedModel{}
+	for _, shared := range shares {
+		list = append(list, CategorySharedModel{ModelID: shared.ModelID, OwnerID: shared.OwnerID, Role: string(shared.Role), SharedAt: shared.SharedAt})
+	}
+	coreapi.WriteGetAPIResponse(ctx, w, r, list, nil)
+}
+
+//getCategoryShareError - maps sharing errors to client errors, nil if err is not one
+func getCategoryShareError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrForbidden):
+		return newForbiddenError(err.Error())
+	case errors.Is(err, repository.ErrInvalidInvite):
+		return coreerrors.NewClientError(repository.ErrInvalidInvite.Error())
+	case errors.Is(err, repository.ErrInvalidRole):
+		return coreerrors.NewClientError(repository.ErrInvalidRole.Error())
+	case errors.Is(err, repository.ErrTooManyShares):
+		return newConflictError(err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	coreapi "github.com/suared/core/api"
+
+	"github.com/suared/core-apiuser/model"
+)
+
+//GET /Lifeapp/Categories/templates
+func getCategoryTemplates(w http.ResponseWriter, r *http.Request) {
+	coreapi.WriteGetAPIResponse(r.Context(), w, r, model.CategoryTemplates(), nil)
+}
+
+//POST /Lifeapp/Categories/templates/{templateName}?name=
+func postCategoryTemplateModel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	templateName := mux.Vars(r)["templateName"]
+	if _, ok := model.GetCategoryTemplate(templateName); !ok {
+		coreapi.WritePostAPIResponse(ctx, w, r, "", newNotFoundError("Category template not found: "+templateName))
+		return
+	}
+	catModel, err := categoryService.CreateCategoryModelFromTemplate(ctx, templateName, r.URL.Query().Get("name"))
+	if err != nil {
+		coreapi.WritePostAPIResponse(ctx, w, r, "", getCategoryError(r, "template", err))
+		return
+	}
+	coreapi.WritePostAPIResponse(ctx, w, r, relPathCategory+"/"+catModel.ID, nil)
+}
@@ -1,10 +1,13 @@
 package api
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"log"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/suared/core-apiuser/model"
@@ -260,3 +263,46 @@ func TestAppLifeCycleE2E(t *testing.T) {
 	}
 
 }
+
+func TestCategoryModelEventsE2E(t *testing.T) {
+	categoriesURI := os.Getenv("PROCESS_LISTEN_URI") + os.Getenv("PROCESS_RELATIVE_PATH") + "/categories"
+	lifeAppCategoriesURI := categoriesURI + "/lifeapp"
+
+	//ensures the lifeapp model exists before subscribing
+	_, err := coretest.SimpleGet(lifeAppCategoriesURI)
+	if err != nil {
+		t.Fatalf("Get failed with: %v", err)
+	}
+	response, err := http.Get(lifeAppCategoriesURI + "/events")
+	if err != nil {
+		t.Fatalf("Events request failed with: %v", err)
+	}
+	defer response.Body.Close()
+	if response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, received: %v", response.Header.Get("Content-Type"))
+	}
+
+	actions := CategoryActions{Operation: "ADD", ID: uuid.NewUUID(), Title: "Live"}
+	byteArr, _ := json.Marshal(actions)
+	coretest.SimplePatch(lifeAppCategoriesURI, byteArr)
+
+	//the added category arrives as an event with an id for resume
+	var eventID string
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "id: ") {
+			eventID = strings.TrimPrefix(line, "id: ")
+		}
+		if strings.HasPrefix(line, "data: ") && strings.Contains(line, actions.ID) {
+			break
+		}
+	}
+	if eventID == "" {
+		t.Errorf("Expected an event for the added category")
+	}
+
+	actions.Operation = "DELETE"
+	byteArr, _ = json.Marshal(actions)
+	coretest.SimplePatch(lifeAppCategoriesURI, byteArr)
+}
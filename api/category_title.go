@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+
+	"github.com/suared/core-apiuser/model"
+)
+
+//newConflictError - core errors has no conflict constructor yet
+func newConflictError(err string) error {
+	return coreerrors.Error{ErrorType: http.StatusConflict,
+		DeveloperMessage: err}
+}
+
+//PUT /Lifeapp/Categories/{modelID}/titlePolicy   <model.CategoryTitlePolicy>
+func putCategoryTitlePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	policy := model.CategoryTitlePolicy{}
+	err := json.NewDecoder(r.Body).Decode(&policy)
+	if err != nil || !policy.Valid() {
+		coreapi.WritePutAPIResponse(ctx, w, r, coreerrors.NewClientError(model.ErrInvalidTitlePolicy.Error()))
+		return
+	}
+	err = categoryService.SetTitlePolicy(ctx, getCategoryModelID(r), policy)
+	if err != nil {
+		err = getCategoryError(r, "titlePolicy", err)
+	}
+	coreapi.WritePutAPIResponse(ctx, w, r, err)
+}
+
+//getCategoryTitleError - maps title policy errors to client errors, nil if err is not one
+func getCategoryTitleError(err error) error {
+	var duplicate *model.DuplicateTitleError
+	switch {
+	case errors.As(err, &duplicate):
+		return newConflictError(duplicate.Error())
+	case errors.Is(err, model.ErrInvalidTitlePolicy):
+		return coreerrors.NewClientError(model.ErrInvalidTitlePolicy.Error())
+	}
+	return nil
+}
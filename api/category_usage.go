@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+
+	coreapi "github.com/suared/core/api"
+)
+
+//GET /Lifeapp/Categories/usage
+func getCategoryUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	usage, err := categoryService.GetUsage(ctx)
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, getCategoryError(r, "usage", err))
+		return
+	}
+	coreapi.WriteGetAPIResponse(ctx, w, r, usage, nil)
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	coreapi "github.com/suared/core/api"
+	coreerrors "github.com/suared/core/errors"
+
+	"github.com/suared/core-apiuser/repository"
+	"github.com/suared/core-apiuser/webhook"
+)
+
+//CategoryWebhookRequest - POST body to register a webhook.  Deliveries are only made to public addresses, a url resolving to a private one records dead deliveries
+type CategoryWebhookRequest struct {
+	URL string `json:"url"`
+}
+
+//CategoryWebhook - a registered webhook.  Secret is only returned when the webhook is created
+type CategoryWebhook struct {
+	ID        string    `json:"id"`
+	ModelID   string    `json:"modelID"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+//CategoryWebhookDelivery - one delivery of an event to a webhook.  Status is pending, delivered or dead (all attempts failed).
+//Pending deliveries are retried at nextAttemptAt
+type CategoryWebhookDelivery struct {
+	ID            string     `json:"id"`
+	WebhookID     string     `json:"webhookID"`
+	EventID       string     `json:"eventID"`
+	EventType     string     `json:"eventType"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	StatusCode    int        `json:"statusCode,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+func newCategoryWebhook(hook *repository.CategoryWebhookDAO) CategoryWebhook {
+	return CategoryWebhook{ID: hook.WebhookID, ModelID: hook.ModelID, URL: hook.URL, Secret: hook.Secret, CreatedAt: hook.CreatedAt}
+}
+
+//confirmCategoryModel - writes a not found error and returns false if the model does not exist for the caller
+func confirmCategoryModel(w http.ResponseWriter, r *http.Request, modelID string, when string) bool {
+	ctx := r.Context()
+	categories, err := categoryService.GetCategoryModel(ctx, modelID)
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, getCategoryError(r, when, err))
+		return false
+	}
+	if categories.ID == "" {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, newNotFoundError("Category model not found: "+modelID))
+		return false
+	}
+	return true
+}
+
+//POST /Lifeapp/Categories/{modelID}/webhooks   <CategoryWebhookRequest>
+func postCategoryWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	modelID := getCategoryModelID(r)
+	request := CategoryWebhookRequest{}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		coreapi.WritePostAPIResponse(ctx, w, r, "", coreerrors.NewClientError("Webhook request must be JSON with a url"))
+		return
+	}
+	if !confirmCategoryModel(w, r, modelID, "webhook") {
+		return
+	}
+	hook, err := categoryService.AddWebhook(ctx, modelID, request.URL)
+	if err != nil {
+		var apiErr error
+		if errors.Is(err, webhook.ErrInvalidURL) {
+			apiErr = coreerrors.NewClientError(webhook.ErrInvalidURL.Error())
+		} else {
+			apiErr = getCategoryError(r, "webhook", err)
+		}
+		coreapi.WritePostAPIResponse(ctx, w, r, "", apiErr)
+		return
+	}
+	//the secret is only available here, so the new webhook is returned with the location
+	w.Header().Set("Location", relPathCategory+"/"+modelID+"/webhooks/"+hook.WebhookID)
+	coreapi.WriteGetAPIResponse(ctx, w, r, newCategoryWebhook(hook), nil)
+}
+
+//GET /Lifeapp/Categories/{modelID}/webhooks
+func getCategoryWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	hooks, err := categoryService.ListWebhooks(ctx, getCategoryModelID(r))
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, getCategoryError(r, "webhooks", err))
+		return
+	}
+	list := []CategoryWebhook{}
+	for _, hook := range hooks {
+		list = append(list, newCategoryWebhook(hook))
+	}
+	coreapi.WriteGetAPIResponse(ctx, w, r, list, nil)
+}
+
+//DELETE /Lifeapp/Categories/{modelID}/webhooks/{webhookID}
+func deleteCategoryWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	err := categoryService.DeleteWebhook(ctx, getCategoryModelID(r), mux.Vars(r)["webhookID"])
+	if err != nil {
+		err = getCategoryError(r, "webhook", err)
+	}
+	coreapi.WriteDeleteAPIResponse(ctx, w, r, err)
+}
+
+//GET /Lifeapp/Categories/{modelID}/webhooks/deliveries?limit=
+func getCategoryWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	limit := repository.DefaultPageLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 || limit > repository.MaxPageLimit {
+			apiErr := coreerrors.NewClientError("limit must be a number from 1 to " + strconv.Itoa(repository.MaxPageLimit))
+			coreapi.WriteGetAPIResponse(ctx, w, r, nil, apiErr)
+			return
+		}
+	}
+	deliveries, err := categoryService.ListWebhookDeliveries(ctx, getCategoryModelID(r), limit)
+	if err != nil {
+		coreapi.WriteGetAPIResponse(ctx, w, r, nil, getCategoryError(r, "deliveries", err))
+		return
+	}
+	list := []CategoryWebhookDelivery{}
+	for _, delivery := range deliveries {
+		item := CategoryWebhookDelivery{
+			ID:         delivery.DeliveryID,
+			WebhookID:  delivery.WebhookID,
+			EventID:    delivery.EventID,
+			EventType:  delivery.EventType,
+			Status:     delivery.Status,
+			Attempts:   delivery.Attempts,
+			StatusCode: delivery.StatusCode,
+			LastError:  delivery.LastError,
+			CreatedAt:  delivery.CreatedAt,
+			UpdatedAt:  delivery.UpdatedAt,
+		}
+		if delivery.Status == repository.DeliveryPending && !delivery.NextAttemptAt.IsZero() {
+			item.NextAttemptAt = &delivery.NextAttemptAt
+		}
+		list = append(list, item)
+	}
+	coreapi.WriteGetAPIResponse(ctx, w, r, list, nil)
+}
@@ -0,0 +1,245 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+/*
+A backup is a portable tar.gz of the decoded category models, independent of the storage encoding and table:
+
+	models/<userID>/<modelID>.json  - one model as returned by the API, including its title policy and limits
+	manifest.json                   - written last, lists every model with the SHA-256 of its file
+
+Restore verifies the whole archive against the manifest before anything is written
+*/
+
+//FormatVersion - the archive layout written by Write, Read refuses newer layouts
+const FormatVersion = 1
+
+//ManifestPath - the archive path of the manifest
+const ManifestPath = "manifest.json"
+
+const modelsDir = "models"
+
+//Manifest - what the archive holds.  Users is the filter the backup was taken with, empty for the whole table
+type Manifest struct {
+	FormatVersion int           `json:"formatVersion"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	Table         string        `json:"table,omitempty"`
+	Users         []string      `json:"users,omitempty"`
+	Models        []ModelEntry  `json:"models"`
+	Skipped       []SkippedItem `json:"skipped,omitempty"`
+}
+
+//ModelEntry - one model file in the archive
+type ModelEntry struct {
+	UserID     string `json:"userID"`
+	ModelID    string `json:"modelID"`
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	SHA256     string `json:"sha256"`
+	Bytes      int64  `json:"bytes"`
+	Categories int    `json:"categories"`
+}
+
+//SkippedItem - a stored model that could not be decoded so is not in the archive, see categoryctl validate
+type SkippedItem struct {
+	UserID  string `json:"userID"`
+	SortKey string `json:"sortKey"`
+	Reason  string `json:"reason"`
+}
+
+//ModelSource - where Write reads the models, implemented by repository.CategoryOperator
+type ModelSource interface {
+	ScanModels(ctx context.Context, each func(dao *repository.CategoryDAO) error) error
+	SelectModels(ctx context.Context, userID string) ([]*repository.CategoryDAO, error)
+}
+
+//WriteOptions - Users limits the backup to their models, empty backs up every model in the table.  Table is recorded in the manifest
+type WriteOptions struct {
+	Users []string
+	Table string
+}
+
+//modelPath - the archive path of the model, ids are escaped as user ids are usually emails and could hold a /
+func modelPath(userID string, modelID string) string {
+	return path.Join(modelsDir, url.PathEscape(userID), url.PathEscape(modelID)+".json")
+}
+
+func countCategories(userModel *repository.CategoryUserModel) int {
+	count := 0
+	stack := append([]*model.Category{}, userModel.Children...)
+	for len(stack) > 0 {
+		category := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if category != nil {
+			count++
+			stack = append(stack, category.Children...)
+		}
+	}
+	return count
+}
+
+//Write - writes the archive of the models to w and returns its manifest.  Models that cannot be decoded are listed as skipped
+func Write(ctx context.Context, source ModelSource, w io.Writer, options WriteOptions) (*Manifest, error) {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	manifest := &Manifest{FormatVersion: FormatVersion, CreatedAt: time.Now().UTC(), Table: options.Table, Users: options.Users}
+
+	add := func(dao *repository.CategoryDAO) error {
+		if decodeErr := dao.DecodeError(); decodeErr != nil {
+			manifest.Skipped = append(manifest.Skipped, SkippedItem{UserID: dao.User(), SortKey: dao.SortKey(), Reason: decodeErr.Err.Error()})
+			return nil
+		}
+		data, err := json.MarshalIndent(dao.CategoryUserModel, "", "  ")
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		entry := ModelEntry{UserID: dao.User(), ModelID: dao.ID, Name: dao.Name, Path: modelPath(dao.User(), dao.ID),
+			SHA256: hex.EncodeToString(sum[:]), Bytes: int64(len(data)), Categories: countCategories(&dao.CategoryUserModel)}
+		manifest.Models = append(manifest.Models, entry)
+		return writeFile(tarWriter, entry.Path, data, manifest.CreatedAt)
+	}
+
+	var err error
+	if len(options.Users) == 0 {
+		err = source.ScanModels(ctx, add)
+	}
+	for _, userID := range options.Users {
+		var daos []*repository.CategoryDAO
+		daos, err = source.SelectModels(ctx, userID)
+		for i := 0; err == nil && i < len(daos); i++ {
+			err = add(daos[i])
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Category backup failed with: %w", err)
+	}
+
+	sort.Slice(manifest.Models, func(i, j int) bool { return manifest.Models[i].Path < manifest.Models[j].Path })
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = writeFile(tarWriter, ManifestPath, data, manifest.CreatedAt)
+	}
+	if err == nil {
+		err = tarWriter.Close()
+	}
+	if err == nil {
+		err = gzipWriter.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Category backup write failed with: %w", err)
+	}
+	return manifest, nil
+}
+
+func writeFile(tarWriter *tar.Writer, name string, data []byte, modTime time.Time) error {
+	err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg})
+	if err != nil {
+		return err
+	}
+	_, err = tarWriter.Write(data)
+	return err
+}
+
+//Archive - a read and verified backup
+type Archive struct {
+	Manifest *Manifest
+	files    map[string][]byte
+}
+
+//ErrCorruptArchive - the archive does not match its manifest
+var ErrCorruptArchive = errors.New("category backup archive does not match its manifest")
+
+//Read - reads the archive into memory and verifies every model file against the manifest.  Fails with ErrCorruptArchive on a missing,
+//changed or unlisted file
+func Read(r io.Reader) (*Archive, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptArchive, err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	archive := &Archive{files: make(map[string][]byte)}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptArchive, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptArchive, err)
+		}
+		archive.files[header.Name] = data
+	}
+
+	manifestData, found := archive.files[ManifestPath]
+	if !found {
+		return nil, fmt.Errorf("%w: no %v", ErrCorruptArchive, ManifestPath)
+	}
+	archive.Manifest = new(Manifest)
+	err = json.Unmarshal(manifestData, archive.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptArchive, err)
+	}
+	if archive.Manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("Category backup format version %v is newer than this build supports (%v)", archive.Manifest.FormatVersion, FormatVersion)
+	}
+
+	listed := map[string]bool{ManifestPath: true}
+	for _, entry := range archive.Manifest.Models {
+		listed[entry.Path] = true
+		data, found := archive.files[entry.Path]
+		if !found {
+			return nil, fmt.Errorf("%w: %v is missing", ErrCorruptArchive, entry.Path)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != entry.SHA256 || int64(len(data)) != entry.Bytes {
+			return nil, fmt.Errorf("%w: %v checksum differs", ErrCorruptArchive, entry.Path)
+		}
+	}
+	for name := range archive.files {
+		if !listed[name] {
+			return nil, fmt.Errorf("%w: %v is not in the manifest", ErrCorruptArchive, name)
+		}
+	}
+	return archive, nil
+}
+
+//Model - the model of the manifest entry
+func (archive *Archive) Model(entry ModelEntry) (repository.CategoryUserModel, error) {
+	var userModel repository.CategoryUserModel
+	err := json.Unmarshal(archive.files[entry.Path], &userModel)
+	if err != nil {
+		return userModel, fmt.Errorf("%v: %w", entry.Path, err)
+	}
+	if userModel.ID != entry.ModelID {
+		return userModel, fmt.Errorf("%w: %v holds model %v", ErrCorruptArchive, entry.Path, userModel.ID)
+	}
+	return userModel, nil
+}
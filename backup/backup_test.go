@@ -0,0 +1,185 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+//memoryModels - a ModelSource and ModelStore over a map of user id to models
+type memoryModels struct {
+	models map[string][]*repository.CategoryDAO
+	saved  []string
+}
+
+func (store *memoryModels) ScanModels(ctx context.Context, each func(dao *repository.CategoryDAO) error) error {
+	for _, userID := range []string{"testuser1", "a/b@example.com"} {
+		for _, dao := range store.models[userID] {
+			if err := each(dao); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (store *memoryModels) SelectModels(ctx context.Context, userID string) ([]*repository.CategoryDAO, error) {
+	return store.models[userID], nil
+}
+
+func (store *memoryModels) SelectModel(ctx context.Context, userID string, modelID string) (*repository.CategoryDAO, error) {
+	for _, dao := range store.models[userID] {
+		if dao.ID == modelID {
+			return dao, nil
+		}
+	}
+	return &repository.CategoryDAO{}, nil
+}
+
+func (store *memoryModels) Save(ctx context.Context, userID string, userModel repository.CategoryUserModel, reason string, events ...model.CategoryEvent) error {
+	if len(events) != 1 || events[0].Type != model.CategoryModelReplaced {
+		return errors.New("expected a replaced event")
+	}
+	store.saved = append(store.saved, userID+"/"+userModel.ID)
+	return nil
+}
+
+func modelDAO(userID string, modelID string, titles ...string) *repository.CategoryDAO {
+	dao := &repository.CategoryDAO{UserID: userID}
+	dao.ID = modelID
+	dao.Name = "Model " + modelID
+	dao.TitlePolicy = model.CategoryTitlePolicy{Uniqueness: model.TitlesSuffix}
+	for _, title := range titles {
+		dao.AddChild(model.Category{ID: title, Title: title})
+	}
+	return dao
+}
+
+func testSource() *memoryModels {
+	undecodable := &repository.CategoryDAO{UserID: "a/b@example.com", CategorySortKey: "broken", CategoryUserModelData: []byte("not a model")}
+	undecodable.Populate()
+	return &memoryModels{models: map[string][]*repository.CategoryDAO{
+		"testuser1":       {modelDAO("testuser1", "m1", "Work", "Home"), modelDAO("testuser1", "m2")},
+		"a/b@example.com": {modelDAO("a/b@example.com", "m3", "Garden"), undecodable},
+	}}
+}
+
+func TestWriteAndRead(t *testing.T) {
+	var archiveData bytes.Buffer
+	manifest, err := Write(context.TODO(), testSource(), &archiveData, WriteOptions{Table: "category_test"})
+	if err != nil {
+		t.Fatalf("Write failed with: %v", err)
+	}
+	if len(manifest.Models) != 3 || len(manifest.Skipped) != 1 || manifest.Skipped[0].SortKey != "broken" {
+		t.Fatalf("Expected three models and the undecodable item skipped, received: %+v", manifest)
+	}
+	if manifest.Models[0].Path != "models/a%2Fb@example.com/m3.json" || manifest.Models[1].Categories != 2 {
+		t.Errorf("Expected escaped paths in order with category counts, received: %+v", manifest.Models)
+	}
+
+	archive, err := Read(bytes.NewReader(archiveData.Bytes()))
+	if err != nil {
+		t.Fatalf("Read failed with: %v", err)
+	}
+	if archive.Manifest.Table != "category_test" || len(archive.Manifest.Models) != 3 {
+		t.Errorf("Expected the manifest back, received: %+v", archive.Manifest)
+	}
+	userModel, err := archive.Model(archive.Manifest.Models[1])
+	original := testSource().models["testuser1"][0]
+	if err != nil || !userModel.CategoryRoot.Equals(&original.CategoryRoot) || userModel.TitlePolicy != original.TitlePolicy {
+		t.Errorf("Expected the model with its title policy, received: %+v %v", userModel, err)
+	}
+
+	//only the users asked for
+	archiveData.Reset()
+	manifest, err = Write(context.TODO(), testSource(), &archiveData, WriteOptions{Users: []string{"testuser1"}})
+	if err != nil || len(manifest.Models) != 2 || len(manifest.Skipped) != 0 {
+		t.Errorf("Expected the user's two models, received: %+v %v", manifest, err)
+	}
+}
+
+//rewriteArchive - copies the archive changing the named file with change, nil data drops it
+func rewriteArchive(t *testing.T, data []byte, name string, change func([]byte) []byte) []byte {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	var out bytes.Buffer
+	gzipWriter := gzip.NewWriter(&out)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		content, _ := ioutil.ReadAll(tarReader)
+		if header.Name == name {
+			if content = change(content); content == nil {
+				continue
+			}
+		}
+		header.Size = int64(len(content))
+		tarWriter.WriteHeader(header)
+		tarWriter.Write(content)
+	}
+	tarWriter.Close()
+	gzipWriter.Close()
+	return out.Bytes()
+}
+
+func TestReadCorruptArchive(t *testing.T) {
+	var archiveData bytes.Buffer
+	_, err := Write(context.TODO(), testSource(), &archiveData, WriteOptions{})
+	if err != nil {
+		t.Fatalf("Write failed with: %v", err)
+	}
+	path := modelPath("testuser1", "m1")
+	tests := map[string][]byte{
+		"changed":     rewriteArchive(t, archiveData.Bytes(), path, func(content []byte) []byte { return bytes.Replace(content, []byte("Work"), []byte("Play"), 1) }),
+		"missing":     rewriteArchive(t, archiveData.Bytes(), path, func([]byte) []byte { return nil }),
+		"no manifest": rewriteArchive(t, archiveData.Bytes(), ManifestPath, func([]byte) []byte { return nil }),
+		"truncated":   archiveData.Bytes()[:archiveData.Len()/2],
+	}
+	for name, data := range tests {
+		if _, err = Read(bytes.NewReader(data)); !errors.Is(err, ErrCorruptArchive) {
+			t.Errorf("Expected the %v archive to be refused, received: %v", name, err)
+		}
+	}
+}
+
+func TestRestore(t *testing.T) {
+	var archiveData bytes.Buffer
+	_, err := Write(context.TODO(), testSource(), &archiveData, WriteOptions{})
+	if err != nil {
+		t.Fatalf("Write failed with: %v", err)
+	}
+	archive, err := Read(&archiveData)
+	if err != nil {
+		t.Fatalf("Read failed with: %v", err)
+	}
+
+	//testuser1 already has m1 in the target
+	target := &memoryModels{models: map[string][]*repository.CategoryDAO{"testuser1": {modelDAO("testuser1", "m1")}}}
+	result, err := Restore(context.TODO(), target, archive, RestoreOptions{DryRun: true})
+	if err != nil || len(result.Restored) != 2 || len(result.Existing) != 1 || len(target.saved) != 0 {
+		t.Errorf("Expected a dry run to report without writing, received: %+v %v %v", result, target.saved, err)
+	}
+	result, err = Restore(context.TODO(), target, archive, RestoreOptions{Users: []string{"testuser1"}})
+	if err != nil || len(result.Restored) != 1 || len(target.saved) != 1 || target.saved[0] != "testuser1/m2" {
+		t.Errorf("Expected only the user's missing model restored, received: %+v %v %v", result, target.saved, err)
+	}
+	target.saved = nil
+	result, err = Restore(context.TODO(), target, archive, RestoreOptions{Overwrite: true})
+	if err != nil || len(result.Restored) != 3 || len(result.Existing) != 0 || len(target.saved) != 3 {
+		t.Errorf("Expected every model restored with overwrite, received: %+v %v %v", result, target.saved, err)
+	}
+}
@@ -0,0 +1,93 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	awsSession "github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+
+	"github.com/suared/core-apiuser/repository"
+)
+
+//ScheduledBackupResult - returned by the scheduled backup, Location is the uploaded s3:// url or the local file
+type ScheduledBackupResult struct {
+	Location string `json:"location"`
+	Models   int    `json:"models"`
+	Skipped  int    `json:"skipped"`
+}
+
+//HandleScheduledBackup - the Lambda handler for the backup schedule, see infra/dev/backup.tf
+func HandleScheduledBackup(ctx context.Context, event events.CloudWatchEvent) (ScheduledBackupResult, error) {
+	return RunScheduledBackup(ctx)
+}
+
+//RunScheduledBackup - backs up the whole table to a file in PROCESS_CATEGORY_BACKUP_DIR (the temp dir when empty).  When
+//PROCESS_CATEGORY_BACKUP_BUCKET is set the file is uploaded under PROCESS_CATEGORY_BACKUP_PREFIX and removed
+func RunScheduledBackup(ctx context.Context) (ScheduledBackupResult, error) {
+	repo, err := repository.NewCategoryRepository()
+	if err != nil {
+		return ScheduledBackupResult{}, err
+	}
+	dir := os.Getenv("PROCESS_CATEGORY_BACKUP_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	name := "category-backup-" + time.Now().UTC().Format("20060102T150405Z") + ".tar.gz"
+	filename := filepath.Join(dir, name)
+	file, err := os.Create(filename)
+	if err != nil {
+		return ScheduledBackupResult{}, err
+	}
+	manifest, err := Write(ctx, repository.NewCategoryOperator(repo, "backup"), file, WriteOptions{Table: os.Getenv("PROCESS_AWS_DYNAMOTABLE_CATEGORY")})
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filename)
+		return ScheduledBackupResult{}, err
+	}
+	result := ScheduledBackupResult{Location: filename, Models: len(manifest.Models), Skipped: len(manifest.Skipped)}
+	for _, skipped := range manifest.Skipped {
+		log.Printf("Category backup skipped %v %v: %v", skipped.UserID, skipped.SortKey, skipped.Reason)
+	}
+
+	bucket := os.Getenv("PROCESS_CATEGORY_BACKUP_BUCKET")
+	if bucket == "" {
+		return result, nil
+	}
+	key := os.Getenv("PROCESS_CATEGORY_BACKUP_PREFIX") + name
+	err = upload(ctx, filename, bucket, key)
+	if err != nil {
+		return result, fmt.Errorf("Category backup upload to %v failed, the archive is kept at %v: %w", bucket, filename, err)
+	}
+	os.Remove(filename)
+	result.Location = "s3://" + bucket + "/" + key
+	return result, nil
+}
+
+func upload(ctx context.Context, filename string, bucket string, key string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	awsSess, err := awsSession.NewSessionWithOptions(awsSession.Options{Config: aws.Config{Region: aws.String(os.Getenv("PROCESS_AWS_REGION"))}})
+	if err != nil {
+		return err
+	}
+	_, err = s3manager.NewUploader(awsSess).UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        file,
+		ContentType: aws.String("application/gzip"),
+	})
+	return err
+}
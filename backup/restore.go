@@ -0,0 +1,74 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+//ModelStore - where Restore writes the models, implemented by repository.CategoryOperator
+type ModelStore interface {
+	SelectModel(ctx context.Context, userID string, modelID string) (*repository.CategoryDAO, error)
+	Save(ctx context.Context, userID string, userModel repository.CategoryUserModel, reason string, events ...model.CategoryEvent) error
+}
+
+//RestoreOptions - Users limits the restore to their models, empty restores the whole archive.  Models already in the target are left
+//unless Overwrite is set.  DryRun reports what would be restored without writing
+type RestoreOptions struct {
+	Users     []string
+	Overwrite bool
+	DryRun    bool
+}
+
+//RestoreResult - Restored lists the models written, or that would be on a dry run.  Existing lists the models left as they were
+type RestoreResult struct {
+	Restored []ModelEntry
+	Existing []ModelEntry
+}
+
+//Restore - writes the archive's models to the store.  Every selected model is decoded before the first write so a bad archive writes nothing,
+//on a write failure the result holds the models restored so far
+func Restore(ctx context.Context, store ModelStore, archive *Archive, options RestoreOptions) (RestoreResult, error) {
+	users := make(map[string]bool, len(options.Users))
+	for _, userID := range options.Users {
+		users[userID] = true
+	}
+	var entries []ModelEntry
+	var userModels []repository.CategoryUserModel
+	for _, entry := range archive.Manifest.Models {
+		if len(users) > 0 && !users[entry.UserID] {
+			continue
+		}
+		userModel, err := archive.Model(entry)
+		if err != nil {
+			return RestoreResult{}, err
+		}
+		entries = append(entries, entry)
+		userModels = append(userModels, userModel)
+	}
+
+	result := RestoreResult{}
+	reason := "restore of the backup from " + archive.Manifest.CreatedAt.Format("2006-01-02T15:04:05Z")
+	for i, entry := range entries {
+		current, err := store.SelectModel(ctx, entry.UserID, entry.ModelID)
+		if err != nil {
+			return result, fmt.Errorf("Category restore of %v failed with: %w", entry.Path, err)
+		}
+		//a stored model that cannot be decoded still exists
+		if (current.ID != "" || current.DecodeError() != nil) && !options.Overwrite {
+			result.Existing = append(result.Existing, entry)
+			continue
+		}
+		if !options.DryRun {
+			event := model.NewCategoryEvent(model.CategoryModelReplaced, entry.UserID, entry.ModelID)
+			err = store.Save(ctx, entry.UserID, userModels[i], reason, event)
+			if err != nil {
+				return result, fmt.Errorf("Category restore of %v failed with: %w", entry.Path, err)
+			}
+		}
+		result.Restored = append(result.Restored, entry)
+	}
+	return result, nil
+}
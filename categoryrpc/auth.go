@@ -0,0 +1,76 @@
+package categoryrpc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/suared/core/security"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//authorizationMetadata - the metadata key carrying the same value as the REST Authorization header
+const authorizationMetadata = "authorization"
+
+//AuthContext - sets up the security auth context the repository relies on from the authorization metadata, the same value as the REST
+//Authorization header.  No value is the anonymous user.  Called by the interceptors before each call
+func AuthContext(ctx context.Context, authorization string) (context.Context, error) {
+	r, err := http.NewRequest(http.MethodPost, "/", nil)
+	if err != nil {
+		return ctx, err
+	}
+	r = r.WithContext(ctx)
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	authCtx, err := security.SetupAuthFromHTTP(r)
+	if err != nil {
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return authCtx, nil
+}
+
+//callContext - the context a call runs with, set up like the REST routes: the caller from the authorization metadata and the
+//invocation cache when PROCESS_CATEGORY_CACHE_SCOPE is invocation
+func (s *Server) callContext(ctx context.Context) (context.Context, error) {
+	authorization := ""
+	if values := metadata.ValueFromIncomingContext(ctx, authorizationMetadata); len(values) > 0 {
+		authorization = values[0]
+	}
+	ctx, err := AuthContext(ctx, authorization)
+	if err != nil {
+		return ctx, err
+	}
+	return s.categoryService.WithInvocationCache(ctx), nil
+}
+
+//UnaryAuthInterceptor - authenticates a unary call before it is handled, see callContext
+func (s *Server) UnaryAuthInterceptor(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.callContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, request)
+}
+
+//StreamAuthInterceptor - authenticates a streaming call before it is handled, the handler sees the context through the stream
+func (s *Server) StreamAuthInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.callContext(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authServerStream{ServerStream: stream, ctx: ctx})
+}
+
+//authServerStream - a server stream with the authenticated context
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+//Context - the authenticated context
+func (stream *authServerStream) Context() context.Context {
+	return stream.ctx
+}
@@ -0,0 +1,935 @@
+// The category service for backend callers, mirroring service.CategoryService.  category.pb.go and category_grpc.pb.go are generated
+// from this file with protoc-gen-go v1.36.10 and protoc-gen-go-grpc v1.5.1, regenerate them after a change from the repository root with:
+//   protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative categoryrpc/category.proto
+//
+// Calls are authenticated like the REST API: send the "authorization" metadata with the same value as the Authorization header.
+// Send "owner" on a request to work on a model another user shared with the caller.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: categoryrpc/category.proto
+
+package categoryrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Category struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Children      []*Category            `protobuf:"bytes,3,rep,name=children,proto3" json:"children,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Category) Reset() {
+	*x = Category{}
+	mi := &file_categoryrpc_category_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Category) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Category) ProtoMessage() {}
+
+func (x *Category) ProtoReflect() protoreflect.Message {
+	mi := &file_categoryrpc_category_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Category.ProtoReflect.Descriptor instead.
+func (*Category) Descriptor() ([]byte, []int) {
+	return file_categoryrpc_category_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Category) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Category) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Category) GetChildren() []*Category {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+type CategoryLimits struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	MaxDepth       int32                  `protobuf:"varint,1,opt,name=max_depth,json=maxDepth,proto3" json:"max_depth,omitempty"`
+	MaxChildren    int32                  `protobuf:"varint,2,opt,name=max_children,json=maxChildren,proto3" json:"max_children,omitempty"`
+	MaxCategories  int32                  `protobuf:"varint,3,opt,name=max_categories,json=maxCategories,proto3" json:"max_categories,omitempty"`
+	MaxTitleLength int32                  `protobuf:"varint,4,opt,name=max_title_length,json=maxTitleLength,proto3" json:"max_title_length,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CategoryLimits) Reset() {
+	*x = CategoryLimits{}
+	mi := &file_categoryrpc_category_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoryLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoryLimits) ProtoMessage() {}
+
+func (x *CategoryLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_categoryrpc_category_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoryLimits.ProtoReflect.Descriptor instead.
+func (*CategoryLimits) Descriptor() ([]byte, []int) {
+	return file_categoryrpc_category_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CategoryLimits) GetMaxDepth() int32 {
+	if x != nil {
+		return x.MaxDepth
+	}
+	return 0
+}
+
+func (x *CategoryLimits) GetMaxChildren() int32 {
+	if x != nil {
+		return x.MaxChildren
+	}
+	return 0
+}
+
+func (x *CategoryLimits) GetMaxCategories() int32 {
+	if x != nil {
+		return x.MaxCategories
+	}
+	return 0
+}
+
+func (x *CategoryLimits) GetMaxTitleLength() int32 {
+	if x != nil {
+		return x.MaxTitleLength
+	}
+	return 0
+}
+
+type CategoryModel struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name       string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Categories []*Category            `protobuf:"bytes,3,rep,name=categories,proto3" json:"categories,omitempty"`
+	// The sibling title policy, e.g. "reject,ignoreCase"
+	TitlePolicy string `protobuf:"bytes,4,opt,name=title_policy,json=titlePolicy,proto3" json:"title_policy,omitempty"`
+	// The model's own limits, 0 is unlimited
+	Limits        *CategoryLimits `protobuf:"bytes,5,opt,name=limits,proto3" json:"limits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CategoryModel) Reset() {
+	*x = CategoryModel{}
+	mi := &file_categoryrpc_category_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoryModel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoryModel) ProtoMessage() {}
+
+func (x *CategoryModel) ProtoReflect() protoreflect.Message {
+	mi := &file_categoryrpc_category_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoryModel.ProtoReflect.Descriptor instead.
+func (*CategoryModel) Descriptor() ([]byte, []int) {
+	return file_categoryrpc_category_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CategoryModel) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CategoryModel) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CategoryModel) GetCategories() []*Category {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *CategoryModel) GetTitlePolicy() string {
+	if x != nil {
+		return x.TitlePolicy
+	}
+	return ""
+}
+
+func (x *CategoryModel) GetLimits() *CategoryLimits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+type GetCategoryModelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelId       string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCategoryModelRequest) Reset() {
+	*x = GetCategoryModelRequest{}
+	mi := &file_categoryrpc_category_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCategoryModelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCategoryModelRequest) ProtoMessage() {}
+
+func (x *GetCategoryModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_categoryrpc_category_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCategoryModelRequest.ProtoReflect.Descriptor instead.
+func (*GetCategoryModelRequest) Descriptor() ([]byte, []int) {
+	return file_categoryrpc_category_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetCategoryModelRequest) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *GetCategoryModelRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+type AddCategoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelId       string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	ParentId      string                 `protobuf:"bytes,3,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	Category      *Category              `protobuf:"bytes,4,opt,name=category,proto3" json:"category,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddCategoryRequest) Reset() {
+	*x = AddCategoryRequest{}
+	mi := &file_categoryrpc_category_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddCategoryRequest) ProtoMessage() {}
+
+func (x *AddCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_categoryrpc_category_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddCategoryRequest.ProtoReflect.Descriptor instead.
+func (*AddCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_categoryrpc_category_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AddCategoryRequest) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *AddCategoryRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *AddCategoryRequest) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *AddCategoryRequest) GetCategory() *Category {
+	if x != nil {
+		return x.Category
+	}
+	return nil
+}
+
+type MoveCategoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelId       string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Id            string                 `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	NewParentId   string                 `protobuf:"bytes,4,opt,name=new_parent_id,json=newParentId,proto3" json:"new_parent_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveCategoryRequest) Reset() {
+	*x = MoveCategoryRequest{}
+	mi := &file_categoryrpc_category_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveCategoryRequest) ProtoMessage() {}
+
+func (x *MoveCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_categoryrpc_category_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveCategoryRequest.ProtoReflect.Descriptor instead.
+func (*MoveCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_categoryrpc_category_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MoveCategoryRequest) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *MoveCategoryRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *MoveCategoryRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *MoveCategoryRequest) GetNewParentId() string {
+	if x != nil {
+		return x.NewParentId
+	}
+	return ""
+}
+
+type UpdateCategoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelId       string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Category      *Category              `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCategoryRequest) Reset() {
+	*x = UpdateCategoryRequest{}
+	mi := &file_categoryrpc_category_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCategoryRequest) ProtoMessage() {}
+
+func (x *UpdateCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_categoryrpc_category_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCategoryRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_categoryrpc_category_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UpdateCategoryRequest) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *UpdateCategoryRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *UpdateCategoryRequest) GetCategory() *Category {
+	if x != nil {
+		return x.Category
+	}
+	return nil
+}
+
+type DeleteCategoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelId       string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Id            string                 `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCategoryRequest) Reset() {
+	*x = DeleteCategoryRequest{}
+	mi := &file_categoryrpc_category_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCategoryRequest) ProtoMessage() {}
+
+func (x *DeleteCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_categoryrpc_category_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCategoryRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_categoryrpc_category_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteCategoryRequest) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *DeleteCategoryRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *DeleteCategoryRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ReplaceCategoryModelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Owner         string                 `protobuf:"bytes,1,opt,name=owner,proto3" json:"owner,omitempty"`
+	Model         *CategoryModel         `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplaceCategoryModelRequest) Reset() {
+	*x = ReplaceCategoryModelRequest{}
+	mi := &file_categoryrpc_category_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplaceCategoryModelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplaceCategoryModelRequest) ProtoMessage() {}
+
+func (x *ReplaceCategoryModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_categoryrpc_category_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplaceCategoryModelRequest.ProtoReflect.Descriptor instead.
+func (*ReplaceCategoryModelRequest) Descriptor() ([]byte, []int) {
+	return file_categoryrpc_category_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReplaceCategoryModelRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *ReplaceCategoryModelRequest) GetModel() *CategoryModel {
+	if x != nil {
+		return x.Model
+	}
+	return nil
+}
+
+type WatchCategoryModelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelId       string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	LastEventId   string                 `protobuf:"bytes,3,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchCategoryModelRequest) Reset() {
+	*x = WatchCategoryModelRequest{}
+	mi := &file_categoryrpc_category_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchCategoryModelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchCategoryModelRequest) ProtoMessage() {}
+
+func (x *WatchCategoryModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_categoryrpc_category_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchCategoryModelRequest.ProtoReflect.Descriptor instead.
+func (*WatchCategoryModelRequest) Descriptor() ([]byte, []int) {
+	return file_categoryrpc_category_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WatchCategoryModelRequest) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *WatchCategoryModelRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *WatchCategoryModelRequest) GetLastEventId() string {
+	if x != nil {
+		return x.LastEventId
+	}
+	return ""
+}
+
+type CategoryEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// CategoryAdded, CategoryMoved, CategoryRenamed, CategoryDeleted, CategoryModelReplaced, CategoryModelDeleted, or reset
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ActorId       string                 `protobuf:"bytes,4,opt,name=actor_id,json=actorId,proto3" json:"actor_id,omitempty"`
+	ModelId       string                 `protobuf:"bytes,5,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	CategoryId    string                 `protobuf:"bytes,6,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	ParentId      string                 `protobuf:"bytes,7,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	OldParentId   string                 `protobuf:"bytes,8,opt,name=old_parent_id,json=oldParentId,proto3" json:"old_parent_id,omitempty"`
+	Title         string                 `protobuf:"bytes,9,opt,name=title,proto3" json:"title,omitempty"`
+	OldTitle      string                 `protobuf:"bytes,10,opt,name=old_title,json=oldTitle,proto3" json:"old_title,omitempty"`
+	OccurredAt    *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CategoryEvent) Reset() {
+	*x = CategoryEvent{}
+	mi := &file_categoryrpc_category_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoryEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoryEvent) ProtoMessage() {}
+
+func (x *CategoryEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_categoryrpc_category_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoryEvent.ProtoReflect.Descriptor instead.
+func (*CategoryEvent) Descriptor() ([]byte, []int) {
+	return file_categoryrpc_category_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CategoryEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CategoryEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CategoryEvent) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CategoryEvent) GetActorId() string {
+	if x != nil {
+		return x.ActorId
+	}
+	return ""
+}
+
+func (x *CategoryEvent) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+func (x *CategoryEvent) GetCategoryId() string {
+	if x != nil {
+		return x.CategoryId
+	}
+	return ""
+}
+
+func (x *CategoryEvent) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *CategoryEvent) GetOldParentId() string {
+	if x != nil {
+		return x.OldParentId
+	}
+	return ""
+}
+
+func (x *CategoryEvent) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CategoryEvent) GetOldTitle() string {
+	if x != nil {
+		return x.OldTitle
+	}
+	return ""
+}
+
+func (x *CategoryEvent) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+var File_categoryrpc_category_proto protoreflect.FileDescriptor
+
+const file_categoryrpc_category_proto_rawDesc = "" +
+	"\n" +
+	"\x1acategoryrpc/category.proto\x12\vcategory.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"c\n" +
+	"\bCategory\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x121\n" +
+	"\bchildren\x18\x03 \x03(\v2\x15.category.v1.CategoryR\bchildren\"\xa1\x01\n" +
+	"\x0eCategoryLimits\x12\x1b\n" +
+	"\tmax_depth\x18\x01 \x01(\x05R\bmaxDepth\x12!\n" +
+	"\fmax_children\x18\x02 \x01(\x05R\vmaxChildren\x12%\n" +
+	"\x0emax_categories\x18\x03 \x01(\x05R\rmaxCategories\x12(\n" +
+	"\x10max_title_length\x18\x04 \x01(\x05R\x0emaxTitleLength\"\xc2\x01\n" +
+	"\rCategoryModel\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x125\n" +
+	"\n" +
+	"categories\x18\x03 \x03(\v2\x15.category.v1.CategoryR\n" +
+	"categories\x12!\n" +
+	"\ftitle_policy\x18\x04 \x01(\tR\vtitlePolicy\x123\n" +
+	"\x06limits\x18\x05 \x01(\v2\x1b.category.v1.CategoryLimitsR\x06limits\"J\n" +
+	"\x17GetCategoryModelRequest\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\"\x95\x01\n" +
+	"\x12AddCategoryRequest\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x1b\n" +
+	"\tparent_id\x18\x03 \x01(\tR\bparentId\x121\n" +
+	"\bcategory\x18\x04 \x01(\v2\x15.category.v1.CategoryR\bcategory\"z\n" +
+	"\x13MoveCategoryRequest\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\x12\"\n" +
+	"\rnew_parent_id\x18\x04 \x01(\tR\vnewParentId\"{\n" +
+	"\x15UpdateCategoryRequest\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x121\n" +
+	"\bcategory\x18\x03 \x01(\v2\x15.category.v1.CategoryR\bcategory\"X\n" +
+	"\x15DeleteCategoryRequest\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\"e\n" +
+	"\x1bReplaceCategoryModelRequest\x12\x14\n" +
+	"\x05owner\x18\x01 \x01(\tR\x05owner\x120\n" +
+	"\x05model\x18\x02 \x01(\v2\x1a.category.v1.CategoryModelR\x05model\"p\n" +
+	"\x19WatchCategoryModelRequest\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\"\n" +
+	"\rlast_event_id\x18\x03 \x01(\tR\vlastEventId\"\xd4\x02\n" +
+	"\rCategoryEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x19\n" +
+	"\bactor_id\x18\x04 \x01(\tR\aactorId\x12\x19\n" +
+	"\bmodel_id\x18\x05 \x01(\tR\amodelId\x12\x1f\n" +
+	"\vcategory_id\x18\x06 \x01(\tR\n" +
+	"categoryId\x12\x1b\n" +
+	"\tparent_id\x18\a \x01(\tR\bparentId\x12\"\n" +
+	"\rold_parent_id\x18\b \x01(\tR\voldParentId\x12\x14\n" +
+	"\x05title\x18\t \x01(\tR\x05title\x12\x1b\n" +
+	"\told_title\x18\n" +
+	" \x01(\tR\boldTitle\x12;\n" +
+	"\voccurred_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt2\xdf\x04\n" +
+	"\x0fCategoryService\x12T\n" +
+	"\x10GetCategoryModel\x12$.category.v1.GetCategoryModelRequest\x1a\x1a.category.v1.CategoryModel\x12J\n" +
+	"\vAddCategory\x12\x1f.category.v1.AddCategoryRequest\x1a\x1a.category.v1.CategoryModel\x12L\n" +
+	"\fMoveCategory\x12 .category.v1.MoveCategoryRequest\x1a\x1a.category.v1.CategoryModel\x12P\n" +
+	"\x0eUpdateCategory\x12\".category.v1.UpdateCategoryRequest\x1a\x1a.category.v1.CategoryModel\x12P\n" +
+	"\x0eDeleteCategory\x12\".category.v1.DeleteCategoryRequest\x1a\x1a.category.v1.CategoryModel\x12\\\n" +
+	"\x14ReplaceCategoryModel\x12(.category.v1.ReplaceCategoryModelRequest\x1a\x1a.category.v1.CategoryModel\x12Z\n" +
+	"\x12WatchCategoryModel\x12&.category.v1.WatchCategoryModelRequest\x1a\x1a.category.v1.CategoryEvent0\x01B,Z*github.com/suared/core-apiuser/categoryrpcb\x06proto3"
+
+var (
+	file_categoryrpc_category_proto_rawDescOnce sync.Once
+	file_categoryrpc_category_proto_rawDescData []byte
+)
+
+func file_categoryrpc_category_proto_rawDescGZIP() []byte {
+	file_categoryrpc_category_proto_rawDescOnce.Do(func() {
+		file_categoryrpc_category_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_categoryrpc_category_proto_rawDesc), len(file_categoryrpc_category_proto_rawDesc)))
+	})
+	return file_categoryrpc_category_proto_rawDescData
+}
+
+var file_categoryrpc_category_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_categoryrpc_category_proto_goTypes = []any{
+	(*Category)(nil),                    // 0: category.v1.Category
+	(*CategoryLimits)(nil),              // 1: category.v1.CategoryLimits
+	(*CategoryModel)(nil),               // 2: category.v1.CategoryModel
+	(*GetCategoryModelRequest)(nil),     // 3: category.v1.GetCategoryModelRequest
+	(*AddCategoryRequest)(nil),          // 4: category.v1.AddCategoryRequest
+	(*MoveCategoryRequest)(nil),         // 5: category.v1.MoveCategoryRequest
+	(*UpdateCategoryRequest)(nil),       // 6: category.v1.UpdateCategoryRequest
+	(*DeleteCategoryRequest)(nil),       // 7: category.v1.DeleteCategoryRequest
+	(*ReplaceCategoryModelRequest)(nil), // 8: category.v1.ReplaceCategoryModelRequest
+	(*WatchCategoryModelRequest)(nil),   // 9: category.v1.WatchCategoryModelRequest
+	(*CategoryEvent)(nil),               // 10: category.v1.CategoryEvent
+	(*timestamppb.Timestamp)(nil),       // 11: google.protobuf.Timestamp
+}
+var file_categoryrpc_category_proto_depIdxs = []int32{
+	0,  // 0: category.v1.Category.children:type_name -> category.v1.Category
+	0,  // 1: category.v1.CategoryModel.categories:type_name -> category.v1.Category
+	1,  // 2: category.v1.CategoryModel.limits:type_name -> category.v1.CategoryLimits
+	0,  // 3: category.v1.AddCategoryRequest.category:type_name -> category.v1.Category
+	0,  // 4: category.v1.UpdateCategoryRequest.category:type_name -> category.v1.Category
+	2,  // 5: category.v1.ReplaceCategoryModelRequest.model:type_name -> category.v1.CategoryModel
+	11, // 6: category.v1.CategoryEvent.occurred_at:type_name -> google.protobuf.Timestamp
+	3,  // 7: category.v1.CategoryService.GetCategoryModel:input_type -> category.v1.GetCategoryModelRequest
+	4,  // 8: category.v1.CategoryService.AddCategory:input_type -> category.v1.AddCategoryRequest
+	5,  // 9: category.v1.CategoryService.MoveCategory:input_type -> category.v1.MoveCategoryRequest
+	6,  // 10: category.v1.CategoryService.UpdateCategory:input_type -> category.v1.UpdateCategoryRequest
+	7,  // 11: category.v1.CategoryService.DeleteCategory:input_type -> category.v1.DeleteCategoryRequest
+	8,  // 12: category.v1.CategoryService.ReplaceCategoryModel:input_type -> category.v1.ReplaceCategoryModelRequest
+	9,  // 13: category.v1.CategoryService.WatchCategoryModel:input_type -> category.v1.WatchCategoryModelRequest
+	2,  // 14: category.v1.CategoryService.GetCategoryModel:output_type -> category.v1.CategoryModel
+	2,  // 15: category.v1.CategoryService.AddCategory:output_type -> category.v1.CategoryModel
+	2,  // 16: category.v1.CategoryService.MoveCategory:output_type -> category.v1.CategoryModel
+	2,  // 17: category.v1.CategoryService.UpdateCategory:output_type -> category.v1.CategoryModel
+	2,  // 18: category.v1.CategoryService.DeleteCategory:output_type -> category.v1.CategoryModel
+	2,  // 19: category.v1.CategoryService.ReplaceCategoryModel:output_type -> category.v1.CategoryModel
+	10, // 20: category.v1.CategoryService.WatchCategoryModel:output_type -> category.v1.CategoryEvent
+	14, // [14:21] is the sub-list for method output_type
+	7,  // [7:14] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_categoryrpc_category_proto_init() }
+func file_categoryrpc_category_proto_init() {
+	if File_categoryrpc_category_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_categoryrpc_category_proto_rawDesc), len(file_categoryrpc_category_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_categoryrpc_category_proto_goTypes,
+		DependencyIndexes: file_categoryrpc_category_proto_depIdxs,
+		MessageInfos:      file_categoryrpc_category_proto_msgTypes,
+	}.Build()
+	File_categoryrpc_category_proto = out.File
+	file_categoryrpc_category_proto_goTypes = nil
+	file_categoryrpc_category_proto_depIdxs = nil
+}
@@ -0,0 +1,376 @@
+// The category service for backend callers, mirroring service.CategoryService.  category.pb.go and category_grpc.pb.go are generated
+// from this file with protoc-gen-go v1.36.10 and protoc-gen-go-grpc v1.5.1, regenerate them after a change from the repository root with:
+//   protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative categoryrpc/category.proto
+//
+// Calls are authenticated like the REST API: send the "authorization" metadata with the same value as the Authorization header.
+// Send "owner" on a request to work on a model another user shared with the caller.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: categoryrpc/category.proto
+
+package categoryrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CategoryService_GetCategoryModel_FullMethodName     = "/category.v1.CategoryService/GetCategoryModel"
+	CategoryService_AddCategory_FullMethodName          = "/category.v1.CategoryService/AddCategory"
+	CategoryService_MoveCategory_FullMethodName         = "/category.v1.CategoryService/MoveCategory"
+	CategoryService_UpdateCategory_FullMethodName       = "/category.v1.CategoryService/UpdateCategory"
+	CategoryService_DeleteCategory_FullMethodName       = "/category.v1.CategoryService/DeleteCategory"
+	CategoryService_ReplaceCategoryModel_FullMethodName = "/category.v1.CategoryService/ReplaceCategoryModel"
+	CategoryService_WatchCategoryModel_FullMethodName   = "/category.v1.CategoryService/WatchCategoryModel"
+)
+
+// CategoryServiceClient is the client API for CategoryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CategoryServiceClient interface {
+	// The model, the lifeapp model is created on first read.  NOT_FOUND when another model does not exist
+	GetCategoryModel(ctx context.Context, in *GetCategoryModelRequest, opts ...grpc.CallOption) (*CategoryModel, error)
+	// Adds a category under parent_id, at the top level when it is empty.  Returns the model after the change
+	AddCategory(ctx context.Context, in *AddCategoryRequest, opts ...grpc.CallOption) (*CategoryModel, error)
+	// Moves a category and its children under new_parent_id, to the top level when it is empty
+	MoveCategory(ctx context.Context, in *MoveCategoryRequest, opts ...grpc.CallOption) (*CategoryModel, error)
+	// Changes a category's title
+	UpdateCategory(ctx context.Context, in *UpdateCategoryRequest, opts ...grpc.CallOption) (*CategoryModel, error)
+	// Deletes a category and its children
+	DeleteCategory(ctx context.Context, in *DeleteCategoryRequest, opts ...grpc.CallOption) (*CategoryModel, error)
+	// Replaces the whole tree, expert use only
+	ReplaceCategoryModel(ctx context.Context, in *ReplaceCategoryModelRequest, opts ...grpc.CallOption) (*CategoryModel, error)
+	// Changes to the model as they are made.  Resumes after last_event_id when the events are still held, otherwise the first
+	// event is a reset and the caller should read the model again
+	WatchCategoryModel(ctx context.Context, in *WatchCategoryModelRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CategoryEvent], error)
+}
+
+type categoryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCategoryServiceClient(cc grpc.ClientConnInterface) CategoryServiceClient {
+	return &categoryServiceClient{cc}
+}
+
+func (c *categoryServiceClient) GetCategoryModel(ctx context.Context, in *GetCategoryModelRequest, opts ...grpc.CallOption) (*CategoryModel, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CategoryModel)
+	err := c.cc.Invoke(ctx, CategoryService_GetCategoryModel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *categoryServiceClient) AddCategory(ctx context.Context, in *AddCategoryRequest, opts ...grpc.CallOption) (*CategoryModel, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CategoryModel)
+	err := c.cc.Invoke(ctx, CategoryService_AddCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *categoryServiceClient) MoveCategory(ctx context.Context, in *MoveCategoryRequest, opts ...grpc.CallOption) (*CategoryModel, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CategoryModel)
+	err := c.cc.Invoke(ctx, CategoryService_MoveCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *categoryServiceClient) UpdateCategory(ctx context.Context, in *UpdateCategoryRequest, opts ...grpc.CallOption) (*CategoryModel, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CategoryModel)
+	err := c.cc.Invoke(ctx, CategoryService_UpdateCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *categoryServiceClient) DeleteCategory(ctx context.Context, in *DeleteCategoryRequest, opts ...grpc.CallOption) (*CategoryModel, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CategoryModel)
+	err := c.cc.Invoke(ctx, CategoryService_DeleteCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *categoryServiceClient) ReplaceCategoryModel(ctx context.Context, in *ReplaceCategoryModelRequest, opts ...grpc.CallOption) (*CategoryModel, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CategoryModel)
+	err := c.cc.Invoke(ctx, CategoryService_ReplaceCategoryModel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *categoryServiceClient) WatchCategoryModel(ctx context.Context, in *WatchCategoryModelRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[CategoryEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CategoryService_ServiceDesc.Streams[0], CategoryService_WatchCategoryModel_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchCategoryModelRequest, CategoryEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CategoryService_WatchCategoryModelClient = grpc.ServerStreamingClient[CategoryEvent]
+
+// CategoryServiceServer is the server API for CategoryService service.
+// All implementations must embed UnimplementedCategoryServiceServer
+// for forward compatibility.
+type CategoryServiceServer interface {
+	// The model, the lifeapp model is created on first read.  NOT_FOUND when another model does not exist
+	GetCategoryModel(context.Context, *GetCategoryModelRequest) (*CategoryModel, error)
+	// Adds a category under parent_id, at the top level when it is empty.  Returns the model after the change
+	AddCategory(context.Context, *AddCategoryRequest) (*CategoryModel, error)
+	// Moves a category and its children under new_parent_id, to the top level when it is empty
+	MoveCategory(context.Context, *MoveCategoryRequest) (*CategoryModel, error)
+	// Changes a category's title
+	UpdateCategory(context.Context, *UpdateCategoryRequest) (*CategoryModel, error)
+	// Deletes a category and its children
+	DeleteCategory(context.Context, *DeleteCategoryRequest) (*CategoryModel, error)
+	// Replaces the whole tree, expert use only
+	ReplaceCategoryModel(context.Context, *ReplaceCategoryModelRequest) (*CategoryModel, error)
+	// Changes to the model as they are made.  Resumes after last_event_id when the events are still held, otherwise the first
+	// event is a reset and the caller should read the model again
+	WatchCategoryModel(*WatchCategoryModelRequest, grpc.ServerStreamingServer[CategoryEvent]) error
+	mustEmbedUnimplementedCategoryServiceServer()
+}
+
+// UnimplementedCategoryServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCategoryServiceServer struct{}
+
+func (UnimplementedCategoryServiceServer) GetCategoryModel(context.Context, *GetCategoryModelRequest) (*CategoryModel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCategoryModel not implemented")
+}
+func (UnimplementedCategoryServiceServer) AddCategory(context.Context, *AddCategoryRequest) (*CategoryModel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCategory not implemented")
+}
+func (UnimplementedCategoryServiceServer) MoveCategory(context.Context, *MoveCategoryRequest) (*CategoryModel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveCategory not implemented")
+}
+func (UnimplementedCategoryServiceServer) UpdateCategory(context.Context, *UpdateCategoryRequest) (*CategoryModel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCategory not implemented")
+}
+func (UnimplementedCategoryServiceServer) DeleteCategory(context.Context, *DeleteCategoryRequest) (*CategoryModel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCategory not implemented")
+}
+func (UnimplementedCategoryServiceServer) ReplaceCategoryModel(context.Context, *ReplaceCategoryModelRequest) (*CategoryModel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplaceCategoryModel not implemented")
+}
+func (UnimplementedCategoryServiceServer) WatchCategoryModel(*WatchCategoryModelRequest, grpc.ServerStreamingServer[CategoryEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCategoryModel not implemented")
+}
+func (UnimplementedCategoryServiceServer) mustEmbedUnimplementedCategoryServiceServer() {}
+func (UnimplementedCategoryServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeCategoryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CategoryServiceServer will
+// result in compilation errors.
+type UnsafeCategoryServiceServer interface {
+	mustEmbedUnimplementedCategoryServiceServer()
+}
+
+func RegisterCategoryServiceServer(s grpc.ServiceRegistrar, srv CategoryServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCategoryServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CategoryService_ServiceDesc, srv)
+}
+
+func _CategoryService_GetCategoryModel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCategoryModelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CategoryServiceServer).GetCategoryModel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CategoryService_GetCategoryModel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CategoryServiceServer).GetCategoryModel(ctx, req.(*GetCategoryModelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CategoryService_AddCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CategoryServiceServer).AddCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CategoryService_AddCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CategoryServiceServer).AddCategory(ctx, req.(*AddCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CategoryService_MoveCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CategoryServiceServer).MoveCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CategoryService_MoveCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CategoryServiceServer).MoveCategory(ctx, req.(*MoveCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CategoryService_UpdateCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CategoryServiceServer).UpdateCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CategoryService_UpdateCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CategoryServiceServer).UpdateCategory(ctx, req.(*UpdateCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CategoryService_DeleteCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CategoryServiceServer).DeleteCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CategoryService_DeleteCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CategoryServiceServer).DeleteCategory(ctx, req.(*DeleteCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CategoryService_ReplaceCategoryModel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplaceCategoryModelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CategoryServiceServer).ReplaceCategoryModel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CategoryService_ReplaceCategoryModel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CategoryServiceServer).ReplaceCategoryModel(ctx, req.(*ReplaceCategoryModelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CategoryService_WatchCategoryModel_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCategoryModelRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CategoryServiceServer).WatchCategoryModel(m, &grpc.GenericServerStream[WatchCategoryModelRequest, CategoryEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CategoryService_WatchCategoryModelServer = grpc.ServerStreamingServer[CategoryEvent]
+
+// CategoryService_ServiceDesc is the grpc.ServiceDesc for CategoryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CategoryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "category.v1.CategoryService",
+	HandlerType: (*CategoryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCategoryModel",
+			Handler:    _CategoryService_GetCategoryModel_Handler,
+		},
+		{
+			MethodName: "AddCategory",
+			Handler:    _CategoryService_AddCategory_Handler,
+		},
+		{
+			MethodName: "MoveCategory",
+			Handler:    _CategoryService_MoveCategory_Handler,
+		},
+		{
+			MethodName: "UpdateCategory",
+			Handler:    _CategoryService_UpdateCategory_Handler,
+		},
+		{
+			MethodName: "DeleteCategory",
+			Handler:    _CategoryService_DeleteCategory_Handler,
+		},
+		{
+			MethodName: "ReplaceCategoryModel",
+			Handler:    _CategoryService_ReplaceCategoryModel_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchCategoryModel",
+			Handler:       _CategoryService_WatchCategoryModel_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "categoryrpc/category.proto",
+}
@@ -0,0 +1,260 @@
+package categoryrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+	"github.com/suared/core-apiuser/service"
+)
+
+/*
+The CategoryService of category.proto over service.CategoryService, for backend callers that want typed access without the REST envelope.
+category.pb.go and category_grpc.pb.go are generated from category.proto, see the command there.
+
+NewGRPCServer registers the service with interceptors that authenticate every call like the REST routes, runner.go serves it next to
+the web api when PROCESS_GRPC_LISTEN_ADDR is set.  Errors are gRPC statuses with the code the REST status maps to
+*/
+
+//ResetEventType - the first event of a watch that could not resume, read the model again
+const ResetEventType = "reset"
+
+//lifeappModelName - the alias of the lifeapp model as on the REST routes
+const lifeappModelName = "lifeapp"
+
+//Server - implements the CategoryService of category.proto
+type Server struct {
+	UnimplementedCategoryServiceServer
+	categoryService *service.CategoryService
+}
+
+//NewServer - the server over the category service
+func NewServer(categoryService *service.CategoryService) *Server {
+	return &Server{categoryService: categoryService}
+}
+
+//NewGRPCServer - a gRPC server with the category service registered, every call is authenticated by the server's interceptors
+func NewGRPCServer(categoryService *service.CategoryService, options ...grpc.ServerOption) *grpc.Server {
+	s := NewServer(categoryService)
+	options = append(options, grpc.ChainUnaryInterceptor(s.UnaryAuthInterceptor), grpc.ChainStreamInterceptor(s.StreamAuthInterceptor))
+	grpcServer := grpc.NewServer(options...)
+	RegisterCategoryServiceServer(grpcServer, s)
+	return grpcServer
+}
+
+//statusError - the service error with the code its REST status maps to
+func statusError(err error) error {
+	var limitErr *model.CategoryLimitError
+	var duplicate *model.DuplicateTitleError
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, repository.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, repository.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, model.ErrCategoryNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, model.ErrCategoryMoveCycle):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &limitErr), errors.Is(err, model.ErrInvalidTitlePolicy), errors.Is(err, repository.ErrCategoryTooLarge):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &duplicate):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, repository.ErrCategoryConflict):
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func modelID(id string) string {
+	if id == lifeappModelName {
+		return service.MyLifeCategoryUserModelID
+	}
+	return id
+}
+
+//readModel - the stored model as a message, NotFound when it does not exist
+func (s *Server) readModel(ctx context.Context, id string) (*CategoryModel, error) {
+	catModel, err := s.categoryService.GetCategoryModel(ctx, modelID(id))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	if catModel.ID == "" {
+		return nil, status.Error(codes.NotFound, "Category model not found: "+id)
+	}
+	return toModelMessage(catModel), nil
+}
+
+//changed - the model after a change, or the change's error
+func (s *Server) changed(ctx context.Context, id string, err error) (*CategoryModel, error) {
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return s.readModel(ctx, id)
+}
+
+//GetCategoryModel - see category.proto
+func (s *Server) GetCategoryModel(ctx context.Context, request *GetCategoryModelRequest) (*CategoryModel, error) {
+	return s.readModel(s.categoryService.WithCategoryOwner(ctx, request.Owner), request.ModelId)
+}
+
+//AddCategory - see category.proto
+func (s *Server) AddCategory(ctx context.Context, request *AddCategoryRequest) (*CategoryModel, error) {
+	if request.Category == nil || request.Category.Id == "" || request.Category.Title == "" {
+		return nil, status.Error(codes.InvalidArgument, "category with an id and title is required")
+	}
+	ctx = s.categoryService.WithCategoryOwner(ctx, request.Owner)
+	err := s.categoryService.AddCategory(ctx, modelID(request.ModelId), request.ParentId, model.Category{ID: request.Category.Id, Title: request.Category.Title})
+	return s.changed(ctx, request.ModelId, err)
+}
+
+//MoveCategory - see category.proto
+func (s *Server) MoveCategory(ctx context.Context, request *MoveCategoryRequest) (*CategoryModel, error) {
+	if request.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	ctx = s.categoryService.WithCategoryOwner(ctx, request.Owner)
+	err := s.categoryService.MoveCategory(ctx, modelID(request.ModelId), request.NewParentId, request.Id)
+	return s.changed(ctx, request.ModelId, err)
+}
+
+//UpdateCategory - see category.proto
+func (s *Server) UpdateCategory(ctx context.Context, request *UpdateCategoryRequest) (*CategoryModel, error) {
+	if request.Category == nil || request.Category.Id == "" || request.Category.Title == "" {
+		return nil, status.Error(codes.InvalidArgument, "category with an id and title is required")
+	}
+	ctx = s.categoryService.WithCategoryOwner(ctx, request.Owner)
+	err := s.categoryService.UpdateCategory(ctx, modelID(request.ModelId), model.Category{ID: request.Category.Id, Title: request.Category.Title})
+	return s.changed(ctx, request.ModelId, err)
+}
+
+//DeleteCategory - see category.proto
+func (s *Server) DeleteCategory(ctx context.Context, request *DeleteCategoryRequest) (*CategoryModel, error) {
+	if request.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	ctx = s.categoryService.WithCategoryOwner(ctx, request.Owner)
+	err := s.categoryService.DeleteCategory(ctx, modelID(request.ModelId), request.Id)
+	return s.changed(ctx, request.ModelId, err)
+}
+
+//ReplaceCategoryModel - see category.proto
+func (s *Server) ReplaceCategoryModel(ctx context.Context, request *ReplaceCategoryModelRequest) (*CategoryModel, error) {
+	if request.Model == nil || request.Model.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "model with an id is required")
+	}
+	replacement, err := fromModelMessage(request.Model)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	ctx = s.categoryService.WithCategoryOwner(ctx, request.Owner)
+	err = s.categoryService.ReplaceCategoryModel(ctx, replacement)
+	return s.changed(ctx, request.Model.Id, err)
+}
+
+//WatchCategoryModel - see category.proto.  Ends with Aborted when the watcher falls behind, resume from the last event received
+func (s *Server) WatchCategoryModel(request *WatchCategoryModelRequest, stream CategoryService_WatchCategoryModelServer) error {
+	ctx := s.categoryService.WithCategoryOwner(stream.Context(), request.Owner)
+	id := modelID(request.ModelId)
+	//confirms the model exists and the caller can read it before watching
+	if _, err := s.readModel(ctx, id); err != nil {
+		return err
+	}
+	missed, resumed, events, cancel := s.categoryService.SubscribeCategoryEvents(ctx, id, request.LastEventId)
+	defer cancel()
+
+	if !resumed {
+		if err := stream.Send(&CategoryEvent{Type: ResetEventType, ModelId: id}); err != nil {
+			return err
+		}
+	}
+	for _, event := range missed {
+		if err := stream.Send(toEventMessage(event)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, open := <-events:
+			if !open {
+				return status.Error(codes.Aborted, "Watch fell behind, resume from the last event received")
+			}
+			if err := stream.Send(toEventMessage(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toCategoryMessages(categories []*model.Category) []*Category {
+	messages := make([]*Category, len(categories))
+	for i, category := range categories {
+		messages[i] = &Category{Id: category.ID, Title: category.Title, Children: toCategoryMessages(category.Children)}
+	}
+	return messages
+}
+
+func toModelMessage(catModel *repository.CategoryUserModel) *CategoryModel {
+	limits := catModel.Limits
+	return &CategoryModel{
+		Id:          catModel.ID,
+		Name:        catModel.Name,
+		Categories:  toCategoryMessages(catModel.Children),
+		TitlePolicy: catModel.TitlePolicy.String(),
+		Limits: &CategoryLimits{MaxDepth: int32(limits.MaxDepth), MaxChildren: int32(limits.MaxChildren),
+			MaxCategories: int32(limits.MaxCategories), MaxTitleLength: int32(limits.MaxTitleLength)},
+	}
+}
+
+func fromCategoryMessages(messages []*Category) []*model.Category {
+	categories := make([]*model.Category, 0, len(messages))
+	for _, message := range messages {
+		if message != nil {
+			categories = append(categories, &model.Category{ID: message.Id, Title: message.Title, Children: fromCategoryMessages(message.Children)})
+		}
+	}
+	return categories
+}
+
+func fromModelMessage(message *CategoryModel) (*repository.CategoryUserModel, error) {
+	policy, err := model.ParseCategoryTitlePolicy(message.TitlePolicy)
+	if err != nil {
+		return nil, fmt.Errorf("title_policy: %w", err)
+	}
+	catModel := &repository.CategoryUserModel{TitlePolicy: policy}
+	catModel.ID = modelID(message.Id)
+	catModel.Name = message.Name
+	catModel.Children = fromCategoryMessages(message.Categories)
+	if message.Limits != nil {
+		catModel.Limits = model.CategoryLimits{MaxDepth: int(message.Limits.MaxDepth), MaxChildren: int(message.Limits.MaxChildren),
+			MaxCategories: int(message.Limits.MaxCategories), MaxTitleLength: int(message.Limits.MaxTitleLength)}
+	}
+	return catModel, nil
+}
+
+func toEventMessage(event model.CategoryEvent) *CategoryEvent {
+	return &CategoryEvent{
+		Id:          event.ID,
+		Type:        string(event.Type),
+		UserId:      event.UserID,
+		ActorId:     event.ActorID,
+		ModelId:     event.ModelID,
+		CategoryId:  event.CategoryID,
+		ParentId:    event.ParentID,
+		OldParentId: event.OldParentID,
+		Title:       event.Title,
+		OldTitle:    event.OldTitle,
+		OccurredAt:  timestamppb.New(event.OccurredAt),
+	}
+}
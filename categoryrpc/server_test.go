@@ -0,0 +1,100 @@
+package categoryrpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/suared/core/security"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+func TestCategoryModelMessages(t *testing.T) {
+	catModel := &repository.CategoryUserModel{TitlePolicy: model.CategoryTitlePolicy{Uniqueness: model.TitlesReject, IgnoreCase: true}, Limits: model.CategoryLimits{MaxDepth: 3}}
+	catModel.ID = "m1"
+	catModel.Name = "Tree"
+	catModel.Children = []*model.Category{{ID: "work", Title: "Work", Children: []*model.Category{{ID: "launch", Title: "Launch"}}}, {ID: "home", Title: "Home"}}
+
+	message := toModelMessage(catModel)
+	if message.TitlePolicy != "reject,ignoreCase" || message.Limits.MaxDepth != 3 || message.Categories[0].Children[0].Id != "launch" {
+		t.Errorf("Expected the model as a message, received: %+v", message)
+	}
+	back, err := fromModelMessage(message)
+	if err != nil || !back.CategoryRoot.Equals(&catModel.CategoryRoot) || back.TitlePolicy != catModel.TitlePolicy || back.Limits != catModel.Limits {
+		t.Errorf("Expected the message back as the model, received: %+v %v", back, err)
+	}
+	message.TitlePolicy = "sometimes"
+	if _, err = fromModelMessage(message); err == nil {
+		t.Errorf("Expected an invalid title policy to be refused")
+	}
+}
+
+func TestCategoryStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{nil, codes.OK},
+		{fmt.Errorf("Service Failed with: %w", repository.ErrForbidden), codes.PermissionDenied},
+		{fmt.Errorf("write failed: %w", &repository.CategoryQuotaError{Quota: "models", Max: 1}), codes.ResourceExhausted},
+		{fmt.Errorf("Service Failed with: %w", &model.CategoryLimitError{Limit: model.LimitDepth, Max: 2}), codes.InvalidArgument},
+		{&model.DuplicateTitleError{Title: "Work"}, codes.FailedPrecondition},
+		{fmt.Errorf("Service Delete Category a Failed with: %w", model.ErrCategoryNotFound), codes.NotFound},
+		{fmt.Errorf("Service Move Category a under b Failed with: %w", model.ErrCategoryMoveCycle), codes.InvalidArgument},
+		{fmt.Errorf("table unavailable"), codes.Internal},
+	}
+	for _, test := range tests {
+		if code := status.Code(statusError(test.err)); code != test.code {
+			t.Errorf("Expected code %v for %v, received: %v", test.code, test.err, code)
+		}
+	}
+}
+
+func TestAuthContext(t *testing.T) {
+	ctx, err := AuthContext(context.TODO(), "")
+	if err != nil || !security.IsAnonymous(ctx) {
+		t.Errorf("Expected no authorization to be the anonymous user, received: %v", err)
+	}
+	_, err = AuthContext(context.TODO(), `COGNITO id_token="not a jwt"`)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected an invalid token to be unauthenticated, received: %v", err)
+	}
+}
+
+func TestAuthInterceptors(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewGRPCServer(nil)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Unable to dial the test server: %v", err)
+	}
+	defer conn.Close()
+	client := NewCategoryServiceClient(conn)
+
+	//an invalid token is refused by the interceptors before the handler or the service are reached
+	ctx := metadata.AppendToOutgoingContext(context.TODO(), authorizationMetadata, `COGNITO id_token="not a jwt"`)
+	if _, err = client.GetCategoryModel(ctx, &GetCategoryModelRequest{ModelId: "m1"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected a unary call with an invalid token to be unauthenticated, received: %v", err)
+	}
+	stream, err := client.WatchCategoryModel(ctx, &WatchCategoryModelRequest{ModelId: "m1"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected a stream with an invalid token to be unauthenticated, received: %v", err)
+	}
+}
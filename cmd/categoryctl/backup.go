@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/suared/core-apiuser/backup"
+)
+
+//userList - the users of a comma separated -user, empty for every user
+func userList(users string) []string {
+	var list []string
+	for _, userID := range strings.Split(users, ",") {
+		if userID = strings.TrimSpace(userID); userID != "" {
+			list = append(list, userID)
+		}
+	}
+	return list
+}
+
+func runBackup(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	users := flags.String("user", "", "comma separated user ids, every user when empty")
+	output := flags.String("o", "", "the archive to write, e.g. category-backup.tar.gz")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		return errors.New("-o is required")
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	manifest, err := backup.Write(ctx, tool.operator, file, backup.WriteOptions{Users: userList(*users), Table: tool.table})
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(*output)
+		return err
+	}
+	for _, skipped := range manifest.Skipped {
+		fmt.Fprintf(tool.out, "skipped %v %v, cannot be decoded: %v\n", skipped.UserID, skipped.SortKey, skipped.Reason)
+	}
+	fmt.Fprintf(tool.out, "%v models written to %v, %v skipped\n", len(manifest.Models), *output, len(manifest.Skipped))
+	return nil
+}
+
+//readArchive - the verified archive of the file
+func readArchive(filename string) (*backup.Archive, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return backup.Read(file)
+}
+
+func runVerify(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	file := flags.String("file", "", "the archive to check")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	archive, err := readArchive(*file)
+	if err != nil {
+		return err
+	}
+	manifest := archive.Manifest
+	for _, entry := range manifest.Models {
+		if _, err = archive.Model(entry); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(tool.out, "%v models of table %q from %v, checksums match.  %v items were skipped by the backup\n",
+		len(manifest.Models), manifest.Table, manifest.CreatedAt, len(manifest.Skipped))
+	return nil
+}
+
+func runRestore(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	file := flags.String("file", "", "the archive to restore")
+	users := flags.String("user", "", "comma separated user ids, every user in the archive when empty")
+	overwrite := flags.Bool("overwrite", false, "replace models already in the table, they are kept as versions")
+	flags.BoolVar(&tool.dryRun, "dry-run", false, "print what would be restored without writing")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	archive, err := readArchive(*file)
+	if err != nil {
+		return err
+	}
+	result, err := backup.Restore(ctx, tool.operator, archive, backup.RestoreOptions{Users: userList(*users), Overwrite: *overwrite, DryRun: tool.dryRun})
+	for _, entry := range result.Existing {
+		fmt.Fprintf(tool.out, "exists, left %v %v\n", entry.UserID, entry.ModelID)
+	}
+	verb := "restored"
+	if tool.dryRun {
+		verb = "to restore (dry run)"
+	}
+	for _, entry := range result.Restored {
+		fmt.Fprintf(tool.out, "%v %v %v\n", verb, entry.UserID, entry.ModelID)
+	}
+	fmt.Fprintf(tool.out, "%v models %v into %v, %v left\n", len(result.Restored), verb, tool.table, len(result.Existing))
+	return err
+}
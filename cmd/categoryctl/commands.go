@@ -0,0 +1,599 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+//categoryTool - the state shared by the commands
+type categoryTool struct {
+	operator *repository.CategoryOperator
+	out      io.Writer
+	dryRun   bool
+	table    string
+}
+
+//modelFlags - the flags most commands take, -dry-run is only used by the commands that change a model
+type modelFlags struct {
+	*flag.FlagSet
+	user  string
+	model string
+}
+
+func newModelFlags(tool *categoryTool, name string) *modelFlags {
+	flags := &modelFlags{FlagSet: flag.NewFlagSet(name, flag.ExitOnError)}
+	flags.StringVar(&flags.user, "user", "", "the user id that owns the model")
+	flags.StringVar(&flags.model, "model", "", "the model id")
+	flags.BoolVar(&tool.dryRun, "dry-run", false, "print the changes without writing them")
+	return flags
+}
+
+//parse - parses the args, requiring -user and -model when required is set
+func (flags *modelFlags) parse(args []string, required bool) error {
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if required && (flags.user == "" || flags.model == "") {
+		return errors.New("-user and -model are required")
+	}
+	return nil
+}
+
+//readModel - the decoded model, an error when it is not found or cannot be decoded
+func (tool *categoryTool) readModel(ctx context.Context, userID string, modelID string) (*repository.CategoryUserModel, error) {
+	dao, err := tool.operator.SelectModel(ctx, userID, modelID)
+	if err != nil {
+		return nil, err
+	}
+	if decodeErr := dao.DecodeError(); decodeErr != nil {
+		return nil, decodeErr
+	}
+	if dao.ID == "" {
+		return nil, fmt.Errorf("model %v of user %v not found", modelID, userID)
+	}
+	return &dao.CategoryUserModel, nil
+}
+
+//save - prints the changes from before (nil for a new model) and stores changed unless this is a dry run
+func (tool *categoryTool) save(ctx context.Context, userID string, before *repository.CategoryUserModel, changed *repository.CategoryUserModel, reason string, events ...model.CategoryEvent) error {
+	var beforeRoot *model.CategoryRoot
+	if before != nil {
+		beforeRoot = diffableRoot(before)
+	}
+	diffs := model.DiffCategoryRoots(beforeRoot, diffableRoot(changed))
+	for _, diff := range diffs {
+		fmt.Fprintf(tool.out, "%v %v %q", diff.Type, diff.CategoryID, diff.Title)
+		if diff.OldTitle != "" {
+			fmt.Fprintf(tool.out, " from %q", diff.OldTitle)
+		}
+		if diff.Type == model.CategoryMoved {
+			fmt.Fprintf(tool.out, " to parent %q from %q", diff.ParentID, diff.OldParentID)
+		}
+		fmt.Fprintln(tool.out)
+	}
+	if before != nil && (before.Name != changed.Name || before.TitlePolicy != changed.TitlePolicy || before.Limits != changed.Limits) {
+		fmt.Fprintf(tool.out, "model settings changed\n")
+	}
+	if tool.dryRun {
+		fmt.Fprintf(tool.out, "dry run, %v not written\n", changed.ID)
+		return nil
+	}
+	if len(events) == 0 {
+		events = []model.CategoryEvent{model.NewCategoryEvent(model.CategoryModelReplaced, userID, changed.ID)}
+	}
+	err := tool.operator.Save(ctx, userID, *changed, reason, events...)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(tool.out, "%v saved\n", changed.ID)
+	return nil
+}
+
+//checkModel - refuses a changed model the API would refuse
+func (tool *categoryTool) checkModel(changed *repository.CategoryUserModel) error {
+	if problems := validateCategoryModel(changed, tool.operator.Limits()); len(problems) > 0 {
+		return fmt.Errorf("the change leaves a problem: %v", problems[0])
+	}
+	return nil
+}
+
+//readRepairedModel - readModel for changes to single categories, refused until repair has fixed the tree so ids and parents can be trusted
+func (tool *categoryTool) readRepairedModel(ctx context.Context, userID string, modelID string) (*repository.CategoryUserModel, error) {
+	userModel, err := tool.readModel(ctx, userID, modelID)
+	if err != nil {
+		return nil, err
+	}
+	for _, problem := range validateCategoryModel(userModel, tool.operator.Limits()) {
+		if problem.Repairable {
+			return nil, fmt.Errorf("run repair first, %v", problem)
+		}
+	}
+	return userModel, nil
+}
+
+func runList(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := newModelFlags(tool, "list")
+	err := flags.parse(args, false)
+	if err != nil {
+		return err
+	}
+	if flags.user == "" {
+		return errors.New("-user is required")
+	}
+	daos, err := tool.operator.SelectModels(ctx, flags.user)
+	if err != nil {
+		return err
+	}
+	for _, dao := range daos {
+		if decodeErr := dao.DecodeError(); decodeErr != nil {
+			fmt.Fprintf(tool.out, "%v\tundecodable: %v\n", dao.SortKey(), decodeErr.Err)
+			continue
+		}
+		fmt.Fprintf(tool.out, "%v\t%v\t%v categories\t%v bytes\t%v\n", dao.ID, dao.Name, countCategories(dao.Children), dao.StoredBytes, dao.StoredEncoding())
+	}
+	return nil
+}
+
+func runDump(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := newModelFlags(tool, "dump")
+	format := flags.String("format", "tree", "tree or json")
+	err := flags.parse(args, true)
+	if err != nil {
+		return err
+	}
+	userModel, err := tool.readModel(ctx, flags.user, flags.model)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "tree":
+		writeCategoryTree(tool.out, userModel)
+		return nil
+	case "json":
+		encoder := json.NewEncoder(tool.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(userModel)
+	}
+	return fmt.Errorf("-format must be tree or json, received: %v", *format)
+}
+
+func runValidate(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := newModelFlags(tool, "validate")
+	err := flags.parse(args, false)
+	if err != nil {
+		return err
+	}
+	checked, failed := 0, 0
+	validate := func(dao *repository.CategoryDAO) error {
+		if flags.model != "" && dao.SortKey() != flags.model {
+			return nil
+		}
+		checked++
+		var problems []string
+		if decodeErr := dao.DecodeError(); decodeErr != nil {
+			problems = append(problems, "cannot be decoded: "+decodeErr.Err.Error())
+		} else {
+			for _, problem := range validateCategoryModel(&dao.CategoryUserModel, tool.operator.Limits()) {
+				problems = append(problems, problem.String())
+			}
+		}
+		if len(problems) > 0 {
+			failed++
+			fmt.Fprintf(tool.out, "%v %v\n\t%v\n", dao.User(), dao.SortKey(), strings.Join(problems, "\n\t"))
+		}
+		return nil
+	}
+
+	if flags.user == "" {
+		err = tool.operator.ScanModels(ctx, validate)
+	} else {
+		var daos []*repository.CategoryDAO
+		daos, err = tool.operator.SelectModels(ctx, flags.user)
+		for i := 0; err == nil && i < len(daos); i++ {
+			err = validate(daos[i])
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(tool.out, "%v models checked, %v with problems\n", checked, failed)
+	if failed > 0 {
+		return fmt.Errorf("%v models with problems", failed)
+	}
+	return nil
+}
+
+func runRepair(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := newModelFlags(tool, "repair")
+	err := flags.parse(args, true)
+	if err != nil {
+		return err
+	}
+	before, err := tool.readModel(ctx, flags.user, flags.model)
+	if err != nil {
+		return err
+	}
+	repaired := copyCategoryModel(before)
+	changes := repairCategoryModel(repaired)
+	for _, change := range changes {
+		fmt.Fprintln(tool.out, change)
+	}
+	for _, problem := range validateCategoryModel(repaired, tool.operator.Limits()) {
+		fmt.Fprintf(tool.out, "not repaired: %v\n", problem)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(tool.out, "nothing to repair\n")
+		return nil
+	}
+	return tool.save(ctx, flags.user, before, repaired, "repair")
+}
+
+//formatFromFlag - the named format, or the one of the file extension when no name is given
+func formatFromFlag(name string, file string) (model.CategoryFormat, error) {
+	if name == "" {
+		name = strings.TrimPrefix(filepath.Ext(file), ".")
+	}
+	if name == "" {
+		return model.FormatJSON, nil
+	}
+	format, ok := model.CategoryFormatFromName(name)
+	if !ok {
+		return "", fmt.Errorf("unsupported format: %v", name)
+	}
+	return format, nil
+}
+
+func runExport(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := newModelFlags(tool, "export")
+	output := flags.String("o", "", "the file to write, stdout when empty")
+	formatName := flags.String("format", "", "json, markdown, opml or csv, from the file extension when empty")
+	err := flags.parse(args, true)
+	if err != nil {
+		return err
+	}
+	format, err := formatFromFlag(*formatName, *output)
+	if err != nil {
+		return err
+	}
+	userModel, err := tool.readModel(ctx, flags.user, flags.model)
+	if err != nil {
+		return err
+	}
+	data, err := model.ExportCategoryRoot(&userModel.CategoryRoot, format)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = tool.out.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(*output, data, 0644)
+}
+
+func runImport(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := newModelFlags(tool, "import")
+	file := flags.String("file", "", "the file to import")
+	formatName := flags.String("format", "", "json, markdown, opml or csv, from the file extension when empty")
+	name := flags.String("name", "", "the model name, the imported name when empty")
+	err := flags.parse(args, false)
+	if err != nil {
+		return err
+	}
+	if flags.user == "" || *file == "" {
+		return errors.New("-user and -file are required")
+	}
+	format, err := formatFromFlag(*formatName, *file)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	imported, err := model.ImportCategoryRoot(data, format)
+	if err != nil {
+		return err
+	}
+
+	var before *repository.CategoryUserModel
+	changed := repository.NewCategoryUserModel(imported.Name)
+	changed.TitlePolicy, err = model.ParseCategoryTitlePolicy(os.Getenv("PROCESS_CATEGORY_TITLE_POLICY"))
+	if err != nil {
+		return fmt.Errorf("PROCESS_CATEGORY_TITLE_POLICY: %w", err)
+	}
+	if flags.model != "" {
+		dao, err := tool.operator.SelectModel(ctx, flags.user, flags.model)
+		if err != nil {
+			return err
+		}
+		//a model that cannot be decoded is replaced, its data is kept as the version
+		if dao.ID != "" {
+			before = &dao.CategoryUserModel
+			changed = copyCategoryModel(before)
+		}
+		changed.ID = flags.model
+	}
+	if *name != "" {
+		changed.Name = *name
+	}
+	changed.Children = imported.Children
+	err = changed.ApplyTitlePolicy(changed.TitlePolicy)
+	if err == nil {
+		err = tool.checkModel(changed)
+	}
+	if err != nil {
+		return err
+	}
+	return tool.save(ctx, flags.user, before, changed, "import "+filepath.Base(*file))
+}
+
+func runMove(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := newModelFlags(tool, "move")
+	id := flags.String("id", "", "the category to move")
+	parentID := flags.String("parent", "", "the new parent, the top level when empty")
+	err := flags.parse(args, true)
+	if err != nil {
+		return err
+	}
+	before, err := tool.readRepairedModel(ctx, flags.user, flags.model)
+	if err != nil {
+		return err
+	}
+	changed := copyCategoryModel(before)
+	moved, oldParent := changed.FindChildByID(*id)
+	if *id == "" || moved.ID == "" {
+		return fmt.Errorf("category %q not found", *id)
+	}
+	newParent, _ := changed.FindChildByID(*parentID)
+	if *parentID != "" && newParent.ID == "" {
+		return fmt.Errorf("parent %q not found", *parentID)
+	}
+	if *parentID != "" && isDescendant(moved, *parentID) {
+		return fmt.Errorf("cannot move %v below itself", *id)
+	}
+
+	event := model.NewCategoryEvent(model.CategoryMoved, flags.user, changed.ID)
+	event.CategoryID = moved.ID
+	event.ParentID = newParent.ID
+	event.Title = moved.Title
+	if oldParent != nil {
+		event.OldParentID = oldParent.ID
+	}
+	events := []model.CategoryEvent{event}
+	//the title policy of the new siblings can rename the moved category, reported as a rename after the move like the API
+	siblings, _ := changed.SiblingsOf(newParent.ID)
+	title, err := changed.TitlePolicy.ResolveTitle(siblings, newParent.ID, moved.Title, moved.ID)
+	if err != nil {
+		return err
+	}
+	if title != moved.Title {
+		renamed := model.NewCategoryEvent(model.CategoryRenamed, flags.user, changed.ID)
+		renamed.CategoryID = moved.ID
+		renamed.ParentID = newParent.ID
+		renamed.OldTitle = moved.Title
+		renamed.Title = title
+		events = append(events, renamed)
+		moved.Title = title
+	}
+	//removed and added here vs. CategoryRoot Move, which needs a parent for top level categories
+	if oldParent == nil {
+		changed.RemoveChildByID(moved.ID)
+	} else {
+		oldParent.RemoveChildByID(moved.ID)
+	}
+	if newParent.ID == "" {
+		changed.AddChild(*moved)
+	} else {
+		newParent.AddChild(*moved)
+	}
+	err = tool.checkModel(changed)
+	if err != nil {
+		return err
+	}
+	return tool.save(ctx, flags.user, before, changed, "move "+moved.ID, events...)
+}
+
+func runRename(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := newModelFlags(tool, "rename")
+	id := flags.String("id", "", "the category to rename")
+	title := flags.String("title", "", "the new title")
+	err := flags.parse(args, true)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(*title) == "" {
+		return errors.New("-title is required")
+	}
+	before, err := tool.readRepairedModel(ctx, flags.user, flags.model)
+	if err != nil {
+		return err
+	}
+	changed := copyCategoryModel(before)
+	category, parent := changed.FindChildByID(*id)
+	if *id == "" || category.ID == "" {
+		return fmt.Errorf("category %q not found", *id)
+	}
+	event := model.NewCategoryEvent(model.CategoryRenamed, flags.user, changed.ID)
+	event.CategoryID = category.ID
+	if parent != nil {
+		event.ParentID = parent.ID
+	}
+	siblings, _ := changed.SiblingsOf(event.ParentID)
+	event.Title, err = changed.TitlePolicy.ResolveTitle(siblings, event.ParentID, *title, category.ID)
+	if err != nil {
+		return err
+	}
+	event.OldTitle = category.Title
+	category.Title = event.Title
+	err = tool.checkModel(changed)
+	if err != nil {
+		return err
+	}
+	return tool.save(ctx, flags.user, before, changed, "rename "+category.ID, event)
+}
+
+func runVersions(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := newModelFlags(tool, "versions")
+	err := flags.parse(args, true)
+	if err != nil {
+		return err
+	}
+	versions, err := tool.operator.SelectVersions(ctx, flags.user, flags.model)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		fmt.Fprintf(tool.out, "no versions of %v\n", flags.model)
+	}
+	for _, version := range versions {
+		fmt.Fprintf(tool.out, "%v\t%v\t%v bytes\tbefore %v\n", version.VersionID, version.ActorID, len(version.CategoryUserModelData), version.Reason)
+	}
+	return nil
+}
+
+func runRollback(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := newModelFlags(tool, "rollback")
+	versionID := flags.String("version", "", "the version to restore, see versions")
+	err := flags.parse(args, true)
+	if err != nil {
+		return err
+	}
+	version, err := tool.operator.SelectVersion(ctx, flags.user, flags.model, *versionID)
+	if err != nil {
+		return err
+	}
+	if version == nil {
+		return fmt.Errorf("version %q of %v not found", *versionID, flags.model)
+	}
+	restored, err := version.Model()
+	if err != nil {
+		return err
+	}
+	dao, err := tool.operator.SelectModel(ctx, flags.user, flags.model)
+	if err != nil {
+		return err
+	}
+	var before *repository.CategoryUserModel
+	if dao.ID != "" {
+		before = &dao.CategoryUserModel
+	}
+	//the restored model keeps its id even if the stored data had another
+	restored.ID = flags.model
+	return tool.save(ctx, flags.user, before, &restored, "rollback to "+version.VersionID)
+}
+
+func runIndex(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := newModelFlags(tool, "index")
+	err := flags.parse(args, false)
+	if err != nil {
+		return err
+	}
+	checked, added, failed := 0, 0, 0
+	index := func(dao *repository.CategoryDAO) error {
+		checked++
+		if dao.CategoryModelKey != "" {
+			return nil
+		}
+		fmt.Fprintf(tool.out, "%v %v\n", dao.User(), dao.SortKey())
+		added++
+		if tool.dryRun {
+			return nil
+		}
+		_, err := tool.operator.IndexModel(ctx, dao)
+		if err != nil {
+			failed++
+			added--
+			fmt.Fprintf(tool.out, "%v %v failed: %v\n", dao.User(), dao.SortKey(), err)
+		}
+		return nil
+	}
+
+	if flags.user == "" {
+		err = tool.operator.ScanModels(ctx, index)
+	} else {
+		var daos []*repository.CategoryDAO
+		daos, err = tool.operator.SelectModels(ctx, flags.user)
+		for i := 0; err == nil && i < len(daos); i++ {
+			err = index(daos[i])
+		}
+	}
+	if err != nil {
+		return err
+	}
+	verb := "added to the index"
+	if tool.dryRun {
+		verb = "to add (dry run)"
+	}
+	fmt.Fprintf(tool.out, "%v models checked, %v %v, %v failed\n", checked, added, verb, failed)
+	if failed > 0 {
+		return fmt.Errorf("%v models failed", failed)
+	}
+	return nil
+}
+
+func runMigrate(ctx context.Context, tool *categoryTool, args []string) error {
+	flags := newModelFlags(tool, "migrate")
+	err := flags.parse(args, false)
+	if err != nil {
+		return err
+	}
+	target := tool.operator.Encoding()
+	checked, rewritten, failed := 0, 0, 0
+	migrate := func(dao *repository.CategoryDAO) error {
+		checked++
+		if decodeErr := dao.DecodeError(); decodeErr != nil {
+			failed++
+			fmt.Fprintf(tool.out, "%v %v cannot be decoded, skipped: %v\n", dao.User(), dao.SortKey(), decodeErr.Err)
+			return nil
+		}
+		if dao.StoredEncoding() == target {
+			return nil
+		}
+		fmt.Fprintf(tool.out, "%v %v %v to %v\n", dao.User(), dao.ID, dao.StoredEncoding(), target)
+		rewritten++
+		if tool.dryRun {
+			return nil
+		}
+		_, err := tool.operator.Migrate(ctx, dao)
+		if err != nil {
+			failed++
+			rewritten--
+			fmt.Fprintf(tool.out, "%v %v failed: %v\n", dao.User(), dao.ID, err)
+		}
+		return nil
+	}
+
+	if flags.user == "" {
+		err = tool.operator.ScanModels(ctx, migrate)
+	} else {
+		var daos []*repository.CategoryDAO
+		daos, err = tool.operator.SelectModels(ctx, flags.user)
+		for i := 0; err == nil && i < len(daos); i++ {
+			err = migrate(daos[i])
+		}
+	}
+	if err != nil {
+		return err
+	}
+	verb := "rewritten"
+	if tool.dryRun {
+		verb = "to rewrite (dry run)"
+	}
+	fmt.Fprintf(tool.out, "%v models checked, %v %v, %v failed\n", checked, rewritten, verb, failed)
+	if failed > 0 {
+		return fmt.Errorf("%v models failed", failed)
+	}
+	return nil
+}
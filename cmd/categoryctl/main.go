@@ -0,0 +1,96 @@
+/*
+categoryctl - operator tool for the category table, reads and changes any user's models directly with the table credentials vs. through the API.
+
+	categoryctl [-endpoint url] [-table name] [-actor name] [-no-versions] <command> [flags]
+
+The environment is loaded from infra/.env like the API, run it from the repository root.  -endpoint defaults to PROCESS_AWS_DYNAMOENDPOINT
+so the local Dynamo is used in development.  Every command that changes a model takes -dry-run to print the changes without writing them,
+and keeps the model as it was before the change so it can be listed with versions and restored with rollback.
+Running API processes can serve the old model for up to PROCESS_CATEGORY_CACHE_TTL after a change.
+
+backup, verify and restore work with the archives of the backup package, restore into another table with -table.
+*/
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/suared/core-apiuser/repository"
+)
+
+//command - one categoryctl command, run returns the error to print
+type command struct {
+	usage string
+	run   func(ctx context.Context, tool *categoryTool, args []string) error
+}
+
+var commands = map[string]command{
+	"list":     {"list -user id                        the user's models", runList},
+	"dump":     {"dump -user id -model id [-format f]  the model as a tree or json", runDump},
+	"validate": {"validate [-user id] [-model id]      report problems, every model in the table without -user", runValidate},
+	"repair":   {"repair -user id -model id            fix the problems validate reports as repairable", runRepair},
+	"export":   {"export -user id -model id [-o file]  write the model as json, markdown, opml or csv", runExport},
+	"import":   {"import -user id [-model id] -file f  replace the model's tree from a file, a new model without -model", runImport},
+	"move":     {"move -user id -model id -id c [-parent p]  move a category, to the top level without -parent", runMove},
+	"rename":   {"rename -user id -model id -id c -title t   change a category title", runRename},
+	"versions": {"versions -user id -model id          the versions kept before changes made here", runVersions},
+	"rollback": {"rollback -user id -model id -version v  restore a version", runRollback},
+	"migrate":  {"migrate [-user id]                   rewrite models stored in another encoding than PROCESS_CATEGORY_STORAGE_ENCODING", runMigrate},
+	"index":    {"index [-user id]                     add models written before the model index to it", runIndex},
+	"backup":   {"backup [-user id,id] -o file         archive the models as tar.gz, every model in the table without -user", runBackup},
+	"verify":   {"verify -file f                       check a backup against its manifest", runVerify},
+	"restore":  {"restore -file f [-user id,id] [-overwrite]  restore a backup into -table, models already there are left without -overwrite", runRestore},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: categoryctl [-endpoint url] [-table name] [-actor name] [-no-versions] <command> [flags]\n\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %v\n", commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nchanging commands take -dry-run, run categoryctl <command> -h for its flags\n")
+}
+
+func main() {
+	endpoint := flag.String("endpoint", os.Getenv("PROCESS_AWS_DYNAMOENDPOINT"), "Dynamo endpoint, empty for AWS")
+	table := flag.String("table", os.Getenv("PROCESS_AWS_DYNAMOTABLE_CATEGORY"), "category table name")
+	actor := flag.String("actor", "categoryctl:"+os.Getenv("USER"), "recorded on versions and audit records of changes")
+	noVersions := flag.Bool("no-versions", false, "change models without keeping the stored model as a version, for models too large to keep")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "categoryctl: unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	//the repository reads its configuration from the environment
+	os.Setenv("PROCESS_AWS_DYNAMOENDPOINT", *endpoint)
+	os.Setenv("PROCESS_AWS_DYNAMOTABLE_CATEGORY", *table)
+	repo, err := repository.NewCategoryRepository()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "categoryctl: %v\n", err)
+		os.Exit(1)
+	}
+	operator := repository.NewCategoryOperator(repo, *actor)
+	operator.KeepVersions = !*noVersions
+	tool := &categoryTool{operator: operator, out: os.Stdout, table: *table}
+	err = cmd.run(context.Background(), tool, flag.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "categoryctl %v: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
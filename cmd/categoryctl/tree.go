@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/suared/core/uuid"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+//untitledCategory - the title repair gives categories without one
+const untitledCategory = "Untitled"
+
+//categoryProblem - one problem validate found in a model.  Repairable problems are fixed by repairCategoryModel
+type categoryProblem struct {
+	CategoryID string
+	Message    string
+	Repairable bool
+}
+
+func (problem categoryProblem) String() string {
+	repair := ""
+	if problem.Repairable {
+		repair = " (repairable)"
+	}
+	if problem.CategoryID == "" {
+		return problem.Message + repair
+	}
+	return fmt.Sprintf("%v: %v%v", problem.CategoryID, problem.Message, repair)
+}
+
+//writeCategoryTree - the model as an indented tree, one category per line with its id
+func writeCategoryTree(w io.Writer, userModel *repository.CategoryUserModel) {
+	fmt.Fprintf(w, "%v (%v) %v categories, title policy %v\n", userModel.Name, userModel.ID, countCategories(userModel.Children), userModel.TitlePolicy)
+	writeCategoryBranches(w, userModel.Children, "")
+}
+
+func writeCategoryBranches(w io.Writer, children []*model.Category, indent string) {
+	for i, child := range children {
+		branch, next := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, next = "└── ", "    "
+		}
+		if child == nil {
+			fmt.Fprintf(w, "%v%v<nil>\n", indent, branch)
+			continue
+		}
+		fmt.Fprintf(w, "%v%v%v (%v)\n", indent, branch, child.Title, child.ID)
+		writeCategoryBranches(w, child.Children, indent+next)
+	}
+}
+
+//titlesEnforced - true when the policy does not allow sibling duplicates
+func titlesEnforced(policy model.CategoryTitlePolicy) bool {
+	return policy.Uniqueness == model.TitlesReject || policy.Uniqueness == model.TitlesSuffix
+}
+
+//validateCategoryModel - every problem with the stored model that the API would refuse or mishandle.  limits are the deployment limits
+func validateCategoryModel(userModel *repository.CategoryUserModel, limits model.CategoryLimits) []categoryProblem {
+	var problems []categoryProblem
+	if userModel.ID == "" || !repository.IsModelSortKey(userModel.ID) {
+		problems = append(problems, categoryProblem{Message: fmt.Sprintf("model id %q is empty or contains #", userModel.ID)})
+	}
+	if !userModel.TitlePolicy.Valid() {
+		problems = append(problems, categoryProblem{Message: fmt.Sprintf("title policy %q is invalid", userModel.TitlePolicy)})
+	}
+	if !userModel.Limits.Valid() {
+		problems = append(problems, categoryProblem{Message: "model limits are negative"})
+	}
+
+	seen := make(map[string]bool)
+	var walk func(children []*model.Category, parentID string, level int)
+	walk = func(children []*model.Category, parentID string, level int) {
+		titles := make(map[string]string)
+		for _, child := range children {
+			if child == nil {
+				problems = append(problems, categoryProblem{CategoryID: parentID, Message: "has an empty child entry", Repairable: true})
+				continue
+			}
+			switch {
+			case child.ID == "":
+				problems = append(problems, categoryProblem{CategoryID: parentID, Message: fmt.Sprintf("child %q has no id", child.Title), Repairable: true})
+			case seen[child.ID]:
+				problems = append(problems, categoryProblem{CategoryID: child.ID, Message: "id is used by more than one category", Repairable: true})
+			}
+			seen[child.ID] = true
+			if child.Level != level {
+				problems = append(problems, categoryProblem{CategoryID: child.ID, Message: fmt.Sprintf("level is %v, expected %v", child.Level, level), Repairable: true})
+			}
+			if strings.TrimSpace(child.Title) == "" {
+				problems = append(problems, categoryProblem{CategoryID: child.ID, Message: "title is empty", Repairable: true})
+			} else if titlesEnforced(userModel.TitlePolicy) {
+				key := userModel.TitlePolicy.TitleKey(child.Title)
+				if existingID, found := titles[key]; found {
+					problems = append(problems, categoryProblem{CategoryID: child.ID, Message: fmt.Sprintf("title %q is already used by sibling %v", child.Title, existingID), Repairable: true})
+				} else {
+					titles[key] = child.ID
+				}
+			}
+			walk(child.Children, child.ID, level+1)
+		}
+	}
+	walk(userModel.Children, "", 1)
+
+	//repairs can leave limit problems, so they are checked on a repaired copy to report only what repair cannot fix
+	repaired := copyCategoryModel(userModel)
+	repairCategoryModel(repaired)
+	if err := repaired.CheckLimits(userModel.Limits.Within(limits)); err != nil {
+		problems = append(problems, categoryProblem{Message: err.Error()})
+	}
+	return problems
+}
+
+//repairCategoryModel - fixes the repairable problems in place and returns what was changed.  Empty entries are removed, missing and
+//repeated ids replaced, levels reset, empty titles set to untitledCategory and sibling duplicates suffixed when the title policy enforces it
+func repairCategoryModel(userModel *repository.CategoryUserModel) []string {
+	var changes []string
+	seen := make(map[string]bool)
+	var walk func(children []*model.Category, parentID string, level int) []*model.Category
+	walk = func(children []*model.Category, parentID string, level int) []*model.Category {
+		kept := children[:0]
+		for _, child := range children {
+			if child == nil {
+				changes = append(changes, fmt.Sprintf("removed an empty child entry of %q", parentID))
+				continue
+			}
+			if child.ID == "" || seen[child.ID] {
+				newID := uuid.NewUUID()
+				changes = append(changes, fmt.Sprintf("gave %q the id %v in place of %q", child.Title, newID, child.ID))
+				child.ID = newID
+			}
+			seen[child.ID] = true
+			if child.Level != level {
+				changes = append(changes, fmt.Sprintf("set the level of %v to %v from %v", child.ID, level, child.Level))
+				child.Level = level
+			}
+			if strings.TrimSpace(child.Title) == "" {
+				changes = append(changes, fmt.Sprintf("titled %v %q", child.ID, untitledCategory))
+				child.Title = untitledCategory
+			}
+			child.Children = walk(child.Children, child.ID, level+1)
+			kept = append(kept, child)
+		}
+		return kept
+	}
+	userModel.Children = walk(userModel.Children, "", 1)
+
+	if titlesEnforced(userModel.TitlePolicy) {
+		//the reject policy is repaired like suffix, the earlier sibling keeps the title
+		suffix := userModel.TitlePolicy
+		suffix.Uniqueness = model.TitlesSuffix
+		before := userModel.CategoryRoot.Clone(false)
+		userModel.ApplyTitlePolicy(suffix)
+		for _, diff := range model.DiffCategoryRoots(before, &userModel.CategoryRoot) {
+			changes = append(changes, fmt.Sprintf("renamed duplicate %v to %q from %q", diff.CategoryID, diff.Title, diff.OldTitle))
+		}
+	}
+	return changes
+}
+
+//copyCategoryModel - a deep copy so a change can be compared with the model as stored
+func copyCategoryModel(userModel *repository.CategoryUserModel) *repository.CategoryUserModel {
+	copied := *userModel
+	copied.Children = copyCategories(userModel.Children)
+	return &copied
+}
+
+//copyCategories - Clone without the level reset so the copy keeps problems for repair to find
+func copyCategories(children []*model.Category) []*model.Category {
+	if children == nil {
+		return nil
+	}
+	copied := make([]*model.Category, len(children))
+	for i, child := range children {
+		if child != nil {
+			category := *child
+			category.Children = copyCategories(child.Children)
+			copied[i] = &category
+		}
+	}
+	return copied
+}
+
+//countCategories - the categories in the tree, GetAllChildren without failing on empty entries
+func countCategories(children []*model.Category) int {
+	count := 0
+	for _, child := range children {
+		if child != nil {
+			count += 1 + countCategories(child.Children)
+		}
+	}
+	return count
+}
+
+//diffableRoot - a copy of the tree without empty entries, which DiffCategoryRoots cannot compare
+func diffableRoot(userModel *repository.CategoryUserModel) *model.CategoryRoot {
+	var compact func(children []*model.Category) []*model.Category
+	compact = func(children []*model.Category) []*model.Category {
+		var kept []*model.Category
+		for _, child := range children {
+			if child != nil {
+				category := *child
+				category.Children = compact(child.Children)
+				kept = append(kept, &category)
+			}
+		}
+		return kept
+	}
+	root := userModel.CategoryRoot
+	root.Children = compact(userModel.Children)
+	return &root
+}
+
+//isDescendant - true when id is the category or one of its descendants
+func isDescendant(category *model.Category, id string) bool {
+	if category.ID == id {
+		return true
+	}
+	for _, child := range category.Children {
+		if child != nil && isDescendant(child, id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+func brokenCategoryModel() *repository.CategoryUserModel {
+	catModel := &repository.CategoryUserModel{TitlePolicy: model.CategoryTitlePolicy{Uniqueness: model.TitlesReject, IgnoreCase: true}}
+	catModel.ID = "m1"
+	catModel.Name = "Tree"
+	catModel.Children = []*model.Category{
+		{ID: "work", Level: 1, Title: "Work", Children: []*model.Category{
+			{ID: "launch", Level: 3, Title: "Launch"},
+			nil,
+			{ID: "home", Level: 2, Title: " "},
+		}},
+		{ID: "home", Level: 1, Title: "Home"},
+		{ID: "", Level: 1, Title: "work"},
+	}
+	return catModel
+}
+
+func TestValidateAndRepairCategoryModel(t *testing.T) {
+	catModel := brokenCategoryModel()
+	problems := validateCategoryModel(catModel, repository.DefaultCategoryLimits)
+	var found []string
+	for _, problem := range problems {
+		if !problem.Repairable {
+			t.Errorf("Expected every problem to be repairable, received: %v", problem)
+		}
+		found = append(found, problem.String())
+	}
+	expected := []string{"launch: level is 3", "work: has an empty child entry", "home: title is empty", "home: id is used by more than one", `child "work" has no id`, `title "work" is already used by sibling work`}
+	for _, message := range expected {
+		if !strings.Contains(strings.Join(found, "\n"), message) {
+			t.Errorf("Expected the problem %q, received: %v", message, found)
+		}
+	}
+
+	repaired := copyCategoryModel(catModel)
+	changes := repairCategoryModel(repaired)
+	if len(changes) == 0 || len(catModel.Children[0].Children) != 3 {
+		t.Fatalf("Expected the copy to be repaired and the original kept, received: %v", changes)
+	}
+	if problems = validateCategoryModel(repaired, repository.DefaultCategoryLimits); len(problems) != 0 {
+		t.Errorf("Expected no problems after repair, received: %v", problems)
+	}
+	work := repaired.Children[0]
+	if len(work.Children) != 2 || work.Children[0].Level != 2 || work.Children[1].Title != untitledCategory {
+		t.Errorf("Expected the empty entry removed, the level and title fixed, received: %v", work.Children)
+	}
+	//depth first, the later category with the id is given a new one
+	if work.Children[1].ID != "home" || repaired.Children[1].ID == "home" {
+		t.Errorf("Expected the repeated id replaced, received: %v", repaired.Children[1])
+	}
+	if repaired.Children[2].ID == "" || repaired.Children[2].Title != "work (2)" {
+		t.Errorf("Expected the missing id and duplicate title fixed, received: %v", repaired.Children[2])
+	}
+}
+
+func TestValidateCategoryModelLimits(t *testing.T) {
+	catModel := &repository.CategoryUserModel{Limits: model.CategoryLimits{MaxDepth: 1}}
+	catModel.ID = "m1"
+	catModel.AddChild(model.Category{ID: "work", Title: "Work", Children: []*model.Category{{ID: "launch", Title: "Launch"}}})
+	problems := validateCategoryModel(catModel, repository.DefaultCategoryLimits)
+	if len(problems) != 1 || problems[0].Repairable || !strings.Contains(problems[0].Message, "depth") {
+		t.Errorf("Expected the model's depth limit to be reported as not repairable, received: %v", problems)
+	}
+	catModel.ID = "bad#id"
+	catModel.Limits = model.CategoryLimits{}
+	if problems = validateCategoryModel(catModel, repository.DefaultCategoryLimits); len(problems) != 1 {
+		t.Errorf("Expected the model id to be refused, received: %v", problems)
+	}
+}
+
+func TestWriteCategoryTree(t *testing.T) {
+	catModel := brokenCategoryModel()
+	var out bytes.Buffer
+	writeCategoryTree(&out, catModel)
+	expected := `Tree (m1) 5 categories, title policy reject,ignoreCase
+├── Work (work)
+│   ├── Launch (launch)
+│   ├── <nil>
+│   └──   (home)
+├── Home (home)
+└── work ()
+`
+	if out.String() != expected {
+		t.Errorf("Expected the tree, received:\n%v", out.String())
+	}
+
+	if !isDescendant(catModel.Children[0], "launch") || isDescendant(catModel.Children[1], "launch") {
+		t.Errorf("Expected launch below work only")
+	}
+	root := diffableRoot(catModel)
+	if len(root.Children[0].Children) != 2 || len(catModel.Children[0].Children) != 3 {
+		t.Errorf("Expected empty entries left out of the copy only, received: %v", root.Children[0].Children)
+	}
+}
@@ -1,9 +1,28 @@
 module github.com/suared/core-apiuser
 
-go 1.12
+go 1.24.0
 
 require (
 	github.com/akrylysov/algnhsa v0.12.1
+	github.com/aws/aws-lambda-go v1.9.0
+	github.com/aws/aws-sdk-go v1.23.17
+	github.com/fxamacker/cbor/v2 v2.9.2
 	github.com/gorilla/mux v1.7.3
+	github.com/graphql-go/graphql v0.8.1
 	github.com/suared/core v0.0.0-20191019180754-80c2686b89c3
+	golang.org/x/text v0.32.0
+	google.golang.org/grpc v1.79.3
+	google.golang.org/protobuf v1.36.10
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible // indirect
+	github.com/jmespath/go-jmespath v0.0.0-20180206201540-c2b33e8439af // indirect
+	github.com/joho/godotenv v1.3.0 // indirect
+	github.com/segmentio/ksuid v1.0.2 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/net v0.48.0 // indirect
+	golang.org/x/sys v0.39.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20251202230838-ff82c1b0f217 // indirect
 )
@@ -0,0 +1,61 @@
+# Same binary as the api, LAMBDA_HANDLER=backup selects the scheduled category backup (see backup/lambda.go)
+# Restore with categoryctl restore, the Lambda only writes archives
+resource "aws_lambda_function" "backup_lambda" {
+  count         = var.category_backup_enabled ? 1 : 0
+  function_name = "LifeApp_backup_dev"
+
+  s3_bucket = aws_s3_bucket_object.binary.bucket
+  s3_key    = aws_s3_bucket_object.binary.key
+
+  handler = "binarypkg"
+  runtime = "go1.x"
+
+  memory_size = var.lambda_memory_size
+  role        = aws_iam_role.demo_lambda_exec.arn
+  # a full table scan outlasts the api timeout
+  timeout = 300
+
+  environment {
+    variables = merge(var.environment_variables, {
+      LAMBDA_HANDLER                 = "backup"
+      PROCESS_CATEGORY_BACKUP_BUCKET = var.category_backup_bucket
+    })
+  }
+
+  tags = var.tags
+}
+
+resource "aws_cloudwatch_event_rule" "category_backup" {
+  count               = var.category_backup_enabled ? 1 : 0
+  name                = "lifeapp-category-backup-dev"
+  schedule_expression = var.category_backup_schedule
+  tags                = var.tags
+}
+
+resource "aws_cloudwatch_event_target" "category_backup" {
+  count = var.category_backup_enabled ? 1 : 0
+  rule  = aws_cloudwatch_event_rule.category_backup[0].name
+  arn   = aws_lambda_function.backup_lambda[0].arn
+}
+
+resource "aws_lambda_permission" "category_backup" {
+  count         = var.category_backup_enabled ? 1 : 0
+  statement_id  = "AllowExecutionFromCloudWatch"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.backup_lambda[0].function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.category_backup[0].arn
+}
+
+resource "aws_iam_policy" "dynamodb-backup-policy" {
+  count       = var.category_backup_enabled ? 1 : 0
+  name        = "lifeapp-dynamodb-backup-policy"
+  description = "grants scan access to category_dev and upload to the backup bucket"
+  policy      = templatefile("lambda_dynamo_backup_iam.json", { bucket = var.category_backup_bucket })
+}
+
+resource "aws_iam_role_policy_attachment" "lambda-db-backup-policy" {
+  count      = var.category_backup_enabled ? 1 : 0
+  role       = aws_iam_role.demo_lambda_exec.name
+  policy_arn = aws_iam_policy.dynamodb-backup-policy[0].arn
+}
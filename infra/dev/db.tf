@@ -19,6 +19,20 @@ resource "aws_dynamodb_table" "process_table" {
     }
   }
 
-  #  ttl                         = "${var.dynamodb_table_ttl}"
+  #model items set CategoryModelKey to their hash key, listing queries this index so it only reads models, see repository/categorymodelindex.go
+  global_secondary_index {
+    name            = "CategoryModels"
+    hash_key        = "CategoryModelKey"
+    range_key       = var.dyamodb_range_key
+    read_capacity   = var.dyamodb_read_capacity
+    write_capacity  = var.dyamodb_write_capacity
+    projection_type = "KEYS_ONLY"
+  }
+
+  #audit and idempotency records set ExpiresAt (epoch seconds) from PROCESS_CATEGORY_AUDIT_RETENTION and PROCESS_CATEGORY_IDEMPOTENCY_TTL
+  ttl {
+    attribute_name = "ExpiresAt"
+    enabled        = true
+  }
   tags = var.tags
 }
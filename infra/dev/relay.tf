@@ -0,0 +1,58 @@
+# Same binary as the api, LAMBDA_HANDLER=relay selects the category event outbox relay (see service/category_events.go RelayOutbox)
+# Publishes events left in the outbox by a failed publish and operator changes, and retries pending webhook deliveries
+resource "aws_lambda_function" "relay_lambda" {
+  count         = var.category_relay_enabled ? 1 : 0
+  function_name = "LifeApp_relay_dev"
+
+  s3_bucket = aws_s3_bucket_object.binary.bucket
+  s3_key    = aws_s3_bucket_object.binary.key
+
+  handler = "binarypkg"
+  runtime = "go1.x"
+
+  memory_size = var.lambda_memory_size
+  role        = aws_iam_role.demo_lambda_exec.arn
+  # a full table scan plus webhook retries outlast the api timeout
+  timeout = 300
+
+  environment {
+    variables = merge(var.environment_variables, { LAMBDA_HANDLER = "relay" })
+  }
+
+  tags = var.tags
+}
+
+resource "aws_cloudwatch_event_rule" "category_relay" {
+  count               = var.category_relay_enabled ? 1 : 0
+  name                = "lifeapp-category-relay-dev"
+  schedule_expression = var.category_relay_schedule
+  tags                = var.tags
+}
+
+resource "aws_cloudwatch_event_target" "category_relay" {
+  count = var.category_relay_enabled ? 1 : 0
+  rule  = aws_cloudwatch_event_rule.category_relay[0].name
+  arn   = aws_lambda_function.relay_lambda[0].arn
+}
+
+resource "aws_lambda_permission" "category_relay" {
+  count         = var.category_relay_enabled ? 1 : 0
+  statement_id  = "AllowExecutionFromCloudWatch"
+  action        = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.relay_lambda[0].function_name
+  principal     = "events.amazonaws.com"
+  source_arn    = aws_cloudwatch_event_rule.category_relay[0].arn
+}
+
+resource "aws_iam_policy" "dynamodb-relay-policy" {
+  count       = var.category_relay_enabled ? 1 : 0
+  name        = "lifeapp-dynamodb-relay-policy"
+  description = "grants scan access to category_dev for the outbox relay"
+  policy      = file("lambda_dynamo_relay_iam.json")
+}
+
+resource "aws_iam_role_policy_attachment" "lambda-db-relay-policy" {
+  count      = var.category_relay_enabled ? 1 : 0
+  role       = aws_iam_role.demo_lambda_exec.name
+  policy_arn = aws_iam_policy.dynamodb-relay-policy[0].arn
+}
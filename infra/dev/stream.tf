@@ -0,0 +1,43 @@
+# Same binary as the api, LAMBDA_HANDLER=stream selects the category table stream handler (see stream/categorystream.go)
+# Diffs need dyamodb_stream_view_type = "NEW_AND_OLD_IMAGES"
+resource "aws_lambda_function" "stream_lambda" {
+  count         = var.dyamodb_stream_enabled ? 1 : 0
+  function_name = "LifeApp_stream_dev"
+
+  s3_bucket = aws_s3_bucket_object.binary.bucket
+  s3_key    = aws_s3_bucket_object.binary.key
+
+  handler = "binarypkg"
+  runtime = "go1.x"
+
+  memory_size = var.lambda_memory_size
+  role        = aws_iam_role.demo_lambda_exec.arn
+  timeout     = var.lambda_timeout
+
+  environment {
+    variables = merge(var.environment_variables, { LAMBDA_HANDLER = "stream" })
+  }
+
+  tags = var.tags
+}
+
+resource "aws_lambda_event_source_mapping" "category_stream" {
+  count             = var.dyamodb_stream_enabled ? 1 : 0
+  event_source_arn  = aws_dynamodb_table.process_table.stream_arn
+  function_name     = aws_lambda_function.stream_lambda[0].arn
+  starting_position = "LATEST"
+  batch_size        = 100
+}
+
+resource "aws_iam_policy" "dynamodb-stream-policy" {
+  count       = var.dyamodb_stream_enabled ? 1 : 0
+  name        = "lifeapp-dynamodb-stream-policy"
+  description = "grants read access to the category_dev stream"
+  policy      = file("lambda_dynamo_stream_iam.json")
+}
+
+resource "aws_iam_role_policy_attachment" "lambda-db-stream-policy" {
+  count      = var.dyamodb_stream_enabled ? 1 : 0
+  role       = aws_iam_role.demo_lambda_exec.name
+  policy_arn = aws_iam_policy.dynamodb-stream-policy[0].arn
+}
@@ -57,6 +57,21 @@ variable "dyamodb_stream_enabled" {
 variable "dyamodb_stream_view_type" {
 }
 
+variable "category_backup_enabled" {
+}
+
+variable "category_backup_schedule" {
+}
+
+variable "category_backup_bucket" {
+}
+
+variable "category_relay_enabled" {
+}
+
+variable "category_relay_schedule" {
+}
+
 variable "dynamodb_table_attributes" {
   default = [
     {
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/suared/core/uuid"
@@ -9,6 +10,12 @@ import (
 	_ "github.com/suared/core/infra"
 )
 
+//ErrCategoryNotFound - a change names a category id that is not in the model
+var ErrCategoryNotFound = errors.New("category not found")
+
+//ErrCategoryMoveCycle - a category cannot be moved under itself or one of its descendants
+var ErrCategoryMoveCycle = errors.New("category cannot be moved under itself or its descendants")
+
 //Category - The model object
 type Category struct {
 	ID       string      `json:"id"`
@@ -68,6 +75,19 @@ func (cat *Category) AddChild(child Category) *Category {
 	return &child
 }
 
+//Clone - Returns a deep copy of the category and all of its children.  When regenerateIDs is true every copied category is given a new ID
+func (cat *Category) Clone(regenerateIDs bool) *Category {
+	clone := *cat
+	clone.Children = nil
+	if regenerateIDs {
+		clone.ID = uuid.NewUUID()
+	}
+	for i := range cat.Children {
+		clone.Children = append(clone.Children, cat.Children[i].Clone(regenerateIDs))
+	}
+	return &clone
+}
+
 //GetChildByName - Returns first child with matching name, immediate child search only
 func (cat *Category) GetChildByName(name string) *Category {
 	for i := range cat.Children {
@@ -206,11 +226,26 @@ func (root *CategoryRoot) Equals(compare *CategoryRoot) bool {
 	return true
 }
 
-//AddChild - Adds a new level 1 child to the root
+//Clone - Returns a deep copy of the root and all categories.  When regenerateIDs is true the root and every category are given new IDs
+func (root *CategoryRoot) Clone(regenerateIDs bool) *CategoryRoot {
+	clone := *root
+	clone.Children = nil
+	if regenerateIDs {
+		clone.ID = uuid.NewUUID()
+	}
+	for i := range root.Children {
+		clone.Children = append(clone.Children, root.Children[i].Clone(regenerateIDs))
+	}
+	return &clone
+}
+
+//AddChild - Adds a new level 1 child to the root.  Children are re-added so descendant levels are reset and no child pointers are shared with the caller's tree
 func (root *CategoryRoot) AddChild(category Category) *Category {
-	category.Level = 1
-	root.Children = append(root.Children, &category)
-	return &category
+	//a level 0 holder gives the same recursive level reset as Category AddChild
+	holder := Category{}
+	added := holder.AddChild(category)
+	root.Children = append(root.Children, added)
+	return added
 }
 
 //RemoveChildByName - Removes first child with matching name, immediate child search only
@@ -222,8 +257,8 @@ func (root *CategoryRoot) RemoveChildByName(name string) {
 func (root *CategoryRoot) RemoveChildByID(id string) {
 	//First Find the Category
 	_, parent := root.FindChildByID(id)
-	//Remove it from root if parent is empty, otherwise from parent
-	if parent.ID == "" {
+	//Remove it from root if parent is empty (nil for top level categories), otherwise from parent
+	if parent == nil || parent.ID == "" {
 		root.Children = removeCategoryItemByID(root.Children, id)
 	} else {
 		parent.Children = removeCategoryItemByID(parent.Children, id)
@@ -316,6 +351,37 @@ func (root *CategoryRoot) Indent(catID, newParentID string) {
 	root.Move(catID, futureParentCat)
 }
 
+//Merge - Adds the imported tree into this root.  Categories are matched by ID anywhere in the tree first, then by title among the siblings of the matched parent.
+//Matched categories keep their current title and location and their children are merged in turn, unmatched categories are added along with their children
+func (root *CategoryRoot) Merge(imported *CategoryRoot) {
+	for i := range imported.Children {
+		root.mergeCategory(nil, imported.Children[i])
+	}
+}
+
+//mergeCategory - merges incoming under parent, a nil parent signifies the root
+func (root *CategoryRoot) mergeCategory(parent *Category, incoming *Category) {
+	existing, _ := root.FindChildByID(incoming.ID)
+	if existing.ID == "" {
+		if parent == nil {
+			existing = root.GetChildByName(incoming.Title)
+		} else {
+			existing = parent.GetChildByName(incoming.Title)
+		}
+	}
+	if existing.ID == "" {
+		if parent == nil {
+			root.AddChild(*incoming)
+		} else {
+			parent.AddChild(*incoming)
+		}
+		return
+	}
+	for i := range incoming.Children {
+		root.mergeCategory(existing, incoming.Children[i])
+	}
+}
+
 //NewCategoryRoot - Constructs a new Root Category Holder
 func NewCategoryRoot(name string) *CategoryRoot {
 	return &CategoryRoot{ID: uuid.NewUUID(), Name: name}
@@ -0,0 +1,75 @@
+package model
+
+//CategoryDiff - One structural difference between two versions of a category tree.  Type is one of CategoryAdded, CategoryDeleted, CategoryMoved or CategoryRenamed,
+//parent ids are empty for the root
+type CategoryDiff struct {
+	Type        CategoryEventType `json:"type"`
+	CategoryID  string            `json:"categoryID"`
+	ParentID    string            `json:"parentID,omitempty"`
+	OldParentID string            `json:"oldParentID,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	OldTitle    string            `json:"oldTitle,omitempty"`
+}
+
+//categoryPosition - where a category sits in one version of the tree
+type categoryPosition struct {
+	category *Category
+	parentID string
+}
+
+func indexCategories(children []*Category, parentID string, index map[string]categoryPosition, order *[]string) {
+	for _, child := range children {
+		index[child.ID] = categoryPosition{category: child, parentID: parentID}
+		*order = append(*order, child.ID)
+		indexCategories(child.Children, child.ID, index, order)
+	}
+}
+
+//DiffCategoryRoots - Returns the changes from old to new matched by category id, either root may be nil for a created or removed model.
+//Added and deleted subtrees are reported once at the top of the subtree.  A category that moved and was renamed is reported twice, move first
+func DiffCategoryRoots(old *CategoryRoot, new *CategoryRoot) []CategoryDiff {
+	oldIndex, newIndex := make(map[string]categoryPosition), make(map[string]categoryPosition)
+	var oldOrder, newOrder []string
+	if old != nil {
+		indexCategories(old.Children, "", oldIndex, &oldOrder)
+	}
+	if new != nil {
+		indexCategories(new.Children, "", newIndex, &newOrder)
+	}
+
+	var diffs []CategoryDiff
+	for _, id := range oldOrder {
+		oldPosition := oldIndex[id]
+		if _, found := newIndex[id]; found {
+			continue
+		}
+		//the parent going away already covers this one
+		if _, parentDeleted := oldIndex[oldPosition.parentID]; parentDeleted {
+			if _, parentKept := newIndex[oldPosition.parentID]; !parentKept {
+				continue
+			}
+		}
+		diffs = append(diffs, CategoryDiff{Type: CategoryDeleted, CategoryID: id, OldParentID: oldPosition.parentID, Title: oldPosition.category.Title})
+	}
+
+	for _, id := range newOrder {
+		newPosition := newIndex[id]
+		oldPosition, found := oldIndex[id]
+		if !found {
+			if _, parentAdded := newIndex[newPosition.parentID]; parentAdded {
+				if _, parentExisted := oldIndex[newPosition.parentID]; !parentExisted {
+					continue
+				}
+			}
+			diffs = append(diffs, CategoryDiff{Type: CategoryAdded, CategoryID: id, ParentID: newPosition.parentID, Title: newPosition.category.Title})
+			continue
+		}
+		if oldPosition.parentID != newPosition.parentID {
+			diffs = append(diffs, CategoryDiff{Type: CategoryMoved, CategoryID: id, ParentID: newPosition.parentID, OldParentID: oldPosition.parentID, Title: newPosition.category.Title})
+		}
+		if oldPosition.category.Title != newPosition.category.Title {
+			diffs = append(diffs, CategoryDiff{Type: CategoryRenamed, CategoryID: id, ParentID: newPosition.parentID, Title: newPosition.category.Title, OldTitle: oldPosition.category.Title})
+		}
+	}
+	return diffs
+}
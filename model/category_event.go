@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/suared/core/uuid"
+)
+
+//CategoryEventType - The kind of change a CategoryEvent describes
+type CategoryEventType string
+
+//Event types, one event is emitted per change
+const (
+	//CategoryAdded - a category (and any children) was added, including copies
+	CategoryAdded CategoryEventType = "CategoryAdded"
+	//CategoryMoved - a category moved to a new parent, ParentID is the new parent and OldParentID the previous one.  Empty is the root
+	CategoryMoved CategoryEventType = "CategoryMoved"
+	//CategoryRenamed - a category title changed
+	CategoryRenamed CategoryEventType = "CategoryRenamed"
+	//CategoryDeleted - a category and its children were removed
+	CategoryDeleted CategoryEventType = "CategoryDeleted"
+	//CategoryModelReplaced - the whole model was created or replaced (import, merge, template, replace), consumers should reload it
+	CategoryModelReplaced CategoryEventType = "CategoryModelReplaced"
+	//CategoryModelDeleted - the whole model was removed
+	CategoryModelDeleted CategoryEventType = "CategoryModelDeleted"
+)
+
+//CategoryEvent - A change to a user's category model for downstream consumers.  IDs are time ordered.
+//UserID is the model owner, ActorID the user that made the change when the model is shared
+type CategoryEvent struct {
+	ID          string            `json:"id"`
+	Type        CategoryEventType `json:"type"`
+	UserID      string            `json:"userID"`
+	ActorID     string            `json:"actorID,omitempty"`
+	ModelID     string            `json:"modelID"`
+	CategoryID  string            `json:"categoryID,omitempty"`
+	ParentID    string            `json:"parentID,omitempty"`
+	OldParentID string            `json:"oldParentID,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	OldTitle    string            `json:"oldTitle,omitempty"`
+	OccurredAt  time.Time         `json:"occurredAt"`
+}
+
+//NewCategoryEvent - Constructs an event with a new id and the current time
+func NewCategoryEvent(eventType CategoryEventType, userID string, modelID string) CategoryEvent {
+	return CategoryEvent{ID: uuid.NewUUID(), Type: eventType, UserID: userID, ModelID: modelID, OccurredAt: time.Now().UTC()}
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
@@ -27,43 +28,47 @@ func ConvertCategoryRootToBytes(root *CategoryRoot) ([]byte, error) {
 	return data, err
 }
 
-//getCategoryRootFromFile - Restore Category Root From File
-func getCategoryRootFromFile(fileloc string) *CategoryRoot {
-
-	var theRoot *CategoryRoot
-
-	f, err := os.Open(os.Getenv("CATEGORY_MODEL_TESTFILE_DIR") + fileloc)
-
-	data, err := ioutil.ReadAll(f)
+//ReadCategoryRootFile - Restores a Category Root from a file in the provided format
+func ReadCategoryRootFile(filename string, format CategoryFormat) (*CategoryRoot, error) {
+	data, err := ioutil.ReadFile(filename)
 	if err != nil {
-		log.Printf("Received error on open file e: %v", err)
-		panic(err)
+		return nil, fmt.Errorf("Unable to read category file: %v, received: %v", filename, err)
 	}
-	defer f.Close()
-
-	theRoot, err = GetCategoryRootFromBytes(data)
+	root, err := ImportCategoryRoot(data, format)
 	if err != nil {
-		log.Printf("Received error retrieving Bytes from file e: %v", err)
-		panic(err)
+		return nil, fmt.Errorf("Unable to import category file: %v, received: %v", filename, err)
 	}
+	return root, nil
+}
 
-	return theRoot
+//WriteCategoryRootFile - Saves a Category Root to a file in the provided format, replacing the file if it exists
+func WriteCategoryRootFile(filename string, root *CategoryRoot, format CategoryFormat) error {
+	data, err := ExportCategoryRoot(root, format)
+	if err != nil {
+		return fmt.Errorf("Unable to export category root for file: %v, received: %v", filename, err)
+	}
+	err = ioutil.WriteFile(filename, data, 0644)
+	if err != nil {
+		return fmt.Errorf("Unable to write category file: %v, received: %v", filename, err)
+	}
+	return nil
 }
 
-func saveCategoryRootToFile(filename string, root *CategoryRoot) {
-	fileDir := os.Getenv("CATEGORY_MODEL_TESTFILE_DIR")
-	f, err := os.Create(fileDir + filename)
+//getCategoryRootFromFile - Restore Category Root From File, test helper so panics on error
+func getCategoryRootFromFile(fileloc string) *CategoryRoot {
+	theRoot, err := ReadCategoryRootFile(os.Getenv("CATEGORY_MODEL_TESTFILE_DIR")+fileloc, FormatJSON)
 	if err != nil {
-		log.Printf("Received error on create file e: %v", err)
+		log.Printf("Received error on reading file e: %v", err)
 		panic(err)
 	}
-	defer f.Close()
+	return theRoot
+}
 
-	data, err := ConvertCategoryRootToBytes(root)
+//saveCategoryRootToFile - Save Category Root to File, test helper so panics on error
+func saveCategoryRootToFile(filename string, root *CategoryRoot) {
+	err := WriteCategoryRootFile(os.Getenv("CATEGORY_MODEL_TESTFILE_DIR")+filename, root, FormatJSON)
 	if err != nil {
-		log.Printf("Received error on converting category to file e: %v", err)
+		log.Printf("Received error on saving file e: %v", err)
 		panic(err)
 	}
-	f.Write(data)
-	f.Sync()
 }
@@ -0,0 +1,366 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/suared/core/uuid"
+)
+
+//CategoryFormat - Identifies a supported import/ export representation of a category tree
+type CategoryFormat string
+
+const (
+	//FormatJSON - Default storage representation
+	FormatJSON CategoryFormat = "json"
+	//FormatMarkdown - Indented bullet list, one category per line.  IDs are not preserved
+	FormatMarkdown CategoryFormat = "markdown"
+	//FormatOPML - Outline format used by outliners, IDs are preserved as a custom attribute
+	FormatOPML CategoryFormat = "opml"
+	//FormatCSV - One row per category with a slash separated path column
+	FormatCSV CategoryFormat = "csv"
+)
+
+//mediaTypes - first entry is the preferred content type written on export, the rest are accepted on import/ negotiation
+var mediaTypes = map[CategoryFormat][]string{
+	FormatJSON:     {"application/json"},
+	FormatMarkdown: {"text/markdown", "text/x-markdown"},
+	FormatOPML:     {"text/x-opml", "application/xml", "text/xml"},
+	FormatCSV:      {"text/csv"},
+}
+
+//ContentType - returns the content type to use when writing this format
+func (format CategoryFormat) ContentType() string {
+	return mediaTypes[format][0]
+}
+
+//CategoryFormatFromMediaType - returns the format for a content type (parameters such as charset are ignored), false if not supported
+func CategoryFormatFromMediaType(contentType string) (CategoryFormat, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	for format, types := range mediaTypes {
+		for i := range types {
+			if types[i] == mediaType {
+				return format, true
+			}
+		}
+	}
+	return "", false
+}
+
+//CategoryFormatFromName - returns the format for a short name (e.g. ?format=opml or a file extension without the dot), false if not supported
+func CategoryFormatFromName(name string) (CategoryFormat, bool) {
+	switch strings.ToLower(name) {
+	case "json":
+		return FormatJSON, true
+	case "markdown", "md":
+		return FormatMarkdown, true
+	case "opml":
+		return FormatOPML, true
+	case "csv":
+		return FormatCSV, true
+	}
+	return "", false
+}
+
+//ExportCategoryRoot - returns the byte representation of the root in the requested format
+func ExportCategoryRoot(root *CategoryRoot, format CategoryFormat) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return ConvertCategoryRootToBytes(root)
+	case FormatMarkdown:
+		return exportMarkdown(root)
+	case FormatOPML:
+		return exportOPML(root)
+	case FormatCSV:
+		return exportCSV(root)
+	}
+	return nil, fmt.Errorf("Unsupported category format: %v", format)
+}
+
+//ImportCategoryRoot - returns a new Category Root from the byte representation in the provided format.  Categories without IDs are assigned new ones, a repeated ID is an error
+func ImportCategoryRoot(data []byte, format CategoryFormat) (*CategoryRoot, error) {
+	switch format {
+	case FormatJSON:
+		return GetCategoryRootFromBytes(data)
+	case FormatMarkdown:
+		return importMarkdown(data)
+	case FormatOPML:
+		return importOPML(data)
+	case FormatCSV:
+		return importCSV(data)
+	}
+	return nil, fmt.Errorf("Unsupported category format: %v", format)
+}
+
+//Markdown
+
+const markdownIndent = "  "
+
+func exportMarkdown(root *CategoryRoot) ([]byte, error) {
+	var buf bytes.Buffer
+	if root.Name != "" {
+		buf.WriteString("# " + root.Name + "\n\n")
+	}
+	for _, cat := range root.GetAllChildren() {
+		buf.WriteString(strings.Repeat(markdownIndent, cat.Level-1) + "- " + cat.Title + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+//importMarkdown - accepts "-", "*" or "+" bullets indented with spaces or tabs.  Depth is based on the indent of the line relative to its ancestors so any consistent indent width works
+func importMarkdown(data []byte) (*CategoryRoot, error) {
+	root := NewCategoryRoot("")
+
+	//stack of open ancestors and their indent widths, empty stack means the next item is added to the root
+	var parents []*Category
+	var indents []int
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "# ") && root.Name == "" && len(root.Children) == 0 {
+			root.Name = strings.TrimSpace(trimmed[2:])
+			continue
+		}
+		if len(trimmed) < 2 || !strings.ContainsAny(trimmed[:1], "-*+") || trimmed[1] != ' ' {
+			return nil, fmt.Errorf("Markdown line %v is not a bullet item: %q", lineNum, line)
+		}
+		title := strings.TrimSpace(trimmed[2:])
+		indent := len(strings.Replace(line[:len(line)-len(trimmed)], "\t", "    ", -1))
+
+		//pop back to the nearest ancestor with a smaller indent
+		for len(indents) > 0 && indents[len(indents)-1] >= indent {
+			parents = parents[:len(parents)-1]
+			indents = indents[:len(indents)-1]
+		}
+
+		var added *Category
+		if len(parents) == 0 {
+			added = root.AddChild(*NewCategory(title))
+		} else {
+			added = parents[len(parents)-1].AddChild(*NewCategory(title))
+		}
+		parents = append(parents, added)
+		indents = append(indents, indent)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+//OPML
+
+type opmlDocument struct {
+	XMLName xml.Name      `xml:"opml"`
+	Version string        `xml:"version,attr"`
+	Title   string        `xml:"head>title"`
+	Body    []opmlOutline `xml:"body>outline"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	ID       string        `xml:"id,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+func toOPMLOutlines(children []*Category) []opmlOutline {
+	var outlines []opmlOutline
+	for _, child := range children {
+		outlines = append(outlines, opmlOutline{Text: child.Title, ID: child.ID, Outlines: toOPMLOutlines(child.Children)})
+	}
+	return outlines
+}
+
+func exportOPML(root *CategoryRoot) ([]byte, error) {
+	doc := opmlDocument{Version: "2.0", Title: root.Name, Body: toOPMLOutlines(root.Children)}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+//addOPMLOutlines - adds the outlines through the provided add method so levels are set as the tree is built.  IDs must be unique as they
+//identify the category in the model, seen holds the IDs added so far
+func addOPMLOutlines(add func(Category) *Category, outlines []opmlOutline, seen map[string]bool) error {
+	for i := range outlines {
+		cat := Category{ID: outlines[i].ID, Title: outlines[i].Text}
+		if cat.ID == "" {
+			cat.ID = uuid.NewUUID()
+		} else if seen[cat.ID] {
+			return fmt.Errorf("OPML outline %q repeats the id %v", cat.Title, cat.ID)
+		}
+		seen[cat.ID] = true
+		added := add(cat)
+		err := addOPMLOutlines(added.AddChild, outlines[i].Outlines, seen)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func importOPML(data []byte) (*CategoryRoot, error) {
+	doc := opmlDocument{}
+	err := xml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse OPML: %v", err)
+	}
+	root := NewCategoryRoot(doc.Title)
+	err = addOPMLOutlines(root.AddChild, doc.Body, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+//CSV
+
+var csvHeader = []string{"id", "path", "title"}
+
+const csvPathSeparator = "/"
+
+//escapePathSegment - titles may contain the separator so it is escaped with a backslash
+func escapePathSegment(title string) string {
+	title = strings.Replace(title, `\`, `\\`, -1)
+	return strings.Replace(title, csvPathSeparator, `\`+csvPathSeparator, -1)
+}
+
+//splitPath - reverses escapePathSegment across a full path
+func splitPath(path string) []string {
+	var segments []string
+	var current strings.Builder
+	escaped := false
+	for _, r := range path {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case string(r) == csvPathSeparator:
+			segments = append(segments, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(segments, current.String())
+}
+
+func exportCSV(root *CategoryRoot) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	err := writer.Write(csvHeader)
+	if err != nil {
+		return nil, err
+	}
+	var writeRows func(prefix string, children []*Category) error
+	writeRows = func(prefix string, children []*Category) error {
+		for _, child := range children {
+			path := prefix + escapePathSegment(child.Title)
+			if err := writer.Write([]string{child.ID, path, child.Title}); err != nil {
+				return err
+			}
+			if err := writeRows(path+csvPathSeparator, child.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err = writeRows("", root.Children)
+	if err != nil {
+		return nil, err
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+//importCSV - rows may be in any order, missing ancestors in a path are created.  The id and title columns are optional, the title defaults to the last path segment
+func importCSV(data []byte) (*CategoryRoot, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read CSV header: %v", err)
+	}
+	columns := make(map[string]int)
+	for i := range header {
+		columns[strings.ToLower(strings.TrimSpace(header[i]))] = i
+	}
+	pathCol, ok := columns["path"]
+	if !ok {
+		return nil, fmt.Errorf("CSV header must contain a path column, received: %v", header)
+	}
+	column := func(record []string, name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	root := NewCategoryRoot("")
+	byPath := make(map[string]*Category)
+	//ensure - returns the category at the given path segments creating it (and any ancestors) if needed
+	var ensure func(segments []string) *Category
+	ensure = func(segments []string) *Category {
+		key := strings.Join(segments, "\x00")
+		if found, ok := byPath[key]; ok {
+			return found
+		}
+		var added *Category
+		if len(segments) == 1 {
+			added = root.AddChild(*NewCategory(segments[0]))
+		} else {
+			added = ensure(segments[:len(segments)-1]).AddChild(*NewCategory(segments[len(segments)-1]))
+		}
+		byPath[key] = added
+		return added
+	}
+
+	//idLines - the line each id was read from, ids must be unique as they identify the category in the model
+	idLines := make(map[string]int)
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read CSV line %v: %v", line, err)
+		}
+		if pathCol >= len(record) || strings.TrimSpace(record[pathCol]) == "" {
+			return nil, fmt.Errorf("CSV line %v is missing the path", line)
+		}
+		cat := ensure(splitPath(strings.TrimSpace(record[pathCol])))
+		if id := column(record, "id"); id != "" && id != cat.ID {
+			if idLines[id] != 0 {
+				return nil, fmt.Errorf("CSV line %v repeats the id %v from line %v", line, id, idLines[id])
+			}
+			idLines[id] = line
+			cat.ID = id
+		}
+		if title := column(record, "title"); title != "" {
+			cat.Title = title
+		}
+	}
+	return root, nil
+}
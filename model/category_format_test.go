@@ -0,0 +1,173 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func getFormatTestRoot() *CategoryRoot {
+	root := NewCategoryRoot("Formats")
+	root.AddChild(*NewCategory("Work"))
+	personal := root.AddChild(*NewCategory("Personal"))
+	personal.AddChild(*getDisconnectedCategorySet())
+	root.AddChild(*NewCategory("Read/Write \\ Misc"))
+	return root
+}
+
+func TestCategoryFormatRoundTrip(t *testing.T) {
+	root := getFormatTestRoot()
+
+	for _, format := range []CategoryFormat{FormatJSON, FormatOPML, FormatCSV} {
+		data, err := ExportCategoryRoot(root, format)
+		if err != nil {
+			t.Errorf("Export %v failed with: %v", format, err)
+			continue
+		}
+		imported, err := ImportCategoryRoot(data, format)
+		if err != nil {
+			t.Errorf("Import %v failed with: %v, data: %s", format, err, data)
+			continue
+		}
+		//root ids are not exported and csv has no name column, everything else should be identical
+		if format == FormatCSV {
+			imported.Name = root.Name
+		}
+		imported.ID = root.ID
+		if !imported.Equals(root) {
+			t.Errorf("Round trip for %v not equal, received: %v, data: %s", format, imported.GetAllChildren(), data)
+		}
+	}
+
+	//markdown does not keep ids so compare structure only
+	data, err := ExportCategoryRoot(root, FormatMarkdown)
+	if err != nil {
+		t.Errorf("Export markdown failed with: %v", err)
+	}
+	imported, err := ImportCategoryRoot(data, FormatMarkdown)
+	if err != nil {
+		t.Errorf("Import markdown failed with: %v", err)
+	}
+	if imported.Name != "Formats" {
+		t.Errorf("Expected markdown heading to set the name, received: %v", imported.Name)
+	}
+	original := root.GetAllChildren()
+	list := imported.GetAllChildren()
+	if len(list) != len(original) {
+		t.Fatalf("Expected %v categories from markdown, received: %v", len(original), len(list))
+	}
+	for i := range list {
+		if list[i].Title != original[i].Title || list[i].Level != original[i].Level {
+			t.Errorf("Markdown item %v expected: %v, received: %v", i, original[i], list[i])
+		}
+	}
+}
+
+func TestCategoryFormatImports(t *testing.T) {
+	markdown := "* Home\n\t* Kitchen\n\t\t* Pantry\n\t* Garage\n* Car\n"
+	root, err := ImportCategoryRoot([]byte(markdown), FormatMarkdown)
+	if err != nil {
+		t.Fatalf("Markdown import failed with: %v", err)
+	}
+	pantry, pantryParent := root.FindChildByName("Pantry")
+	if pantry.Level != 3 || pantryParent.Title != "Kitchen" {
+		t.Errorf("Expected Pantry at level 3 under Kitchen, received: %v under %v", pantry, pantryParent)
+	}
+	garage, garageParent := root.FindChildByName("Garage")
+	if garage.Level != 2 || garageParent.Title != "Home" {
+		t.Errorf("Expected Garage at level 2 under Home, received: %v under %v", garage, garageParent)
+	}
+
+	_, err = ImportCategoryRoot([]byte("- Home\nnot a bullet\n"), FormatMarkdown)
+	if err == nil {
+		t.Error("Expected error for a non bullet markdown line")
+	}
+
+	//rows out of order and missing ancestors are created
+	csvData := "path,id\nHome/Kitchen/Pantry,pantry-id\nHome,home-id\n"
+	root, err = ImportCategoryRoot([]byte(csvData), FormatCSV)
+	if err != nil {
+		t.Fatalf("CSV import failed with: %v", err)
+	}
+	pantry, pantryParent = root.FindChildByID("pantry-id")
+	if pantry.Level != 3 || pantryParent.Title != "Kitchen" {
+		t.Errorf("Expected Pantry at level 3 under Kitchen, received: %v under %v", pantry, pantryParent)
+	}
+	if root.GetChildByName("Home").ID != "home-id" {
+		t.Errorf("Expected later row to set the Home id, received: %v", root.GetChildByName("Home"))
+	}
+
+	_, err = ImportCategoryRoot([]byte("id,title\n1,Home\n"), FormatCSV)
+	if err == nil {
+		t.Error("Expected error for CSV without a path column")
+	}
+
+	_, err = ImportCategoryRoot([]byte("<opml><body><outline"), FormatOPML)
+	if err == nil {
+		t.Error("Expected error for invalid OPML")
+	}
+
+	//ids identify categories in the model, a repeated id is refused vs. importing two categories with it
+	opml := `<opml><body><outline text="Home" id="dup"><outline text="Kitchen" id="dup"/></outline></body></opml>`
+	_, err = ImportCategoryRoot([]byte(opml), FormatOPML)
+	if err == nil || !strings.Contains(err.Error(), "dup") {
+		t.Errorf("Expected error for a repeated OPML id, received: %v", err)
+	}
+	_, err = ImportCategoryRoot([]byte("path,id\nHome,dup\nWork,dup\n"), FormatCSV)
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected error for a repeated CSV id, received: %v", err)
+	}
+
+	format, ok := CategoryFormatFromMediaType("text/csv; charset=utf-8")
+	if !ok || format != FormatCSV {
+		t.Errorf("Expected csv format from media type, received: %v", format)
+	}
+	_, ok = CategoryFormatFromMediaType("image/png")
+	if ok {
+		t.Error("Expected image/png to be unsupported")
+	}
+}
+
+func TestCategoryRootMerge(t *testing.T) {
+	root := getFormatTestRoot()
+	work := root.GetChildByName("Work")
+	count := len(root.GetAllChildren())
+
+	imported, err := ImportCategoryRoot([]byte(strings.Join([]string{
+		"- Work",
+		"  - Projects",
+		"- Personal",
+		"  - Play",
+		"    - Books",
+		"- Travel",
+	}, "\n")), FormatMarkdown)
+	if err != nil {
+		t.Fatalf("Markdown import failed with: %v", err)
+	}
+	root.Merge(imported)
+
+	if len(root.GetAllChildren()) != count+3 {
+		t.Errorf("Expected 3 new categories after merge, received: %v", len(root.GetAllChildren())-count)
+	}
+	if root.GetChildByName("Work").ID != work.ID {
+		t.Error("Expected Work to be matched by title and keep its id")
+	}
+	books, booksParent := root.FindChildByName("Books")
+	if books.Level != 3 || booksParent.Title != "Play" {
+		t.Errorf("Expected Books at level 3 under Play, received: %v under %v", books, booksParent)
+	}
+	if root.GetChildByName("Travel").Level != 1 {
+		t.Error("Expected Travel to be added to the root")
+	}
+
+	//matching by id wins over title and keeps the current location
+	imported = NewCategoryRoot("")
+	moved := *books
+	moved.Children = nil
+	moved.AddChild(*NewCategory("Novels"))
+	imported.AddChild(moved)
+	root.Merge(imported)
+	novels, novelsParent := root.FindChildByName("Novels")
+	if novelsParent.ID != books.ID || novels.Level != 4 {
+		t.Errorf("Expected Novels at level 4 under Books, received: %v under %v", novels, novelsParent)
+	}
+}
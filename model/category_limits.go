@@ -0,0 +1,112 @@
+package model
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+//CategoryLimitName - the limit a tree exceeded
+type CategoryLimitName string
+
+const (
+	//LimitDepth - levels below the root, top level categories are level 1
+	LimitDepth CategoryLimitName = "depth"
+	//LimitChildren - children of one category, or top level categories of the root
+	LimitChildren CategoryLimitName = "children"
+	//LimitCategories - categories in the whole tree
+	LimitCategories CategoryLimitName = "categories"
+	//LimitTitleLength - characters in a title
+	LimitTitleLength CategoryLimitName = "titleLength"
+)
+
+//CategoryLimits - bounds on the size of a tree, 0 is unlimited
+type CategoryLimits struct {
+	MaxDepth       int `json:"maxDepth,omitempty"`
+	MaxChildren    int `json:"maxChildren,omitempty"`
+	MaxCategories  int `json:"maxCategories,omitempty"`
+	MaxTitleLength int `json:"maxTitleLength,omitempty"`
+}
+
+//CategoryLimitError - returned when a tree exceeds one of its limits.  CategoryID is the first category found over the limit, empty for the root
+type CategoryLimitError struct {
+	Limit      CategoryLimitName
+	Max        int
+	CategoryID string
+}
+
+func (err *CategoryLimitError) Error() string {
+	if err.CategoryID == "" {
+		return fmt.Sprintf("Category model exceeds the %v limit of %v", err.Limit, err.Max)
+	}
+	return fmt.Sprintf("Category model exceeds the %v limit of %v at category: %v", err.Limit, err.Max, err.CategoryID)
+}
+
+//Valid - false when a limit is negative
+func (limits CategoryLimits) Valid() bool {
+	return limits.MaxDepth >= 0 && limits.MaxChildren >= 0 && limits.MaxCategories >= 0 && limits.MaxTitleLength >= 0
+}
+
+//Within - the tighter of each limit, used to apply a model's own limits inside the deployment limits
+func (limits CategoryLimits) Within(outer CategoryLimits) CategoryLimits {
+	return CategoryLimits{
+		MaxDepth:       tighterLimit(limits.MaxDepth, outer.MaxDepth),
+		MaxChildren:    tighterLimit(limits.MaxChildren, outer.MaxChildren),
+		MaxCategories:  tighterLimit(limits.MaxCategories, outer.MaxCategories),
+		MaxTitleLength: tighterLimit(limits.MaxTitleLength, outer.MaxTitleLength),
+	}
+}
+
+func tighterLimit(a int, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+//CheckTitle - a *CategoryLimitError when the title is too long
+func (limits CategoryLimits) CheckTitle(categoryID string, title string) error {
+	if limits.MaxTitleLength > 0 && utf8.RuneCountInString(title) > limits.MaxTitleLength {
+		return &CategoryLimitError{Limit: LimitTitleLength, Max: limits.MaxTitleLength, CategoryID: categoryID}
+	}
+	return nil
+}
+
+//CheckLimits - the first limit the tree exceeds as a *CategoryLimitError, nil when within the limits.  The tree is walked without recursion
+//and stops at the first problem, so an over deep tree is safe to check
+func (root *CategoryRoot) CheckLimits(limits CategoryLimits) error {
+	if limits.MaxChildren > 0 && len(root.Children) > limits.MaxChildren {
+		return &CategoryLimitError{Limit: LimitChildren, Max: limits.MaxChildren}
+	}
+	type pending struct {
+		category *Category
+		depth    int
+	}
+	stack := make([]pending, 0, len(root.Children))
+	for i := len(root.Children) - 1; i >= 0; i-- {
+		stack = append(stack, pending{category: root.Children[i], depth: 1})
+	}
+	count := 0
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		category := next.category
+
+		count++
+		if limits.MaxCategories > 0 && count > limits.MaxCategories {
+			return &CategoryLimitError{Limit: LimitCategories, Max: limits.MaxCategories, CategoryID: category.ID}
+		}
+		if limits.MaxDepth > 0 && next.depth > limits.MaxDepth {
+			return &CategoryLimitError{Limit: LimitDepth, Max: limits.MaxDepth, CategoryID: category.ID}
+		}
+		if err := limits.CheckTitle(category.ID, category.Title); err != nil {
+			return err
+		}
+		if limits.MaxChildren > 0 && len(category.Children) > limits.MaxChildren {
+			return &CategoryLimitError{Limit: LimitChildren, Max: limits.MaxChildren, CategoryID: category.ID}
+		}
+		for i := len(category.Children) - 1; i >= 0; i-- {
+			stack = append(stack, pending{category: category.Children[i], depth: next.depth + 1})
+		}
+	}
+	return nil
+}
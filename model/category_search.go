@@ -0,0 +1,180 @@
+package model
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+//CategoryMatchType - how a category title matched a search, in rank order from exact to fuzzy
+type CategoryMatchType string
+
+const (
+	//MatchExact - the title equals the query
+	MatchExact CategoryMatchType = "exact"
+	//MatchPrefix - the title starts with the query
+	MatchPrefix CategoryMatchType = "prefix"
+	//MatchSubstring - the query is somewhere in the title
+	MatchSubstring CategoryMatchType = "substring"
+	//MatchFuzzy - the title or one of its words is within the edit distance of the query
+	MatchFuzzy CategoryMatchType = "fuzzy"
+)
+
+var matchRanks = map[CategoryMatchType]int{MatchExact: 0, MatchPrefix: 1, MatchSubstring: 2, MatchFuzzy: 3}
+
+//ParseCategoryMatchType - Returns the match type for the name, empty is MatchFuzzy.  ok is false for unknown names
+func ParseCategoryMatchType(name string) (CategoryMatchType, bool) {
+	if name == "" {
+		return MatchFuzzy, true
+	}
+	_, ok := matchRanks[CategoryMatchType(name)]
+	return CategoryMatchType(name), ok
+}
+
+//CategorySearchOptions - the zero value is a case-insensitive search allowing every match type with the default edit distance and no limit
+type CategorySearchOptions struct {
+	//Match - the loosest match returned, e.g. MatchPrefix returns exact and prefix matches.  Empty allows fuzzy matches
+	Match CategoryMatchType
+	//CaseSensitive - compare titles as is vs. ignoring case
+	CaseSensitive bool
+	//MaxDistance - the edit distance allowed for fuzzy matches.  0 uses 1 for queries of 4 to 7 characters, 2 for longer ones and none for shorter ones
+	MaxDistance int
+	//Limit - the number of results, 0 returns all
+	Limit int
+}
+
+//CategorySearchResult - one matching category.  Path is the titles from the top level category down to and including the match
+type CategorySearchResult struct {
+	Category *Category         `json:"category"`
+	ParentID string            `json:"parentID,omitempty"`
+	Path     []string          `json:"path"`
+	Match    CategoryMatchType `json:"match"`
+	//Distance - the edit distance of a fuzzy match, 0 otherwise
+	Distance int `json:"distance,omitempty"`
+}
+
+//RanksBefore - true when r is a better result than other: closer match type, then smaller edit distance, then nearer the top of the tree, then by title.
+//Used to merge results from several models
+func (r CategorySearchResult) RanksBefore(other CategorySearchResult) bool {
+	if matchRanks[r.Match] != matchRanks[other.Match] {
+		return matchRanks[r.Match] < matchRanks[other.Match]
+	}
+	if r.Distance != other.Distance {
+		return r.Distance < other.Distance
+	}
+	if len(r.Path) != len(other.Path) {
+		return len(r.Path) < len(other.Path)
+	}
+	return r.Category.Title < other.Category.Title
+}
+
+//Search - Returns the categories with titles matching the query, best matches first and ties in tree order.  An empty query matches nothing
+func (root *CategoryRoot) Search(query string, opts CategorySearchOptions) []CategorySearchResult {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+	loosest, ok := matchRanks[opts.Match]
+	if !ok {
+		loosest = matchRanks[MatchFuzzy]
+	}
+	maxDistance := opts.MaxDistance
+	if maxDistance <= 0 {
+		maxDistance = defaultSearchDistance(query)
+	}
+	if !opts.CaseSensitive {
+		query = strings.ToLower(query)
+	}
+
+	var results []CategorySearchResult
+	var walk func(children []*Category, parentID string, path []string)
+	walk = func(children []*Category, parentID string, path []string) {
+		for _, child := range children {
+			childPath := append(append([]string{}, path...), child.Title)
+			title := child.Title
+			if !opts.CaseSensitive {
+				title = strings.ToLower(title)
+			}
+			match, distance, found := matchTitle(title, query, maxDistance)
+			if found && matchRanks[match] <= loosest {
+				results = append(results, CategorySearchResult{Category: child, ParentID: parentID, Path: childPath, Match: match, Distance: distance})
+			}
+			walk(child.Children, child.ID, childPath)
+		}
+	}
+	walk(root.Children, "", nil)
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].RanksBefore(results[j])
+	})
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results
+}
+
+//defaultSearchDistance - short queries would fuzzy match almost anything
+func defaultSearchDistance(query string) int {
+	length := utf8.RuneCountInString(query)
+	switch {
+	case length < 4:
+		return 0
+	case length < 8:
+		return 1
+	}
+	return 2
+}
+
+//matchTitle - the closest way the title matches the query
+func matchTitle(title string, query string, maxDistance int) (CategoryMatchType, int, bool) {
+	switch {
+	case title == query:
+		return MatchExact, 0, true
+	case strings.HasPrefix(title, query):
+		return MatchPrefix, 0, true
+	case strings.Contains(title, query):
+		return MatchSubstring, 0, true
+	}
+	if maxDistance == 0 {
+		return "", 0, false
+	}
+	best := editDistance(title, query)
+	for _, word := range strings.Fields(title) {
+		if distance := editDistance(word, query); distance < best {
+			best = distance
+		}
+	}
+	if best > maxDistance {
+		return "", 0, false
+	}
+	return MatchFuzzy, best, true
+}
+
+//editDistance - Levenshtein distance in runes
+func editDistance(a string, b string) int {
+	first, second := []rune(a), []rune(b)
+	previous := make([]int, len(second)+1)
+	current := make([]int, len(second)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(first); i++ {
+		current[0] = i
+		for j := 1; j <= len(second); j++ {
+			cost := 1
+			if first[i-1] == second[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous, current = current, previous
+	}
+	return previous[len(second)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//CategoryTemplate - A named starter tree used to seed new category models
+type CategoryTemplate struct {
+	Name        string                  `json:"name" yaml:"name"`
+	Title       string                  `json:"title" yaml:"title"`
+	Description string                  `json:"description" yaml:"description"`
+	Categories  []*CategoryTemplateItem `json:"categories" yaml:"categories"`
+}
+
+//CategoryTemplateItem - One category of a template, titles only as ids are generated for each new model
+type CategoryTemplateItem struct {
+	Title      string                  `json:"title" yaml:"title"`
+	Categories []*CategoryTemplateItem `json:"categories,omitempty" yaml:"categories,omitempty"`
+}
+
+//UnmarshalYAML - An item is a "- Title" scalar, a "- Title:" mapping to its child items or a "- title: Title" mapping with optional categories
+func (item *CategoryTemplateItem) UnmarshalYAML(node *yaml.Node) error {
+	switch {
+	case node.Kind == yaml.ScalarNode:
+		return node.Decode(&item.Title)
+	case node.Kind != yaml.MappingNode:
+		return fmt.Errorf("line %v: expected a category title or mapping", node.Line)
+	case len(node.Content) == 2 && node.Content[0].Value != "title" && node.Content[0].Value != "categories":
+		item.Title = node.Content[0].Value
+		return node.Content[1].Decode(&item.Categories)
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		switch key.Value {
+		case "title":
+			if err := node.Content[i+1].Decode(&item.Title); err != nil {
+				return err
+			}
+		case "categories":
+			if err := node.Content[i+1].Decode(&item.Categories); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("line %v: unknown category item key: %v", key.Line, key.Value)
+		}
+	}
+	if item.Title == "" {
+		return fmt.Errorf("line %v: category item title is required", node.Line)
+	}
+	return nil
+}
+
+//NewCategoryRoot - Constructs a new Category Root from the template with fresh category ids
+func (tmpl *CategoryTemplate) NewCategoryRoot() *CategoryRoot {
+	root := NewCategoryRoot(tmpl.Title)
+	addTemplateItems(root.AddChild, tmpl.Categories)
+	return root
+}
+
+func addTemplateItems(add func(Category) *Category, items []*CategoryTemplateItem) {
+	for i := range items {
+		added := add(*NewCategory(items[i].Title))
+		addTemplateItems(added.AddChild, items[i].Categories)
+	}
+}
+
+//go:embed templates
+var templateFiles embed.FS
+
+var templateRegistry = struct {
+	sync.RWMutex
+	templates map[string]*CategoryTemplate
+}{templates: make(map[string]*CategoryTemplate)}
+
+//Templates shipped with the binary, an invalid file is a build problem so fail fast
+func init() {
+	entries, err := templateFiles.ReadDir("templates")
+	if err != nil {
+		panic(fmt.Errorf("Unable to read embedded category templates: %v", err))
+	}
+	for i := range entries {
+		filename := path.Join("templates", entries[i].Name())
+		data, err := templateFiles.ReadFile(filename)
+		if err != nil {
+			panic(fmt.Errorf("Unable to read embedded category template %v: %v", filename, err))
+		}
+		tmpl, err := ParseCategoryTemplate(filename, data)
+		if err != nil {
+			panic(err)
+		}
+		err = RegisterCategoryTemplate(tmpl)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+//templateKey - names are matched ignoring case with spaces, dashes and underscores treated the same, e.g. "Household Budget" == "household_budget"
+func templateKey(name string) string {
+	name = strings.NewReplacer("_", " ", "-", " ").Replace(strings.ToLower(name))
+	return strings.Join(strings.Fields(name), " ")
+}
+
+//RegisterCategoryTemplate - Adds the template to the registry, replacing any template with the same name
+func RegisterCategoryTemplate(tmpl *CategoryTemplate) error {
+	key := templateKey(tmpl.Name)
+	if key == "" {
+		return fmt.Errorf("Category template name is required, template title: %v", tmpl.Title)
+	}
+	templateRegistry.Lock()
+	defer templateRegistry.Unlock()
+	templateRegistry.templates[key] = tmpl
+	return nil
+}
+
+//GetCategoryTemplate - Returns the registered template, false if not found
+func GetCategoryTemplate(name string) (*CategoryTemplate, bool) {
+	templateRegistry.RLock()
+	defer templateRegistry.RUnlock()
+	tmpl, ok := templateRegistry.templates[templateKey(name)]
+	return tmpl, ok
+}
+
+//CategoryTemplates - Returns all registered templates sorted by name
+func CategoryTemplates() []*CategoryTemplate {
+	templateRegistry.RLock()
+	defer templateRegistry.RUnlock()
+	var list []*CategoryTemplate
+	for _, tmpl := range templateRegistry.templates {
+		list = append(list, tmpl)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+//ParseCategoryTemplate - Parses a template file, the format is based on the extension (.json, .yaml or .yml)
+func ParseCategoryTemplate(filename string, data []byte) (*CategoryTemplate, error) {
+	tmpl := &CategoryTemplate{}
+	var err error
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".json":
+		err = json.Unmarshal(data, tmpl)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(tmpl)
+	default:
+		err = fmt.Errorf("unsupported extension")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse category template %v: %v", filename, err)
+	}
+	if tmpl.Name == "" {
+		return nil, fmt.Errorf("Category template %v is missing a name", filename)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestEmbeddedCategoryTemplates(t *testing.T) {
+	for _, name := range []string{"life", "Household Budget", "household-budget", "student"} {
+		if _, ok := GetCategoryTemplate(name); !ok {
+			t.Errorf("Expected embedded template: %v", name)
+		}
+	}
+	if len(CategoryTemplates()) < 3 {
+		t.Errorf("Expected at least 3 templates, received: %v", CategoryTemplates())
+	}
+
+	life, _ := GetCategoryTemplate("life")
+	root := life.NewCategoryRoot()
+	if root.Name != "Life Categories" || len(root.Children) != 2 {
+		t.Errorf("Unexpected life template root: %v, children: %v", root.Name, root.Children)
+	}
+	//ids are generated per root
+	if life.NewCategoryRoot().Children[0].ID == root.Children[0].ID {
+		t.Error("Expected fresh ids for each root created from a template")
+	}
+
+	budget, _ := GetCategoryTemplate("household budget")
+	root = budget.NewCategoryRoot()
+	utilities, utilitiesParent := root.FindChildByName("Utilities")
+	if utilities.Level != 2 || utilitiesParent.Title != "Housing" {
+		t.Errorf("Expected Utilities at level 2 under Housing, received: %v under %v", utilities, utilitiesParent)
+	}
+}
+
+func TestParseCategoryTemplateYAML(t *testing.T) {
+	data := []byte(`
+# comment
+name: "quoted: name"
+title: 'It''s mine'
+categories:
+  - One:
+    - Two:
+        - Three
+    - Four
+  - Five
+  - title: Six
+    categories: [Seven, {Eight: [Nine]}]
+  - &ten Ten
+  - *ten
+  - "Eleven
+    continued"
+`)
+	tmpl, err := ParseCategoryTemplate("test.yml", data)
+	if err != nil {
+		t.Fatalf("Parse failed with: %v", err)
+	}
+	if tmpl.Name != "quoted: name" || tmpl.Title != "It's mine" {
+		t.Errorf("Unexpected scalars, name: %v, title: %v", tmpl.Name, tmpl.Title)
+	}
+	root := tmpl.NewCategoryRoot()
+	three, threeParent := root.FindChildByName("Three")
+	if three.Level != 3 || threeParent.Title != "Two" {
+		t.Errorf("Expected Three at level 3 under Two, received: %v under %v", three, threeParent)
+	}
+	four, fourParent := root.FindChildByName("Four")
+	if four.Level != 2 || fourParent.Title != "One" {
+		t.Errorf("Expected Four at level 2 under One, received: %v under %v", four, fourParent)
+	}
+	nine, nineParent := root.FindChildByName("Nine")
+	if nine == nil || nine.Level != 3 || nineParent.Title != "Eight" {
+		t.Errorf("Expected Nine at level 3 under Eight, received: %v under %v", nine, nineParent)
+	}
+	if len(root.Children) != 6 || root.Children[4].Title != "Ten" || root.Children[5].Title != "Eleven continued" {
+		t.Errorf("Expected alias and multi-line scalars, received: %v", root.Children)
+	}
+
+	invalid := []string{
+		"title: missing name\n",
+		"name: x\nunknown: y\n",
+		"name: x\ncategories:\n  - title: Leaf\n    color: red\n",
+		"name: x\ncategories:\n  - categories: [Child]\n",
+		"name: x\ncategories: Outside\n",
+		"name: x\ncategories:\n\t- Tab\n",
+	}
+	for i := range invalid {
+		_, err = ParseCategoryTemplate("invalid.yaml", []byte(invalid[i]))
+		if err == nil {
+			t.Errorf("Expected error for invalid template %v: %q", i, invalid[i])
+		}
+	}
+	_, err = ParseCategoryTemplate("template.txt", data)
+	if err == nil {
+		t.Error("Expected error for unsupported extension")
+	}
+}
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"strconv"
+	"strings"
 	"testing"
 
 	_ "github.com/suared/core/infra"
@@ -87,7 +89,7 @@ func TestBaseCategorySetup(t *testing.T) {
 	}
 
 	outdentTestIDSave := beetlejuice.ID
-	// Note: this is just the same as above at a deeper nesting level
+	//Note: this is just the same as above at a deeper nesting level
 	beetlejuice.AddChild(*getDisconnectedCategorySet())
 	play = beetlejuice.GetChildByName("Play")
 	if play.Level != 5 && play.Title != "Play" {
@@ -230,6 +232,89 @@ func TestBaseCategorySetup(t *testing.T) {
 
 }
 
+func TestCategoryClone(t *testing.T) {
+	play := getDisconnectedCategorySet()
+
+	same := play.Clone(false)
+	if !same.Equals(play) {
+		t.Errorf("Expected clone to equal the original, received: %v", same.GetAllChildren())
+	}
+	//changes to the clone must not reach the original
+	same.GetChildByName("Videos").Title = "Movies"
+	same.GetChildByName("Movies").AddChild(*NewCategory("Heat"))
+	if play.GetChildByName("Videos").ID == "" || len(play.GetChildByName("Videos").Children) != 2 {
+		t.Errorf("Clone shares children with the original: %v", play.GetAllChildren())
+	}
+
+	fresh := play.Clone(true)
+	originalList := append([]*Category{play}, play.GetAllChildren()...)
+	freshList := append([]*Category{fresh}, fresh.GetAllChildren()...)
+	for i := range freshList {
+		if freshList[i].ID == originalList[i].ID || freshList[i].Title != originalList[i].Title || freshList[i].Level != originalList[i].Level {
+			t.Errorf("Expected new id with same title and level, original: %v, clone: %v", originalList[i], freshList[i])
+		}
+	}
+
+	//root AddChild resets levels of the whole subtree and does not share children
+	root := NewCategoryRoot("Clone")
+	videos := play.GetChildByName("Videos")
+	added := root.AddChild(*videos)
+	if added.Children[0].Level != 2 {
+		t.Errorf("Expected level 2 children under a root category, received: %v", added.Children[0])
+	}
+	added.Children[0].Title = "Changed"
+	if videos.Children[0].Title == "Changed" {
+		t.Error("Root AddChild shares children with the added category")
+	}
+
+	rootClone := root.Clone(true)
+	if rootClone.ID == root.ID || rootClone.Children[0].ID == root.Children[0].ID || rootClone.Name != root.Name {
+		t.Errorf("Unexpected root clone: %v", rootClone)
+	}
+}
+
+func TestDiffCategoryRoots(t *testing.T) {
+	old := NewCategoryRoot("Testing")
+	personal := old.AddChild(Category{ID: "personal", Title: "Personal"})
+	personal.AddChild(Category{ID: "health", Title: "Health"})
+	work := old.AddChild(Category{ID: "work", Title: "Work"})
+	work.AddChild(Category{ID: "meetings", Title: "Meetings"}).AddChild(Category{ID: "standup", Title: "Standup"})
+
+	new := old.Clone(false)
+	health, _ := new.FindChildByID("health")
+	health.Title = "Fitness"
+	newWork, _ := new.FindChildByID("work")
+	new.Move("health", newWork)
+	new.RemoveChildByID("personal")
+	newWork.RemoveChildByID("meetings")
+	new.AddChild(Category{ID: "side", Title: "Side Projects"}).AddChild(Category{ID: "app", Title: "App"})
+
+	diffs := DiffCategoryRoots(old, new)
+	expected := []CategoryDiff{
+		{Type: CategoryDeleted, CategoryID: "personal", Title: "Personal"},
+		{Type: CategoryDeleted, CategoryID: "meetings", OldParentID: "work", Title: "Meetings"},
+		{Type: CategoryMoved, CategoryID: "health", ParentID: "work", OldParentID: "personal", Title: "Fitness"},
+		{Type: CategoryRenamed, CategoryID: "health", ParentID: "work", Title: "Fitness", OldTitle: "Health"},
+		{Type: CategoryAdded, CategoryID: "side", Title: "Side Projects"},
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("Expected %v diffs, received: %+v", len(expected), diffs)
+	}
+	for i := range expected {
+		if diffs[i] != expected[i] {
+			t.Errorf("Diff %v expected: %+v, received: %+v", i, expected[i], diffs[i])
+		}
+	}
+
+	if diffs = DiffCategoryRoots(old, old); len(diffs) != 0 {
+		t.Errorf("Expected no diffs for the same tree, received: %+v", diffs)
+	}
+	//a created model is all top level adds
+	if diffs = DiffCategoryRoots(nil, old); len(diffs) != 2 || diffs[0].Type != CategoryAdded {
+		t.Errorf("Expected 2 adds for a new model, received: %+v", diffs)
+	}
+}
+
 func TestCategorySliceRemover(t *testing.T) {
 	testSlice := removeCategorySliceIndex(getCategoryArray(), 0)
 	if testSlice[0].Title != "test2" {
@@ -280,3 +365,147 @@ func getDisconnectedCategorySet() *Category {
 	playCat.AddChild(*NewCategory("Music"))
 	return playCat
 }
+
+func TestCategorySearch(t *testing.T) {
+	root := NewCategoryRoot("Testing")
+	sports := root.AddChild(Category{ID: "sports", Title: "Sports"})
+	sports.AddChild(Category{ID: "team", Title: "Team Sports"})
+	sports.AddChild(Category{ID: "soccer", Title: "Soccer"})
+	root.AddChild(Category{ID: "work", Title: "Work"}).AddChild(Category{ID: "sporting", Title: "Sport Events"})
+
+	results := root.Search("sports", CategorySearchOptions{})
+	expected := []struct {
+		id    string
+		match CategoryMatchType
+	}{{"sports", MatchExact}, {"team", MatchSubstring}, {"sporting", MatchFuzzy}}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %v results, received: %+v", len(expected), results)
+	}
+	for i := range expected {
+		if results[i].Category.ID != expected[i].id || results[i].Match != expected[i].match {
+			t.Errorf("Result %v expected: %+v, received: %v %v", i, expected[i], results[i].Category.ID, results[i].Match)
+		}
+	}
+	if strings.Join(results[1].Path, "/") != "Sports/Team Sports" || results[1].ParentID != "sports" {
+		t.Errorf("Expected the path to the match, received: %v, %v", results[1].Path, results[1].ParentID)
+	}
+
+	//typos match fuzzily, prefix only and case sensitive searches narrow the results
+	if results = root.Search("Socer", CategorySearchOptions{}); len(results) != 1 || results[0].Distance != 1 {
+		t.Errorf("Expected a fuzzy match for a typo, received: %+v", results)
+	}
+	if results = root.Search("SPORT", CategorySearchOptions{Match: MatchPrefix}); len(results) != 2 || results[0].Match != MatchPrefix {
+		t.Errorf("Expected only the prefix matches, received: %+v", results)
+	}
+	if results = root.Search("SPORT", CategorySearchOptions{CaseSensitive: true}); len(results) != 0 {
+		t.Errorf("Expected no case sensitive matches, received: %+v", results)
+	}
+	if results = root.Search("sport", CategorySearchOptions{Limit: 1}); len(results) != 1 || results[0].Category.ID != "sports" {
+		t.Errorf("Expected the best result only, received: %+v", results)
+	}
+	if results = root.Search(" ", CategorySearchOptions{}); len(results) != 0 {
+		t.Errorf("Expected no results for an empty query")
+	}
+	if _, ok := ParseCategoryMatchType("regex"); ok {
+		t.Errorf("Expected an unknown match type")
+	}
+}
+
+func TestCategoryTitlePolicy(t *testing.T) {
+	root := NewCategoryRoot("Testing")
+	work := root.AddChild(Category{ID: "work", Title: "Work"})
+	work.AddChild(Category{ID: "cafe", Title: "Caf\u00e9"})
+
+	allow := CategoryTitlePolicy{}
+	if title, err := allow.ResolveTitle(root.Children, "", "Work", "new"); err != nil || title != "Work" {
+		t.Errorf("Expected duplicates to be allowed by default, received: %v, %v", title, err)
+	}
+
+	reject, err := ParseCategoryTitlePolicy("reject,ignoreCase,normalize")
+	if err != nil || reject.String() != "reject,ignoreCase,normalize" {
+		t.Fatalf("Expected the policy to parse, received: %+v, %v", reject, err)
+	}
+	_, err = reject.ResolveTitle(root.Children, "", " work", "new")
+	duplicate, ok := err.(*DuplicateTitleError)
+	if !ok || duplicate.ExistingID != "work" {
+		t.Errorf("Expected a duplicate title error, received: %v", err)
+	}
+	//a decomposed accent is the same title once normalized
+	if _, err = reject.ResolveTitle(work.Children, "work", "Cafe\u0301", "new"); err == nil {
+		t.Errorf("Expected the normalized title to be a duplicate")
+	}
+	if _, err = (CategoryTitlePolicy{Uniqueness: TitlesReject}).ResolveTitle(work.Children, "work", "Cafe\u0301", "new"); err != nil {
+		t.Errorf("Expected different titles without normalizing, received: %v", err)
+	}
+	if title, err := reject.ResolveTitle(root.Children, "", "Work", "work"); err != nil || title != "Work" {
+		t.Errorf("Expected the category itself to be ignored, received: %v, %v", title, err)
+	}
+
+	suffix := CategoryTitlePolicy{Uniqueness: TitlesSuffix}
+	root.AddChild(Category{ID: "work2", Title: "Work (2)"})
+	if title, _ := suffix.ResolveTitle(root.Children, "", "Work", "new"); title != "Work (3)" {
+		t.Errorf("Expected the first free suffix, received: %v", title)
+	}
+
+	//imports keep the first title and suffix or reject the rest
+	imported := NewCategoryRoot("Imported")
+	imported.AddChild(Category{ID: "a", Title: "Home"})
+	imported.AddChild(Category{ID: "b", Title: "Home"}).AddChild(Category{ID: "c", Title: "Garden"})
+	imported.Children[1].AddChild(Category{ID: "d", Title: "Garden"})
+	if err = imported.Clone(false).ApplyTitlePolicy(reject); err == nil {
+		t.Errorf("Expected the import to be rejected")
+	}
+	if err = imported.ApplyTitlePolicy(suffix); err != nil || imported.Children[1].Title != "Home (2)" || imported.Children[1].Children[1].Title != "Garden (2)" {
+		t.Errorf("Expected suffixed duplicates, received: %v, %v", imported.GetAllChildren(), err)
+	}
+
+	if _, err = ParseCategoryTitlePolicy("unique"); err != ErrInvalidTitlePolicy {
+		t.Errorf("Expected an invalid policy, received: %v", err)
+	}
+}
+
+func TestCategoryLimits(t *testing.T) {
+	root := NewCategoryRoot("Testing")
+	work := root.AddChild(Category{ID: "work", Title: "Work"})
+	work.AddChild(Category{ID: "meetings", Title: "Meetings"})
+	work.AddChild(Category{ID: "email", Title: "Email"})
+
+	if err := root.CheckLimits(CategoryLimits{}); err != nil {
+		t.Errorf("Expected no limits to pass, received: %v", err)
+	}
+	if err := root.CheckLimits(CategoryLimits{MaxDepth: 2, MaxChildren: 2, MaxCategories: 3, MaxTitleLength: 8}); err != nil {
+		t.Errorf("Expected the tree to be within its limits, received: %v", err)
+	}
+	checks := []struct {
+		limits     CategoryLimits
+		limit      CategoryLimitName
+		categoryID string
+	}{
+		{CategoryLimits{MaxDepth: 1}, LimitDepth, "meetings"},
+		{CategoryLimits{MaxChildren: 1}, LimitChildren, "work"},
+		{CategoryLimits{MaxCategories: 2}, LimitCategories, "email"},
+		{CategoryLimits{MaxTitleLength: 7}, LimitTitleLength, "meetings"},
+	}
+	for _, check := range checks {
+		err, ok := root.CheckLimits(check.limits).(*CategoryLimitError)
+		if !ok || err.Limit != check.limit || err.CategoryID != check.categoryID {
+			t.Errorf("Expected the %v limit at %v, received: %v", check.limit, check.categoryID, err)
+		}
+	}
+
+	//a chain far deeper than the limit is refused without walking it all
+	deep := NewCategoryRoot("Deep")
+	deep.Children = []*Category{{ID: "0", Title: "0"}}
+	for i, last := 1, deep.Children[0]; i < 10000; i++ {
+		last.Children = []*Category{{ID: strconv.Itoa(i), Title: strconv.Itoa(i)}}
+		last = last.Children[0]
+	}
+	if err, ok := deep.CheckLimits(CategoryLimits{MaxDepth: 32}).(*CategoryLimitError); !ok || err.CategoryID != "32" {
+		t.Errorf("Expected the deep chain to be refused at depth 33, received: %v", err)
+	}
+
+	within := CategoryLimits{MaxDepth: 5, MaxChildren: 600}.Within(CategoryLimits{MaxDepth: 32, MaxChildren: 500, MaxCategories: 100})
+	if within != (CategoryLimits{MaxDepth: 5, MaxChildren: 500, MaxCategories: 100}) {
+		t.Errorf("Expected the tighter of each limit, received: %+v", within)
+	}
+}
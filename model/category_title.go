@@ -0,0 +1,181 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+//TitleUniqueness - what happens when a category title is already used by a sibling
+type TitleUniqueness string
+
+const (
+	//TitlesAllowDuplicates - siblings may share a title, the zero value so existing models are unchanged
+	TitlesAllowDuplicates TitleUniqueness = "allow"
+	//TitlesReject - a duplicate title is refused with a *DuplicateTitleError
+	TitlesReject TitleUniqueness = "reject"
+	//TitlesSuffix - a duplicate title is made unique with a " (2)", " (3)"... suffix
+	TitlesSuffix TitleUniqueness = "suffix"
+)
+
+//ErrInvalidTitlePolicy - the policy names an unknown uniqueness or option
+var ErrInvalidTitlePolicy = errors.New("title policy must be allow, reject or suffix optionally followed by ,ignoreCase and ,normalize")
+
+//CategoryTitlePolicy - how sibling titles are compared and what happens to duplicates.  The zero value allows duplicates
+type CategoryTitlePolicy struct {
+	Uniqueness TitleUniqueness `json:"uniqueness,omitempty"`
+	//IgnoreCase - "Work" and "work" are the same title
+	IgnoreCase bool `json:"ignoreCase,omitempty"`
+	//Normalize - compare the Unicode NFC form so composed and decomposed accents are the same title
+	Normalize bool `json:"normalize,omitempty"`
+}
+
+//DuplicateTitleError - returned when the reject policy refuses a title, ParentID is empty for the top level
+type DuplicateTitleError struct {
+	Title      string
+	ParentID   string
+	ExistingID string
+}
+
+func (err *DuplicateTitleError) Error() string {
+	return fmt.Sprintf("Category title %q is already used by sibling: %v", err.Title, err.ExistingID)
+}
+
+//ParseCategoryTitlePolicy - parses "<uniqueness>[,ignoreCase][,normalize]", e.g. "reject,ignoreCase".  Empty allows duplicates
+func ParseCategoryTitlePolicy(value string) (CategoryTitlePolicy, error) {
+	policy := CategoryTitlePolicy{}
+	if strings.TrimSpace(value) == "" {
+		return policy, nil
+	}
+	for i, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case i == 0:
+			policy.Uniqueness = TitleUniqueness(part)
+			if !policy.Valid() {
+				return CategoryTitlePolicy{}, ErrInvalidTitlePolicy
+			}
+		case strings.EqualFold(part, "ignoreCase"):
+			policy.IgnoreCase = true
+		case strings.EqualFold(part, "normalize"):
+			policy.Normalize = true
+		default:
+			return CategoryTitlePolicy{}, ErrInvalidTitlePolicy
+		}
+	}
+	return policy, nil
+}
+
+//Valid - false for an unknown uniqueness
+func (policy CategoryTitlePolicy) Valid() bool {
+	switch policy.Uniqueness {
+	case "", TitlesAllowDuplicates, TitlesReject, TitlesSuffix:
+		return true
+	}
+	return false
+}
+
+//String - the ParseCategoryTitlePolicy form
+func (policy CategoryTitlePolicy) String() string {
+	value := string(policy.Uniqueness)
+	if value == "" {
+		value = string(TitlesAllowDuplicates)
+	}
+	if policy.IgnoreCase {
+		value += ",ignoreCase"
+	}
+	if policy.Normalize {
+		value += ",normalize"
+	}
+	return value
+}
+
+//enforced - false when duplicates are allowed
+func (policy CategoryTitlePolicy) enforced() bool {
+	return policy.Uniqueness == TitlesReject || policy.Uniqueness == TitlesSuffix
+}
+
+//TitleKey - the form titles are compared in
+func (policy CategoryTitlePolicy) TitleKey(title string) string {
+	key := strings.TrimSpace(title)
+	if policy.Normalize {
+		key = norm.NFC.String(key)
+	}
+	if policy.IgnoreCase {
+		key = strings.ToLower(key)
+	}
+	return key
+}
+
+//ResolveTitle - Returns the title to use for a category joining the siblings, the category itself (excludeID) is not compared against.
+//The reject policy returns a *DuplicateTitleError, the suffix policy returns the first free "title (n)"
+func (policy CategoryTitlePolicy) ResolveTitle(siblings []*Category, parentID string, title string, excludeID string) (string, error) {
+	if !policy.enforced() {
+		return title, nil
+	}
+	used := make(map[string]string, len(siblings))
+	for _, sibling := range siblings {
+		if sibling.ID != excludeID {
+			used[policy.TitleKey(sibling.Title)] = sibling.ID
+		}
+	}
+	existingID, found := used[policy.TitleKey(title)]
+	if !found {
+		return title, nil
+	}
+	if policy.Uniqueness == TitlesReject {
+		return "", &DuplicateTitleError{Title: title, ParentID: parentID, ExistingID: existingID}
+	}
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%v (%v)", title, n)
+		if _, found = used[policy.TitleKey(candidate)]; !found {
+			return candidate, nil
+		}
+	}
+}
+
+//ApplyTitlePolicy - checks every sibling group in the tree, used for imports and whole model changes.  Earlier siblings keep their titles,
+//later duplicates are suffixed or the first one is returned as a *DuplicateTitleError
+func (root *CategoryRoot) ApplyTitlePolicy(policy CategoryTitlePolicy) error {
+	if !policy.enforced() {
+		return nil
+	}
+	return applyTitlePolicy(policy, root.Children, "")
+}
+
+//ApplyTitlePolicy - checks the sibling groups below the category, see CategoryRoot ApplyTitlePolicy.  The category's own title is resolved by the caller with ResolveTitle
+func (cat *Category) ApplyTitlePolicy(policy CategoryTitlePolicy) error {
+	if !policy.enforced() {
+		return nil
+	}
+	return applyTitlePolicy(policy, cat.Children, cat.ID)
+}
+
+func applyTitlePolicy(policy CategoryTitlePolicy, children []*Category, parentID string) error {
+	for i, child := range children {
+		title, err := policy.ResolveTitle(children[:i], parentID, child.Title, child.ID)
+		if err != nil {
+			return err
+		}
+		child.Title = title
+		err = applyTitlePolicy(policy, child.Children, child.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//SiblingsOf - the children of the parent id, the top level categories for an empty id.  ok is false when the parent is not in the tree
+func (root *CategoryRoot) SiblingsOf(parentID string) ([]*Category, bool) {
+	if parentID == "" {
+		return root.Children, true
+	}
+	parent, _ := root.FindChildByID(parentID)
+	if parent.ID == "" {
+		return nil, false
+	}
+	return parent.Children, true
+}
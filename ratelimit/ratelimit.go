@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+/*
+Token bucket rate limiting.  Each key (e.g. a user id) has a bucket holding up to Burst tokens that refills at Rate tokens per second,
+a request takes one token or is refused with the time until the next token is available.
+
+Bucket state is kept in a Store so it can be shared between processes, MemoryStore keeps it in this process
+*/
+
+//StoreMemory - the name of the in-memory store, also used for an empty name
+const StoreMemory = "memory"
+
+//Bucket - the refill rate per second and the most tokens a bucket holds
+type Bucket struct {
+	Rate  float64
+	Burst int
+}
+
+//State - one key's bucket, Tokens as of Updated
+type State struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+//Take - refills the state to now and takes a token.  Returns the new state and 0 when a token was taken,
+//otherwise the unchanged tokens and the wait until one is available
+func (bucket Bucket) Take(state State, now time.Time) (State, time.Duration) {
+	tokens := float64(bucket.Burst)
+	if !state.Updated.IsZero() {
+		elapsed := now.Sub(state.Updated).Seconds()
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		tokens = math.Min(float64(bucket.Burst), state.Tokens+elapsed*bucket.Rate)
+	}
+	if tokens >= 1 {
+		return State{Tokens: tokens - 1, Updated: now}, 0
+	}
+	wait := time.Duration(math.Ceil((1 - tokens) / bucket.Rate * float64(time.Second)))
+	return State{Tokens: tokens, Updated: now}, wait
+}
+
+//full - true once the bucket would have refilled, the state can be forgotten
+func (bucket Bucket) full(state State, now time.Time) bool {
+	return state.Tokens+now.Sub(state.Updated).Seconds()*bucket.Rate >= float64(bucket.Burst)
+}
+
+//Store - keeps the bucket state per key.  Take must read, update and save the key's state atomically
+type Store interface {
+	Take(ctx context.Context, key string, bucket Bucket, now time.Time) (time.Duration, error)
+}
+
+//MemoryStore - bucket state in this process, full buckets are dropped periodically so idle keys do not accumulate
+type MemoryStore struct {
+	mutex     sync.Mutex
+	states    map[string]State
+	takes     int
+	sweepSize int
+}
+
+//NewMemoryStore - an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]State), sweepSize: 1000}
+}
+
+//Take - see Store
+func (store *MemoryStore) Take(ctx context.Context, key string, bucket Bucket, now time.Time) (time.Duration, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	state, wait := bucket.Take(store.states[key], now)
+	store.states[key] = state
+	store.takes++
+	if store.takes >= store.sweepSize {
+		store.takes = 0
+		for stored, state := range store.states {
+			if bucket.full(state, now) {
+				delete(store.states, stored)
+			}
+		}
+	}
+	return wait, nil
+}
+
+//NewStore - the named store, only StoreMemory is built in.  Other stores are set on the Limiter directly
+func NewStore(name string) (Store, error) {
+	if name == "" || name == StoreMemory {
+		return NewMemoryStore(), nil
+	}
+	return nil, fmt.Errorf("Unknown rate limit store: %v", name)
+}
+
+//Limiter - takes tokens from a bucket per key
+type Limiter struct {
+	Store  Store
+	Bucket Bucket
+	//Now - the clock, replaced in tests
+	Now func() time.Time
+}
+
+//NewLimiter - a limiter allowing rate requests per second per key with bursts of up to burst requests
+func NewLimiter(store Store, rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{Store: store, Bucket: Bucket{Rate: rate, Burst: burst}, Now: time.Now}
+}
+
+//Allow - 0 when the key's request may go ahead, otherwise how long to wait before retrying
+func (limiter *Limiter) Allow(ctx context.Context, key string) (time.Duration, error) {
+	return limiter.Store.Take(ctx, key, limiter.Bucket, limiter.Now())
+}
+
+//RetryAfter - the Retry-After header value for the wait, whole seconds rounded up
+func RetryAfter(wait time.Duration) string {
+	return fmt.Sprintf("%d", int64(math.Ceil(wait.Seconds())))
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBucketTake(t *testing.T) {
+	bucket := Bucket{Rate: 2, Burst: 3}
+	now := time.Now()
+	state := State{}
+	var wait time.Duration
+	for i := 0; i < 3; i++ {
+		state, wait = bucket.Take(state, now)
+		if wait != 0 {
+			t.Fatalf("Expected the burst to be allowed, refused request %v", i+1)
+		}
+	}
+	state, wait = bucket.Take(state, now)
+	if wait != 500*time.Millisecond {
+		t.Errorf("Expected to wait for the next token, received: %v", wait)
+	}
+	//refills at the rate
+	if _, wait = bucket.Take(state, now.Add(500*time.Millisecond)); wait != 0 {
+		t.Errorf("Expected a token after the wait, received: %v", wait)
+	}
+	if RetryAfter(1200*time.Millisecond) != "2" {
+		t.Errorf("Expected Retry-After in whole seconds rounded up")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := NewLimiter(NewMemoryStore(), 1, 2)
+	limiter.Now = func() time.Time { return now }
+	ctx := context.TODO()
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if wait, _ := limiter.Allow(ctx, "user1"); wait == 0 {
+				mutex.Lock()
+				allowed++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 2 {
+		t.Errorf("Expected only the burst to be allowed, received: %v", allowed)
+	}
+	//keys have their own buckets
+	if wait, _ := limiter.Allow(ctx, "user2"); wait != 0 {
+		t.Errorf("Expected another user to be allowed, received: %v", wait)
+	}
+
+	if _, err := NewStore("redis"); err == nil {
+		t.Errorf("Expected an unknown store")
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store := NewMemoryStore()
+	store.sweepSize = 2
+	bucket := Bucket{Rate: 1, Burst: 1}
+	now := time.Now()
+	store.Take(context.TODO(), "idle", bucket, now)
+	store.Take(context.TODO(), "busy", bucket, now.Add(time.Minute))
+	if _, ok := store.states["idle"]; ok || len(store.states) != 1 {
+		t.Errorf("Expected the refilled bucket to be dropped, received: %v", store.states)
+	}
+}
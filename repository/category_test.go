@@ -2,12 +2,21 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
 
 	"github.com/suared/core-apiuser/model"
 
 	_ "github.com/suared/core/infra"
+	"github.com/suared/core/repository"
 	"github.com/suared/core/security"
+	"github.com/suared/core/uuid"
 )
 
 //CRUD is only real test here with 1 model move as there are no pointers that are re-used yet...
@@ -21,6 +30,13 @@ func TestSimpleCategoryDynamoLifeCycle(t *testing.T) {
 		t.Errorf("Repo initialization failed with: %v", err)
 	}
 
+	//saved progress from an earlier run, inserts refuse existing models
+	for _, id := range []string{"A", "B", "C"} {
+		saved := CategoryUserModel{}
+		saved.ID = id
+		repository.Delete(ctx, saved)
+	}
+
 	root := NewCategoryUserModel("Testing")
 	personalCat := model.NewCategory("Personal")
 	root.AddChild(*personalCat)
@@ -58,6 +74,7 @@ func TestSimpleCategoryDynamoLifeCycle(t *testing.T) {
 
 	personalCat = root.GetChildByName("Personal")
 	personalCat.AddChild(*getDisconnectedCategorySet())
+	root.Version = dbroot.Version
 	err = repository.Update(ctx, *root)
 	if err != nil {
 		t.Errorf("error during update: %v", err)
@@ -141,6 +158,123 @@ func TestSimpleCategoryDynamoLifeCycle(t *testing.T) {
 
 }
 
+//Two repositories, e.g. two Lambda containers, change a model read at the same version one after the other.  The second write is refused
+//instead of losing the first change
+func TestCategoryConflictingWrites(t *testing.T) {
+	ctx := security.SetupTestAuthFromContext(context.TODO(), 1)
+	first, err := NewCategoryRepository()
+	if err != nil {
+		t.Fatalf("Repo initialization failed with: %v", err)
+	}
+	second, err := NewCategoryRepository()
+	if err != nil {
+		t.Fatalf("Repo initialization failed with: %v", err)
+	}
+
+	root := NewCategoryUserModel("Conflict")
+	err = first.Insert(ctx, *root)
+	if err != nil {
+		t.Fatalf("Unexpected insert error: %v", err)
+	}
+	defer first.Delete(ctx, *root)
+	if err = second.Insert(ctx, *root); !errors.Is(err, ErrCategoryConflict) {
+		t.Errorf("Expected inserting an existing model to conflict, received: %v", err)
+	}
+
+	firstModel, err := first.SelectForUpdate(ctx, *root)
+	if err != nil || firstModel.Version != 1 {
+		t.Fatalf("Expected version 1, received: %v, %v", firstModel.Version, err)
+	}
+	secondModel, err := second.SelectForUpdate(ctx, *root)
+	if err != nil {
+		t.Fatalf("Unexpected select error: %v", err)
+	}
+
+	firstModel.AddChild(*model.NewCategory("First"))
+	err = first.Update(ctx, firstModel)
+	if err != nil {
+		t.Fatalf("Unexpected update error: %v", err)
+	}
+	secondModel.AddChild(*model.NewCategory("Second"))
+	if err = second.Update(ctx, secondModel); !errors.Is(err, ErrCategoryConflict) {
+		t.Fatalf("Expected the second write to conflict, received: %v", err)
+	}
+
+	//retried from a fresh read, both changes are kept
+	secondModel, err = second.SelectForUpdate(ctx, *root)
+	if err != nil || secondModel.Version != 2 {
+		t.Fatalf("Expected version 2, received: %v, %v", secondModel.Version, err)
+	}
+	secondModel.AddChild(*model.NewCategory("Second"))
+	err = second.Update(ctx, secondModel)
+	if err != nil {
+		t.Fatalf("Unexpected retry error: %v", err)
+	}
+	stored, err := first.SelectForUpdate(ctx, *root)
+	if err != nil || stored.Version != 3 || len(stored.Children) != 2 {
+		t.Errorf("Expected both children at version 3, received: %v, %v, %v", stored.Version, stored.Children, err)
+	}
+}
+
+//An operator change made with other credentials, e.g. categoryctl, is seen through the cache and is left in the outbox to be relayed
+func TestCategoryOperatorWriteVisible(t *testing.T) {
+	ctx := security.SetupTestAuthFromContext(context.TODO(), 1)
+	apiRepo, err := NewCategoryRepository()
+	if err != nil {
+		t.Fatalf("Repo initialization failed with: %v", err)
+	}
+	toolRepo, err := NewCategoryRepository()
+	if err != nil {
+		t.Fatalf("Repo initialization failed with: %v", err)
+	}
+	toolRepo.SetOutbox(true)
+	cached := &CachedCategoryRepository{CategoryRepository: apiRepo, scope: CacheScopeProcess, size: 10, ttl: time.Minute, cache: NewCategoryCache(10, time.Minute)}
+
+	root := NewCategoryUserModel("Operator")
+	err = cached.Insert(ctx, *root)
+	if err != nil {
+		t.Fatalf("Unexpected insert error: %v", err)
+	}
+	defer cached.Delete(ctx, *root)
+	cachedModel, err := cached.SelectOne(ctx, *root)
+	if err != nil || cachedModel.Version != 1 {
+		t.Fatalf("Expected version 1 to be cached, received: %v, %v", cachedModel.Version, err)
+	}
+
+	operator := NewCategoryOperator(toolRepo, "operator1")
+	operator.KeepVersions = false
+	userID := security.GetAuth(ctx).GetUser()
+	dao, err := operator.SelectModel(ctx, userID, root.ID)
+	if err != nil {
+		t.Fatalf("Unexpected operator read error: %v", err)
+	}
+	changed := dao.CategoryUserModel
+	changed.AddChild(*model.NewCategory("Fixed"))
+	err = operator.Save(ctx, userID, changed, "test fix")
+	if err != nil {
+		t.Fatalf("Unexpected operator save error: %v", err)
+	}
+
+	cachedModel, err = cached.SelectOne(ctx, *root)
+	if err != nil || cachedModel.Version != 2 || len(cachedModel.Children) != 1 {
+		t.Errorf("Expected the operator change through the cache, received: %v, %v, %v", cachedModel.Version, cachedModel.Children, err)
+	}
+	events, err := toolRepo.SelectOutbox(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected outbox error: %v", err)
+	}
+	found := false
+	for _, event := range events {
+		if event.ModelID == root.ID && event.ActorID == "operator1" && event.Type == model.CategoryModelReplaced {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the operator change in the outbox, received: %v", events)
+	}
+	toolRepo.DeleteOutbox(ctx, events)
+}
+
 //Leveraging this start from model test
 func getDisconnectedCategorySet() *model.Category {
 	/*
@@ -160,3 +294,149 @@ func getDisconnectedCategorySet() *model.Category {
 	playCat.AddChild(*model.NewCategory("Music"))
 	return playCat
 }
+
+func TestCategoryIdempotencyLease(t *testing.T) {
+	ctx := security.SetupTestAuthFromContext(context.TODO(), 1)
+	repo, err := NewCategoryRepository()
+	if err != nil {
+		t.Fatalf("Repo initialization failed with: %v", err)
+	}
+	repo.idempotencyLease = time.Second
+	key := "lease-" + uuid.NewUUID()
+	defer repo.DeleteIdempotencyKey(ctx, key)
+
+	first, err := repo.ReserveIdempotencyKey(ctx, key, "hash-1")
+	if err != nil || first.Completed() {
+		t.Fatalf("Expected a new key to be reserved, received: %+v, %v", first, err)
+	}
+	if _, err = repo.ReserveIdempotencyKey(ctx, key, "hash-1"); !errors.Is(err, ErrIdempotencyInProgress) {
+		t.Errorf("Expected the key to be in progress during the lease, received: %v", err)
+	}
+
+	//the first request stopped without finishing, only a retry of it takes the key over and only once
+	time.Sleep(1100 * time.Millisecond)
+	if _, err = repo.ReserveIdempotencyKey(ctx, key, "hash-2"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Errorf("Expected another request to be refused, received: %v", err)
+	}
+	retry, err := repo.ReserveIdempotencyKey(ctx, key, "hash-1")
+	if err != nil || retry.Completed() {
+		t.Fatalf("Expected the expired lease to be taken over, received: %+v, %v", retry, err)
+	}
+	if _, err = repo.ReserveIdempotencyKey(ctx, key, "hash-1"); !errors.Is(err, ErrIdempotencyInProgress) {
+		t.Errorf("Expected the taken over key to be in progress, received: %v", err)
+	}
+
+	//the first request finishing late does not overwrite the retry's response
+	if err = repo.SaveIdempotencyResult(ctx, first, 500, nil, nil); !errors.Is(err, ErrIdempotencyLeaseLost) {
+		t.Errorf("Expected the first request to have lost its lease, received: %v", err)
+	}
+
+	//a completed key is replayed after the lease
+	err = repo.SaveIdempotencyResult(ctx, retry, 201, nil, []byte("created"))
+	if err != nil {
+		t.Fatalf("Save failed with: %v", err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if record, err := repo.ReserveIdempotencyKey(ctx, key, "hash-1"); err != nil || record == nil || record.Status != 201 {
+		t.Errorf("Expected the completed response, received: %+v, %v", record, err)
+	}
+}
+
+func TestCategorySelectReadsOnlyModels(t *testing.T) {
+	t.Setenv("PROCESS_CATEGORY_MODEL_INDEX", "true")
+	ctx := security.SetupTestAuthFromContext(context.TODO(), 1)
+	repo, err := NewCategoryRepository()
+	if err != nil {
+		t.Fatalf("Repo initialization failed with: %v", err)
+	}
+	if !repo.modelIndex {
+		t.Fatalf("Expected the model index to be created for the development table")
+	}
+
+	//a partition holding far more of the other item types than models
+	for i := 0; i < 150; i++ {
+		key := "filler-" + uuid.NewUUID()
+		if _, err = repo.ReserveIdempotencyKey(ctx, key, "hash"); err != nil {
+			t.Fatalf("Reserve failed with: %v", err)
+		}
+		defer repo.DeleteIdempotencyKey(ctx, key)
+	}
+	root := NewCategoryUserModel("Indexed")
+	if err = repo.Insert(ctx, *root); err != nil {
+		t.Fatalf("Insert failed with: %v", err)
+	}
+	defer repo.Delete(ctx, *root)
+
+	var scanned int64
+	repo.client.Handlers.Complete.PushBack(func(r *request.Request) {
+		if output, ok := r.Data.(*awsDynamoDB.QueryOutput); ok {
+			scanned += aws.Int64Value(output.ScannedCount)
+		}
+	})
+	models, err := repo.Select(ctx, CategoryUserModel{})
+	found := false
+	for i := range models {
+		found = found || models[i].ID == root.ID
+	}
+	if err != nil || !found || scanned != int64(len(models)) {
+		t.Errorf("Expected only the %v models to be read, found: %v, read: %v, err: %v", len(models), found, scanned, err)
+	}
+
+	scanned = 0
+	page, err := repo.SelectPage(ctx, CategoryUserModel{}, 1, "")
+	if err != nil || len(page.Models) != 1 || scanned != 1 {
+		t.Errorf("Expected a page of one model from one item read, received: %v models, read: %v, err: %v", len(page.Models), scanned, err)
+	}
+}
+
+func TestCategoryLimitsConfig(t *testing.T) {
+	configMap := repository.NewBasicConfig("categoryDatabase")
+	limits, err := limitsFromConfig(configMap)
+	if err != nil || limits != DefaultCategoryLimits {
+		t.Errorf("Expected the default limits, received: %+v, %v", limits, err)
+	}
+	configMap.AddEntry("maxDepth", "0")
+	configMap.AddEntry("maxTitleLength", "80")
+	limits, err = limitsFromConfig(configMap)
+	if err != nil || limits.MaxDepth != 0 || limits.MaxTitleLength != 80 || limits.MaxChildren != DefaultCategoryLimits.MaxChildren {
+		t.Errorf("Expected the configured limits, received: %+v, %v", limits, err)
+	}
+	configMap.AddEntry("maxChildren", "-1")
+	if _, err = limitsFromConfig(configMap); err == nil {
+		t.Errorf("Expected a negative limit to be refused")
+	}
+}
+
+func TestCategoryQuota(t *testing.T) {
+	configMap := repository.NewBasicConfig("categoryDatabase")
+	quota, err := quotaFromConfig(configMap)
+	if err != nil || quota.enabled() {
+		t.Errorf("Expected no quota by default, received: %+v, %v", quota, err)
+	}
+	configMap.AddEntry("quotaModels", "2")
+	configMap.AddEntry("quotaBytes", "1000")
+	quota, err = quotaFromConfig(configMap)
+	if err != nil || quota != (CategoryQuota{MaxModels: 2, MaxBytes: 1000}) {
+		t.Fatalf("Expected the configured quota, received: %+v, %v", quota, err)
+	}
+
+	usage := CategoryUsage{ModelCount: 2, StorageBytes: 900}
+	if err = quota.check(usage, 1, 10); !errors.Is(err, ErrQuotaExceeded) || !strings.Contains(err.Error(), "models") {
+		t.Errorf("Expected the models quota, received: %v", err)
+	}
+	if err = quota.check(usage, 0, 101); !errors.Is(err, ErrQuotaExceeded) || !strings.Contains(err.Error(), "storage") {
+		t.Errorf("Expected the storage quota, received: %v", err)
+	}
+	if err = quota.check(usage, 0, 100); err != nil {
+		t.Errorf("Expected a change up to the quota to be allowed, received: %v", err)
+	}
+	//shrinking is allowed when already over, e.g. after the quota was lowered
+	if err = quota.check(CategoryUsage{ModelCount: 5, StorageBytes: 5000}, -1, -500); err != nil {
+		t.Errorf("Expected a removal to be allowed over the quota, received: %v", err)
+	}
+
+	configMap.AddEntry("quotaBytes", "lots")
+	if _, err = quotaFromConfig(configMap); err == nil {
+		t.Errorf("Expected an invalid quota to be refused")
+	}
+}
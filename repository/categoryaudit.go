@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core/repository/dynamodb"
+)
+
+//auditSortKeyPrefix - namespace for audit records, audit#<modelID>#<time>#<eventID> so a model's records can be queried by time range
+const auditSortKeyPrefix = "audit" + categoryKeySeparator
+
+//auditTimeFormat - fixed width UTC so sort keys order by time
+const auditTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+//auditSortKeyEnd - sorts after any time in the audit sort key
+const auditSortKeyEnd = "~"
+
+//DefaultAuditRetention - how long audit records are kept when PROCESS_CATEGORY_AUDIT_RETENTION is not set
+const DefaultAuditRetention = 90 * 24 * time.Hour
+
+//CategoryAuditDAO - One category change kept for the audit log, the record is the event written with the change
+type CategoryAuditDAO struct {
+	CategoryHashKey string
+	CategorySortKey string
+	UserID          string
+
+	Event model.CategoryEvent
+	//ExpiresAt - epoch seconds, the table TTL attribute removes the record after the retention period.  0 keeps it
+	ExpiresAt int64 `json:",omitempty"`
+}
+
+//HashKey - This is the value that would be set as the dynamo hashkey
+func (dao *CategoryAuditDAO) HashKey() string {
+	return dao.CategoryHashKey
+}
+
+//SortKey - This is the value that would be set as the dynamo sortKey
+func (dao *CategoryAuditDAO) SortKey() string {
+	return dao.CategorySortKey
+}
+
+//User - the owner of the model
+func (dao *CategoryAuditDAO) User() string {
+	return dao.UserID
+}
+
+//New - creates a new instance of this specific type to support return values of the right type
+func (dao *CategoryAuditDAO) New() dynamodb.DAO {
+	return new(CategoryAuditDAO)
+}
+
+//Refresh - updates the Hashkey and SortKey.  Used by the library before calls
+func (dao *CategoryAuditDAO) Refresh() {
+	dao.CategoryHashKey = "category_" + dao.UserID
+	dao.CategorySortKey = auditSortKey(dao.Event.ModelID, dao.Event.OccurredAt) + categoryKeySeparator + dao.Event.ID
+}
+
+//Populate - nothing to calculate, the event is stored as is
+func (dao *CategoryAuditDAO) Populate() {
+}
+
+//auditSortKey - the model's audit sort key up to the time, used as the bounds of a time range
+func auditSortKey(modelID string, at time.Time) string {
+	return auditSortKeyPrefix + modelID + categoryKeySeparator + at.UTC().Format(auditTimeFormat)
+}
+
+//expired - true once the retention period has passed, the TTL can take a day or two to remove the item
+func (dao *CategoryAuditDAO) expired(now time.Time) bool {
+	return dao.ExpiresAt > 0 && dao.ExpiresAt <= now.Unix()
+}
+
+//CategoryAuditPage - one page of audit records, newest first.  Cursor is empty on the last page
+type CategoryAuditPage struct {
+	Records []model.CategoryEvent
+	Cursor  string
+}
+
+//auditItems - transaction puts for the audit records of the events, added to the model write
+func (repo *CategoryRepository) auditItems(userID string, events []model.CategoryEvent) ([]*awsDynamoDB.TransactWriteItem, error) {
+	var items []*awsDynamoDB.TransactWriteItem
+	for i := range events {
+		auditDAO := &CategoryAuditDAO{UserID: userID, Event: events[i]}
+		if repo.auditRetention > 0 {
+			auditDAO.ExpiresAt = events[i].OccurredAt.Add(repo.auditRetention).Unix()
+		}
+		auditDAO.Refresh()
+		put, err := dynamodbattribute.MarshalMap(auditDAO)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &awsDynamoDB.TransactWriteItem{Put: &awsDynamoDB.Put{TableName: repo.tableName(), Item: put}})
+	}
+	return items, nil
+}
+
+//eventItems - the outbox and audit items stored with a model change, as configured
+func (repo *CategoryRepository) eventItems(dao *CategoryDAO, events []model.CategoryEvent) ([]*awsDynamoDB.TransactWriteItem, error) {
+	var items []*awsDynamoDB.TransactWriteItem
+	if repo.outboxEnabled {
+		outbox, err := repo.outboxItems(dao.UserID, events)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, outbox...)
+	}
+	if dao.audit {
+		audit, err := repo.auditItems(dao.UserID, events)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, audit...)
+	}
+	return items, nil
+}
+
+//SelectAudit - Returns up to limit audit records of the model between from and to, newest first.  Zero times leave the range open.
+//Owners and editors can read the audit log
+func (repo *CategoryRepository) SelectAudit(ctx context.Context, modelID string, from time.Time, to time.Time, limit int, cursor string) (CategoryAuditPage, error) {
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+	template := &CategoryAuditDAO{UserID: CategoryOwner(ctx), Event: model.CategoryEvent{ModelID: modelID}}
+	template.Refresh()
+	err := repo.authorize(ctx, "selectAudit", template, modelID, RoleEditor)
+	if err != nil {
+		return CategoryAuditPage{}, err
+	}
+
+	prefix := auditSortKeyPrefix + modelID + categoryKeySeparator
+	lower, upper := prefix, prefix+auditSortKeyEnd
+	if !from.IsZero() {
+		lower = auditSortKey(modelID, from)
+	}
+	if !to.IsZero() {
+		upper = auditSortKey(modelID, to) + auditSortKeyEnd
+	}
+	startKey, err := repo.decodeCursor(cursor, template.HashKey())
+	if err != nil {
+		return CategoryAuditPage{}, err
+	}
+	//a cursor from another model's audit log is outside the key condition
+	if startKey != nil && !strings.HasPrefix(aws.StringValue(startKey[repo.config.Values()["sortKeyName"]].S), prefix) {
+		return CategoryAuditPage{}, ErrInvalidCursor
+	}
+
+	page := CategoryAuditPage{}
+	now := time.Now()
+	consumed := 0
+	for {
+		result, err := repo.client.QueryWithContext(ctx, &awsDynamoDB.QueryInput{
+			TableName:              repo.tableName(),
+			KeyConditionExpression: aws.String("#hashKey = :hashKey AND #sortKey BETWEEN :lower AND :upper"),
+			ExpressionAttributeNames: map[string]*string{
+				"#hashKey": aws.String(repo.config.Values()["hashKeyName"]),
+				"#sortKey": aws.String(repo.config.Values()["sortKeyName"]),
+			},
+			ExpressionAttributeValues: map[string]*awsDynamoDB.AttributeValue{
+				":hashKey": {S: aws.String(template.HashKey())},
+				":lower":   {S: aws.String(lower)},
+				":upper":   {S: aws.String(upper)},
+			},
+			ScanIndexForward:  aws.Bool(false),
+			ExclusiveStartKey: startKey,
+			Limit:             aws.Int64(int64(limit - consumed)),
+		})
+		if err != nil {
+			return CategoryAuditPage{}, fmt.Errorf("Category audit query failed with: %v", err)
+		}
+		for i := range result.Items {
+			consumed++
+			auditDAO := &CategoryAuditDAO{}
+			err = dynamodbattribute.UnmarshalMap(result.Items[i], auditDAO)
+			if err != nil {
+				return CategoryAuditPage{}, err
+			}
+			if auditDAO.expired(now) {
+				continue
+			}
+			page.Records = append(page.Records, auditDAO.Event)
+		}
+		startKey = result.LastEvaluatedKey
+		if len(startKey) == 0 || consumed >= limit {
+			break
+		}
+	}
+	page.Cursor = repo.encodeCursor(startKey)
+	return page, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core/repository"
+)
+
+func TestCategoryAuditKeys(t *testing.T) {
+	earlier := time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC)
+	later := earlier.Add(time.Second)
+	if !(auditSortKey("model1", earlier) < auditSortKey("model1", later)) {
+		t.Errorf("Expected audit sort keys in time order")
+	}
+	//the time is stored in UTC so zones do not change the order
+	if auditSortKey("model1", later.In(time.FixedZone("east", 5*3600))) != auditSortKey("model1", later) {
+		t.Errorf("Expected the same sort key in any zone")
+	}
+
+	audit := &CategoryAuditDAO{UserID: "testuser1", Event: model.CategoryEvent{ID: "event1", ModelID: "model1", OccurredAt: earlier}}
+	audit.Refresh()
+	if audit.HashKey() != "category_testuser1" || audit.SortKey() != "audit#model1#2021-03-04T05:06:07.000000008Z#event1" || IsModelSortKey(audit.SortKey()) {
+		t.Errorf("Expected the audit namespace, received: %v", audit.SortKey())
+	}
+
+	now := time.Now()
+	if audit.expired(now) {
+		t.Errorf("Expected records without an expiry to be kept")
+	}
+	audit.ExpiresAt = now.Add(-time.Minute).Unix()
+	if !audit.expired(now) {
+		t.Errorf("Expected the record to be expired")
+	}
+}
+
+func TestCategoryAuditItems(t *testing.T) {
+	configMap := repository.NewBasicConfig("categoryDatabase")
+	configMap.AddEntry("table", "category")
+	repo := &CategoryRepository{config: configMap, auditRetention: time.Hour}
+	occurred := time.Now().UTC()
+	events := []model.CategoryEvent{{ID: "event1", ModelID: "model1", OccurredAt: occurred}}
+
+	items, err := repo.auditItems("testuser1", events)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Expected an audit item per event, received: %v, %v", items, err)
+	}
+	expiresAt := aws.StringValue(items[0].Put.Item["ExpiresAt"].N)
+	if expiresAt != strconv.FormatInt(occurred.Add(time.Hour).Unix(), 10) {
+		t.Errorf("Expected ExpiresAt to be set, received: %v", items[0].Put.Item)
+	}
+
+	repo.auditRetention = 0
+	items, _ = repo.auditItems("testuser1", events)
+	if _, ok := items[0].Put.Item["ExpiresAt"]; ok {
+		t.Errorf("Expected no ExpiresAt without a retention period")
+	}
+
+	dao := &CategoryDAO{UserID: "testuser1"}
+	if items, _ = repo.eventItems(dao, events); len(items) != 0 {
+		t.Errorf("Expected no items with the outbox and audit off, received: %v", len(items))
+	}
+	dao.audit = true
+	repo.outboxEnabled = true
+	if items, _ = repo.eventItems(dao, events); len(items) != 2 {
+		t.Errorf("Expected an outbox and an audit item, received: %v", len(items))
+	}
+}
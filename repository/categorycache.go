@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core/security"
+)
+
+//DefaultCacheTTL - used when PROCESS_CATEGORY_CACHE_TTL is not set
+const DefaultCacheTTL = 30 * time.Second
+
+//Cache scopes, set with PROCESS_CATEGORY_CACHE_SCOPE
+const (
+	//CacheScopeProcess - one cache shared by all requests, for the long running web api
+	CacheScopeProcess = "process"
+	//CacheScopeInvocation - a cache per request from CachedCategoryRepository.WithInvocationCache, for Lambda where other containers write to the same models
+	CacheScopeInvocation = "invocation"
+)
+
+//CategoryStore - the repository calls used by the service, implemented by CategoryRepository and CachedCategoryRepository
+type CategoryStore interface {
+	Insert(ctx context.Context, userModel CategoryUserModel, events ...model.CategoryEvent) error
+	Update(ctx context.Context, userModel CategoryUserModel, events ...model.CategoryEvent) error
+	Delete(ctx context.Context, template CategoryUserModel, events ...model.CategoryEvent) error
+	SelectOne(ctx context.Context, template CategoryUserModel) (CategoryUserModel, error)
+	SelectForUpdate(ctx context.Context, template CategoryUserModel) (CategoryUserModel, error)
+	SelectPage(ctx context.Context, template CategoryUserModel, limit int, cursor string) (CategoryPage, error)
+	SelectOutbox(ctx context.Context) ([]model.CategoryEvent, error)
+	DeleteOutbox(ctx context.Context, events []model.CategoryEvent) error
+	InsertInvite(ctx context.Context, modelID string, role CategoryRole, ttl time.Duration) (*CategoryInviteDAO, error)
+	AcceptInvite(ctx context.Context, token string) (*CategorySharedDAO, error)
+	SelectGrants(ctx context.Context, modelID string) ([]*CategoryGrantDAO, error)
+	SelectShared(ctx context.Context) ([]*CategorySharedDAO, error)
+	DeleteGrant(ctx context.Context, modelID string, granteeID string) error
+	InsertWebhook(ctx context.Context, webhook *CategoryWebhookDAO) error
+	SelectWebhooks(ctx context.Context, modelID string) ([]*CategoryWebhookDAO, error)
+	DeleteWebhook(ctx context.Context, modelID string, webhookID string) error
+	SaveDelivery(ctx context.Context, delivery *CategoryDeliveryDAO) error
+	InsertDelivery(ctx context.Context, delivery *CategoryDeliveryDAO) error
+	SelectDeliveries(ctx context.Context, modelID string, limit int) ([]*CategoryDeliveryDAO, error)
+	SelectAudit(ctx context.Context, modelID string, from time.Time, to time.Time, limit int, cursor string) (CategoryAuditPage, error)
+	SelectUsage(ctx context.Context) (CategoryUsage, error)
+	ReserveIdempotencyKey(ctx context.Context, key string, requestHash string) (*CategoryIdempotencyDAO, error)
+	SaveIdempotencyResult(ctx context.Context, reservation *CategoryIdempotencyDAO, status int, header map[string]string, body []byte) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+}
+
+//CategoryCacheStats - counters since the cache was created
+type CategoryCacheStats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Evictions     int64 `json:"evictions"`
+	Invalidations int64 `json:"invalidations"`
+	Entries       int   `json:"entries"`
+}
+
+type categoryCacheEntry struct {
+	key     string
+	model   CategoryUserModel
+	expires time.Time
+}
+
+//CategoryCache - LRU of decoded models keyed by user and model id, safe for concurrent use.  Entries are only used while the stored
+//model version matches, see CachedCategoryRepository SelectOne, the TTL bounds how long an unused model is kept
+type CategoryCache struct {
+	mutex    sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	lru      *list.List
+	//generation - bumped on every invalidation so a read that started before a write does not cache the old model
+	generation uint64
+	stats      CategoryCacheStats
+}
+
+//NewCategoryCache - capacity is the max number of models kept
+func NewCategoryCache(capacity int, ttl time.Duration) *CategoryCache {
+	return &CategoryCache{capacity: capacity, ttl: ttl, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+func categoryCacheKey(userID string, modelID string) string {
+	return userID + "/" + modelID
+}
+
+//get - returns a copy of the cached model so callers can change it freely
+func (cache *CategoryCache) get(key string) (CategoryUserModel, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	element, ok := cache.entries[key]
+	if ok && time.Now().After(element.Value.(*categoryCacheEntry).expires) {
+		cache.removeElement(element)
+		ok = false
+	}
+	if !ok {
+		cache.stats.Misses++
+		return CategoryUserModel{}, false
+	}
+	cache.stats.Hits++
+	cache.lru.MoveToFront(element)
+	return copyUserModel(element.Value.(*categoryCacheEntry).model), true
+}
+
+//copyUserModel - a deep copy so callers changing the tree do not change the cached model, settings such as the title policy are kept
+func copyUserModel(userModel CategoryUserModel) CategoryUserModel {
+	copied := userModel
+	copied.CategoryRoot = *userModel.Clone(false)
+	return copied
+}
+
+//currentGeneration - read before loading a model, pass to put
+func (cache *CategoryCache) currentGeneration() uint64 {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.generation
+}
+
+//put - stores a copy of the model unless an invalidation happened since generation was read
+func (cache *CategoryCache) put(key string, userModel CategoryUserModel, generation uint64) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if generation != cache.generation {
+		return
+	}
+	entry := &categoryCacheEntry{key: key, model: copyUserModel(userModel), expires: time.Now().Add(cache.ttl)}
+	if element, ok := cache.entries[key]; ok {
+		element.Value = entry
+		cache.lru.MoveToFront(element)
+		return
+	}
+	cache.entries[key] = cache.lru.PushFront(entry)
+	for cache.lru.Len() > cache.capacity {
+		cache.removeElement(cache.lru.Back())
+		cache.stats.Evictions++
+	}
+}
+
+//invalidate - removes the key, called on every write through this process
+func (cache *CategoryCache) invalidate(key string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.generation++
+	cache.stats.Invalidations++
+	if element, ok := cache.entries[key]; ok {
+		cache.removeElement(element)
+	}
+}
+
+func (cache *CategoryCache) removeElement(element *list.Element) {
+	cache.lru.Remove(element)
+	delete(cache.entries, element.Value.(*categoryCacheEntry).key)
+}
+
+//Stats - returns a snapshot of the counters
+func (cache *CategoryCache) Stats() CategoryCacheStats {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	stats := cache.stats
+	stats.Entries = cache.lru.Len()
+	return stats
+}
+
+type invocationCacheKey struct{}
+
+//CachedCategoryRepository - read through cache in front of CategoryRepository.  Calls other than SelectOne and the model writes go straight
+//to the repository, including SelectForUpdate so a change always starts from the stored model
+type CachedCategoryRepository struct {
+	*CategoryRepository
+	scope string
+	size  int
+	ttl   time.Duration
+	//cache - the process cache, nil in the invocation scope
+	cache *CategoryCache
+}
+
+//WithInvocationCache - in the invocation scope, attaches a cache that lives as long as the request context.  Returns ctx unchanged otherwise
+func (repo *CachedCategoryRepository) WithInvocationCache(ctx context.Context) context.Context {
+	if repo.scope != CacheScopeInvocation || repo.size == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, invocationCacheKey{}, NewCategoryCache(repo.size, repo.ttl))
+}
+
+//cacheFor - the cache for this request, nil if there is none
+func (repo *CachedCategoryRepository) cacheFor(ctx context.Context) *CategoryCache {
+	if repo.scope == CacheScopeInvocation {
+		cache, _ := ctx.Value(invocationCacheKey{}).(*CategoryCache)
+		return cache
+	}
+	return repo.cache
+}
+
+//SelectOne - returns the cached model while it is at the stored version, otherwise reads from the repository and caches found models.
+//Checking the version catches writes made by other processes and the operator tools, the cache saves reading and decoding the model data
+func (repo *CachedCategoryRepository) SelectOne(ctx context.Context, template CategoryUserModel) (CategoryUserModel, error) {
+	cache := repo.cacheFor(ctx)
+	//shared models are read through so a removed grant takes effect right away
+	if cache == nil || CategoryOwner(ctx) != security.GetAuth(ctx).GetUser() {
+		return repo.CategoryRepository.SelectOne(ctx, template)
+	}
+	userID := security.GetAuth(ctx).GetUser()
+	key := categoryCacheKey(userID, template.ID)
+	if userModel, ok := cache.get(key); ok {
+		version, found, err := repo.storedVersion(ctx, userID, template.ID)
+		if err != nil {
+			return CategoryUserModel{}, err
+		}
+		if found && version == userModel.Version {
+			return userModel, nil
+		}
+		cache.invalidate(key)
+	}
+	generation := cache.currentGeneration()
+	userModel, err := repo.CategoryRepository.SelectOne(ctx, template)
+	//not found is not cached so a model created by another process is seen right away
+	if err == nil && userModel.ID != "" {
+		cache.put(key, userModel, generation)
+	}
+	return userModel, err
+}
+
+//Insert - invalidates the model even if the write fails as the stored state is then unknown
+func (repo *CachedCategoryRepository) Insert(ctx context.Context, userModel CategoryUserModel, events ...model.CategoryEvent) error {
+	defer repo.invalidate(ctx, userModel.ID)
+	return repo.CategoryRepository.Insert(ctx, userModel, events...)
+}
+
+//Update - invalidates the model even if the write fails as the stored state is then unknown
+func (repo *CachedCategoryRepository) Update(ctx context.Context, userModel CategoryUserModel, events ...model.CategoryEvent) error {
+	defer repo.invalidate(ctx, userModel.ID)
+	return repo.CategoryRepository.Update(ctx, userModel, events...)
+}
+
+//Delete - invalidates the model even if the delete fails
+func (repo *CachedCategoryRepository) Delete(ctx context.Context, template CategoryUserModel, events ...model.CategoryEvent) error {
+	defer repo.invalidate(ctx, template.ID)
+	return repo.CategoryRepository.Delete(ctx, template, events...)
+}
+
+func (repo *CachedCategoryRepository) invalidate(ctx context.Context, modelID string) {
+	if cache := repo.cacheFor(ctx); cache != nil {
+		//keyed by the owner so an editor's change is seen by the owner
+		cache.invalidate(categoryCacheKey(CategoryOwner(ctx), modelID))
+	}
+}
+
+//Scope - the configured cache scope
+func (repo *CachedCategoryRepository) Scope() string {
+	return repo.scope
+}
+
+//CacheStats - counters for the process cache, or the request's cache in the invocation scope
+func (repo *CachedCategoryRepository) CacheStats(ctx context.Context) CategoryCacheStats {
+	if cache := repo.cacheFor(ctx); cache != nil {
+		return cache.Stats()
+	}
+	return CategoryCacheStats{}
+}
+
+//NewCachedCategoryRepository - wraps the repository using the cache size, ttl and scope from its config.  A size of 0 disables caching
+func NewCachedCategoryRepository(repo *CategoryRepository) (*CachedCategoryRepository, error) {
+	values := repo.config.Values()
+	cached := &CachedCategoryRepository{CategoryRepository: repo, scope: values["cacheScope"]}
+	if cached.scope == "" {
+		cached.scope = CacheScopeProcess
+	}
+	if cached.scope != CacheScopeProcess && cached.scope != CacheScopeInvocation {
+		return nil, fmt.Errorf("PROCESS_CATEGORY_CACHE_SCOPE must be %v or %v, received: %v", CacheScopeProcess, CacheScopeInvocation, cached.scope)
+	}
+
+	if sizeValue := values["cacheSize"]; sizeValue != "" {
+		var err error
+		cached.size, err = strconv.Atoi(sizeValue)
+		if err != nil || cached.size < 0 {
+			return nil, fmt.Errorf("PROCESS_CATEGORY_CACHE_SIZE must be 0 or a positive number, received: %v", sizeValue)
+		}
+	}
+	cached.ttl = DefaultCacheTTL
+	if ttlValue := values["cacheTTL"]; ttlValue != "" {
+		var err error
+		cached.ttl, err = time.ParseDuration(ttlValue)
+		if err != nil || cached.ttl <= 0 {
+			return nil, fmt.Errorf("PROCESS_CATEGORY_CACHE_TTL must be a positive duration (e.g. 30s), received: %v", ttlValue)
+		}
+	}
+
+	if cached.size > 0 && cached.scope == CacheScopeProcess {
+		cached.cache = NewCategoryCache(cached.size, cached.ttl)
+	}
+	return cached, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/suared/core-apiuser/model"
+)
+
+func getCacheTestModel(id string) CategoryUserModel {
+	userModel := *NewCategoryUserModel("cache test")
+	userModel.ID = id
+	userModel.AddChild(model.Category{ID: id + "-child", Title: "child"})
+	return userModel
+}
+
+func TestCategoryCache(t *testing.T) {
+	cache := NewCategoryCache(2, time.Minute)
+
+	withSettings := getCacheTestModel("1")
+	withSettings.TitlePolicy = model.CategoryTitlePolicy{Uniqueness: model.TitlesReject}
+	withSettings.Limits = model.CategoryLimits{MaxDepth: 3}
+	cache.put("u/1", withSettings, cache.currentGeneration())
+	cache.put("u/2", getCacheTestModel("2"), cache.currentGeneration())
+	cached, ok := cache.get("u/1")
+	if !ok || cached.ID != "1" {
+		t.Fatalf("Expected cached model 1, received: %v", cached.ID)
+	}
+	if cached.TitlePolicy.Uniqueness != model.TitlesReject || cached.Limits.MaxDepth != 3 {
+		t.Errorf("Expected the model settings to be cached, received: %+v, %+v", cached.TitlePolicy, cached.Limits)
+	}
+
+	//callers get a copy, changes do not reach the cache
+	cached.Children[0].Title = "changed"
+	cached, _ = cache.get("u/1")
+	if cached.Children[0].Title != "child" {
+		t.Errorf("Expected cached model to be unchanged, received: %v", cached.Children[0].Title)
+	}
+
+	//2 is least recently used
+	cache.put("u/3", getCacheTestModel("3"), cache.currentGeneration())
+	if _, ok = cache.get("u/2"); ok {
+		t.Errorf("Expected least recently used model to be evicted")
+	}
+
+	//a read that started before an invalidation is not cached
+	generation := cache.currentGeneration()
+	cache.invalidate("u/1")
+	cache.put("u/1", getCacheTestModel("1"), generation)
+	if _, ok = cache.get("u/1"); ok {
+		t.Errorf("Expected stale read not to be cached")
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.Evictions != 1 || stats.Invalidations != 1 || stats.Entries != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	expiring := NewCategoryCache(2, time.Millisecond)
+	expiring.put("u/1", getCacheTestModel("1"), expiring.currentGeneration())
+	time.Sleep(5 * time.Millisecond)
+	if _, ok = expiring.get("u/1"); ok {
+		t.Errorf("Expected expired model to be a miss")
+	}
+}
+
+func TestCategoryCacheConcurrent(t *testing.T) {
+	cache := NewCategoryCache(10, time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < 200; n++ {
+				key := "u/" + strconv.Itoa(n%20)
+				if _, ok := cache.get(key); !ok {
+					cache.put(key, getCacheTestModel(key), cache.currentGeneration())
+				}
+				if n%7 == i {
+					cache.invalidate(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if stats := cache.Stats(); stats.Entries > 10 || stats.Hits+stats.Misses != 1600 {
+		t.Errorf("Unexpected stats after concurrent use: %+v", stats)
+	}
+}
+
+func TestCachedCategoryRepositoryScope(t *testing.T) {
+	ctx := context.Background()
+	repo := &CachedCategoryRepository{scope: CacheScopeInvocation, size: 10, ttl: time.Minute}
+	if repo.cacheFor(ctx) != nil {
+		t.Errorf("Expected no cache outside an invocation")
+	}
+	ctx = repo.WithInvocationCache(ctx)
+	if repo.cacheFor(ctx) == nil {
+		t.Errorf("Expected a cache for the invocation")
+	}
+
+	repo = &CachedCategoryRepository{scope: CacheScopeProcess, size: 10, ttl: time.Minute, cache: NewCategoryCache(10, time.Minute)}
+	if repo.WithInvocationCache(context.Background()) != context.Background() || repo.cacheFor(ctx) != repo.cache {
+		t.Errorf("Expected the process cache in the process scope")
+	}
+}
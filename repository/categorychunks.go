@@ -0,0 +1,426 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core/uuid"
+)
+
+/*
+Models whose stored data is larger than the chunk size are split across items so a single item stays under the Dynamo item limit:
+
+	<modelID>             - the model item, holds the first chunk plus ChunkCount and ChunkVersion
+	<modelID>#chunk#1..N  - the remaining chunks, each tagged with the ChunkVersion of the write that created it
+
+All items of a model are written and read in one transaction so a reader never sees chunks from different writes
+*/
+
+//DefaultChunkBytes - leaves room for keys and attribute names under the Dynamo item limit
+const DefaultChunkBytes = 350 * 1024
+
+//maxTransactionItems - Dynamo limit on items in one transaction.  At DefaultChunkBytes the byte limit is reached first, this only binds
+//for a smaller chunk size
+const maxTransactionItems = 100
+
+//maxTransactionBytes - Dynamo limit on the total size of the items in one transaction, with the item and chunk limits this is what bounds
+//the largest model.  About 3.9MB of model data fits once keys, attribute names and events are counted, see checkTransactionSize
+const maxTransactionBytes = 4 * 1024 * 1024
+
+const chunkSortKeyInfix = categoryKeySeparator + "chunk" + categoryKeySeparator
+
+//categoryChunkItem - attributes of a chunk item, also used to read the chunk attributes of the model item
+type categoryChunkItem struct {
+	CategoryHashKey       string
+	CategorySortKey       string
+	UserID                string
+	CategoryUserModelData []byte
+	ChunkVersion          string
+	ChunkCount            int
+	StoredBytes           int64
+	ModelVersion          int64 `json:",omitempty"`
+}
+
+//chunkSortKey - sort key of chunk n (1 based) of the model
+func chunkSortKey(modelID string, n int) string {
+	return modelID + chunkSortKeyInfix + strconv.Itoa(n)
+}
+
+//splitChunks - splits data into chunkBytes sized pieces, always returns at least one piece
+func splitChunks(data []byte, chunkBytes int) [][]byte {
+	var chunks [][]byte
+	for len(data) > chunkBytes {
+		chunks = append(chunks, data[:chunkBytes])
+		data = data[chunkBytes:]
+	}
+	return append(chunks, data)
+}
+
+//ErrCategoryTooLarge - matched with errors.Is for any *CategoryTooLargeError
+var ErrCategoryTooLarge = errors.New("category model too large")
+
+//CategoryTooLargeError - returned when a model write does not fit in one transaction, the model is not stored.  Bytes and Items are the
+//size of the refused write, with the model data making up most of Bytes
+type CategoryTooLargeError struct {
+	ModelID  string
+	Bytes    int
+	MaxBytes int
+	Items    int
+	MaxItems int
+}
+
+func (err *CategoryTooLargeError) Error() string {
+	return fmt.Sprintf("Category model %v is too large to store, the write is %v bytes in %v items, the limit is %v bytes in %v items", err.ModelID, err.Bytes, err.Items, err.MaxBytes, err.MaxItems)
+}
+
+//Is - matches ErrCategoryTooLarge
+func (err *CategoryTooLargeError) Is(target error) bool {
+	return target == ErrCategoryTooLarge
+}
+
+//checkTransactionSize - returns a *CategoryTooLargeError when the items are over the Dynamo transaction limits
+func checkTransactionSize(dao *CategoryDAO, items []*awsDynamoDB.TransactWriteItem) error {
+	size := 0
+	for _, item := range items {
+		switch {
+		case item.Put != nil:
+			size += itemBytes(item.Put.Item)
+		case item.Delete != nil:
+			size += itemBytes(item.Delete.Key)
+		case item.Update != nil:
+			size += itemBytes(item.Update.Key) + itemBytes(item.Update.ExpressionAttributeValues)
+		case item.ConditionCheck != nil:
+			size += itemBytes(item.ConditionCheck.Key)
+		}
+	}
+	if size > maxTransactionBytes || len(items) > maxTransactionItems {
+		return &CategoryTooLargeError{ModelID: dao.SortKey(), Bytes: size, MaxBytes: maxTransactionBytes, Items: len(items), MaxItems: maxTransactionItems}
+	}
+	return nil
+}
+
+//itemBytes - the size Dynamo counts for the attributes, the lengths of the names and values
+func itemBytes(attributes map[string]*awsDynamoDB.AttributeValue) int {
+	size := 0
+	for name, value := range attributes {
+		size += len(name) + valueBytes(value)
+	}
+	return size
+}
+
+//valueBytes - the size of one attribute value, numbers are counted by their digits which is slightly more than Dynamo counts.
+//Lists and maps add 3 bytes plus 1 per element
+func valueBytes(value *awsDynamoDB.AttributeValue) int {
+	size := 0
+	switch {
+	case value == nil:
+	case value.S != nil:
+		size = len(*value.S)
+	case value.N != nil:
+		size = len(*value.N)
+	case value.B != nil:
+		size = len(value.B)
+	case value.BOOL != nil, value.NULL != nil:
+		size = 1
+	case value.M != nil:
+		size = 3 + len(value.M) + itemBytes(value.M)
+	case value.L != nil:
+		size = 3 + len(value.L)
+		for _, element := range value.L {
+			size += valueBytes(element)
+		}
+	}
+	for _, element := range value.SS {
+		size += len(*element)
+	}
+	for _, element := range value.NS {
+		size += len(*element)
+	}
+	for _, element := range value.BS {
+		size += len(element)
+	}
+	return size
+}
+
+//categoryStoredState - the model item as it was read.  Writes rely on it instead of reading the item again, their version condition fails
+//if the item changed since
+type categoryStoredState struct {
+	modelID     string
+	chunkCount  int
+	storedBytes int64
+}
+
+//storedState - the state of a model item read from the database, nil if it was not found
+func storedState(dao *CategoryDAO) *categoryStoredState {
+	if dao.HashKey() == "" {
+		return nil
+	}
+	stored := &categoryStoredState{modelID: dao.SortKey(), chunkCount: dao.ChunkCount, storedBytes: dao.StoredBytes}
+	if stored.storedBytes == 0 {
+		//sizes were not recorded before quotas, the data is the whole model once chunks are assembled
+		stored.storedBytes = int64(len(dao.CategoryUserModelData))
+	}
+	return stored
+}
+
+//readState - the state the model was read with, nil for a model being created or not read from the repository
+func (dao *CategoryDAO) readState() *categoryStoredState {
+	if dao.create || dao.stored == nil || dao.stored.modelID != dao.SortKey() {
+		return nil
+	}
+	return dao.stored
+}
+
+//write - stores the dao, splitting it into chunks when the data is larger than the chunk size.  Chunks no longer needed by the new version are removed
+//and any events are added to the outbox and audit log in the same transaction.  The chunk layout and size being replaced are the ones the model was
+//read with, the version condition refuses the write if they changed
+func (repo *CategoryRepository) write(ctx context.Context, dao *CategoryDAO, events []model.CategoryEvent) error {
+	dao.Refresh()
+	condition := repo.modelCondition(dao)
+	stored := dao.readState()
+	modelDelta := 0
+	if stored == nil {
+		stored = &categoryStoredState{}
+		modelDelta = 1
+	}
+	oldCount := stored.chunkCount
+	dao.StoredBytes = int64(len(dao.CategoryUserModelData))
+
+	eventItems, err := repo.eventItems(dao, events)
+	if err != nil {
+		return err
+	}
+	usageItems, err := repo.usageItems(ctx, dao.UserID, modelDelta, stored.storedBytes, dao.StoredBytes)
+	if err != nil {
+		return err
+	}
+	eventItems = append(eventItems, usageItems...)
+	dao.ModelVersion = dao.Version + 1
+
+	chunks := splitChunks(dao.CategoryUserModelData, repo.chunkBytes)
+	if len(chunks) == 1 && oldCount == 0 && len(eventItems) == 0 {
+		dao.ChunkCount = 0
+		dao.ChunkVersion = ""
+		return repo.putModel(ctx, dao, condition)
+	}
+
+	//the dao keeps the full data for the caller, only the stored item holds the first chunk
+	modelItem := *dao
+	modelItem.CategoryUserModelData = chunks[0]
+	modelItem.ChunkCount = len(chunks) - 1
+	modelItem.ChunkVersion = uuid.NewUUID()
+	if modelItem.ChunkCount == 0 {
+		modelItem.ChunkVersion = ""
+	}
+
+	var items []*awsDynamoDB.TransactWriteItem
+	put, err := dynamodbattribute.MarshalMap(&modelItem)
+	if err != nil {
+		return err
+	}
+	items = append(items, &awsDynamoDB.TransactWriteItem{Put: &awsDynamoDB.Put{
+		TableName:                 repo.tableName(),
+		Item:                      put,
+		ConditionExpression:       condition.expression,
+		ExpressionAttributeNames:  condition.names,
+		ExpressionAttributeValues: condition.values,
+	}})
+	for n := 1; n < len(chunks); n++ {
+		put, err = dynamodbattribute.MarshalMap(categoryChunkItem{
+			CategoryHashKey:       dao.HashKey(),
+			CategorySortKey:       chunkSortKey(dao.SortKey(), n),
+			UserID:                dao.UserID,
+			CategoryUserModelData: chunks[n],
+			ChunkVersion:          modelItem.ChunkVersion,
+		})
+		if err != nil {
+			return err
+		}
+		items = append(items, &awsDynamoDB.TransactWriteItem{Put: &awsDynamoDB.Put{TableName: repo.tableName(), Item: put}})
+	}
+	for n := len(chunks); n <= oldCount; n++ {
+		items = append(items, &awsDynamoDB.TransactWriteItem{Delete: &awsDynamoDB.Delete{TableName: repo.tableName(), Key: repo.itemKey(dao.HashKey(), chunkSortKey(dao.SortKey(), n))}})
+	}
+	items = append(items, eventItems...)
+	err = checkTransactionSize(dao, items)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.client.TransactWriteItemsWithContext(ctx, &awsDynamoDB.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		//the model put is the first item, otherwise the usage update is the only conditional item and another write may have used the quota first
+		reasons := cancellationReasons(err)
+		if len(reasons) > 0 && reasons[0] == conditionalCheckFailedReason {
+			return conflictError(dao)
+		}
+		err = fmt.Errorf("Category model chunked write failed with: %v", err)
+		if len(reasons) > 0 && len(usageItems) > 0 {
+			return repo.quotaError(ctx, dao.UserID, modelDelta, dao.StoredBytes-stored.storedBytes, err)
+		}
+		return err
+	}
+	dao.ChunkCount = modelItem.ChunkCount
+	dao.ChunkVersion = modelItem.ChunkVersion
+	dao.Version = dao.ModelVersion
+	return nil
+}
+
+//putModel - stores a model held in one item with no events
+func (repo *CategoryRepository) putModel(ctx context.Context, dao *CategoryDAO, condition categoryCondition) error {
+	item, err := dynamodbattribute.MarshalMap(dao)
+	if err != nil {
+		return err
+	}
+	_, err = repo.client.PutItemWithContext(ctx, &awsDynamoDB.PutItemInput{
+		TableName:                 repo.tableName(),
+		Item:                      item,
+		ConditionExpression:       condition.expression,
+		ExpressionAttributeNames:  condition.names,
+		ExpressionAttributeValues: condition.values,
+	})
+	if conditionFailed(err) {
+		return conflictError(dao)
+	}
+	if err != nil {
+		return fmt.Errorf("Category model write failed with: %v", err)
+	}
+	dao.Version = dao.ModelVersion
+	return nil
+}
+
+//remove - deletes the model item along with any chunks and shares, events are added to the outbox and audit log in the same transaction.
+//A model that was not read first is read here for its chunks, the delete is conditional on the version read like a write
+func (repo *CategoryRepository) remove(ctx context.Context, dao *CategoryDAO, events []model.CategoryEvent) error {
+	dao.Refresh()
+	if dao.readState() == nil {
+		current, err := repo.readDAO(ctx, dao, true)
+		if err != nil {
+			return err
+		}
+		dao.stored = storedState(current)
+		dao.Version = current.ModelVersion
+	}
+	condition := repo.modelCondition(dao)
+	stored := dao.readState()
+	eventItems, err := repo.eventItems(dao, events)
+	if err != nil {
+		return err
+	}
+	shareItems, err := repo.shareItems(ctx, dao.UserID, dao.SortKey())
+	if err != nil {
+		return err
+	}
+	oldCount := 0
+	if stored != nil {
+		oldCount = stored.chunkCount
+		usageItems, err := repo.usageItems(ctx, dao.UserID, -1, stored.storedBytes, 0)
+		if err != nil {
+			return err
+		}
+		eventItems = append(eventItems, usageItems...)
+	}
+
+	items := []*awsDynamoDB.TransactWriteItem{{Delete: &awsDynamoDB.Delete{
+		TableName:                 repo.tableName(),
+		Key:                       repo.itemKey(dao.HashKey(), dao.SortKey()),
+		ConditionExpression:       condition.expression,
+		ExpressionAttributeNames:  condition.names,
+		ExpressionAttributeValues: condition.values,
+	}}}
+	if oldCount == 0 && len(eventItems) == 0 && len(shareItems) == 0 {
+		_, err = repo.client.DeleteItemWithContext(ctx, &awsDynamoDB.DeleteItemInput{
+			TableName:                 repo.tableName(),
+			Key:                       items[0].Delete.Key,
+			ConditionExpression:       condition.expression,
+			ExpressionAttributeNames:  condition.names,
+			ExpressionAttributeValues: condition.values,
+		})
+		if conditionFailed(err) {
+			return conflictError(dao)
+		}
+		return err
+	}
+	for n := 1; n <= oldCount; n++ {
+		items = append(items, &awsDynamoDB.TransactWriteItem{Delete: &awsDynamoDB.Delete{TableName: repo.tableName(), Key: repo.itemKey(dao.HashKey(), chunkSortKey(dao.SortKey(), n))}})
+	}
+	sharesFrom := len(items)
+	items = append(items, shareItems...)
+	items = append(items, eventItems...)
+	err = checkTransactionSize(dao, items)
+	if err != nil {
+		return err
+	}
+	_, err = repo.client.TransactWriteItemsWithContext(ctx, &awsDynamoDB.TransactWriteItemsInput{TransactItems: items})
+	reasons := cancellationReasons(err)
+	for i := range reasons {
+		//the model changed, or an invite was accepted since the shares were read
+		if reasons[i] == conditionalCheckFailedReason && (i == 0 || (i >= sharesFrom && i < sharesFrom+len(shareItems))) {
+			return conflictError(dao)
+		}
+	}
+	return err
+}
+
+//assembleChunks - reads the model item and its chunks in one transaction and decodes the joined data into the dao.
+//The chunk count from the first read can be stale if a write happened in between, in which case the read is retried with the current count
+func (repo *CategoryRepository) assembleChunks(ctx context.Context, dao *CategoryDAO) error {
+	count := dao.ChunkCount
+	for attempt := 0; attempt < 3; attempt++ {
+		keys := []*awsDynamoDB.TransactGetItem{{Get: &awsDynamoDB.Get{TableName: repo.tableName(), Key: repo.itemKey(dao.HashKey(), dao.SortKey())}}}
+		for n := 1; n <= count; n++ {
+			keys = append(keys, &awsDynamoDB.TransactGetItem{Get: &awsDynamoDB.Get{TableName: repo.tableName(), Key: repo.itemKey(dao.HashKey(), chunkSortKey(dao.SortKey(), n))}})
+		}
+		result, err := repo.client.TransactGetItemsWithContext(ctx, &awsDynamoDB.TransactGetItemsInput{TransactItems: keys})
+		if err != nil {
+			return err
+		}
+
+		//removed since the first read
+		if len(result.Responses) == 0 || result.Responses[0].Item == nil {
+			dao.CategoryUserModel = CategoryUserModel{}
+			return nil
+		}
+
+		items := make([]categoryChunkItem, len(result.Responses))
+		for i := range result.Responses {
+			err = dynamodbattribute.UnmarshalMap(result.Responses[i].Item, &items[i])
+			if err != nil {
+				return err
+			}
+		}
+		if items[0].ChunkCount != count {
+			count = items[0].ChunkCount
+			continue
+		}
+
+		data := items[0].CategoryUserModelData
+		consistent := true
+		for n := 1; n <= count; n++ {
+			if items[n].ChunkVersion != items[0].ChunkVersion {
+				consistent = false
+				break
+			}
+			data = append(data, items[n].CategoryUserModelData...)
+		}
+		if !consistent {
+			continue
+		}
+
+		//the read state comes from the model item read with the chunks, not the first read
+		dao.CategoryUserModelData = data
+		dao.ChunkCount = count
+		dao.ChunkVersion = items[0].ChunkVersion
+		dao.ModelVersion = items[0].ModelVersion
+		dao.StoredBytes = items[0].StoredBytes
+		dao.decode()
+		return nil
+	}
+	return fmt.Errorf("Category model %v chunks changed during read, try again", dao.SortKey())
+}
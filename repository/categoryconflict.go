@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//ErrCategoryConflict - matched with errors.Is when a model changed between being read and written, read it again and retry the change
+var ErrCategoryConflict = errors.New("category model was changed by another request")
+
+//conditionalCheckFailedReason - the cancellation reason of a transaction item whose condition failed
+const conditionalCheckFailedReason = "ConditionalCheckFailed"
+
+//categoryCondition - a condition expression with its names and values.  Either map is nil when unused as Dynamo refuses empty maps
+type categoryCondition struct {
+	expression *string
+	names      map[string]*string
+	values     map[string]*awsDynamoDB.AttributeValue
+}
+
+//modelCondition - the write is only made while the stored model is at the version it was read at.  A new model, or one that was not read
+//from the repository, must not exist yet.  Models read before versions were stored have no version attribute
+func (repo *CategoryRepository) modelCondition(dao *CategoryDAO) categoryCondition {
+	names := map[string]*string{"#sortKey": aws.String(repo.config.Values()["sortKeyName"])}
+	switch {
+	case dao.readState() == nil:
+		return categoryCondition{expression: aws.String("attribute_not_exists(#sortKey)"), names: names}
+	case dao.Version == 0:
+		return categoryCondition{expression: aws.String("attribute_exists(#sortKey) AND attribute_not_exists(ModelVersion)"), names: names}
+	}
+	return categoryCondition{
+		expression: aws.String("ModelVersion = :version"),
+		values:     map[string]*awsDynamoDB.AttributeValue{":version": {N: aws.String(strconv.FormatInt(dao.Version, 10))}},
+	}
+}
+
+//conditionFailed - true when err is a failed PutItem condition
+func conditionFailed(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == awsDynamoDB.ErrCodeConditionalCheckFailedException
+}
+
+//cancellationReasons - the reason code per item of a cancelled transaction, in item order.  This sdk version only has them in the message,
+//e.g. "Transaction cancelled, please refer cancellation reasons for specific reasons [ConditionalCheckFailed, None]"
+func cancellationReasons(err error) []string {
+	awsErr, ok := err.(awserr.Error)
+	if !ok || awsErr.Code() != awsDynamoDB.ErrCodeTransactionCanceledException {
+		return nil
+	}
+	message := awsErr.Message()
+	start := strings.LastIndex(message, "[")
+	end := strings.LastIndex(message, "]")
+	if start < 0 || end < start {
+		return nil
+	}
+	reasons := strings.Split(message[start+1:end], ",")
+	for i := range reasons {
+		reasons[i] = strings.TrimSpace(reasons[i])
+	}
+	return reasons
+}
+
+//conflictError - the model's ErrCategoryConflict
+func conflictError(dao *CategoryDAO) error {
+	return fmt.Errorf("%w: %v", ErrCategoryConflict, dao.SortKey())
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/suared/core/repository"
+)
+
+func TestCategoryModelCondition(t *testing.T) {
+	configMap := repository.NewBasicConfig("categoryDatabase")
+	configMap.AddEntry("sortKeyName", "CategorySortKey")
+	repo := &CategoryRepository{config: configMap}
+
+	//created, or never read so the stored chunks are unknown
+	for _, dao := range []*CategoryDAO{{create: true}, {}} {
+		condition := repo.modelCondition(dao)
+		if *condition.expression != "attribute_not_exists(#sortKey)" || *condition.names["#sortKey"] != "CategorySortKey" || condition.values != nil {
+			t.Errorf("Expected a model that was not read to require no stored item, received: %v", *condition.expression)
+		}
+	}
+
+	//read before versions were stored
+	dao := &CategoryDAO{CategorySortKey: "model1"}
+	dao.ID = "model1"
+	dao.stored = &categoryStoredState{modelID: "model1"}
+	condition := repo.modelCondition(dao)
+	if *condition.expression != "attribute_exists(#sortKey) AND attribute_not_exists(ModelVersion)" || condition.values != nil {
+		t.Errorf("Expected an unversioned model to require no stored version, received: %v", *condition.expression)
+	}
+
+	dao.Version = 7
+	condition = repo.modelCondition(dao)
+	if *condition.expression != "ModelVersion = :version" || *condition.values[":version"].N != "7" || condition.names != nil {
+		t.Errorf("Expected the read version to be required, received: %v", *condition.expression)
+	}
+
+	//the read state belongs to the model id it was read with
+	dao.create = true
+	if dao.readState() != nil {
+		t.Errorf("Expected no read state for a created model")
+	}
+	dao.create = false
+	dao.CategorySortKey = "model2"
+	if dao.readState() != nil {
+		t.Errorf("Expected no read state for another model id")
+	}
+
+	//the version is read from the item, not the encoded model
+	userModel := getSizedCategoryUserModel(2)
+	userModel.Version = 3
+	data, _ := EncodeCategoryUserModel(userModel, EncodingGzipCBOR)
+	dao = &CategoryDAO{UserID: "testuser1", CategorySortKey: userModel.ID, CategoryUserModelData: data, ModelVersion: 5}
+	dao.Refresh()
+	dao.Populate()
+	if dao.Version != 5 {
+		t.Errorf("Expected the stored version, received: %v", dao.Version)
+	}
+	if stored := dao.readState(); stored == nil || stored.storedBytes != int64(len(data)) || stored.chunkCount != 0 {
+		t.Errorf("Expected the read state of the item, received: %+v", stored)
+	}
+	replacement := CategoryUserModel{}
+	replacement.ID = userModel.ID
+	replacement.Replaces(dao.CategoryUserModel)
+	if replacement.Version != 5 || replacement.stored != dao.stored {
+		t.Errorf("Expected the replacement to take the read state, received: %v", replacement.Version)
+	}
+
+	if err := conflictError(dao); !errors.Is(err, ErrCategoryConflict) {
+		t.Errorf("Expected a conflict, received: %v", err)
+	}
+}
+
+func TestCategoryCancellationReasons(t *testing.T) {
+	err := awserr.New(awsDynamoDB.ErrCodeTransactionCanceledException, "Transaction cancelled, please refer cancellation reasons for specific reasons [ConditionalCheckFailed, None, None]", nil)
+	reasons := cancellationReasons(err)
+	if len(reasons) != 3 || reasons[0] != conditionalCheckFailedReason || reasons[2] != "None" {
+		t.Errorf("Unexpected reasons: %q", reasons)
+	}
+	err = awserr.New(awsDynamoDB.ErrCodeTransactionCanceledException, "Transaction cancelled", nil)
+	if reasons = cancellationReasons(err); reasons != nil {
+		t.Errorf("Expected no reasons without the list, received: %q", reasons)
+	}
+	err = awserr.New(awsDynamoDB.ErrCodeConditionalCheckFailedException, "The conditional request failed [x]", nil)
+	if reasons = cancellationReasons(err); reasons != nil || !conditionFailed(err) {
+		t.Errorf("Expected a failed condition, not a cancelled transaction, received: %q", reasons)
+	}
+}
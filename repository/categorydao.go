@@ -1,14 +1,15 @@
 package repository
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
+	"strings"
+
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 
 	"github.com/suared/core/repository/dynamodb"
 	"github.com/suared/core/security"
-	"github.com/suared/core/ziptools"
 )
 
 //CategoryDAO - Caller would ipmlement the relevant DAO which would include the model to be saved along with any other key values (e.g. hash/sort for dynamo)
@@ -16,11 +17,48 @@ type CategoryDAO struct {
 	CategoryHashKey string
 	CategorySortKey string
 	UserID          string
+	//CategoryModelKey - the hash key again on model items only, so the sparse model index lists models without the other item types, see categorymodelindex.go
+	CategoryModelKey string `json:",omitempty"`
 
 	//using zip for storage to keep dynamo costs low, hence removing the UserModel from unmarshal to replace with zip equivalent
 	//because the life of a dao is only for a db interaction, handling the conversion in Refresh is fine
 	CategoryUserModel     `json:"-"`
 	CategoryUserModelData []byte
+	//Set when the data is split across chunk items, see categorychunks.go
+	ChunkCount   int    `json:",omitempty"`
+	ChunkVersion string `json:",omitempty"`
+	//StoredBytes - the size of the encoded model across its chunks, used for storage quotas
+	StoredBytes int64 `json:",omitempty"`
+	//ModelVersion - bumped by every write, writes are conditional on the version the model was read at.  Items written before versions were stored have none
+	ModelVersion int64 `json:",omitempty"`
+
+	//set by Populate, used to find items still stored in an older encoding
+	storedEncoding CategoryEncoding
+	//set by Populate when the stored data cannot be decoded
+	decodeErr *CategoryDecodeError
+	//set by the repository DAO call, writes add an audit record per event
+	audit bool
+	//set by Insert, the write fails if the model already exists
+	create bool
+}
+
+//categoryKeySeparator - model ids never contain it.  Other item types sharing the user's hash key (e.g. quarantine) use a "<type>#..." sort key namespace
+const categoryKeySeparator = "#"
+
+//IsModelSortKey - false for items in another sort key namespace (chunks, outbox, quarantine)
+func IsModelSortKey(sortKey string) bool {
+	return !strings.Contains(sortKey, categoryKeySeparator)
+}
+
+//CategoryDecodeError - returned when a stored category model cannot be decoded
+type CategoryDecodeError struct {
+	UserID  string
+	SortKey string
+	Err     error
+}
+
+func (err *CategoryDecodeError) Error() string {
+	return fmt.Sprintf("Unable to decode Category model: %v for user: %v, received: %v", err.SortKey, err.UserID, err.Err)
 }
 
 //HashKey - This is the value that would be set as the dynamo hashkey
@@ -46,24 +84,62 @@ func (dao *CategoryDAO) New() dynamodb.DAO {
 //Refresh - updates the Hashkey and SortKey.  Used by the library before calls
 func (dao *CategoryDAO) Refresh() {
 	dao.CategoryHashKey = "category_" + dao.UserID
-	dao.CategorySortKey = dao.ID
+	//read results have no model until Populate, keep the stored sort key so failures can be traced to the item
+	if dao.ID != "" {
+		dao.CategorySortKey = dao.ID
+	}
+	if dao.CategorySortKey != "" && IsModelSortKey(dao.CategorySortKey) {
+		dao.CategoryModelKey = dao.CategoryHashKey
+	}
 }
 
 //Populate - Called for any post processing after the DAO is generated from the library (e.g. calculated fields, unzip, etc)
 func (dao *CategoryDAO) Populate() {
-	var buf bytes.Buffer
+	//Other item types in the same hash key are not models, the repository skips them
+	if !IsModelSortKey(dao.CategorySortKey) {
+		return
+	}
+	//Chunked models only hold the first chunk, the repository assembles and decodes them
+	if dao.ChunkCount > 0 {
+		return
+	}
+	dao.decode()
+}
 
-	//Populate the model from the zip - First step is to unzip the bits
-	err := ziptools.GetGunzipData(&buf, dao.CategoryUserModelData)
+//decode - Decode the model from the stored envelope, the encoding is detected from the data (Note: intentionally not calling refresh here yet as DAOs are one time use and should not be necessary)
+//Failures are kept vs. panicking so one corrupt item does not fail the whole request, see DecodeError
+func (dao *CategoryDAO) decode() {
+	userModel, encoding, err := DecodeCategoryUserModel(dao.CategoryUserModelData)
 	if err != nil {
-		panic(fmt.Errorf("Unable to unzip Category dao for hash: %v", dao.CategoryHashKey))
+		dao.decodeErr = &CategoryDecodeError{UserID: dao.UserID, SortKey: dao.CategorySortKey, Err: err}
+		return
 	}
-	//Then marshall the bits into the model object for use (Note: intentionally not calling refresh here yet as DAOs are one time use and should not be necessary)
-	err = json.Unmarshal(buf.Bytes(), &dao.CategoryUserModel)
+	userModel.Version = dao.ModelVersion
+	userModel.stored = storedState(dao)
+	dao.CategoryUserModel = userModel
+	dao.storedEncoding = encoding
+}
+
+//CategoryDAOFromItem - the dao of a stored item, e.g. a table stream image, unmarshalled and populated the same way as the repository's reads.
+//Chunked models only hold the first chunk, their chunks are separate items so the model is left undecoded, see Populate
+func CategoryDAOFromItem(item map[string]*awsDynamoDB.AttributeValue) (*CategoryDAO, error) {
+	dao := new(CategoryDAO)
+	err := dynamodbattribute.UnmarshalMap(item, dao)
 	if err != nil {
-		panic(fmt.Errorf("Unable to unmarshal unzip Category dao for hash: %v", dao.CategoryHashKey))
+		return nil, fmt.Errorf("Unable to read category item: %w", err)
 	}
+	dao.Populate()
+	return dao, nil
+}
+
+//DecodeError - the decode failure from Populate, nil if the model was decoded
+func (dao *CategoryDAO) DecodeError() *CategoryDecodeError {
+	return dao.decodeErr
+}
 
+//StoredEncoding - the encoding found when this DAO was populated from the database
+func (dao *CategoryDAO) StoredEncoding() CategoryEncoding {
+	return dao.storedEncoding
 }
 
 //NewCategoryDAO - Initializes this object with the user ID from context
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/suared/core/repository"
+)
+
+func TestCategoryDAOPopulate(t *testing.T) {
+	userModel := getSizedCategoryUserModel(10)
+	data, _ := EncodeCategoryUserModel(userModel, EncodingGzipCBOR)
+
+	//as read back from the database, the model is only in the data
+	dao := &CategoryDAO{UserID: "testuser1", CategorySortKey: userModel.ID, CategoryUserModelData: data}
+	dao.Refresh()
+	dao.Populate()
+	if dao.DecodeError() != nil || dao.ID != userModel.ID || dao.StoredEncoding() != EncodingGzipCBOR {
+		t.Errorf("Expected decoded model, received err: %v, id: %v, encoding: %v", dao.DecodeError(), dao.ID, dao.StoredEncoding())
+	}
+
+	//corrupt data is reported vs. panicking and keeps the sort key for tracing
+	dao = &CategoryDAO{UserID: "testuser1", CategorySortKey: "corrupt", CategoryUserModelData: data[:len(data)/2]}
+	dao.Refresh()
+	dao.Populate()
+	if dao.DecodeError() == nil || dao.DecodeError().SortKey != "corrupt" {
+		t.Errorf("Expected decode error for corrupt data with its sort key, received: %v", dao.DecodeError())
+	}
+	if dao.CategoryModelKey != "category_testuser1" {
+		t.Errorf("Expected model items to be in the model index, received: %v", dao.CategoryModelKey)
+	}
+
+	//other namespaces are not decoded
+	dao = &CategoryDAO{UserID: "testuser1", CategorySortKey: quarantineSortKeyPrefix + "corrupt", CategoryUserModelData: []byte("x")}
+	dao.Refresh()
+	dao.Populate()
+	if dao.DecodeError() != nil || IsModelSortKey(dao.SortKey()) || dao.CategoryModelKey != "" {
+		t.Errorf("Expected quarantine item to be skipped, received: %v, %v", dao.DecodeError(), dao.CategoryModelKey)
+	}
+
+	quarantineDAO := &CategoryQuarantineDAO{UserID: "testuser1", OriginalSortKey: "corrupt"}
+	quarantineDAO.Refresh()
+	if quarantineDAO.SortKey() != "quarantine#corrupt" || quarantineDAO.HashKey() != "category_testuser1" {
+		t.Errorf("Unexpected quarantine keys: %v, %v", quarantineDAO.HashKey(), quarantineDAO.SortKey())
+	}
+}
+
+func TestCategoryChunks(t *testing.T) {
+	data := []byte("0123456789")
+	chunks := splitChunks(data, 4)
+	if len(chunks) != 3 || string(chunks[0]) != "0123" || string(chunks[2]) != "89" {
+		t.Errorf("Unexpected chunks: %q", chunks)
+	}
+	if chunks = splitChunks(data, 10); len(chunks) != 1 {
+		t.Errorf("Expected one chunk at the exact size, received: %v", len(chunks))
+	}
+	if chunks = splitChunks(nil, 4); len(chunks) != 1 {
+		t.Errorf("Expected one empty chunk, received: %v", len(chunks))
+	}
+
+	//chunk items are skipped like any other namespace
+	sortKey := chunkSortKey("model1", 2)
+	if sortKey != "model1#chunk#2" || IsModelSortKey(sortKey) {
+		t.Errorf("Unexpected chunk sort key: %v", sortKey)
+	}
+
+	//a chunked model item is left for the repository to assemble vs. reporting a decode error on the partial data
+	userModel := getSizedCategoryUserModel(10)
+	encoded, _ := EncodeCategoryUserModel(userModel, EncodingGzipJSON)
+	dao := &CategoryDAO{UserID: "testuser1", CategorySortKey: userModel.ID, CategoryUserModelData: encoded[:len(encoded)/2], ChunkCount: 1}
+	dao.Populate()
+	if dao.DecodeError() != nil || dao.ID != "" {
+		t.Errorf("Expected chunked model to be left undecoded, received err: %v, id: %v", dao.DecodeError(), dao.ID)
+	}
+	dao.CategoryUserModelData = encoded
+	dao.decode()
+	if dao.DecodeError() != nil || dao.ID != userModel.ID {
+		t.Errorf("Expected assembled model to decode, received err: %v, id: %v", dao.DecodeError(), dao.ID)
+	}
+
+	//the model item read with the chunks carries the version and size of the write that stored them
+	dao = &CategoryDAO{UserID: "testuser1", CategorySortKey: userModel.ID, ChunkCount: 1, ModelVersion: 4, StoredBytes: 10}
+	attributes, err := dynamodbattribute.MarshalMap(dao)
+	if err != nil {
+		t.Fatalf("Marshal failed with: %v", err)
+	}
+	head := categoryChunkItem{}
+	err = dynamodbattribute.UnmarshalMap(attributes, &head)
+	if err != nil || head.ModelVersion != 4 || head.StoredBytes != 10 || head.ChunkCount != 1 {
+		t.Errorf("Expected the model item's read state, received: %+v, %v", head, err)
+	}
+}
+
+func TestCategoryTransactionSize(t *testing.T) {
+	configMap := repository.NewBasicConfig("categoryDatabase")
+	configMap.AddEntry("table", "category")
+	configMap.AddEntry("hashKeyName", "CategoryHashKey")
+	configMap.AddEntry("sortKeyName", "CategorySortKey")
+	repo := &CategoryRepository{config: configMap, chunkBytes: DefaultChunkBytes}
+
+	//refused before anything is written, 12 chunks is under the item limit but not the byte limit
+	data := make([]byte, maxTransactionBytes)
+	rand.Read(data)
+	dao := &CategoryDAO{UserID: "testuser1", CategoryUserModelData: data}
+	dao.ID = "model1"
+	err := repo.write(context.Background(), dao, nil)
+	var tooLarge *CategoryTooLargeError
+	if !errors.As(err, &tooLarge) || !errors.Is(err, ErrCategoryTooLarge) || tooLarge.Bytes <= maxTransactionBytes || tooLarge.Items != 12 {
+		t.Fatalf("Expected the model to be too large, received: %v", err)
+	}
+
+	//the limit counts keys and attribute names, not only the data
+	put := func(data []byte) *awsDynamoDB.TransactWriteItem {
+		return &awsDynamoDB.TransactWriteItem{Put: &awsDynamoDB.Put{Item: map[string]*awsDynamoDB.AttributeValue{
+			"CategorySortKey":       {S: aws.String("model1")},
+			"CategoryUserModelData": {B: data},
+		}}}
+	}
+	overhead := len("CategorySortKey") + len("model1") + len("CategoryUserModelData")
+	items := []*awsDynamoDB.TransactWriteItem{put(make([]byte, maxTransactionBytes-overhead))}
+	if err = checkTransactionSize(dao, items); err != nil {
+		t.Errorf("Expected a write at the limit to fit, received: %v", err)
+	}
+	items = []*awsDynamoDB.TransactWriteItem{put(make([]byte, maxTransactionBytes-overhead+1))}
+	if err = checkTransactionSize(dao, items); !errors.Is(err, ErrCategoryTooLarge) {
+		t.Errorf("Expected a write over the limit to be refused, received: %v", err)
+	}
+	items = nil
+	for len(items) <= maxTransactionItems {
+		items = append(items, put(nil))
+	}
+	if err = checkTransactionSize(dao, items); !errors.Is(err, ErrCategoryTooLarge) {
+		t.Errorf("Expected too many items to be refused, received: %v", err)
+	}
+
+	nested := &awsDynamoDB.AttributeValue{M: map[string]*awsDynamoDB.AttributeValue{
+		"a": {L: []*awsDynamoDB.AttributeValue{{S: aws.String("xy")}, {N: aws.String("10")}}},
+		"b": {BOOL: aws.Bool(true)},
+	}}
+	//3 + 2 entries + "a" (3 + 2 elements + 2 + 2) + "b" (1)
+	if size := valueBytes(nested); size != 3+2+1+9+1+1 {
+		t.Errorf("Unexpected nested size: %v", size)
+	}
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	awsSession "github.com/aws/aws-sdk-go/aws/session"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/suared/core/repository"
+)
+
+//newDynamoClient - the core library session is internal to it, calls it does not support yet (transactions, paging, conditional writes) use a client built from the same config
+func newDynamoClient(config repository.Config) *awsDynamoDB.DynamoDB {
+	values := config.Values()
+	awsConfig := aws.Config{Region: aws.String(values["region"]), Endpoint: aws.String(values["endpoint"])}
+	awsSess := awsSession.Must(awsSession.NewSessionWithOptions(awsSession.Options{Config: awsConfig}))
+	return awsDynamoDB.New(awsSess)
+}
+
+//itemKey - returns the dynamo key for the given hash and sort key values
+func (repo *CategoryRepository) itemKey(hashKey string, sortKey string) map[string]*awsDynamoDB.AttributeValue {
+	values := repo.config.Values()
+	return map[string]*awsDynamoDB.AttributeValue{
+		values["hashKeyName"]: {S: aws.String(hashKey)},
+		values["sortKeyName"]: {S: aws.String(sortKey)},
+	}
+}
+
+//tableName - the configured table
+func (repo *CategoryRepository) tableName() *string {
+	return aws.String(repo.config.Values()["table"])
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/suared/core/ziptools"
+)
+
+/*
+Stored category blobs are wrapped in a small envelope so the encoding can change without breaking existing items:
+
+	byte 0  - categoryBlobMagic
+	byte 1  - envelope format version (categoryBlobVersion)
+	byte 2  - CategoryEncoding of the payload
+	byte 3+ - payload
+
+Items written before the envelope existed are plain gzipped JSON and are detected by the gzip header.  Writes always use the
+repository encoding so older items are migrated the next time they are written, see CategoryRepository MigrateOne to force it.
+Every encoding holds the whole CategoryUserModel
+*/
+
+//CategoryEncoding - Identifies how the category model payload is stored
+type CategoryEncoding byte
+
+const (
+	//EncodingLegacy - gzipped JSON without an envelope, read only
+	EncodingLegacy CategoryEncoding = 0
+	//EncodingGzipJSON - gzipped JSON, the original storage representation
+	EncodingGzipJSON CategoryEncoding = 1
+	//EncodingCBOR - CBOR (RFC 8949) with the JSON field names, faster to encode and decode than JSON
+	EncodingCBOR CategoryEncoding = 2
+	//EncodingGzipCBOR - gzipped CBOR, smallest for large trees
+	EncodingGzipCBOR CategoryEncoding = 3
+)
+
+const (
+	categoryBlobMagic   byte = 0xC7
+	categoryBlobVersion byte = 1
+	gzipMagic0          byte = 0x1f
+	gzipMagic1          byte = 0x8b
+)
+
+//DynamoItemLimit - DynamoDB maximum item size in bytes including attribute names
+const DynamoItemLimit = 400 * 1024
+
+//encodingNames - the config names of the write encodings
+var encodingNames = map[string]CategoryEncoding{
+	"gzip-json": EncodingGzipJSON,
+	"cbor":      EncodingCBOR,
+	"gzip-cbor": EncodingGzipCBOR,
+}
+
+func (encoding CategoryEncoding) String() string {
+	switch encoding {
+	case EncodingLegacy:
+		return "legacy"
+	case EncodingGzipJSON:
+		return "gzip-json"
+	case EncodingCBOR:
+		return "cbor"
+	case EncodingGzipCBOR:
+		return "gzip-cbor"
+	}
+	return fmt.Sprintf("unknown(%d)", byte(encoding))
+}
+
+//cborDecoding - trees nest two CBOR levels per category level, well past the default limit of 32
+var cborDecoding = func() cbor.DecMode {
+	decoding, err := cbor.DecOptions{MaxNestedLevels: 65535, MaxArrayElements: 1<<31 - 1, MaxMapPairs: 1<<31 - 1}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return decoding
+}()
+
+//CategoryEncodingFromName - returns the encoding for a config name (gzip-json, cbor or gzip-cbor).  Empty defaults to gzip-json
+func CategoryEncodingFromName(name string) (CategoryEncoding, error) {
+	if name == "" {
+		return EncodingGzipJSON, nil
+	}
+	encoding, ok := encodingNames[name]
+	if !ok {
+		return 0, fmt.Errorf("Unknown category storage encoding: %v", name)
+	}
+	return encoding, nil
+}
+
+//EncodeCategoryUserModel - returns the enveloped storage representation of the model
+func EncodeCategoryUserModel(userModel CategoryUserModel, encoding CategoryEncoding) ([]byte, error) {
+	var payload []byte
+	var err error
+	switch encoding {
+	case EncodingGzipJSON:
+		payload, err = gzipJSON(userModel)
+	case EncodingCBOR:
+		payload, err = cbor.Marshal(userModel)
+	case EncodingGzipCBOR:
+		payload, err = cbor.Marshal(userModel)
+		if err == nil {
+			var buf bytes.Buffer
+			err = ziptools.GetGzipData(&buf, payload)
+			payload = buf.Bytes()
+		}
+	default:
+		err = fmt.Errorf("Unsupported category storage encoding for writes: %v", encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{categoryBlobMagic, categoryBlobVersion, byte(encoding)}, payload...), nil
+}
+
+//DecodeCategoryUserModel - returns the model from the storage representation along with the encoding that was found
+func DecodeCategoryUserModel(data []byte) (CategoryUserModel, CategoryEncoding, error) {
+	userModel := CategoryUserModel{}
+	if len(data) >= 2 && data[0] == gzipMagic0 && data[1] == gzipMagic1 {
+		err := gunzipJSON(data, &userModel)
+		return userModel, EncodingLegacy, err
+	}
+	if len(data) < 3 || data[0] != categoryBlobMagic {
+		return userModel, EncodingLegacy, fmt.Errorf("Category data is not a recognized format, length: %v", len(data))
+	}
+	if data[1] != categoryBlobVersion {
+		return userModel, EncodingLegacy, fmt.Errorf("Category data envelope version %v is not supported", data[1])
+	}
+	encoding := CategoryEncoding(data[2])
+	payload := data[3:]
+
+	var err error
+	switch encoding {
+	case EncodingGzipJSON:
+		err = gunzipJSON(payload, &userModel)
+	case EncodingCBOR:
+		err = decodeCBOR(payload, &userModel)
+	case EncodingGzipCBOR:
+		var buf bytes.Buffer
+		err = ziptools.GetGunzipData(&buf, payload)
+		if err == nil {
+			err = decodeCBOR(buf.Bytes(), &userModel)
+		}
+	default:
+		err = fmt.Errorf("Category data encoding %v is not supported", encoding)
+	}
+	return userModel, encoding, err
+}
+
+func gzipJSON(userModel CategoryUserModel) ([]byte, error) {
+	data, err := json.Marshal(userModel)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = ziptools.GetGzipData(&buf, data)
+	return buf.Bytes(), err
+}
+
+func gunzipJSON(data []byte, userModel *CategoryUserModel) error {
+	var buf bytes.Buffer
+	err := ziptools.GetGunzipData(&buf, data)
+	if err != nil {
+		return fmt.Errorf("unable to unzip: %v", err)
+	}
+	err = json.Unmarshal(buf.Bytes(), userModel)
+	if err != nil {
+		return fmt.Errorf("unable to unmarshal: %v", err)
+	}
+	return nil
+}
+
+func decodeCBOR(data []byte, userModel *CategoryUserModel) error {
+	err := cborDecoding.Unmarshal(data, userModel)
+	if err != nil {
+		return fmt.Errorf("unable to unmarshal: %v", err)
+	}
+	return nil
+}
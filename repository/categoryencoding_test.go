@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/suared/core-apiuser/model"
+
+	"github.com/suared/core/ziptools"
+)
+
+//getSizedCategoryUserModel - builds a tree with the given number of categories, 8 children per category
+func getSizedCategoryUserModel(size int) CategoryUserModel {
+	userModel := NewCategoryUserModel("Sized")
+	var queue []*model.Category
+	for count := 0; count < size; count++ {
+		cat := model.NewCategory(fmt.Sprintf("Category title number %v", count))
+		if count < 8 {
+			queue = append(queue, userModel.AddChild(*cat))
+			continue
+		}
+		parent := queue[(count-8)/8]
+		queue = append(queue, parent.AddChild(*cat))
+	}
+	return *userModel
+}
+
+func TestCategoryEncodingRoundTrip(t *testing.T) {
+	userModel := getSizedCategoryUserModel(200)
+	userModel.TitlePolicy = model.CategoryTitlePolicy{Uniqueness: model.TitlesSuffix, IgnoreCase: true}
+	userModel.Limits = model.CategoryLimits{MaxDepth: 4}
+
+	for _, encoding := range []CategoryEncoding{EncodingGzipJSON, EncodingCBOR, EncodingGzipCBOR} {
+		data, err := EncodeCategoryUserModel(userModel, encoding)
+		if err != nil {
+			t.Errorf("Encode %v failed with: %v", encoding, err)
+			continue
+		}
+		decoded, found, err := DecodeCategoryUserModel(data)
+		if err != nil {
+			t.Errorf("Decode %v failed with: %v", encoding, err)
+			continue
+		}
+		if found != encoding {
+			t.Errorf("Expected encoding %v to be detected, received: %v", encoding, found)
+		}
+		if !decoded.Equals(&userModel.CategoryRoot) || decoded.TitlePolicy != userModel.TitlePolicy || decoded.Limits != userModel.Limits {
+			t.Errorf("Round trip for %v not equal", encoding)
+		}
+	}
+
+	//the legacy encoding is read only
+	if _, err := EncodeCategoryUserModel(userModel, EncodingLegacy); err == nil {
+		t.Error("Expected the legacy encoding to be refused for writes")
+	}
+
+	//items written before the envelope are plain gzipped json
+	decoded, found, err := DecodeCategoryUserModel(ziptools.GetGzipDataFromStruct(userModel))
+	if err != nil || found != EncodingLegacy || !decoded.Equals(&userModel.CategoryRoot) {
+		t.Errorf("Expected legacy data to decode, encoding: %v, err: %v", found, err)
+	}
+
+	corrupt := [][]byte{
+		nil,
+		[]byte("not a category"),
+		{categoryBlobMagic, categoryBlobVersion + 1, byte(EncodingGzipJSON)},
+		{categoryBlobMagic, categoryBlobVersion, 99},
+		{categoryBlobMagic, categoryBlobVersion, byte(EncodingCBOR), 0xa1, 0x62, 'i', 'd'},
+		{gzipMagic0, gzipMagic1, 0},
+	}
+	for i := range corrupt {
+		_, _, err = DecodeCategoryUserModel(corrupt[i])
+		if err == nil {
+			t.Errorf("Expected error decoding corrupt data %v: %v", i, corrupt[i])
+		}
+	}
+
+	_, err = CategoryEncodingFromName("xml")
+	if err == nil {
+		t.Error("Expected error for unknown encoding name")
+	}
+}
+
+//BenchmarkCategoryEncoding - compares encode time and stored size (bytes/item and percent of the Dynamo item limit) across encodings
+//Run with: go test -run XXX -bench CategoryEncoding
+func BenchmarkCategoryEncoding(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		userModel := getSizedCategoryUserModel(size)
+		for _, encoding := range []CategoryEncoding{EncodingGzipJSON, EncodingCBOR, EncodingGzipCBOR} {
+			b.Run(fmt.Sprintf("%v/%v", encoding, size), func(b *testing.B) {
+				var data []byte
+				for i := 0; i < b.N; i++ {
+					data, _ = EncodeCategoryUserModel(userModel, encoding)
+				}
+				b.ReportMetric(float64(len(data)), "bytes/item")
+				b.ReportMetric(100*float64(len(data))/DynamoItemLimit, "%limit")
+			})
+		}
+	}
+}
+
+func BenchmarkCategoryDecoding(b *testing.B) {
+	userModel := getSizedCategoryUserModel(1000)
+	for _, encoding := range []CategoryEncoding{EncodingGzipJSON, EncodingCBOR, EncodingGzipCBOR} {
+		data, _ := EncodeCategoryUserModel(userModel, encoding)
+		b.Run(encoding.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				DecodeCategoryUserModel(data)
+			}
+		})
+	}
+}
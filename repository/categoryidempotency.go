@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/suared/core/repository/dynamodb"
+	"github.com/suared/core/security"
+)
+
+//idempotencySortKeyPrefix - namespace for Idempotency-Key records, idempotency#<key> in the calling user's partition
+const idempotencySortKeyPrefix = "idempotency" + categoryKeySeparator
+
+//DefaultIdempotencyTTL - how long a key replays its response when PROCESS_CATEGORY_IDEMPOTENCY_TTL is not set
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+//DefaultIdempotencyLease - how long a reserved key stays in progress when PROCESS_CATEGORY_IDEMPOTENCY_LEASE is not set, a few seconds
+//past the 15s server write timeout
+const DefaultIdempotencyLease = 20 * time.Second
+
+//MaxIdempotencyKeyLength - longer keys are refused, they are stored in the sort key
+const MaxIdempotencyKeyLength = 255
+
+//ErrIdempotencyKeyReused - the key was used with a different request, the stored response is not for this one
+var ErrIdempotencyKeyReused = errors.New("Idempotency-Key was already used with a different request")
+
+//ErrIdempotencyInProgress - the first request with the key has not finished, retry once it has
+var ErrIdempotencyInProgress = errors.New("A request with this Idempotency-Key is still in progress")
+
+//ErrInvalidIdempotencyKey - the key is empty or too long
+var ErrInvalidIdempotencyKey = errors.New("Idempotency-Key must be 1 to 255 characters")
+
+//ErrIdempotencyLeaseLost - the reservation's lease ended and a retry took the key over, its response is the one stored
+var ErrIdempotencyLeaseLost = errors.New("Idempotency-Key was taken over by a retry after its lease ended")
+
+//CategoryIdempotencyDAO - the response to a change made with an Idempotency-Key.  Status is 0 while the change is in progress, until
+//LockedUntil when a retry of the request can take the key over as the first request stopped without finishing
+type CategoryIdempotencyDAO struct {
+	CategoryHashKey string
+	CategorySortKey string
+	UserID          string
+
+	Key string
+	//RequestHash - identifies the request the key was first used with, see the api for what it covers
+	RequestHash string
+	Status      int
+	Header      map[string]string `json:",omitempty"`
+	Body        []byte            `json:",omitempty"`
+	CreatedAt   time.Time
+	//ExpiresAt - epoch seconds, the table TTL attribute removes the record after the window
+	ExpiresAt int64
+	//LockedUntil - epoch milliseconds, the end of the in progress lease.  Identifies the reservation holding the key
+	LockedUntil int64 `json:",omitempty"`
+}
+
+//HashKey - This is the value that would be set as the dynamo hashkey
+func (dao *CategoryIdempotencyDAO) HashKey() string {
+	return dao.CategoryHashKey
+}
+
+//SortKey - This is the value that would be set as the dynamo sortKey
+func (dao *CategoryIdempotencyDAO) SortKey() string {
+	return dao.CategorySortKey
+}
+
+//User - the user that sent the request
+func (dao *CategoryIdempotencyDAO) User() string {
+	return dao.UserID
+}
+
+//New - creates a new instance of this specific type to support return values of the right type
+func (dao *CategoryIdempotencyDAO) New() dynamodb.DAO {
+	return new(CategoryIdempotencyDAO)
+}
+
+//Refresh - updates the Hashkey and SortKey.  Used by the library before calls
+func (dao *CategoryIdempotencyDAO) Refresh() {
+	dao.CategoryHashKey = "category_" + dao.UserID
+	dao.CategorySortKey = idempotencySortKeyPrefix + dao.Key
+}
+
+//Populate - nothing to calculate, the response is stored as is
+func (dao *CategoryIdempotencyDAO) Populate() {
+}
+
+//Completed - true once the response is stored and can be replayed
+func (dao *CategoryIdempotencyDAO) Completed() bool {
+	return dao.Status != 0
+}
+
+//expired - the TTL can take a day or two to remove the item
+func (dao *CategoryIdempotencyDAO) expired(now time.Time) bool {
+	return dao.ExpiresAt <= now.Unix()
+}
+
+//ReserveIdempotencyKey - Claims the calling user's key for the request.  Returns the reservation, not Completed, when the key is new, expired,
+//or left in progress past its lease by the same request, and the change should be made.  Returns the stored record to replay when the key
+//completed for the same request, ErrIdempotencyInProgress while it is running and ErrIdempotencyKeyReused when it was used with another request
+func (repo *CategoryRepository) ReserveIdempotencyKey(ctx context.Context, key string, requestHash string) (*CategoryIdempotencyDAO, error) {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return nil, ErrInvalidIdempotencyKey
+	}
+	now := time.Now().UTC()
+	record := &CategoryIdempotencyDAO{
+		UserID:      security.GetAuth(ctx).GetUser(),
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(repo.idempotencyTTL).Unix(),
+		LockedUntil: unixMilli(now.Add(repo.idempotencyLease)),
+	}
+	record.Refresh()
+	item, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		return nil, err
+	}
+	//an expired lease is taken over with the same write, so only one retry makes the change
+	_, err = repo.client.PutItemWithContext(ctx, &awsDynamoDB.PutItemInput{
+		TableName: repo.tableName(),
+		Item:      item,
+		ConditionExpression: aws.String("attribute_not_exists(#sortKey) OR ExpiresAt <= :now OR " +
+			"(#status = :inProgress AND RequestHash = :requestHash AND LockedUntil <= :nowMilli)"),
+		ExpressionAttributeNames: map[string]*string{"#sortKey": aws.String(repo.config.Values()["sortKeyName"]), "#status": aws.String("Status")},
+		ExpressionAttributeValues: map[string]*awsDynamoDB.AttributeValue{
+			":now":         {N: aws.String(strconv.FormatInt(now.Unix(), 10))},
+			":nowMilli":    {N: aws.String(strconv.FormatInt(unixMilli(now), 10))},
+			":inProgress":  {N: aws.String("0")},
+			":requestHash": {S: aws.String(requestHash)},
+		},
+	})
+	if err == nil {
+		return record, nil
+	}
+	if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != awsDynamoDB.ErrCodeConditionalCheckFailedException {
+		return nil, fmt.Errorf("Category idempotency key reserve failed with: %v", err)
+	}
+
+	result, err := repo.client.GetItemWithContext(ctx, &awsDynamoDB.GetItemInput{
+		TableName:      repo.tableName(),
+		Key:            repo.itemKey(record.HashKey(), record.SortKey()),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Category idempotency key read failed with: %v", err)
+	}
+	stored := &CategoryIdempotencyDAO{}
+	err = dynamodbattribute.UnmarshalMap(result.Item, stored)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(result.Item) == 0 || stored.expired(now):
+		//removed or expired since the put, let the caller retry
+		return nil, ErrIdempotencyInProgress
+	case stored.RequestHash != requestHash:
+		return nil, ErrIdempotencyKeyReused
+	case !stored.Completed():
+		return nil, ErrIdempotencyInProgress
+	}
+	return stored, nil
+}
+
+//SaveIdempotencyResult - stores the response of a reservation so retries replay it until the key expires.  Returns ErrIdempotencyLeaseLost
+//when a retry took the key over after the lease, the retry's response is kept
+func (repo *CategoryRepository) SaveIdempotencyResult(ctx context.Context, reservation *CategoryIdempotencyDAO, status int, header map[string]string, body []byte) error {
+	now := time.Now().UTC()
+	record := &CategoryIdempotencyDAO{
+		UserID:      reservation.UserID,
+		Key:         reservation.Key,
+		RequestHash: reservation.RequestHash,
+		Status:      status,
+		Header:      header,
+		Body:        body,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(repo.idempotencyTTL).Unix(),
+	}
+	record.Refresh()
+	item, err := dynamodbattribute.MarshalMap(record)
+	if err != nil {
+		return err
+	}
+	_, err = repo.client.PutItemWithContext(ctx, &awsDynamoDB.PutItemInput{
+		TableName:                repo.tableName(),
+		Item:                     item,
+		ConditionExpression:      aws.String("#status = :inProgress AND LockedUntil = :lockedUntil"),
+		ExpressionAttributeNames: map[string]*string{"#status": aws.String("Status")},
+		ExpressionAttributeValues: map[string]*awsDynamoDB.AttributeValue{
+			":inProgress":  {N: aws.String("0")},
+			":lockedUntil": {N: aws.String(strconv.FormatInt(reservation.LockedUntil, 10))},
+		},
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == awsDynamoDB.ErrCodeConditionalCheckFailedException {
+		return ErrIdempotencyLeaseLost
+	}
+	if err != nil {
+		return fmt.Errorf("Category idempotency result save failed with: %v", err)
+	}
+	return nil
+}
+
+//DeleteIdempotencyKey - releases a reserved key so a retry makes the change, used when the change failed without a response worth replaying
+func (repo *CategoryRepository) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	record := &CategoryIdempotencyDAO{UserID: security.GetAuth(ctx).GetUser(), Key: key}
+	record.Refresh()
+	return dynamodb.Delete(ctx, repo, record)
+}
@@ -9,6 +9,21 @@ import (
 //CategoryUserModel - repository model object to enable future non-direct model adds where appropriate. Intentionally saving/ enabling only this tier for customizations thus far
 type CategoryUserModel struct {
 	model.CategoryRoot
+	//TitlePolicy - sibling title uniqueness, enforced by the service on changes
+	TitlePolicy model.CategoryTitlePolicy `json:"titlePolicy"`
+	//Limits - the model's own size limits, applied within the deployment limits
+	Limits model.CategoryLimits `json:"limits"`
+	//Version - the stored version the model was read at, bumped by every write.  A write of a model read at an older version fails with ErrCategoryConflict
+	Version int64 `json:"version,omitempty"`
+	//stored - the stored item as the model was read, nil for a model not read from the repository
+	stored *categoryStoredState
+}
+
+//Replaces - makes this model the replacement of current, the stored model read with SelectForUpdate.  Updating this model then fails
+//with ErrCategoryConflict if the stored model changed since current was read, an empty current means the model must not exist yet
+func (userModel *CategoryUserModel) Replaces(current CategoryUserModel) {
+	userModel.Version = current.Version
+	userModel.stored = current.stored
 }
 
 //NewCategoryUserModel - initializes the user model
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+/*
+Model items set CategoryModelKey to their hash key, the sparse model index is keyed on it so it holds models only:
+
+	CategoryModels  - CategoryModelKey (hash), CategorySortKey (range), keys only
+
+Listing queries the index for the keys and reads the items with BatchGetItem, so the outbox, audit, webhook and other items sharing
+the user's hash key are never read.  Only keys are projected to keep the index's storage and writes small.
+
+Models written before the index have no CategoryModelKey until they are written again, so listing only uses the index once
+PROCESS_CATEGORY_MODEL_INDEX=true.  Until then, or while the index is not active when the repository starts, listing queries the whole
+partition as before.  Rolling it out to a table with models in it:
+
+	1. deploy with the index (infra/dev/db.tf) and PROCESS_CATEGORY_MODEL_INDEX=false, writes from then on set CategoryModelKey
+	2. run categoryctl index to add the models written before the deploy
+	3. set PROCESS_CATEGORY_MODEL_INDEX=true
+*/
+
+//CategoryModelIndex - the global secondary index over model items, created by infra/dev/db.tf and for development tables by the repository
+const CategoryModelIndex = "CategoryModels"
+
+//categoryModelKeyName - the index hash key, see CategoryDAO CategoryModelKey
+const categoryModelKeyName = "CategoryModelKey"
+
+//maxBatchGetKeys - Dynamo limit on the keys in one BatchGetItem, also the most models read per index query
+const maxBatchGetKeys = 100
+
+//checkModelIndex - true when listing can use the model index, it is off unless enabled as older models may be missing from it.
+//Development tables are created by the core library without it, it is added to them here
+func (repo *CategoryRepository) checkModelIndex(ctx context.Context) bool {
+	values := repo.config.Values()
+	if values["modelIndex"] != "true" {
+		return false
+	}
+	status, err := repo.modelIndexStatus(ctx)
+	if err == nil && status == "" && isDevelopmentEnv(values["env"]) {
+		err = repo.createModelIndex(ctx)
+		if err == nil {
+			status, err = repo.modelIndexStatus(ctx)
+		}
+	}
+	if err != nil || status != awsDynamoDB.IndexStatusActive {
+		log.Printf("Category model index %v is not active (status: %v, error: %v), models are listed from the whole partition", CategoryModelIndex, status, err)
+		return false
+	}
+	return true
+}
+
+//isDevelopmentEnv - the environments the core library creates tables in
+func isDevelopmentEnv(env string) bool {
+	return env == "" || env == "dev" || env == "development"
+}
+
+//modelIndexStatus - the index status, empty when the table has no model index
+func (repo *CategoryRepository) modelIndexStatus(ctx context.Context) (string, error) {
+	result, err := repo.client.DescribeTableWithContext(ctx, &awsDynamoDB.DescribeTableInput{TableName: repo.tableName()})
+	if err != nil {
+		return "", fmt.Errorf("Category table describe failed with: %v", err)
+	}
+	for _, index := range result.Table.GlobalSecondaryIndexes {
+		if aws.StringValue(index.IndexName) == CategoryModelIndex {
+			return aws.StringValue(index.IndexStatus), nil
+		}
+	}
+	return "", nil
+}
+
+func (repo *CategoryRepository) createModelIndex(ctx context.Context) error {
+	values := repo.config.Values()
+	rcu, _ := strconv.ParseInt(values["rcu"], 10, 64)
+	wcu, _ := strconv.ParseInt(values["wcu"], 10, 64)
+	_, err := repo.client.UpdateTableWithContext(ctx, &awsDynamoDB.UpdateTableInput{
+		TableName: repo.tableName(),
+		AttributeDefinitions: []*awsDynamoDB.AttributeDefinition{
+			{AttributeName: aws.String(values["hashKeyName"]), AttributeType: aws.String("S")},
+			{AttributeName: aws.String(values["sortKeyName"]), AttributeType: aws.String("S")},
+			{AttributeName: aws.String(categoryModelKeyName), AttributeType: aws.String("S")},
+		},
+		GlobalSecondaryIndexUpdates: []*awsDynamoDB.GlobalSecondaryIndexUpdate{{Create: &awsDynamoDB.CreateGlobalSecondaryIndexAction{
+			IndexName: aws.String(CategoryModelIndex),
+			KeySchema: []*awsDynamoDB.KeySchemaElement{
+				{AttributeName: aws.String(categoryModelKeyName), KeyType: aws.String("HASH")},
+				{AttributeName: aws.String(values["sortKeyName"]), KeyType: aws.String("RANGE")},
+			},
+			Projection:            &awsDynamoDB.Projection{ProjectionType: aws.String(awsDynamoDB.ProjectionTypeKeysOnly)},
+			ProvisionedThroughput: &awsDynamoDB.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(rcu), WriteCapacityUnits: aws.Int64(wcu)},
+		}}},
+	})
+	if err != nil {
+		return fmt.Errorf("Category model index create failed with: %v", err)
+	}
+	log.Printf("Created category model index %v on table: %v", CategoryModelIndex, values["table"])
+	return nil
+}
+
+//modelStartKey - the key a model query continues after, the index also needs its own hash key
+func (repo *CategoryRepository) modelStartKey(hashKey string, sortKey string) map[string]*awsDynamoDB.AttributeValue {
+	key := repo.itemKey(hashKey, sortKey)
+	if repo.modelIndex {
+		key[categoryModelKeyName] = &awsDynamoDB.AttributeValue{S: aws.String(hashKey)}
+	}
+	return key
+}
+
+//queryModels - reads up to limit items of the user's models after startKey, at most maxBatchGetKeys.  The items are not populated and
+//without the model index other item types are included, skip them with IsModelSortKey.  Returns the key to continue from, nil after the last items
+func (repo *CategoryRepository) queryModels(ctx context.Context, hashKey string, startKey map[string]*awsDynamoDB.AttributeValue, limit int) ([]*CategoryDAO, map[string]*awsDynamoDB.AttributeValue, error) {
+	if limit <= 0 || limit > maxBatchGetKeys {
+		limit = maxBatchGetKeys
+	}
+	input := &awsDynamoDB.QueryInput{
+		TableName:                 repo.tableName(),
+		KeyConditionExpression:    aws.String("#hashKey = :hashKey"),
+		ExpressionAttributeNames:  map[string]*string{"#hashKey": aws.String(repo.config.Values()["hashKeyName"])},
+		ExpressionAttributeValues: map[string]*awsDynamoDB.AttributeValue{":hashKey": {S: aws.String(hashKey)}},
+		ExclusiveStartKey:         startKey,
+		Limit:                     aws.Int64(int64(limit)),
+	}
+	if repo.modelIndex {
+		input.IndexName = aws.String(CategoryModelIndex)
+		input.ExpressionAttributeNames["#hashKey"] = aws.String(categoryModelKeyName)
+	}
+	result, err := repo.client.QueryWithContext(ctx, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Category model query failed with: %v", err)
+	}
+	items := result.Items
+	if repo.modelIndex {
+		items, err = repo.batchGetItems(ctx, items)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	daos := make([]*CategoryDAO, 0, len(items))
+	for i := range items {
+		dao := new(CategoryDAO)
+		err = dynamodbattribute.UnmarshalMap(items[i], dao)
+		if err != nil {
+			return nil, nil, err
+		}
+		daos = append(daos, dao)
+	}
+	return daos, result.LastEvaluatedKey, nil
+}
+
+//batchGetItems - the table items of the index keys in the same order, items removed since the index was read are left out.
+//Keys Dynamo leaves unprocessed are read again after a short wait
+func (repo *CategoryRepository) batchGetItems(ctx context.Context, indexKeys []map[string]*awsDynamoDB.AttributeValue) ([]map[string]*awsDynamoDB.AttributeValue, error) {
+	if len(indexKeys) == 0 {
+		return nil, nil
+	}
+	values := repo.config.Values()
+	table := values["table"]
+	keys := make([]map[string]*awsDynamoDB.AttributeValue, 0, len(indexKeys))
+	for _, indexKey := range indexKeys {
+		keys = append(keys, repo.itemKey(aws.StringValue(indexKey[values["hashKeyName"]].S), aws.StringValue(indexKey[values["sortKeyName"]].S)))
+	}
+
+	found := map[string]map[string]*awsDynamoDB.AttributeValue{}
+	request := map[string]*awsDynamoDB.KeysAndAttributes{table: {Keys: keys}}
+	wait := 50 * time.Millisecond
+	for len(request) > 0 {
+		result, err := repo.client.BatchGetItemWithContext(ctx, &awsDynamoDB.BatchGetItemInput{RequestItems: request})
+		if err != nil {
+			return nil, fmt.Errorf("Category model batch read failed with: %v", err)
+		}
+		for _, item := range result.Responses[table] {
+			found[aws.StringValue(item[values["sortKeyName"]].S)] = item
+		}
+		request = result.UnprocessedKeys
+		if len(request) > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			wait *= 2
+		}
+	}
+
+	items := make([]map[string]*awsDynamoDB.AttributeValue, 0, len(found))
+	for _, key := range keys {
+		if item, ok := found[aws.StringValue(key[values["sortKeyName"]].S)]; ok {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+//IndexModel - adds a model item written before the model index to it, returns false when it already is.  The item is otherwise
+//unchanged, its version stays the same
+func (operator *CategoryOperator) IndexModel(ctx context.Context, dao *CategoryDAO) (bool, error) {
+	if dao.CategoryModelKey != "" || dao.HashKey() == "" || !IsModelSortKey(dao.SortKey()) {
+		return false, nil
+	}
+	repo := operator.repo
+	_, err := repo.client.UpdateItemWithContext(ctx, &awsDynamoDB.UpdateItemInput{
+		TableName:           repo.tableName(),
+		Key:                 repo.itemKey(dao.HashKey(), dao.SortKey()),
+		UpdateExpression:    aws.String("SET #modelKey = :hashKey"),
+		ConditionExpression: aws.String("attribute_exists(#sortKey)"),
+		ExpressionAttributeNames: map[string]*string{
+			"#modelKey": aws.String(categoryModelKeyName),
+			"#sortKey":  aws.String(repo.config.Values()["sortKeyName"]),
+		},
+		ExpressionAttributeValues: map[string]*awsDynamoDB.AttributeValue{":hashKey": {S: aws.String(dao.HashKey())}},
+	})
+	if conditionFailed(err) {
+		//removed since it was read
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("Category model index update failed with: %v", err)
+	}
+	dao.CategoryModelKey = dao.HashKey()
+	return true, nil
+}
@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core/repository/dynamodb"
+)
+
+/*
+Operator access for tools run with the table credentials, e.g. cmd/categoryctl.  The API authorizes each call against the signed in user,
+the operator reads and writes any user's models by user id so nothing in the api package may use it.  The service only uses it for the
+outbox relay, which runs without a signed in user.
+
+Operator changes keep the model as it was before the change so they can be rolled back:
+
+	version#<modelID>#<time>  - the stored model data before an operator change, kept for the audit retention
+
+Changes are written with their events in the outbox when PROCESS_CATEGORY_EVENT_OUTBOX is true, and bump the model version like API writes
+so caches holding the model read it again.
+*/
+
+//versionSortKeyPrefix - namespace for the models kept before operator changes
+const versionSortKeyPrefix = "version" + categoryKeySeparator
+
+//CategoryVersionDAO - The stored data of a model before an operator change.  The data is kept as stored so a model that could not be decoded can be kept too
+type CategoryVersionDAO struct {
+	CategoryHashKey string
+	CategorySortKey string
+	UserID          string
+
+	ModelID   string
+	VersionID string
+	Reason    string
+	ActorID   string
+	CreatedAt time.Time
+
+	CategoryUserModelData []byte
+	//ExpiresAt - epoch seconds, the table TTL attribute removes the version after the audit retention.  0 keeps it
+	ExpiresAt int64 `json:",omitempty"`
+}
+
+//HashKey - This is the value that would be set as the dynamo hashkey
+func (dao *CategoryVersionDAO) HashKey() string {
+	return dao.CategoryHashKey
+}
+
+//SortKey - This is the value that would be set as the dynamo sortKey
+func (dao *CategoryVersionDAO) SortKey() string {
+	return dao.CategorySortKey
+}
+
+//User - the owner of the model
+func (dao *CategoryVersionDAO) User() string {
+	return dao.UserID
+}
+
+//New - creates a new instance of this specific type to support return values of the right type
+func (dao *CategoryVersionDAO) New() dynamodb.DAO {
+	return new(CategoryVersionDAO)
+}
+
+//Refresh - updates the Hashkey and SortKey.  Used by the library before calls
+func (dao *CategoryVersionDAO) Refresh() {
+	dao.CategoryHashKey = "category_" + dao.UserID
+	dao.CategorySortKey = versionSortKeyPrefix + dao.ModelID + categoryKeySeparator + dao.VersionID
+}
+
+//Populate - nothing to calculate, the data is decoded on request with Model
+func (dao *CategoryVersionDAO) Populate() {
+}
+
+//Model - the model as it was, a *CategoryDecodeError when the data could not be decoded
+func (dao *CategoryVersionDAO) Model() (CategoryUserModel, error) {
+	modelDAO := &CategoryDAO{UserID: dao.UserID, CategorySortKey: dao.CategorySortKey, CategoryUserModelData: dao.CategoryUserModelData}
+	modelDAO.decode()
+	if decodeErr := modelDAO.DecodeError(); decodeErr != nil {
+		return CategoryUserModel{}, decodeErr
+	}
+	return modelDAO.CategoryUserModel, nil
+}
+
+//CategoryOperator - Reads and writes any user's models without the API's authorization, for tools run with the table credentials
+type CategoryOperator struct {
+	repo    *CategoryRepository
+	actorID string
+	//KeepVersions - store the model before each change, models too large for one item cannot be changed while it is set
+	KeepVersions bool
+}
+
+//NewCategoryOperator - the operator over the repository.  actorID is recorded on versions and the audit records of changes
+func NewCategoryOperator(repo *CategoryRepository, actorID string) *CategoryOperator {
+	return &CategoryOperator{repo: repo, actorID: actorID, KeepVersions: true}
+}
+
+//Limits - the deployment limits, see CategoryRepository Limits
+func (operator *CategoryOperator) Limits() model.CategoryLimits {
+	return operator.repo.Limits()
+}
+
+//Encoding - the encoding models are written in, see PROCESS_CATEGORY_STORAGE_ENCODING
+func (operator *CategoryOperator) Encoding() CategoryEncoding {
+	return operator.repo.encoding
+}
+
+//SelectModel - the user's model item, decoded when possible.  The dao model is empty if not found, check DecodeError before using it
+func (operator *CategoryOperator) SelectModel(ctx context.Context, userID string, modelID string) (*CategoryDAO, error) {
+	dao := &CategoryDAO{UserID: userID}
+	dao.ID = modelID
+	return operator.repo.readDAO(ctx, dao, true)
+}
+
+//SelectModels - all of the user's model items, including the ones that could not be decoded.  The whole partition is read so models
+//not in the model index yet are included, see IndexModel
+func (operator *CategoryOperator) SelectModels(ctx context.Context, userID string) ([]*CategoryDAO, error) {
+	result, err := dynamodb.Select(ctx, operator.repo, &CategoryDAO{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	var daos []*CategoryDAO
+	for i := range result {
+		dao, ok := result[i].(*CategoryDAO)
+		if !ok || !IsModelSortKey(dao.SortKey()) {
+			continue
+		}
+		if dao.ChunkCount > 0 {
+			err = operator.repo.assembleChunks(ctx, dao)
+			if err != nil {
+				return nil, err
+			}
+			if dao.ID == "" && dao.DecodeError() == nil {
+				continue
+			}
+		}
+		daos = append(daos, dao)
+	}
+	return daos, nil
+}
+
+//ScanModels - calls each with every model item in the table, decoded when possible.  Other item types are skipped
+func (operator *CategoryOperator) ScanModels(ctx context.Context, each func(dao *CategoryDAO) error) error {
+	repo := operator.repo
+	var startKey map[string]*awsDynamoDB.AttributeValue
+	for {
+		result, err := repo.client.ScanWithContext(ctx, &awsDynamoDB.ScanInput{
+			TableName:                 repo.tableName(),
+			FilterExpression:          aws.String("NOT contains(#sortKey, :separator)"),
+			ExpressionAttributeNames:  map[string]*string{"#sortKey": aws.String(repo.config.Values()["sortKeyName"])},
+			ExpressionAttributeValues: map[string]*awsDynamoDB.AttributeValue{":separator": {S: aws.String(categoryKeySeparator)}},
+			ExclusiveStartKey:         startKey,
+		})
+		if err != nil {
+			return fmt.Errorf("Category table scan failed with: %v", err)
+		}
+		for i := range result.Items {
+			dao := new(CategoryDAO)
+			err = dynamodbattribute.UnmarshalMap(result.Items[i], dao)
+			if err != nil {
+				return err
+			}
+			dao.Populate()
+			if dao.ChunkCount > 0 {
+				err = repo.assembleChunks(ctx, dao)
+				if err != nil {
+					return err
+				}
+				if dao.ID == "" && dao.DecodeError() == nil {
+					continue
+				}
+			}
+			err = each(dao)
+			if err != nil {
+				return err
+			}
+		}
+		startKey = result.LastEvaluatedKey
+		if len(startKey) == 0 {
+			return nil
+		}
+	}
+}
+
+//Save - stores the user's model, keeping the stored model as a version first when KeepVersions is set.  Events are stored in the audit log
+//and the outbox with the operator as the actor, a model replaced event when none are given, so clients see the change once relayed.  A model read with SelectModel is only saved while the stored model is unchanged, otherwise ErrCategoryConflict
+//is returned.  Any other model, e.g. a restored one, replaces whatever is stored
+func (operator *CategoryOperator) Save(ctx context.Context, userID string, userModel CategoryUserModel, reason string, events ...model.CategoryEvent) error {
+	if userModel.ID == "" || !IsModelSortKey(userModel.ID) {
+		return fmt.Errorf("Category model id is required and cannot contain %v, received: %v", categoryKeySeparator, userModel.ID)
+	}
+	current, err := operator.SelectModel(ctx, userID, userModel.ID)
+	if err != nil {
+		return err
+	}
+	if userModel.stored == nil {
+		userModel.Version = current.ModelVersion
+		userModel.stored = storedState(current)
+	}
+	if operator.KeepVersions {
+		_, err = operator.keepVersion(ctx, current, reason)
+		if err != nil {
+			return err
+		}
+	}
+	dao, err := operator.repo.modelDAO(userID, userModel, true, operator.repo.auditEnabled)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		events = []model.CategoryEvent{model.NewCategoryEvent(model.CategoryModelReplaced, userID, userModel.ID)}
+	}
+	for i := range events {
+		if events[i].ActorID == "" {
+			events[i].ActorID = operator.actorID
+		}
+	}
+	return operator.repo.write(ctx, dao, events)
+}
+
+//Migrate - rewrites a model item read by the operator in the current encoding.  Returns false when it already is.  The rewrite is
+//published as a model replaced event through the outbox, the model is unchanged but its version is not
+func (operator *CategoryOperator) Migrate(ctx context.Context, dao *CategoryDAO) (bool, error) {
+	if decodeErr := dao.DecodeError(); decodeErr != nil {
+		return false, decodeErr
+	}
+	if dao.ID == "" || dao.StoredEncoding() == operator.repo.encoding {
+		return false, nil
+	}
+	data, err := EncodeCategoryUserModel(dao.CategoryUserModel, operator.repo.encoding)
+	if err != nil {
+		return false, fmt.Errorf("Unable to encode category model: %v", err)
+	}
+	dao.CategoryUserModelData = data
+	event := model.NewCategoryEvent(model.CategoryModelReplaced, dao.UserID, dao.ID)
+	event.ActorID = operator.actorID
+	return true, operator.repo.write(ctx, dao, []model.CategoryEvent{event})
+}
+
+//keepVersion - stores the model item read as current as a version, nil when there is no stored model
+func (operator *CategoryOperator) keepVersion(ctx context.Context, current *CategoryDAO, reason string) (*CategoryVersionDAO, error) {
+	userID, modelID := current.UserID, current.SortKey()
+	if len(current.CategoryUserModelData) == 0 {
+		return nil, nil
+	}
+	if len(current.CategoryUserModelData) > operator.repo.chunkBytes {
+		return nil, fmt.Errorf("Category model %v is %v bytes, too large to keep as a version.  Export it and change it without keeping versions", modelID, len(current.CategoryUserModelData))
+	}
+	now := time.Now().UTC()
+	version := &CategoryVersionDAO{
+		UserID:                userID,
+		ModelID:               modelID,
+		VersionID:             now.Format(auditTimeFormat),
+		Reason:                reason,
+		ActorID:               operator.actorID,
+		CreatedAt:             now,
+		CategoryUserModelData: current.CategoryUserModelData,
+	}
+	if operator.repo.auditRetention > 0 {
+		version.ExpiresAt = now.Add(operator.repo.auditRetention).Unix()
+	}
+	return version, dynamodb.InsertOrUpdate(ctx, operator.repo, version)
+}
+
+//SelectVersions - the versions kept of the user's model, newest first
+func (operator *CategoryOperator) SelectVersions(ctx context.Context, userID string, modelID string) ([]*CategoryVersionDAO, error) {
+	var versions []*CategoryVersionDAO
+	now := time.Now()
+	err := operator.repo.queryPrefix(ctx, userID, versionSortKeyPrefix+modelID+categoryKeySeparator, true, 0, func(item map[string]*awsDynamoDB.AttributeValue) error {
+		version := new(CategoryVersionDAO)
+		err := dynamodbattribute.UnmarshalMap(item, version)
+		if err == nil && (version.ExpiresAt == 0 || version.ExpiresAt > now.Unix()) {
+			versions = append(versions, version)
+		}
+		return err
+	})
+	return versions, err
+}
+
+//SelectVersion - one version of the user's model, nil if there is no such version
+func (operator *CategoryOperator) SelectVersion(ctx context.Context, userID string, modelID string, versionID string) (*CategoryVersionDAO, error) {
+	version := &CategoryVersionDAO{UserID: userID, ModelID: modelID, VersionID: versionID}
+	version.Refresh()
+	result, err := operator.repo.client.GetItemWithContext(ctx, &awsDynamoDB.GetItemInput{
+		TableName:      operator.repo.tableName(),
+		Key:            operator.repo.itemKey(version.HashKey(), version.SortKey()),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Category version read failed with: %v", err)
+	}
+	if len(result.Item) == 0 {
+		return nil, nil
+	}
+	err = dynamodbattribute.UnmarshalMap(result.Item, version)
+	return version, err
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/suared/core-apiuser/model"
+)
+
+func TestCategoryVersionModel(t *testing.T) {
+	userModel := getSizedCategoryUserModel(20)
+	userModel.TitlePolicy = model.CategoryTitlePolicy{Uniqueness: model.TitlesSuffix}
+	data, err := EncodeCategoryUserModel(userModel, EncodingCBOR)
+	if err != nil {
+		t.Fatalf("Encode failed with: %v", err)
+	}
+	version := &CategoryVersionDAO{UserID: "testuser1", ModelID: userModel.ID, VersionID: "2026-10-19T10:00:00.000000000Z", CategoryUserModelData: data}
+	version.Refresh()
+	if version.HashKey() != "category_testuser1" || version.SortKey() != "version#"+userModel.ID+"#2026-10-19T10:00:00.000000000Z" || IsModelSortKey(version.SortKey()) {
+		t.Errorf("Expected the version in its own sort key namespace, received: %v %v", version.HashKey(), version.SortKey())
+	}
+
+	restored, err := version.Model()
+	if err != nil || !restored.CategoryRoot.Equals(&userModel.CategoryRoot) || restored.TitlePolicy != userModel.TitlePolicy {
+		t.Errorf("Expected the version to decode with its title policy, received: %v", err)
+	}
+
+	version.CategoryUserModelData = []byte("not a model")
+	var decodeErr *CategoryDecodeError
+	if _, err = version.Model(); !errors.As(err, &decodeErr) {
+		t.Errorf("Expected a decode error for a version that cannot be decoded, received: %v", err)
+	}
+}
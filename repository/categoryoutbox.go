@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core/repository/dynamodb"
+)
+
+//outboxSortKeyPrefix - namespace for events written with a model change and not yet published.  Event ids are time ordered so the outbox reads oldest first
+const outboxSortKeyPrefix = "outbox" + categoryKeySeparator
+
+//CategoryOutboxDAO - An event stored in the same transaction as the model change that caused it
+type CategoryOutboxDAO struct {
+	CategoryHashKey string
+	CategorySortKey string
+	UserID          string
+
+	Event model.CategoryEvent
+}
+
+//HashKey - This is the value that would be set as the dynamo hashkey
+func (dao *CategoryOutboxDAO) HashKey() string {
+	return dao.CategoryHashKey
+}
+
+//SortKey - This is the value that would be set as the dynamo sortKey
+func (dao *CategoryOutboxDAO) SortKey() string {
+	return dao.CategorySortKey
+}
+
+//User - the user whose model changed
+func (dao *CategoryOutboxDAO) User() string {
+	return dao.UserID
+}
+
+//New - creates a new instance of this specific type to support return values of the right type
+func (dao *CategoryOutboxDAO) New() dynamodb.DAO {
+	return new(CategoryOutboxDAO)
+}
+
+//Refresh - updates the Hashkey and SortKey.  Used by the library before calls
+func (dao *CategoryOutboxDAO) Refresh() {
+	dao.CategoryHashKey = "category_" + dao.UserID
+	dao.CategorySortKey = outboxSortKeyPrefix + dao.Event.ID
+}
+
+//Populate - nothing to calculate, the event is stored as is
+func (dao *CategoryOutboxDAO) Populate() {
+}
+
+//outboxItems - transaction puts for the events, added to the model write
+func (repo *CategoryRepository) outboxItems(userID string, events []model.CategoryEvent) ([]*awsDynamoDB.TransactWriteItem, error) {
+	var items []*awsDynamoDB.TransactWriteItem
+	for i := range events {
+		outboxDAO := &CategoryOutboxDAO{UserID: userID, Event: events[i]}
+		outboxDAO.Refresh()
+		put, err := dynamodbattribute.MarshalMap(outboxDAO)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &awsDynamoDB.TransactWriteItem{Put: &awsDynamoDB.Put{TableName: repo.tableName(), Item: put}})
+	}
+	return items, nil
+}
+
+//SelectOutbox - Returns the calling user's unpublished events, oldest first
+func (repo *CategoryRepository) SelectOutbox(ctx context.Context) ([]model.CategoryEvent, error) {
+	var events []model.CategoryEvent
+	err := repo.queryPrefix(ctx, NewCategoryDAO(ctx).UserID, outboxSortKeyPrefix, false, 0, func(item map[string]*awsDynamoDB.AttributeValue) error {
+		outboxDAO := &CategoryOutboxDAO{}
+		err := dynamodbattribute.UnmarshalMap(item, outboxDAO)
+		if err != nil {
+			return err
+		}
+		err = dynamodb.ValidAction(ctx, "selectOutbox", outboxDAO)
+		if err != nil {
+			return err
+		}
+		events = append(events, outboxDAO.Event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+//DeleteOutbox - Removes published events from the outbox.  Events are stored with the model owner, editors clear the events of their changes
+func (repo *CategoryRepository) DeleteOutbox(ctx context.Context, events []model.CategoryEvent) error {
+	for i := range events {
+		outboxDAO := &CategoryOutboxDAO{UserID: events[i].UserID, Event: events[i]}
+		err := repo.authorize(ctx, "deleteOutbox", outboxDAO, events[i].ModelID, RoleEditor)
+		if err != nil {
+			return err
+		}
+		err = dynamodb.Delete(ctx, repo, outboxDAO)
+		if err != nil {
+			return fmt.Errorf("Unable to delete outbox event: %v, received: %v", events[i].ID, err)
+		}
+	}
+	return nil
+}
+
+//ScanOutbox - calls each with the events of every user left in the outbox that occurred before the time, oldest first per user.  The relay
+//passes a time before the requests still publishing their own events
+func (operator *CategoryOperator) ScanOutbox(ctx context.Context, before time.Time, each func(event model.CategoryEvent) error) error {
+	repo := operator.repo
+	var startKey map[string]*awsDynamoDB.AttributeValue
+	for {
+		result, err := repo.client.ScanWithContext(ctx, &awsDynamoDB.ScanInput{
+			TableName:                 repo.tableName(),
+			FilterExpression:          aws.String("begins_with(#sortKey, :prefix)"),
+			ExpressionAttributeNames:  map[string]*string{"#sortKey": aws.String(repo.config.Values()["sortKeyName"])},
+			ExpressionAttributeValues: map[string]*awsDynamoDB.AttributeValue{":prefix": {S: aws.String(outboxSortKeyPrefix)}},
+			ExclusiveStartKey:         startKey,
+		})
+		if err != nil {
+			return fmt.Errorf("Category outbox scan failed with: %v", err)
+		}
+		for i := range result.Items {
+			outboxDAO := &CategoryOutboxDAO{}
+			err = dynamodbattribute.UnmarshalMap(result.Items[i], outboxDAO)
+			if err != nil {
+				return err
+			}
+			if !outboxDAO.Event.OccurredAt.Before(before) {
+				continue
+			}
+			err = each(outboxDAO.Event)
+			if err != nil {
+				return err
+			}
+		}
+		startKey = result.LastEvaluatedKey
+		if len(startKey) == 0 {
+			return nil
+		}
+	}
+}
+
+//DeleteOutbox - removes relayed events of any user
+func (operator *CategoryOperator) DeleteOutbox(ctx context.Context, events []model.CategoryEvent) error {
+	for i := range events {
+		outboxDAO := &CategoryOutboxDAO{UserID: events[i].UserID, Event: events[i]}
+		outboxDAO.Refresh()
+		_, err := operator.repo.client.DeleteItemWithContext(ctx, &awsDynamoDB.DeleteItemInput{
+			TableName: operator.repo.tableName(),
+			Key:       operator.repo.itemKey(outboxDAO.HashKey(), outboxDAO.SortKey()),
+		})
+		if err != nil {
+			return fmt.Errorf("Unable to delete outbox event: %v, received: %v", events[i].ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//DefaultPageLimit - page size used when the caller does not set one
+const DefaultPageLimit = 20
+
+//MaxPageLimit - largest page size allowed, full models are returned so pages are kept small
+const MaxPageLimit = 100
+
+//ErrInvalidCursor - the cursor was not issued by SelectPage for this user
+var ErrInvalidCursor = errors.New("Invalid category page cursor")
+
+//CategoryPage - one page of models, Cursor is empty on the last page
+type CategoryPage struct {
+	Models []CategoryUserModel
+	//Skipped - items on this page that could not be decoded, see SelectWithSkipped
+	Skipped []*CategoryDecodeError
+	Cursor  string
+}
+
+//categoryCursor - the Dynamo LastEvaluatedKey, keys are always strings in this table
+type categoryCursor struct {
+	HashKey string `json:"h"`
+	SortKey string `json:"s"`
+}
+
+//encodeCursor - opaque to callers so the key layout can change without breaking clients
+func (repo *CategoryRepository) encodeCursor(lastKey map[string]*awsDynamoDB.AttributeValue) string {
+	if len(lastKey) == 0 {
+		return ""
+	}
+	values := repo.config.Values()
+	cursor := categoryCursor{
+		HashKey: aws.StringValue(lastKey[values["hashKeyName"]].S),
+		SortKey: aws.StringValue(lastKey[values["sortKeyName"]].S),
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+//decodeCursor - returns the start key for the page, nil for the first page.  Cursors for another user's hash key are rejected
+func (repo *CategoryRepository) decodeCursor(token string, hashKey string) (map[string]*awsDynamoDB.AttributeValue, error) {
+	if token == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	cursor := categoryCursor{}
+	err = json.Unmarshal(data, &cursor)
+	if err != nil || cursor.HashKey != hashKey || cursor.SortKey == "" {
+		return nil, ErrInvalidCursor
+	}
+	return repo.modelStartKey(cursor.HashKey, cursor.SortKey), nil
+}
+
+//SelectPage - Returns up to limit models for the user starting after the cursor.  Only model items are read when the model index is used,
+//otherwise items in other namespaces are read but do not count towards the limit
+func (repo *CategoryRepository) SelectPage(ctx context.Context, template CategoryUserModel, limit int, cursor string) (CategoryPage, error) {
+	if limit <= 0 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	dao, err := repo.DAO(ctx, template, false, false, false)
+	if err != nil {
+		return CategoryPage{}, err
+	}
+	categoryDao := dao.(*CategoryDAO)
+	categoryDao.Refresh()
+
+	startKey, err := repo.decodeCursor(cursor, categoryDao.HashKey())
+	if err != nil {
+		return CategoryPage{}, err
+	}
+
+	page := CategoryPage{}
+	var validated bool
+	//query with the remaining count until the page is full so the last evaluated key always matches the last item consumed
+	for {
+		var result []*CategoryDAO
+		result, startKey, err = repo.queryModels(ctx, categoryDao.HashKey(), startKey, limit-len(page.Models)-len(page.Skipped))
+		if err != nil {
+			return CategoryPage{}, err
+		}
+
+		for _, itemDao := range result {
+			if !validated {
+				err = repo.authorize(ctx, "selectPage", itemDao, "", RoleOwner)
+				if err != nil {
+					return CategoryPage{}, err
+				}
+				validated = true
+			}
+			//Other item types share the hash key when the model index is not used
+			if !IsModelSortKey(itemDao.SortKey()) {
+				continue
+			}
+			itemDao.Populate()
+			if itemDao.ChunkCount > 0 {
+				err = repo.assembleChunks(ctx, itemDao)
+				if err != nil {
+					return CategoryPage{}, err
+				}
+				if itemDao.ID == "" && itemDao.DecodeError() == nil {
+					continue
+				}
+			}
+			if decodeErr := itemDao.DecodeError(); decodeErr != nil {
+				repo.quarantine(ctx, itemDao)
+				page.Skipped = append(page.Skipped, decodeErr)
+				continue
+			}
+			page.Models = append(page.Models, itemDao.CategoryUserModel)
+		}
+
+		if len(startKey) == 0 || len(page.Models)+len(page.Skipped) >= limit {
+			break
+		}
+	}
+
+	page.Cursor = repo.encodeCursor(startKey)
+	return page, nil
+}
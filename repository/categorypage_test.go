@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/suared/core/repository"
+)
+
+func TestCategoryCursor(t *testing.T) {
+	configMap := repository.NewBasicConfig("categoryDatabase")
+	configMap.AddEntry("hashKeyName", "CategoryHashKey")
+	configMap.AddEntry("sortKeyName", "CategorySortKey")
+	repo := &CategoryRepository{config: configMap}
+
+	if repo.encodeCursor(nil) != "" {
+		t.Errorf("Expected no cursor on the last page")
+	}
+	cursor := repo.encodeCursor(repo.itemKey("category_testuser1", "model1"))
+	startKey, err := repo.decodeCursor(cursor, "category_testuser1")
+	if err != nil || *startKey["CategorySortKey"].S != "model1" || *startKey["CategoryHashKey"].S != "category_testuser1" {
+		t.Errorf("Expected cursor round trip, received: %v, %v", startKey, err)
+	}
+
+	//another user's cursor or a tampered one is rejected
+	if _, err = repo.decodeCursor(cursor, "category_testuser2"); err != ErrInvalidCursor {
+		t.Errorf("Expected invalid cursor for another user, received: %v", err)
+	}
+	if _, err = repo.decodeCursor("not a cursor", "category_testuser1"); err != ErrInvalidCursor {
+		t.Errorf("Expected invalid cursor for bad data, received: %v", err)
+	}
+	if startKey, err = repo.decodeCursor("", "category_testuser1"); err != nil || startKey != nil {
+		t.Errorf("Expected first page without a cursor, received: %v, %v", startKey, err)
+	}
+
+	//queries of the model index continue from its key as well
+	repo.modelIndex = true
+	startKey, err = repo.decodeCursor(cursor, "category_testuser1")
+	if err != nil || len(startKey) != 3 || *startKey["CategoryModelKey"].S != "category_testuser1" {
+		t.Errorf("Expected the index key in the start key, received: %v, %v", startKey, err)
+	}
+}
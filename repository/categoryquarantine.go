@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/suared/core/repository/dynamodb"
+)
+
+//quarantineSortKeyPrefix - namespace for copies of items that could not be decoded
+const quarantineSortKeyPrefix = "quarantine" + categoryKeySeparator
+
+//CategoryQuarantineDAO - A copy of a stored category item that could not be decoded, kept as is for later inspection
+type CategoryQuarantineDAO struct {
+	CategoryHashKey string
+	CategorySortKey string
+	UserID          string
+
+	CategoryUserModelData []byte
+	OriginalSortKey       string
+	Reason                string
+	QuarantinedAt         time.Time
+}
+
+//HashKey - This is the value that would be set as the dynamo hashkey
+func (dao *CategoryQuarantineDAO) HashKey() string {
+	return dao.CategoryHashKey
+}
+
+//SortKey - This is the value that would be set as the dynamo sortKey
+func (dao *CategoryQuarantineDAO) SortKey() string {
+	return dao.CategorySortKey
+}
+
+//User - the user that owns the original item
+func (dao *CategoryQuarantineDAO) User() string {
+	return dao.UserID
+}
+
+//New - creates a new instance of this specific type to support return values of the right type
+func (dao *CategoryQuarantineDAO) New() dynamodb.DAO {
+	return new(CategoryQuarantineDAO)
+}
+
+//Refresh - updates the Hashkey and SortKey.  Used by the library before calls
+func (dao *CategoryQuarantineDAO) Refresh() {
+	dao.CategoryHashKey = "category_" + dao.UserID
+	if dao.OriginalSortKey != "" {
+		dao.CategorySortKey = quarantineSortKeyPrefix + dao.OriginalSortKey
+	}
+}
+
+//Populate - nothing to calculate, the data is intentionally left as stored
+func (dao *CategoryQuarantineDAO) Populate() {
+}
+
+//quarantine - copies an item that could not be decoded into the quarantine namespace when enabled.  The original is left in place, repeated failures overwrite the same copy
+func (repo *CategoryRepository) quarantine(ctx context.Context, dao *CategoryDAO) {
+	if !repo.quarantineEnabled {
+		return
+	}
+	quarantineDAO := &CategoryQuarantineDAO{
+		UserID:                dao.UserID,
+		CategoryUserModelData: dao.CategoryUserModelData,
+		OriginalSortKey:       dao.CategorySortKey,
+		Reason:                dao.DecodeError().Error(),
+		QuarantinedAt:         time.Now().UTC(),
+	}
+	err := dynamodb.InsertOrUpdate(ctx, repo, quarantineDAO)
+	if err != nil {
+		//the original item is untouched so this is not fatal to the caller
+		log.Printf("Unable to quarantine category item: %v for user: %v, received: %v", dao.CategorySortKey, dao.UserID, err)
+	}
+}
+
+//SelectQuarantined - Returns the quarantined copies for the calling user, only the quarantine namespace of the partition is read
+func (repo *CategoryRepository) SelectQuarantined(ctx context.Context) ([]*CategoryQuarantineDAO, error) {
+	var quarantined []*CategoryQuarantineDAO
+	err := repo.queryPrefix(ctx, NewCategoryDAO(ctx).UserID, quarantineSortKeyPrefix, false, 0, func(item map[string]*awsDynamoDB.AttributeValue) error {
+		quarantineDAO := new(CategoryQuarantineDAO)
+		err := dynamodbattribute.UnmarshalMap(item, quarantineDAO)
+		if err != nil {
+			return err
+		}
+		err = dynamodb.ValidAction(ctx, "selectQuarantined", quarantineDAO)
+		if err != nil {
+			return err
+		}
+		quarantined = append(quarantined, quarantineDAO)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return quarantined, nil
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/suared/core/repository"
+)
+
+//usageSortKey - the user's running model count and storage size, kept in the same transaction as each model write while a quota is set
+const usageSortKey = "usage" + categoryKeySeparator
+
+//ErrQuotaExceeded - matched with errors.Is for any *CategoryQuotaError
+var ErrQuotaExceeded = errors.New("category quota exceeded")
+
+//CategoryQuota - per user bounds on stored models, 0 is unlimited.  Usage is only tracked while a quota is set, so a quota turned off
+//and on again needs the usage# items removed to be recounted
+type CategoryQuota struct {
+	MaxModels int   `json:"maxModels,omitempty"`
+	MaxBytes  int64 `json:"maxBytes,omitempty"`
+}
+
+//CategoryUsage - the models and encoded bytes stored by one user, chunks included
+type CategoryUsage struct {
+	ModelCount   int   `json:"modelCount"`
+	StorageBytes int64 `json:"storageBytes"`
+}
+
+//CategoryQuotaError - returned when a write would take the owner over a quota.  Quota is "models" or "storage"
+type CategoryQuotaError struct {
+	Quota string
+	Max   int64
+}
+
+func (err *CategoryQuotaError) Error() string {
+	return fmt.Sprintf("Category %v quota of %v exceeded", err.Quota, err.Max)
+}
+
+//Is - matches ErrQuotaExceeded
+func (err *CategoryQuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+//categoryUsageItem - the stored usage, updated with ADD so concurrent writes do not lose counts
+type categoryUsageItem struct {
+	CategoryHashKey string
+	CategorySortKey string
+	UserID          string
+	ModelCount      int
+	StorageBytes    int64
+}
+
+func (quota CategoryQuota) enabled() bool {
+	return quota.MaxModels > 0 || quota.MaxBytes > 0
+}
+
+//Quota - the per user quota, see PROCESS_CATEGORY_QUOTA_*
+func (repo *CategoryRepository) Quota() CategoryQuota {
+	return repo.quota
+}
+
+//SetQuota - replaces the configured quota
+func (repo *CategoryRepository) SetQuota(quota CategoryQuota) {
+	repo.quota = quota
+}
+
+//quotaFromConfig - both quotas default to unlimited
+func quotaFromConfig(config repository.Config) (CategoryQuota, error) {
+	quota := CategoryQuota{}
+	if value := config.Values()["quotaModels"]; value != "" {
+		models, err := strconv.Atoi(value)
+		if err != nil || models < 0 {
+			return CategoryQuota{}, fmt.Errorf("PROCESS_CATEGORY_QUOTA_MODELS must be a number, 0 for no quota, received: %v", value)
+		}
+		quota.MaxModels = models
+	}
+	if value := config.Values()["quotaBytes"]; value != "" {
+		bytes, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || bytes < 0 {
+			return CategoryQuota{}, fmt.Errorf("PROCESS_CATEGORY_QUOTA_BYTES must be a number, 0 for no quota, received: %v", value)
+		}
+		quota.MaxBytes = bytes
+	}
+	return quota, nil
+}
+
+//check - the quota the usage would exceed after the change as a *CategoryQuotaError.  Changes that shrink usage are always allowed
+func (quota CategoryQuota) check(usage CategoryUsage, modelDelta int, bytesDelta int64) error {
+	if quota.MaxModels > 0 && modelDelta > 0 && usage.ModelCount+modelDelta > quota.MaxModels {
+		return &CategoryQuotaError{Quota: "models", Max: int64(quota.MaxModels)}
+	}
+	if quota.MaxBytes > 0 && bytesDelta > 0 && usage.StorageBytes+bytesDelta > quota.MaxBytes {
+		return &CategoryQuotaError{Quota: "storage", Max: quota.MaxBytes}
+	}
+	return nil
+}
+
+//SelectUsage - Returns the usage of the model owner, counted from the stored models when no quota is set
+func (repo *CategoryRepository) SelectUsage(ctx context.Context) (CategoryUsage, error) {
+	return repo.usage(ctx, CategoryOwner(ctx))
+}
+
+//usage - reads the user's usage item.  The first read counts the stored models and saves the result, unless no quota is set
+func (repo *CategoryRepository) usage(ctx context.Context, userID string) (CategoryUsage, error) {
+	hashKey := "category_" + userID
+	if repo.quota.enabled() {
+		result, err := repo.client.GetItemWithContext(ctx, &awsDynamoDB.GetItemInput{
+			TableName:      repo.tableName(),
+			Key:            repo.itemKey(hashKey, usageSortKey),
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return CategoryUsage{}, fmt.Errorf("Category usage read failed with: %v", err)
+		}
+		if len(result.Item) > 0 {
+			stored := categoryUsageItem{}
+			err = dynamodbattribute.UnmarshalMap(result.Item, &stored)
+			return CategoryUsage{ModelCount: stored.ModelCount, StorageBytes: stored.StorageBytes}, err
+		}
+	}
+
+	usage := CategoryUsage{}
+	var startKey map[string]*awsDynamoDB.AttributeValue
+	for {
+		stored, lastKey, err := repo.queryModels(ctx, hashKey, startKey, 0)
+		if err != nil {
+			return CategoryUsage{}, err
+		}
+		for _, item := range stored {
+			if !IsModelSortKey(item.CategorySortKey) {
+				continue
+			}
+			usage.ModelCount++
+			usage.StorageBytes += item.StoredBytes
+			if item.StoredBytes == 0 {
+				usage.StorageBytes += int64(len(item.CategoryUserModelData) + item.ChunkCount*repo.chunkBytes)
+			}
+		}
+		startKey = lastKey
+		if len(startKey) == 0 {
+			break
+		}
+	}
+	if !repo.quota.enabled() {
+		return usage, nil
+	}
+
+	put, err := dynamodbattribute.MarshalMap(categoryUsageItem{CategoryHashKey: hashKey, CategorySortKey: usageSortKey, UserID: userID, ModelCount: usage.ModelCount, StorageBytes: usage.StorageBytes})
+	if err != nil {
+		return CategoryUsage{}, err
+	}
+	_, err = repo.client.PutItemWithContext(ctx, &awsDynamoDB.PutItemInput{
+		TableName:                repo.tableName(),
+		Item:                     put,
+		ConditionExpression:      aws.String("attribute_not_exists(#sortKey)"),
+		ExpressionAttributeNames: map[string]*string{"#sortKey": aws.String(repo.config.Values()["sortKeyName"])},
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == awsDynamoDB.ErrCodeConditionalCheckFailedException {
+		//counted by a concurrent write first, theirs includes any change made since
+		return repo.usage(ctx, userID)
+	}
+	if err != nil {
+		return CategoryUsage{}, fmt.Errorf("Category usage initialize failed with: %v", err)
+	}
+	return usage, nil
+}
+
+//usageItems - the transaction update applying a model write or removal to the user's usage, none when no quota is set.
+//Returns a *CategoryQuotaError when the change would exceed a quota, the update's condition catches concurrent writes
+func (repo *CategoryRepository) usageItems(ctx context.Context, userID string, modelDelta int, oldBytes int64, newBytes int64) ([]*awsDynamoDB.TransactWriteItem, error) {
+	if !repo.quota.enabled() {
+		return nil, nil
+	}
+	usage, err := repo.usage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	bytesDelta := newBytes - oldBytes
+	err = repo.quota.check(usage, modelDelta, bytesDelta)
+	if err != nil {
+		return nil, err
+	}
+
+	update := &awsDynamoDB.Update{
+		TableName:        repo.tableName(),
+		Key:              repo.itemKey("category_"+userID, usageSortKey),
+		UpdateExpression: aws.String("ADD ModelCount :models, StorageBytes :bytes"),
+		ExpressionAttributeValues: map[string]*awsDynamoDB.AttributeValue{
+			":models": {N: aws.String(strconv.Itoa(modelDelta))},
+			":bytes":  {N: aws.String(strconv.FormatInt(bytesDelta, 10))},
+		},
+	}
+	var conditions []string
+	if repo.quota.MaxModels > 0 && modelDelta > 0 {
+		conditions = append(conditions, "ModelCount <= :maxModels")
+		update.ExpressionAttributeValues[":maxModels"] = &awsDynamoDB.AttributeValue{N: aws.String(strconv.Itoa(repo.quota.MaxModels - modelDelta))}
+	}
+	if repo.quota.MaxBytes > 0 && bytesDelta > 0 {
+		conditions = append(conditions, "StorageBytes <= :maxBytes")
+		update.ExpressionAttributeValues[":maxBytes"] = &awsDynamoDB.AttributeValue{N: aws.String(strconv.FormatInt(repo.quota.MaxBytes-bytesDelta, 10))}
+	}
+	if len(conditions) > 0 {
+		update.ConditionExpression = aws.String(strings.Join(conditions, " AND "))
+	}
+	return []*awsDynamoDB.TransactWriteItem{{Update: update}}, nil
+}
+
+//quotaError - the quota a cancelled write exceeded when a concurrent write used it first, otherwise the cancellation itself
+func (repo *CategoryRepository) quotaError(ctx context.Context, userID string, modelDelta int, bytesDelta int64, cancelled error) error {
+	usage, err := repo.usage(ctx, userID)
+	if err == nil {
+		err = repo.quota.check(usage, modelDelta, bytesDelta)
+	}
+	var quotaErr *CategoryQuotaError
+	if errors.As(err, &quotaErr) {
+		return quotaErr
+	}
+	return cancelled
+}
@@ -2,21 +2,41 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/suared/core-apiuser/model"
 	_ "github.com/suared/core/infra"
 	"github.com/suared/core/repository"
 	"github.com/suared/core/repository/dynamodb"
-	"github.com/suared/core/ziptools"
 )
 
 //CategoryRepository - Database interface to the Category table
 type CategoryRepository struct {
-	config  repository.Config
-	session repository.Session
+	config            repository.Config
+	session           repository.Session
+	encoding          CategoryEncoding
+	quarantineEnabled bool
+	chunkBytes        int
+	client            *awsDynamoDB.DynamoDB
+	//outboxEnabled - store change events in the outbox, see PROCESS_CATEGORY_EVENT_OUTBOX.  The service can change it with SetOutbox as it owns publishing
+	outboxEnabled  bool
+	auditEnabled   bool
+	auditRetention time.Duration
+	limits         model.CategoryLimits
+	quota          CategoryQuota
+	idempotencyTTL time.Duration
+	//idempotencyLease - how long a reserved key stays in progress, see PROCESS_CATEGORY_IDEMPOTENCY_LEASE
+	idempotencyLease time.Duration
+	//modelIndex - list models from the model index rather than the whole partition, see categorymodelindex.go
+	modelIndex bool
 }
 
 //Config - Returns the current configuration
@@ -24,131 +44,276 @@ func (repo *CategoryRepository) Config() repository.Config {
 	return repo.config
 }
 
-//DAO - Returns a DAO associated with this repository from a model object
+//DAO - Returns a DAO associated with this repository from a model object.  The DAO is for the owner's model when set with WithCategoryOwner.
+//audit stores an audit record for each event written with the DAO
 func (repo *CategoryRepository) DAO(ctx context.Context, userModel CategoryUserModel, zipme bool, active bool, audit bool) (dynamodb.DAO, error) {
-	dao := NewCategoryDAO(ctx)
+	return repo.modelDAO(CategoryOwner(ctx), userModel, zipme, audit)
+}
+
+//modelDAO - the dao of the user's model, see DAO
+func (repo *CategoryRepository) modelDAO(userID string, userModel CategoryUserModel, zipme bool, audit bool) (*CategoryDAO, error) {
+	dao := new(CategoryDAO)
+	dao.UserID = userID
 	dao.CategoryUserModel = userModel
+	dao.audit = audit
 
 	if zipme == true {
-		dao.CategoryUserModelData = ziptools.GetGzipDataFromStruct(userModel)
+		data, err := EncodeCategoryUserModel(userModel, repo.encoding)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to encode category model: %v", err)
+		}
+		dao.CategoryUserModelData = data
 	}
 	return dao, nil
 }
 
-//Insert - Sample of a basic insert method with validation
-func (repo *CategoryRepository) Insert(ctx context.Context, userModel CategoryUserModel) error {
-	// Populate the Data object First //  active?, audit?
-	dao, err := repo.DAO(ctx, userModel, true, false, false)
+//Insert - Sample of a basic insert method with validation.  Events are stored in the outbox and audit log in the same transaction.
+//Returns ErrCategoryConflict if a model with the id already exists
+func (repo *CategoryRepository) Insert(ctx context.Context, userModel CategoryUserModel, events ...model.CategoryEvent) error {
+	//Populate the Data object First //  active?, audit?
+	dao, err := repo.DAO(ctx, userModel, true, false, repo.auditEnabled)
 
 	if err != nil {
 		log.Printf("Unable to Insert DAO, error Getting DAO: %v", err)
 		return err
 	}
 
-	// Repository layer is responsible for validating auth rules
-	err = dynamodb.ValidAction(ctx, "insert", dao)
+	//Repository layer is responsible for validating auth rules, models are only created by their owner
+	err = repo.authorize(ctx, "insert", dao, userModel.ID, RoleOwner)
 	if err != nil {
 		return err
 	}
 
-	return dynamodb.InsertOrUpdate(ctx, repo, dao)
+	categoryDao := dao.(*CategoryDAO)
+	categoryDao.create = true
+	categoryDao.Version = 0
+	return repo.write(ctx, categoryDao, events)
 }
 
-//Update - Sample of updating a DB entry
-func (repo *CategoryRepository) Update(ctx context.Context, userModel CategoryUserModel) error {
-	dao, err := repo.DAO(ctx, userModel, true, false, false)
+//Update - Sample of updating a DB entry.  Events are stored in the outbox and audit log in the same transaction.
+//Returns ErrCategoryConflict if the stored model changed since the model was read, see SelectForUpdate
+func (repo *CategoryRepository) Update(ctx context.Context, userModel CategoryUserModel, events ...model.CategoryEvent) error {
+	dao, err := repo.DAO(ctx, userModel, true, false, repo.auditEnabled)
 	if err != nil {
 		log.Printf("Unable to Update, error getting DAO, err: %v", err)
 		return err
 	}
 
-	err = dynamodb.ValidAction(ctx, "update", dao)
+	err = repo.authorize(ctx, "update", dao, userModel.ID, RoleEditor)
 	if err != nil {
 		return err
 	}
 
-	return dynamodb.InsertOrUpdate(ctx, repo, dao)
+	return repo.write(ctx, dao.(*CategoryDAO), events)
 
 }
 
-//Delete - Sample of deleting a DB entry
-func (repo *CategoryRepository) Delete(ctx context.Context, template CategoryUserModel) error {
-	dao, err := repo.DAO(ctx, template, false, false, false)
+//Delete - Sample of deleting a DB entry.  Events are stored in the outbox and audit log in the same transaction, the audit log outlives the model
+func (repo *CategoryRepository) Delete(ctx context.Context, template CategoryUserModel, events ...model.CategoryEvent) error {
+	dao, err := repo.DAO(ctx, template, false, false, repo.auditEnabled)
 	if err != nil {
 		log.Printf("Unable getting Dao in Delete, err: %v", err)
 		return err
 	}
 
-	return dynamodb.Delete(ctx, repo, dao)
+	err = repo.authorize(ctx, "delete", dao, template.ID, RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	return repo.remove(ctx, dao.(*CategoryDAO), events)
 }
 
-//Select - Sample of a get all by hashkey
+//Select - Sample of a get all by hashkey.  Items that cannot be decoded are skipped and logged, see SelectWithSkipped
 func (repo *CategoryRepository) Select(ctx context.Context, template CategoryUserModel) ([]CategoryUserModel, error) {
+	outputList, skipped, err := repo.SelectWithSkipped(ctx, template)
+	for i := range skipped {
+		log.Printf("Select skipped category item: %v", skipped[i])
+	}
+	return outputList, err
+}
+
+//SelectWithSkipped - Returns all models for the user along with the items that could not be decoded vs. failing the whole list
+func (repo *CategoryRepository) SelectWithSkipped(ctx context.Context, template CategoryUserModel) ([]CategoryUserModel, []*CategoryDecodeError, error) {
 	dao, err := repo.DAO(ctx, template, false, false, false)
 	if err != nil {
 		log.Printf("Unable to Select, error getting DAO, err: %v", err)
-		return nil, err
+		return nil, nil, err
 	}
 
-	result, err := dynamodb.Select(ctx, repo, dao)
+	categoryDao := dao.(*CategoryDAO)
+	categoryDao.Refresh()
 
 	var outputList []CategoryUserModel
+	var skipped []*CategoryDecodeError
 	//since the search is for user, validation only needs to occur on one item..
 	var validated bool
-	for i := range result {
-		resultDAO := result[i]
-		//Check once only...
-		if !validated {
-			//ignore if no result/ empty
-			if resultDAO.HashKey() != "" {
-				err = dynamodb.ValidAction(ctx, "selectAll", resultDAO)
-
+	var startKey map[string]*awsDynamoDB.AttributeValue
+	for {
+		var result []*CategoryDAO
+		result, startKey, err = repo.queryModels(ctx, categoryDao.HashKey(), startKey, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range result {
+			itemDao := result[i]
+			//Check once only...
+			if !validated {
+				err = dynamodb.ValidAction(ctx, "selectAll", itemDao)
 				if err != nil {
-					return []CategoryUserModel{}, err
+					return []CategoryUserModel{}, nil, err
+				}
+				validated = true
+			}
+			//Other item types share the hash key when the model index is not used
+			if !IsModelSortKey(itemDao.SortKey()) {
+				continue
+			}
+			itemDao.Populate()
+			if itemDao.ChunkCount > 0 {
+				err = repo.assembleChunks(ctx, itemDao)
+				if err != nil {
+					return []CategoryUserModel{}, nil, err
+				}
+				if itemDao.ID == "" && itemDao.DecodeError() == nil {
+					continue
 				}
 			}
+			if decodeErr := itemDao.DecodeError(); decodeErr != nil {
+				repo.quarantine(ctx, itemDao)
+				skipped = append(skipped, decodeErr)
+				continue
+			}
+			outputList = append(outputList, itemDao.CategoryUserModel)
 		}
-		//Convert DAO to Request here then add to list
-		categoryDao, ok := resultDAO.(*CategoryDAO)
-		if !ok {
-			return []CategoryUserModel{}, errors.New("Unable to convert back to categoryDao, DB results unexpected")
+		if len(startKey) == 0 {
+			return outputList, skipped, nil
 		}
-		resultItem := categoryDao.CategoryUserModel
-		outputList = append(outputList, resultItem)
-		//log.Printf("output list: %v", outputList)
 	}
+}
 
-	return outputList, err
+//SelectOne - Returns one model object, can be empty if no results.  Returns a *CategoryDecodeError if the stored model cannot be decoded
+func (repo *CategoryRepository) SelectOne(ctx context.Context, template CategoryUserModel) (CategoryUserModel, error) {
+	return repo.selectOne(ctx, template, false)
+}
 
+//SelectForUpdate - SelectOne with a consistent read that is never cached, for reading a model before changing it.  The Update of the
+//returned model fails with ErrCategoryConflict if another write happened in between
+func (repo *CategoryRepository) SelectForUpdate(ctx context.Context, template CategoryUserModel) (CategoryUserModel, error) {
+	return repo.selectOne(ctx, template, true)
 }
 
-//SelectOne - Returns one model object, can be empty if no results
-func (repo *CategoryRepository) SelectOne(ctx context.Context, template CategoryUserModel) (CategoryUserModel, error) {
-	dao, err := repo.DAO(ctx, template, false, false, false)
+func (repo *CategoryRepository) selectOne(ctx context.Context, template CategoryUserModel, consistent bool) (CategoryUserModel, error) {
+	categoryDao, err := repo.selectDAO(ctx, template, "selectOne", RoleViewer, consistent)
 	if err != nil {
-		log.Printf("Unable to SelectOne, error getting DAO, err: %v", err)
 		return CategoryUserModel{}, err
 	}
 
-	result, err := dynamodb.SelectOne(ctx, repo, dao)
+	if decodeErr := categoryDao.DecodeError(); decodeErr != nil {
+		repo.quarantine(ctx, categoryDao)
+		return CategoryUserModel{}, decodeErr
+	}
 
-	//Convert DAO to Request here then add to list
-	categoryDao, ok := result.(*CategoryDAO)
-	if !ok {
-		return CategoryUserModel{}, errors.New("Unable to convert back to categoryDao, DB results unexpected")
+	return categoryDao.CategoryUserModel, nil
+
+}
+
+//selectDAO - Returns the validated model dao for the template, assembling chunks if needed.  The dao model is empty if not found
+func (repo *CategoryRepository) selectDAO(ctx context.Context, template CategoryUserModel, action string, required CategoryRole, consistent bool) (*CategoryDAO, error) {
+	dao, err := repo.DAO(ctx, template, false, false, false)
+	if err != nil {
+		log.Printf("Unable to %v, error getting DAO, err: %v", action, err)
+		return nil, err
 	}
-	resultItem := categoryDao.CategoryUserModel
 
-	if resultItem.ID != "" {
-		err = dynamodb.ValidAction(ctx, "selectOne", result)
+	//validate before reading so another user's item is not revealed, including whether it exists
+	err = repo.authorize(ctx, action, dao, template.ID, required)
+	if err != nil {
+		return nil, err
+	}
+	return repo.readDAO(ctx, dao.(*CategoryDAO), consistent)
+}
 
+//readDAO - reads the model item for the dao's user and model id, assembling chunks if needed.  The dao model is empty if not found.
+//A consistent read sees every write made before it, used before changing the model
+func (repo *CategoryRepository) readDAO(ctx context.Context, dao *CategoryDAO, consistent bool) (*CategoryDAO, error) {
+	dao.Refresh()
+	result, err := repo.client.GetItemWithContext(ctx, &awsDynamoDB.GetItemInput{
+		TableName:      repo.tableName(),
+		Key:            repo.itemKey(dao.HashKey(), dao.SortKey()),
+		ConsistentRead: aws.Bool(consistent),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//not found
+	categoryDao := new(CategoryDAO)
+	if len(result.Item) == 0 {
+		return categoryDao, nil
+	}
+	err = dynamodbattribute.UnmarshalMap(result.Item, categoryDao)
+	if err != nil {
+		return nil, err
+	}
+	categoryDao.Refresh()
+	categoryDao.Populate()
+
+	if categoryDao.ChunkCount > 0 {
+		err = repo.assembleChunks(ctx, categoryDao)
 		if err != nil {
-			return CategoryUserModel{}, err
+			return nil, err
 		}
 	}
+	return categoryDao, nil
+}
+
+//storedVersion - the version of the stored model item, found is false if there is none.  Only the version is returned, the read is
+//still charged for the whole item
+func (repo *CategoryRepository) storedVersion(ctx context.Context, userID string, modelID string) (version int64, found bool, err error) {
+	result, err := repo.client.GetItemWithContext(ctx, &awsDynamoDB.GetItemInput{
+		TableName:                repo.tableName(),
+		Key:                      repo.itemKey("category_"+userID, modelID),
+		ProjectionExpression:     aws.String("#sortKey, ModelVersion"),
+		ExpressionAttributeNames: map[string]*string{"#sortKey": aws.String(repo.config.Values()["sortKeyName"])},
+	})
+	if err != nil || len(result.Item) == 0 {
+		return 0, false, err
+	}
+	stored := CategoryDAO{}
+	err = dynamodbattribute.UnmarshalMap(result.Item, &stored)
+	return stored.ModelVersion, true, err
+}
+
+//MigrateOne - Rewrites the stored model in the repository encoding if it was stored in another one.  Returns true if the item was rewritten
+func (repo *CategoryRepository) MigrateOne(ctx context.Context, template CategoryUserModel) (bool, error) {
+	categoryDao, err := repo.selectDAO(ctx, template, "migrate", RoleOwner, true)
+	if err != nil {
+		return false, err
+	}
+	if decodeErr := categoryDao.DecodeError(); decodeErr != nil {
+		return false, decodeErr
+	}
+	if categoryDao.ID == "" || categoryDao.StoredEncoding() == repo.encoding {
+		return false, nil
+	}
 
-	return resultItem, err
+	//rewrite the found dao vs. going through Update so the item stays with its owner when run by an admin
+	categoryDao.CategoryUserModelData, err = EncodeCategoryUserModel(categoryDao.CategoryUserModel, repo.encoding)
+	if err != nil {
+		return false, fmt.Errorf("Unable to encode category model: %v", err)
+	}
+	return true, repo.write(ctx, categoryDao, nil)
+}
+
+//Limits - the deployment limits on every model, see PROCESS_CATEGORY_MAX_*
+func (repo *CategoryRepository) Limits() model.CategoryLimits {
+	return repo.limits
+}
 
+//SetOutbox - store change events in the outbox with the change so a failed publish can be relayed
+func (repo *CategoryRepository) SetOutbox(enabled bool) {
+	repo.outboxEnabled = enabled
 }
 
 //SetSession - enables the library to store/ reuse the session for efficiency vs. creating new on each call
@@ -175,9 +340,74 @@ func NewCategoryRepository() (*CategoryRepository, error) {
 	configMap.AddEntry("hashKeyName", "CategoryHashKey")
 	configMap.AddEntry("sortKeyName", "CategorySortKey")
 	configMap.AddEntry("env", os.Getenv("PROCESS_ENV"))
+	configMap.AddEntry("storageEncoding", os.Getenv("PROCESS_CATEGORY_STORAGE_ENCODING"))
+	configMap.AddEntry("quarantine", os.Getenv("PROCESS_CATEGORY_QUARANTINE"))
+	configMap.AddEntry("chunkBytes", os.Getenv("PROCESS_CATEGORY_CHUNK_BYTES"))
+	configMap.AddEntry("cacheSize", os.Getenv("PROCESS_CATEGORY_CACHE_SIZE"))
+	configMap.AddEntry("cacheTTL", os.Getenv("PROCESS_CATEGORY_CACHE_TTL"))
+	configMap.AddEntry("cacheScope", os.Getenv("PROCESS_CATEGORY_CACHE_SCOPE"))
+	configMap.AddEntry("audit", os.Getenv("PROCESS_CATEGORY_AUDIT"))
+	configMap.AddEntry("auditRetention", os.Getenv("PROCESS_CATEGORY_AUDIT_RETENTION"))
+	configMap.AddEntry("maxDepth", os.Getenv("PROCESS_CATEGORY_MAX_DEPTH"))
+	configMap.AddEntry("maxChildren", os.Getenv("PROCESS_CATEGORY_MAX_CHILDREN"))
+	configMap.AddEntry("maxCategories", os.Getenv("PROCESS_CATEGORY_MAX_CATEGORIES"))
+	configMap.AddEntry("maxTitleLength", os.Getenv("PROCESS_CATEGORY_MAX_TITLE_LENGTH"))
+	configMap.AddEntry("quotaModels", os.Getenv("PROCESS_CATEGORY_QUOTA_MODELS"))
+	configMap.AddEntry("quotaBytes", os.Getenv("PROCESS_CATEGORY_QUOTA_BYTES"))
+	configMap.AddEntry("idempotencyTTL", os.Getenv("PROCESS_CATEGORY_IDEMPOTENCY_TTL"))
+	configMap.AddEntry("idempotencyLease", os.Getenv("PROCESS_CATEGORY_IDEMPOTENCY_LEASE"))
+	configMap.AddEntry("outbox", os.Getenv("PROCESS_CATEGORY_EVENT_OUTBOX"))
+	configMap.AddEntry("modelIndex", os.Getenv("PROCESS_CATEGORY_MODEL_INDEX"))
 
 	repo.config = configMap
 
+	encoding, err := CategoryEncodingFromName(configMap.Values()["storageEncoding"])
+	if err != nil {
+		return nil, err
+	}
+	repo.encoding = encoding
+	repo.quarantineEnabled = configMap.Values()["quarantine"] == "true"
+
+	repo.chunkBytes = DefaultChunkBytes
+	if chunkBytes := configMap.Values()["chunkBytes"]; chunkBytes != "" {
+		repo.chunkBytes, err = strconv.Atoi(chunkBytes)
+		if err != nil || repo.chunkBytes <= 0 {
+			return nil, fmt.Errorf("PROCESS_CATEGORY_CHUNK_BYTES must be a positive number, received: %v", chunkBytes)
+		}
+	}
+	repo.auditEnabled = configMap.Values()["audit"] != "false"
+	repo.outboxEnabled = configMap.Values()["outbox"] == "true"
+	repo.auditRetention = DefaultAuditRetention
+	if retention := configMap.Values()["auditRetention"]; retention != "" {
+		repo.auditRetention, err = time.ParseDuration(retention)
+		if err != nil || repo.auditRetention < 0 {
+			return nil, fmt.Errorf("PROCESS_CATEGORY_AUDIT_RETENTION must be a duration (e.g. 2160h), 0 keeps records, received: %v", retention)
+		}
+	}
+	repo.idempotencyTTL = DefaultIdempotencyTTL
+	if ttl := configMap.Values()["idempotencyTTL"]; ttl != "" {
+		repo.idempotencyTTL, err = time.ParseDuration(ttl)
+		if err != nil || repo.idempotencyTTL <= 0 {
+			return nil, fmt.Errorf("PROCESS_CATEGORY_IDEMPOTENCY_TTL must be a positive duration (e.g. 24h), received: %v", ttl)
+		}
+	}
+	repo.idempotencyLease = DefaultIdempotencyLease
+	if lease := configMap.Values()["idempotencyLease"]; lease != "" {
+		repo.idempotencyLease, err = time.ParseDuration(lease)
+		if err != nil || repo.idempotencyLease <= 0 {
+			return nil, fmt.Errorf("PROCESS_CATEGORY_IDEMPOTENCY_LEASE must be a positive duration (e.g. 20s), received: %v", lease)
+		}
+	}
+	repo.limits, err = limitsFromConfig(configMap)
+	if err != nil {
+		return nil, err
+	}
+	repo.quota, err = quotaFromConfig(configMap)
+	if err != nil {
+		return nil, err
+	}
+	repo.client = newDynamoClient(configMap)
+
 	//Convert the config into an initialized dynamoo table
 	repositoryInit, err := dynamodb.CreateTable(repo)
 	if err != nil {
@@ -189,6 +419,37 @@ func NewCategoryRepository() (*CategoryRepository, error) {
 	if !ok {
 		return nil, fmt.Errorf("Repository Category cast did not succeed, have a: %v", repositoryInit)
 	}
+	repository.modelIndex = repository.checkModelIndex(context.Background())
 
 	return repository, nil
 }
+
+//DefaultCategoryLimits - deployment limits used when the PROCESS_CATEGORY_MAX_* variables are not set
+var DefaultCategoryLimits = model.CategoryLimits{MaxDepth: 32, MaxChildren: 500, MaxCategories: 10000, MaxTitleLength: 200}
+
+//limitsFromConfig - each limit defaults from DefaultCategoryLimits, 0 removes it
+func limitsFromConfig(config repository.Config) (model.CategoryLimits, error) {
+	limits := DefaultCategoryLimits
+	settings := []struct {
+		key   string
+		env   string
+		limit *int
+	}{
+		{"maxDepth", "PROCESS_CATEGORY_MAX_DEPTH", &limits.MaxDepth},
+		{"maxChildren", "PROCESS_CATEGORY_MAX_CHILDREN", &limits.MaxChildren},
+		{"maxCategories", "PROCESS_CATEGORY_MAX_CATEGORIES", &limits.MaxCategories},
+		{"maxTitleLength", "PROCESS_CATEGORY_MAX_TITLE_LENGTH", &limits.MaxTitleLength},
+	}
+	for _, setting := range settings {
+		value := config.Values()[setting.key]
+		if value == "" {
+			continue
+		}
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return model.CategoryLimits{}, fmt.Errorf("%v must be a number, 0 for no limit, received: %v", setting.env, value)
+		}
+		*setting.limit = limit
+	}
+	return limits, nil
+}
@@ -0,0 +1,511 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/suared/core/repository/dynamodb"
+	"github.com/suared/core/security"
+)
+
+/*
+Shared models stay in the owner's partition, other users are given a role on one model:
+
+	grant#<modelID>#<granteeID>  - in the owner's partition, the record authorize checks
+	shared#<ownerID>#<modelID>   - in the grantee's partition, the index used to list the models shared with a user
+	invite#<modelID>#<inviteID>  - in the owner's partition, a single use invite accepted with its token
+
+A request for another user's model sets the owner with WithCategoryOwner, model items are then read and written in the owner's partition once the caller is authorized.
+Deleting a model removes its grants, shared index items and invites in the delete's transaction, so a model recreated with the same id is not
+shared.  MaxCategoryShares keeps them within the transaction's item limit
+*/
+
+const (
+	grantSortKeyPrefix  = "grant" + categoryKeySeparator
+	sharedSortKeyPrefix = "shared" + categoryKeySeparator
+	inviteSortKeyPrefix = "invite" + categoryKeySeparator
+)
+
+//DefaultInviteTTL - how long an invite can be accepted when the caller does not set it
+const DefaultInviteTTL = 7 * 24 * time.Hour
+
+//MaxCategoryShares - grants and pending invites one model can have.  Each grant is two items in the model's delete transaction, see shareItems
+const MaxCategoryShares = 30
+
+//CategoryRole - what a user may do with a model
+type CategoryRole string
+
+//Roles, each allows what the roles below it allow
+const (
+	//RoleOwner - the user the model is stored under, the only role that can share, delete or manage webhooks
+	RoleOwner CategoryRole = "owner"
+	//RoleEditor - can change the categories
+	RoleEditor CategoryRole = "editor"
+	//RoleViewer - read only
+	RoleViewer CategoryRole = "viewer"
+)
+
+var roleRank = map[CategoryRole]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+//Allows - true if the role includes the required role
+func (role CategoryRole) Allows(required CategoryRole) bool {
+	return roleRank[role] > 0 && roleRank[role] >= roleRank[required]
+}
+
+//ErrForbidden - the caller does not have the role needed for the action
+var ErrForbidden = errors.New("Category model access denied")
+
+//ErrInvalidRole - only editor and viewer can be granted, there is one owner
+var ErrInvalidRole = errors.New("Category share role must be editor or viewer")
+
+//ErrInvalidInvite - the invite token is unknown, already used or expired
+var ErrInvalidInvite = errors.New("Category invite is not valid or has expired")
+
+//ErrTooManyShares - the model already has MaxCategoryShares grants and pending invites
+var ErrTooManyShares = errors.New("Category model has too many shares and pending invites")
+
+//ParseShareRole - returns the role for an invite
+func ParseShareRole(name string) (CategoryRole, error) {
+	role := CategoryRole(name)
+	if role != RoleEditor && role != RoleViewer {
+		return "", ErrInvalidRole
+	}
+	return role, nil
+}
+
+type categoryOwnerKey struct{}
+
+//WithCategoryOwner - model calls made with the returned context use the owner's model.  An empty owner returns ctx unchanged
+func WithCategoryOwner(ctx context.Context, ownerID string) context.Context {
+	if ownerID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, categoryOwnerKey{}, ownerID)
+}
+
+//CategoryOwner - the user whose models the context works with, the calling user unless set with WithCategoryOwner
+func CategoryOwner(ctx context.Context) string {
+	if ownerID, ok := ctx.Value(categoryOwnerKey{}).(string); ok {
+		return ownerID
+	}
+	return security.GetAuth(ctx).GetUser()
+}
+
+//CategoryGrantDAO - A role on one model given to another user
+type CategoryGrantDAO struct {
+	CategoryHashKey string
+	CategorySortKey string
+	UserID          string
+
+	ModelID   string
+	GranteeID string
+	Role      CategoryRole
+	GrantedAt time.Time
+}
+
+//HashKey - This is the value that would be set as the dynamo hashkey
+func (dao *CategoryGrantDAO) HashKey() string {
+	return dao.CategoryHashKey
+}
+
+//SortKey - This is the value that would be set as the dynamo sortKey
+func (dao *CategoryGrantDAO) SortKey() string {
+	return dao.CategorySortKey
+}
+
+//User - the owner of the model
+func (dao *CategoryGrantDAO) User() string {
+	return dao.UserID
+}
+
+//New - creates a new instance of this specific type to support return values of the right type
+func (dao *CategoryGrantDAO) New() dynamodb.DAO {
+	return new(CategoryGrantDAO)
+}
+
+//Refresh - updates the Hashkey and SortKey.  Used by the library before calls
+func (dao *CategoryGrantDAO) Refresh() {
+	dao.CategoryHashKey = "category_" + dao.UserID
+	dao.CategorySortKey = grantSortKeyPrefix + dao.ModelID + categoryKeySeparator + dao.GranteeID
+}
+
+//Populate - nothing to calculate
+func (dao *CategoryGrantDAO) Populate() {
+}
+
+//CategorySharedDAO - The grantee's copy of a grant, lists the models shared with the user
+type CategorySharedDAO struct {
+	CategoryHashKey string
+	CategorySortKey string
+	UserID          string
+
+	OwnerID  string
+	ModelID  string
+	Role     CategoryRole
+	SharedAt time.Time
+}
+
+//HashKey - This is the value that would be set as the dynamo hashkey
+func (dao *CategorySharedDAO) HashKey() string {
+	return dao.CategoryHashKey
+}
+
+//SortKey - This is the value that would be set as the dynamo sortKey
+func (dao *CategorySharedDAO) SortKey() string {
+	return dao.CategorySortKey
+}
+
+//User - the user the model is shared with
+func (dao *CategorySharedDAO) User() string {
+	return dao.UserID
+}
+
+//New - creates a new instance of this specific type to support return values of the right type
+func (dao *CategorySharedDAO) New() dynamodb.DAO {
+	return new(CategorySharedDAO)
+}
+
+//Refresh - updates the Hashkey and SortKey.  Used by the library before calls
+func (dao *CategorySharedDAO) Refresh() {
+	dao.CategoryHashKey = "category_" + dao.UserID
+	dao.CategorySortKey = sharedSortKeyPrefix + dao.OwnerID + categoryKeySeparator + dao.ModelID
+}
+
+//Populate - nothing to calculate
+func (dao *CategorySharedDAO) Populate() {
+}
+
+//CategoryInviteDAO - An invite to a role on one model, removed when accepted
+type CategoryInviteDAO struct {
+	CategoryHashKey string
+	CategorySortKey string
+	UserID          string
+
+	ModelID   string
+	InviteID  string
+	Role      CategoryRole
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+//HashKey - This is the value that would be set as the dynamo hashkey
+func (dao *CategoryInviteDAO) HashKey() string {
+	return dao.CategoryHashKey
+}
+
+//SortKey - This is the value that would be set as the dynamo sortKey
+func (dao *CategoryInviteDAO) SortKey() string {
+	return dao.CategorySortKey
+}
+
+//User - the owner of the model
+func (dao *CategoryInviteDAO) User() string {
+	return dao.UserID
+}
+
+//New - creates a new instance of this specific type to support return values of the right type
+func (dao *CategoryInviteDAO) New() dynamodb.DAO {
+	return new(CategoryInviteDAO)
+}
+
+//Refresh - updates the Hashkey and SortKey.  Used by the library before calls
+func (dao *CategoryInviteDAO) Refresh() {
+	dao.CategoryHashKey = "category_" + dao.UserID
+	dao.CategorySortKey = inviteSortKeyPrefix + dao.ModelID + categoryKeySeparator + dao.InviteID
+}
+
+//Populate - nothing to calculate
+func (dao *CategoryInviteDAO) Populate() {
+}
+
+//Token - what the owner sends to the invitee.  The invite id is random so the token cannot be guessed from the owner and model
+func (dao *CategoryInviteDAO) Token() string {
+	data, _ := json.Marshal(categoryInviteToken{OwnerID: dao.UserID, ModelID: dao.ModelID, InviteID: dao.InviteID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+type categoryInviteToken struct {
+	OwnerID  string `json:"o"`
+	ModelID  string `json:"m"`
+	InviteID string `json:"i"`
+}
+
+//authorize - the owner and admins pass ValidAction, other users need a grant on the model with at least the required role.
+//Called before the owner's items are read so a caller without access cannot tell whether a model exists
+func (repo *CategoryRepository) authorize(ctx context.Context, action string, dao dynamodb.DAO, modelID string, required CategoryRole) error {
+	err := dynamodb.ValidAction(ctx, action, dao)
+	if err == nil {
+		return nil
+	}
+	grant, err := repo.selectGrant(ctx, dao.User(), modelID, security.GetAuth(ctx).GetUser())
+	if err != nil {
+		return err
+	}
+	if grant == nil || !grant.Role.Allows(required) {
+		return fmt.Errorf("%w: %v of %v requires the %v role", ErrForbidden, action, modelID, required)
+	}
+	return nil
+}
+
+//selectGrant - the grantee's grant on the owner's model, nil if there is none
+func (repo *CategoryRepository) selectGrant(ctx context.Context, ownerID string, modelID string, granteeID string) (*CategoryGrantDAO, error) {
+	if modelID == "" {
+		return nil, nil
+	}
+	grant := &CategoryGrantDAO{UserID: ownerID, ModelID: modelID, GranteeID: granteeID}
+	grant.Refresh()
+	result, err := repo.client.GetItemWithContext(ctx, &awsDynamoDB.GetItemInput{
+		TableName:      repo.tableName(),
+		Key:            repo.itemKey(grant.HashKey(), grant.SortKey()),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Category grant read failed with: %v", err)
+	}
+	if len(result.Item) == 0 {
+		return nil, nil
+	}
+	err = dynamodbattribute.UnmarshalMap(result.Item, grant)
+	return grant, err
+}
+
+//InsertInvite - Creates an invite to the owner's model, only the owner can invite
+func (repo *CategoryRepository) InsertInvite(ctx context.Context, modelID string, role CategoryRole, ttl time.Duration) (*CategoryInviteDAO, error) {
+	if _, err := ParseShareRole(string(role)); err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		ttl = DefaultInviteTTL
+	}
+	secret := make([]byte, 16)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	invite := &CategoryInviteDAO{
+		UserID:    CategoryOwner(ctx),
+		ModelID:   modelID,
+		InviteID:  hex.EncodeToString(secret),
+		Role:      role,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	err = repo.authorize(ctx, "invite", invite, modelID, RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := repo.selectGrants(ctx, invite.UserID, modelID)
+	if err != nil {
+		return nil, err
+	}
+	invites, err := repo.selectInvites(ctx, invite.UserID, modelID)
+	if err != nil {
+		return nil, err
+	}
+	shares := len(grants)
+	for _, existing := range invites {
+		if now.Before(existing.ExpiresAt) {
+			shares++
+			continue
+		}
+		//expired invites are removed here so they stop counting
+		err = dynamodb.Delete(ctx, repo, existing)
+		if err != nil {
+			return nil, fmt.Errorf("Category invite delete failed with: %v", err)
+		}
+	}
+	if shares >= MaxCategoryShares {
+		return nil, fmt.Errorf("%w: %v", ErrTooManyShares, modelID)
+	}
+	return invite, dynamodb.InsertOrUpdate(ctx, repo, invite)
+}
+
+//AcceptInvite - Gives the calling user the invite's role.  The token is the authorization, the grant and index are written and the invite removed in one transaction
+func (repo *CategoryRepository) AcceptInvite(ctx context.Context, token string) (*CategorySharedDAO, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidInvite
+	}
+	decoded := categoryInviteToken{}
+	err = json.Unmarshal(data, &decoded)
+	if err != nil || decoded.OwnerID == "" || decoded.ModelID == "" || decoded.InviteID == "" {
+		return nil, ErrInvalidInvite
+	}
+	granteeID := security.GetAuth(ctx).GetUser()
+	if decoded.OwnerID == granteeID {
+		return nil, ErrInvalidInvite
+	}
+
+	invite := &CategoryInviteDAO{UserID: decoded.OwnerID, ModelID: decoded.ModelID, InviteID: decoded.InviteID}
+	invite.Refresh()
+	result, err := repo.client.GetItemWithContext(ctx, &awsDynamoDB.GetItemInput{
+		TableName:      repo.tableName(),
+		Key:            repo.itemKey(invite.HashKey(), invite.SortKey()),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Category invite read failed with: %v", err)
+	}
+	if len(result.Item) == 0 {
+		return nil, ErrInvalidInvite
+	}
+	err = dynamodbattribute.UnmarshalMap(result.Item, invite)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(invite.ExpiresAt) {
+		return nil, ErrInvalidInvite
+	}
+
+	now := time.Now().UTC()
+	grant := &CategoryGrantDAO{UserID: invite.UserID, ModelID: invite.ModelID, GranteeID: granteeID, Role: invite.Role, GrantedAt: now}
+	grant.Refresh()
+	shared := &CategorySharedDAO{UserID: granteeID, OwnerID: invite.UserID, ModelID: invite.ModelID, Role: invite.Role, SharedAt: now}
+	shared.Refresh()
+	grantItem, err := dynamodbattribute.MarshalMap(grant)
+	if err != nil {
+		return nil, err
+	}
+	sharedItem, err := dynamodbattribute.MarshalMap(shared)
+	if err != nil {
+		return nil, err
+	}
+	exists := aws.String("attribute_exists(#sortKey)")
+	sortKeyName := map[string]*string{"#sortKey": aws.String(repo.config.Values()["sortKeyName"])}
+	_, err = repo.client.TransactWriteItemsWithContext(ctx, &awsDynamoDB.TransactWriteItemsInput{TransactItems: []*awsDynamoDB.TransactWriteItem{
+		{Put: &awsDynamoDB.Put{TableName: repo.tableName(), Item: grantItem}},
+		{Put: &awsDynamoDB.Put{TableName: repo.tableName(), Item: sharedItem}},
+		//single use, a second accept of the same token fails here
+		{Delete: &awsDynamoDB.Delete{
+			TableName:                repo.tableName(),
+			Key:                      repo.itemKey(invite.HashKey(), invite.SortKey()),
+			ConditionExpression:      exists,
+			ExpressionAttributeNames: sortKeyName,
+		}},
+		//the model may have been deleted since the invite was sent
+		{ConditionCheck: &awsDynamoDB.ConditionCheck{
+			TableName:                repo.tableName(),
+			Key:                      repo.itemKey(invite.HashKey(), invite.ModelID),
+			ConditionExpression:      exists,
+			ExpressionAttributeNames: sortKeyName,
+		}},
+	}})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == awsDynamoDB.ErrCodeTransactionCanceledException {
+			return nil, ErrInvalidInvite
+		}
+		return nil, fmt.Errorf("Category invite accept failed with: %v", err)
+	}
+	return shared, nil
+}
+
+//SelectGrants - the users the owner's model is shared with, only the owner can list them
+func (repo *CategoryRepository) SelectGrants(ctx context.Context, modelID string) ([]*CategoryGrantDAO, error) {
+	ownerID := CategoryOwner(ctx)
+	err := repo.authorize(ctx, "selectGrants", &CategoryGrantDAO{UserID: ownerID, ModelID: modelID}, modelID, RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	return repo.selectGrants(ctx, ownerID, modelID)
+}
+
+func (repo *CategoryRepository) selectGrants(ctx context.Context, ownerID string, modelID string) ([]*CategoryGrantDAO, error) {
+	var grants []*CategoryGrantDAO
+	err := repo.queryPrefix(ctx, ownerID, grantSortKeyPrefix+modelID+categoryKeySeparator, false, 0, func(item map[string]*awsDynamoDB.AttributeValue) error {
+		grant := &CategoryGrantDAO{}
+		err := dynamodbattribute.UnmarshalMap(item, grant)
+		grants = append(grants, grant)
+		return err
+	})
+	return grants, err
+}
+
+//selectInvites - the model's invites that have not been accepted, including expired ones
+func (repo *CategoryRepository) selectInvites(ctx context.Context, ownerID string, modelID string) ([]*CategoryInviteDAO, error) {
+	var invites []*CategoryInviteDAO
+	err := repo.queryPrefix(ctx, ownerID, inviteSortKeyPrefix+modelID+categoryKeySeparator, false, 0, func(item map[string]*awsDynamoDB.AttributeValue) error {
+		invite := &CategoryInviteDAO{}
+		err := dynamodbattribute.UnmarshalMap(item, invite)
+		invites = append(invites, invite)
+		return err
+	})
+	return invites, err
+}
+
+//shareItems - deletes of the model's grants, their shared index items and its invites, written with the model's delete.  The invite deletes
+//are conditional so an invite accepted after it was read cancels the delete vs. leaving the new grant behind
+func (repo *CategoryRepository) shareItems(ctx context.Context, ownerID string, modelID string) ([]*awsDynamoDB.TransactWriteItem, error) {
+	grants, err := repo.selectGrants(ctx, ownerID, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("Category grants read failed with: %v", err)
+	}
+	invites, err := repo.selectInvites(ctx, ownerID, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("Category invites read failed with: %v", err)
+	}
+	var items []*awsDynamoDB.TransactWriteItem
+	for _, grant := range grants {
+		grant.Refresh()
+		shared := &CategorySharedDAO{UserID: grant.GranteeID, OwnerID: grant.UserID, ModelID: grant.ModelID}
+		shared.Refresh()
+		items = append(items,
+			&awsDynamoDB.TransactWriteItem{Delete: &awsDynamoDB.Delete{TableName: repo.tableName(), Key: repo.itemKey(grant.HashKey(), grant.SortKey())}},
+			&awsDynamoDB.TransactWriteItem{Delete: &awsDynamoDB.Delete{TableName: repo.tableName(), Key: repo.itemKey(shared.HashKey(), shared.SortKey())}})
+	}
+	for _, invite := range invites {
+		invite.Refresh()
+		items = append(items, &awsDynamoDB.TransactWriteItem{Delete: &awsDynamoDB.Delete{
+			TableName:                repo.tableName(),
+			Key:                      repo.itemKey(invite.HashKey(), invite.SortKey()),
+			ConditionExpression:      aws.String("attribute_exists(#sortKey)"),
+			ExpressionAttributeNames: map[string]*string{"#sortKey": aws.String(repo.config.Values()["sortKeyName"])},
+		}})
+	}
+	return items, nil
+}
+
+//SelectShared - the models other users have shared with the calling user
+func (repo *CategoryRepository) SelectShared(ctx context.Context) ([]*CategorySharedDAO, error) {
+	var shares []*CategorySharedDAO
+	err := repo.queryPrefix(ctx, security.GetAuth(ctx).GetUser(), sharedSortKeyPrefix, false, 0, func(item map[string]*awsDynamoDB.AttributeValue) error {
+		shared := &CategorySharedDAO{}
+		err := dynamodbattribute.UnmarshalMap(item, shared)
+		shares = append(shares, shared)
+		return err
+	})
+	return shares, err
+}
+
+//DeleteGrant - Removes a grant on the owner's model and the grantee's index.  Allowed for the owner, or the grantee leaving the share
+func (repo *CategoryRepository) DeleteGrant(ctx context.Context, modelID string, granteeID string) error {
+	grant := &CategoryGrantDAO{UserID: CategoryOwner(ctx), ModelID: modelID, GranteeID: granteeID}
+	if granteeID != security.GetAuth(ctx).GetUser() {
+		err := repo.authorize(ctx, "deleteGrant", grant, modelID, RoleOwner)
+		if err != nil {
+			return err
+		}
+	}
+	grant.Refresh()
+	shared := &CategorySharedDAO{UserID: granteeID, OwnerID: grant.UserID, ModelID: modelID}
+	shared.Refresh()
+	_, err := repo.client.TransactWriteItemsWithContext(ctx, &awsDynamoDB.TransactWriteItemsInput{TransactItems: []*awsDynamoDB.TransactWriteItem{
+		{Delete: &awsDynamoDB.Delete{TableName: repo.tableName(), Key: repo.itemKey(grant.HashKey(), grant.SortKey())}},
+		{Delete: &awsDynamoDB.Delete{TableName: repo.tableName(), Key: repo.itemKey(shared.HashKey(), shared.SortKey())}},
+	}})
+	if err != nil {
+		return fmt.Errorf("Category grant delete failed with: %v", err)
+	}
+	return nil
+}
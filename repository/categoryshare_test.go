@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/suared/core/security"
+)
+
+func TestCategoryRoles(t *testing.T) {
+	if !RoleOwner.Allows(RoleEditor) || !RoleEditor.Allows(RoleViewer) || !RoleViewer.Allows(RoleViewer) {
+		t.Errorf("Expected each role to include the roles below it")
+	}
+	if RoleViewer.Allows(RoleEditor) || RoleEditor.Allows(RoleOwner) || CategoryRole("").Allows(RoleViewer) || CategoryRole("admin").Allows(RoleViewer) {
+		t.Errorf("Expected lower and unknown roles to be refused")
+	}
+	for _, name := range []string{"owner", "", "Editor"} {
+		if _, err := ParseShareRole(name); err != ErrInvalidRole {
+			t.Errorf("Expected %q to be an invalid share role, received: %v", name, err)
+		}
+	}
+	if role, err := ParseShareRole("viewer"); err != nil || role != RoleViewer {
+		t.Errorf("Expected viewer, received: %v, %v", role, err)
+	}
+}
+
+func TestCategoryOwnerContext(t *testing.T) {
+	ctx := security.SetupTestAuthFromContext(context.TODO(), 2)
+	if CategoryOwner(ctx) != "testuser2" || CategoryOwner(WithCategoryOwner(ctx, "")) != "testuser2" {
+		t.Errorf("Expected the calling user without an owner")
+	}
+	if CategoryOwner(WithCategoryOwner(ctx, "testuser1")) != "testuser1" {
+		t.Errorf("Expected the owner set on the context")
+	}
+
+	//model items are read and written in the owner's partition
+	dao := NewCategoryDAO(ctx)
+	dao.UserID = CategoryOwner(WithCategoryOwner(ctx, "testuser1"))
+	dao.ID = "model1"
+	dao.Refresh()
+	if dao.HashKey() != "category_testuser1" {
+		t.Errorf("Expected the owner's hash key, received: %v", dao.HashKey())
+	}
+}
+
+func TestCategoryInviteToken(t *testing.T) {
+	invite := &CategoryInviteDAO{UserID: "testuser1", ModelID: "model1", InviteID: "abc123"}
+	data, err := base64.RawURLEncoding.DecodeString(invite.Token())
+	if err != nil {
+		t.Fatalf("Expected a base64url token, received: %v", err)
+	}
+	decoded := categoryInviteToken{}
+	err = json.Unmarshal(data, &decoded)
+	if err != nil || decoded.OwnerID != "testuser1" || decoded.ModelID != "model1" || decoded.InviteID != "abc123" {
+		t.Errorf("Expected the token to carry the invite key, received: %+v, %v", decoded, err)
+	}
+	invite.Refresh()
+	if invite.SortKey() != "invite#model1#abc123" || IsModelSortKey(invite.SortKey()) {
+		t.Errorf("Expected the invite namespace, received: %v", invite.SortKey())
+	}
+}
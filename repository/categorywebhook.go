@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core/repository/dynamodb"
+)
+
+//Sort key namespaces, grouped by model so one query returns a model's webhooks or deliveries
+const (
+	webhookSortKeyPrefix  = "webhook" + categoryKeySeparator
+	deliverySortKeyPrefix = "delivery" + categoryKeySeparator
+)
+
+//ErrDeliveryClaimed - returned when a delivery already exists or is being attempted elsewhere
+var ErrDeliveryClaimed = errors.New("webhook delivery exists or is being attempted")
+
+//Delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	//DeliveryDead - all attempts failed, the record is kept as the dead letter
+	DeliveryDead = "dead"
+)
+
+//CategoryWebhookDAO - A webhook registered for one of the user's models
+type CategoryWebhookDAO struct {
+	CategoryHashKey string
+	CategorySortKey string
+	UserID          string
+
+	WebhookID string
+	ModelID   string
+	URL       string
+	Secret    string
+	CreatedAt time.Time
+}
+
+//HashKey - This is the value that would be set as the dynamo hashkey
+func (dao *CategoryWebhookDAO) HashKey() string {
+	return dao.CategoryHashKey
+}
+
+//SortKey - This is the value that would be set as the dynamo sortKey
+func (dao *CategoryWebhookDAO) SortKey() string {
+	return dao.CategorySortKey
+}
+
+//User - the user that registered the webhook
+func (dao *CategoryWebhookDAO) User() string {
+	return dao.UserID
+}
+
+//New - creates a new instance of this specific type to support return values of the right type
+func (dao *CategoryWebhookDAO) New() dynamodb.DAO {
+	return new(CategoryWebhookDAO)
+}
+
+//Refresh - updates the Hashkey and SortKey.  Used by the library before calls
+func (dao *CategoryWebhookDAO) Refresh() {
+	dao.CategoryHashKey = "category_" + dao.UserID
+	dao.CategorySortKey = webhookSortKeyPrefix + dao.ModelID + categoryKeySeparator + dao.WebhookID
+}
+
+//Populate - nothing to calculate
+func (dao *CategoryWebhookDAO) Populate() {
+}
+
+//CategoryDeliveryDAO - One webhook delivery, updated after each attempt
+type CategoryDeliveryDAO struct {
+	CategoryHashKey string
+	CategorySortKey string
+	UserID          string
+
+	DeliveryID string
+	WebhookID  string
+	ModelID    string
+	EventID    string
+	EventType  string
+	Status     string
+	Attempts   int
+	StatusCode int
+	LastError  string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	//Event - the event delivered, kept so a pending delivery can be retried
+	Event *model.CategoryEvent `json:",omitempty"`
+	//NextAttemptAt - when a pending delivery is retried
+	NextAttemptAt time.Time
+	//LockedUntil - epoch milliseconds, the delivery is being attempted until then.  A lease so a delivery stopped mid attempt is retried
+	LockedUntil int64 `json:",omitempty"`
+}
+
+//HashKey - This is the value that would be set as the dynamo hashkey
+func (dao *CategoryDeliveryDAO) HashKey() string {
+	return dao.CategoryHashKey
+}
+
+//SortKey - This is the value that would be set as the dynamo sortKey
+func (dao *CategoryDeliveryDAO) SortKey() string {
+	return dao.CategorySortKey
+}
+
+//User - the owner of the webhook
+func (dao *CategoryDeliveryDAO) User() string {
+	return dao.UserID
+}
+
+//New - creates a new instance of this specific type to support return values of the right type
+func (dao *CategoryDeliveryDAO) New() dynamodb.DAO {
+	return new(CategoryDeliveryDAO)
+}
+
+//Refresh - updates the Hashkey and SortKey.  Delivery ids start with the time ordered event id so the newest sort last
+func (dao *CategoryDeliveryDAO) Refresh() {
+	dao.CategoryHashKey = "category_" + dao.UserID
+	dao.CategorySortKey = deliverySortKeyPrefix + dao.ModelID + categoryKeySeparator + dao.DeliveryID
+}
+
+//Populate - nothing to calculate
+func (dao *CategoryDeliveryDAO) Populate() {
+}
+
+//LockUntil - sets LockedUntil, the zero time releases the delivery
+func (dao *CategoryDeliveryDAO) LockUntil(until time.Time) {
+	dao.LockedUntil = 0
+	if !until.IsZero() {
+		dao.LockedUntil = unixMilli(until)
+	}
+}
+
+//queryPrefix - returns the user's items with the sort key prefix, newest first when descending.  limit 0 returns all
+func (repo *CategoryRepository) queryPrefix(ctx context.Context, userID string, prefix string, descending bool, limit int, each func(item map[string]*awsDynamoDB.AttributeValue) error) error {
+	hashKey := "category_" + userID
+	var startKey map[string]*awsDynamoDB.AttributeValue
+	count := 0
+	for {
+		input := &awsDynamoDB.QueryInput{
+			TableName:              repo.tableName(),
+			KeyConditionExpression: aws.String("#hashKey = :hashKey AND begins_with(#sortKey, :prefix)"),
+			ExpressionAttributeNames: map[string]*string{
+				"#hashKey": aws.String(repo.config.Values()["hashKeyName"]),
+				"#sortKey": aws.String(repo.config.Values()["sortKeyName"]),
+			},
+			ExpressionAttributeValues: map[string]*awsDynamoDB.AttributeValue{
+				":hashKey": {S: aws.String(hashKey)},
+				":prefix":  {S: aws.String(prefix)},
+			},
+			ScanIndexForward:  aws.Bool(!descending),
+			ExclusiveStartKey: startKey,
+		}
+		if limit > 0 {
+			input.Limit = aws.Int64(int64(limit - count))
+		}
+		result, err := repo.client.QueryWithContext(ctx, input)
+		if err != nil {
+			return fmt.Errorf("Category query for %v failed with: %v", prefix, err)
+		}
+		for i := range result.Items {
+			err = each(result.Items[i])
+			if err != nil {
+				return err
+			}
+			count++
+		}
+		startKey = result.LastEvaluatedKey
+		if len(startKey) == 0 || (limit > 0 && count >= limit) {
+			return nil
+		}
+	}
+}
+
+//InsertWebhook - Stores a new webhook on the owner's model, only the owner manages webhooks
+func (repo *CategoryRepository) InsertWebhook(ctx context.Context, webhook *CategoryWebhookDAO) error {
+	webhook.UserID = CategoryOwner(ctx)
+	err := repo.authorize(ctx, "insertWebhook", webhook, webhook.ModelID, RoleOwner)
+	if err != nil {
+		return err
+	}
+	return dynamodb.InsertOrUpdate(ctx, repo, webhook)
+}
+
+//SelectWebhooks - Returns the webhooks for the owner's model, including secrets.  Editors can read them so their changes are delivered
+func (repo *CategoryRepository) SelectWebhooks(ctx context.Context, modelID string) ([]*CategoryWebhookDAO, error) {
+	ownerID := CategoryOwner(ctx)
+	err := repo.authorize(ctx, "selectWebhooks", &CategoryWebhookDAO{UserID: ownerID, ModelID: modelID}, modelID, RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	return repo.selectWebhooks(ctx, ownerID, modelID)
+}
+
+func (repo *CategoryRepository) selectWebhooks(ctx context.Context, ownerID string, modelID string) ([]*CategoryWebhookDAO, error) {
+	var webhooks []*CategoryWebhookDAO
+	err := repo.queryPrefix(ctx, ownerID, webhookSortKeyPrefix+modelID+categoryKeySeparator, false, 0, func(item map[string]*awsDynamoDB.AttributeValue) error {
+		webhook := &CategoryWebhookDAO{}
+		err := dynamodbattribute.UnmarshalMap(item, webhook)
+		webhooks = append(webhooks, webhook)
+		return err
+	})
+	return webhooks, err
+}
+
+//DeleteWebhook - Removes one of the webhooks on the owner's model, deliveries are kept
+func (repo *CategoryRepository) DeleteWebhook(ctx context.Context, modelID string, webhookID string) error {
+	webhook := &CategoryWebhookDAO{UserID: CategoryOwner(ctx), ModelID: modelID, WebhookID: webhookID}
+	err := repo.authorize(ctx, "deleteWebhook", webhook, modelID, RoleOwner)
+	if err != nil {
+		return err
+	}
+	return dynamodb.Delete(ctx, repo, webhook)
+}
+
+//SaveDelivery - Inserts or updates a delivery record, deliveries are made for the owner's and editors' changes
+func (repo *CategoryRepository) SaveDelivery(ctx context.Context, delivery *CategoryDeliveryDAO) error {
+	err := repo.authorize(ctx, "saveDelivery", delivery, delivery.ModelID, RoleEditor)
+	if err != nil {
+		return err
+	}
+	return repo.putDelivery(ctx, delivery, categoryCondition{})
+}
+
+//InsertDelivery - Stores a new delivery record, ErrDeliveryClaimed if the event was already delivered to the webhook
+func (repo *CategoryRepository) InsertDelivery(ctx context.Context, delivery *CategoryDeliveryDAO) error {
+	err := repo.authorize(ctx, "insertDelivery", delivery, delivery.ModelID, RoleEditor)
+	if err != nil {
+		return err
+	}
+	return repo.putDelivery(ctx, delivery, repo.newDeliveryCondition())
+}
+
+func (repo *CategoryRepository) newDeliveryCondition() categoryCondition {
+	return categoryCondition{
+		expression: aws.String("attribute_not_exists(#sortKey)"),
+		names:      map[string]*string{"#sortKey": aws.String(repo.config.Values()["sortKeyName"])},
+	}
+}
+
+//putDelivery - writes the delivery, ErrDeliveryClaimed when the condition fails
+func (repo *CategoryRepository) putDelivery(ctx context.Context, delivery *CategoryDeliveryDAO, condition categoryCondition) error {
+	delivery.Refresh()
+	item, err := dynamodbattribute.MarshalMap(delivery)
+	if err != nil {
+		return err
+	}
+	_, err = repo.client.PutItemWithContext(ctx, &awsDynamoDB.PutItemInput{
+		TableName:                 repo.tableName(),
+		Item:                      item,
+		ConditionExpression:       condition.expression,
+		ExpressionAttributeNames:  condition.names,
+		ExpressionAttributeValues: condition.values,
+	})
+	if conditionFailed(err) {
+		return fmt.Errorf("%w: %v", ErrDeliveryClaimed, delivery.DeliveryID)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to save webhook delivery: %v, received: %v", delivery.DeliveryID, err)
+	}
+	return nil
+}
+
+//SelectDeliveries - Returns the most recent deliveries for the owner's model, newest first
+func (repo *CategoryRepository) SelectDeliveries(ctx context.Context, modelID string, limit int) ([]*CategoryDeliveryDAO, error) {
+	ownerID := CategoryOwner(ctx)
+	err := repo.authorize(ctx, "selectDeliveries", &CategoryDeliveryDAO{UserID: ownerID, ModelID: modelID}, modelID, RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []*CategoryDeliveryDAO
+	err = repo.queryPrefix(ctx, ownerID, deliverySortKeyPrefix+modelID+categoryKeySeparator, true, limit, func(item map[string]*awsDynamoDB.AttributeValue) error {
+		delivery := &CategoryDeliveryDAO{}
+		err := dynamodbattribute.UnmarshalMap(item, delivery)
+		deliveries = append(deliveries, delivery)
+		return err
+	})
+	return deliveries, err
+}
+
+//SelectWebhooks - the webhooks of any user's model, including secrets, for relayed events
+func (operator *CategoryOperator) SelectWebhooks(ctx context.Context, userID string, modelID string) ([]*CategoryWebhookDAO, error) {
+	return operator.repo.selectWebhooks(ctx, userID, modelID)
+}
+
+//SaveDelivery - inserts or updates a delivery record of any user
+func (operator *CategoryOperator) SaveDelivery(ctx context.Context, delivery *CategoryDeliveryDAO) error {
+	return operator.repo.putDelivery(ctx, delivery, categoryCondition{})
+}
+
+//InsertDelivery - stores a new delivery record of any user, ErrDeliveryClaimed if the event was already delivered to the webhook
+func (operator *CategoryOperator) InsertDelivery(ctx context.Context, delivery *CategoryDeliveryDAO) error {
+	return operator.repo.putDelivery(ctx, delivery, operator.repo.newDeliveryCondition())
+}
+
+//ScanDeliveries - calls each with every pending delivery in the table that is due at the time and not being attempted
+func (operator *CategoryOperator) ScanDeliveries(ctx context.Context, at time.Time, each func(delivery *CategoryDeliveryDAO) error) error {
+	repo := operator.repo
+	var startKey map[string]*awsDynamoDB.AttributeValue
+	for {
+		result, err := repo.client.ScanWithContext(ctx, &awsDynamoDB.ScanInput{
+			TableName:                repo.tableName(),
+			FilterExpression:         aws.String("begins_with(#sortKey, :prefix) AND #status = :pending"),
+			ExpressionAttributeNames: map[string]*string{"#sortKey": aws.String(repo.config.Values()["sortKeyName"]), "#status": aws.String("Status")},
+			ExpressionAttributeValues: map[string]*awsDynamoDB.AttributeValue{
+				":prefix":  {S: aws.String(deliverySortKeyPrefix)},
+				":pending": {S: aws.String(DeliveryPending)},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return fmt.Errorf("Category delivery scan failed with: %v", err)
+		}
+		for i := range result.Items {
+			delivery := &CategoryDeliveryDAO{}
+			err = dynamodbattribute.UnmarshalMap(result.Items[i], delivery)
+			if err != nil {
+				return err
+			}
+			if delivery.NextAttemptAt.After(at) || delivery.LockedUntil > unixMilli(at) {
+				continue
+			}
+			err = each(delivery)
+			if err != nil {
+				return err
+			}
+		}
+		startKey = result.LastEvaluatedKey
+		if len(startKey) == 0 {
+			return nil
+		}
+	}
+}
+
+//ClaimDelivery - locks a delivery read with ScanDeliveries until the time, ErrDeliveryClaimed if it changed since it was read
+func (operator *CategoryOperator) ClaimDelivery(ctx context.Context, delivery *CategoryDeliveryDAO, until time.Time) error {
+	now := unixMilli(time.Now())
+	claimed := *delivery
+	claimed.LockUntil(until)
+	err := operator.repo.putDelivery(ctx, &claimed, categoryCondition{
+		expression: aws.String("#status = :pending AND Attempts = :attempts AND (attribute_not_exists(LockedUntil) OR LockedUntil < :now)"),
+		names:      map[string]*string{"#status": aws.String("Status")},
+		values: map[string]*awsDynamoDB.AttributeValue{
+			":pending":  {S: aws.String(DeliveryPending)},
+			":attempts": {N: aws.String(strconv.Itoa(delivery.Attempts))},
+			":now":      {N: aws.String(strconv.FormatInt(now, 10))},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	*delivery = claimed
+	return nil
+}
+
+//unixMilli - epoch milliseconds, see CategoryDeliveryDAO LockedUntil
+func unixMilli(at time.Time) int64 {
+	return at.UnixNano() / int64(time.Millisecond)
+}
@@ -1,19 +1,39 @@
 package main
 
 import (
+	"context"
+	"log"
+	"net"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/gorilla/mux"
 	coreapi "github.com/suared/core/api"
 	_ "github.com/suared/core/infra"
 
 	"github.com/suared/core-apiuser/api"
+	"github.com/suared/core-apiuser/backup"
+	"github.com/suared/core-apiuser/categoryrpc"
+	"github.com/suared/core-apiuser/service"
+	"github.com/suared/core-apiuser/stream"
 
 	"github.com/akrylysov/algnhsa"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
 )
 
 func main() {
-	if os.Getenv("LAMBDA_ENV") == "true" {
+	//LAMBDA_HANDLER=stream runs the category table stream handler from the same binary, see infra/dev/stream.tf
+	//LAMBDA_HANDLER=backup runs the scheduled category backup, see infra/dev/backup.tf
+	//LAMBDA_HANDLER=relay runs the scheduled category event outbox relay, see infra/dev/relay.tf
+	if os.Getenv("LAMBDA_HANDLER") == "stream" {
+		startStreamHandler()
+	} else if os.Getenv("LAMBDA_HANDLER") == "backup" {
+		startBackupHandler()
+	} else if os.Getenv("LAMBDA_HANDLER") == "relay" {
+		startRelayHandler()
+	} else if os.Getenv("LAMBDA_ENV") == "true" {
 		startLambdaAPI()
 	} else {
 		startWebAPI()
@@ -27,7 +47,6 @@ type apiRoutes struct {
 
 func (routes *apiRoutes) SetupRoutes(router *mux.Router) {
 	api.SetupAppRoutes(router)
-	api.SetupAppObjectiveRoutes(router)
 	routes.refRouter = router
 
 }
@@ -37,11 +56,31 @@ func (routes *apiRoutes) StartServer() bool {
 }
 
 func startWebAPI() {
+	//the web api relays the outbox itself when PROCESS_CATEGORY_RELAY_INTERVAL is set, vs. the relay Lambda
+	if interval, err := time.ParseDuration(os.Getenv("PROCESS_CATEGORY_RELAY_INTERVAL")); err == nil && interval > 0 {
+		go service.NewCategoryService().RunOutboxRelay(context.Background(), interval)
+	}
+	//the gRPC category service is served next to the web api when PROCESS_GRPC_LISTEN_ADDR is set
+	if address := os.Getenv("PROCESS_GRPC_LISTEN_ADDR"); address != "" {
+		go startGRPCServer(address)
+	}
 	config := &apiRoutes{}
 	config.autoStart = true
 	coreapi.StartHTTPListener(config)
 }
 
+//startGRPCServer - serves categoryrpc on address, the calls are authenticated from the authorization metadata like the REST routes
+func startGRPCServer(address string) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Fatalf("Unable to listen for gRPC on %v: %v", address, err)
+	}
+	err = categoryrpc.NewGRPCServer(service.NewCategoryService()).Serve(listener)
+	if err != nil {
+		log.Fatalf("gRPC server failed with: %v", err)
+	}
+}
+
 func startLambdaAPI() {
 	config := &apiRoutes{}
 	config.autoStart = false
@@ -50,7 +89,13 @@ func startLambdaAPI() {
 	opts := &algnhsa.Options{UseProxyPath: true}
 	//Since autoStart is false, it is up to the caller to start the engine to be used
 	//log.Printf("at this point, config has: %v, refRouter has: %v", config, config.refRouter)
-	algnhsa.ListenAndServe(config.refRouter, opts)
+	//webhook first attempts run in the background, they are finished before the response as the function is frozen after it
+	svc := service.NewCategoryService()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config.refRouter.ServeHTTP(w, r)
+		svc.WaitWebhookDeliveries()
+	})
+	algnhsa.ListenAndServe(handler, opts)
 	/*
 		Note from this library docs if I add binary content in the future (likel):
 		To make the API Gateway treat certain content types as binary, you need to add the desired types to your API's "Binary Media Types" (Settings section) and also pass them to the algnhsa.ListenAndServe function:
@@ -61,3 +106,67 @@ func startLambdaAPI() {
 		"github.com/akrylysov/algnhsa"
 	*/
 }
+
+//startStreamHandler - In Lambda, handles the category table stream.  Locally, PROCESS_STREAM_REPLAY_FILE runs a recorded stream event JSON through the same handlers
+func startStreamHandler() {
+	stream.RegisterCategoryChangeHandler("log", stream.LogCategoryChange)
+	if os.Getenv("LAMBDA_ENV") == "true" {
+		lambda.Start(stream.HandleCategoryStream)
+		return
+	}
+	file, err := os.Open(os.Getenv("PROCESS_STREAM_REPLAY_FILE"))
+	if err != nil {
+		log.Fatalf("Unable to open PROCESS_STREAM_REPLAY_FILE: %v", err)
+	}
+	defer file.Close()
+	err = stream.ReplayCategoryStream(context.Background(), file)
+	if err != nil {
+		log.Fatalf("Category stream replay failed with: %v", err)
+	}
+}
+
+func startBackupHandler() {
+	if os.Getenv("LAMBDA_ENV") == "true" {
+		lambda.Start(backup.HandleScheduledBackup)
+		return
+	}
+	result, err := backup.RunScheduledBackup(context.Background())
+	if err != nil {
+		log.Fatalf("Category backup failed with: %v", err)
+	}
+	log.Printf("Category backup of %v models written to %v, %v skipped", result.Models, result.Location, result.Skipped)
+}
+
+//RelayResult - the outcome of a relay run, returned by the relay Lambda
+type RelayResult struct {
+	Events     int `json:"events"`
+	Deliveries int `json:"deliveries"`
+}
+
+//startRelayHandler - publishes the category events left in the outbox and retries pending webhook deliveries, on a schedule in Lambda and once locally
+func startRelayHandler() {
+	svc := service.NewCategoryService()
+	relay := func(ctx context.Context) (RelayResult, error) {
+		result := RelayResult{}
+		var err error
+		result.Events, err = svc.RelayOutbox(ctx)
+		//background work is frozen between invocations
+		svc.WaitWebhookDeliveries()
+		if err != nil {
+			return result, err
+		}
+		result.Deliveries, err = svc.RetryWebhookDeliveries(ctx)
+		return result, err
+	}
+	if os.Getenv("LAMBDA_ENV") == "true" {
+		lambda.Start(func(ctx context.Context, event events.CloudWatchEvent) (RelayResult, error) {
+			return relay(ctx)
+		})
+		return
+	}
+	result, err := relay(context.Background())
+	if err != nil {
+		log.Fatalf("Category relay failed after %v events and %v deliveries: %v", result.Events, result.Deliveries, err)
+	}
+	log.Printf("Category relay published %v events and retried %v webhook deliveries", result.Events, result.Deliveries)
+}
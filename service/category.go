@@ -4,11 +4,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+
 	"github.com/suared/core-apiuser/model"
 	"github.com/suared/core-apiuser/repository"
 )
 
-var categoryRepo *repository.CategoryRepository
+var categoryRepo repository.CategoryStore
+
+//categoryCache - read through cache in front of the repository, see repository.CachedCategoryRepository
+var categoryCache *repository.CachedCategoryRepository
+
+//categoryOperator - any user's outbox and webhooks for the outbox relay, which runs without a signed in user
+var categoryOperator *repository.CategoryOperator
+
+//defaultTemplate - seeds the lifeapp model for first time users, set per deployment with PROCESS_CATEGORY_DEFAULT_TEMPLATE
+var defaultTemplate *model.CategoryTemplate
+
+//DefaultCategoryTemplateName - used when PROCESS_CATEGORY_DEFAULT_TEMPLATE is not set
+const DefaultCategoryTemplateName = "life"
 
 //MyLifeCategoryUserModelID - One time generated UUID will be used for the life app as there is only 1 per user
 const MyLifeCategoryUserModelID = "1SBsF9WrcSmBwWvzWVojegYR6z2"
@@ -18,7 +32,28 @@ func init() {
 	if err != nil {
 		panic("Unable to setup Category Repository while initializing the category service")
 	}
-	categoryRepo = catRepo
+	categoryCache, err = repository.NewCachedCategoryRepository(catRepo)
+	if err != nil {
+		panic("Unable to setup Category cache while initializing the category service: " + err.Error())
+	}
+	categoryRepo = categoryCache
+	categoryOperator = repository.NewCategoryOperator(catRepo, "relay")
+
+	templateName := os.Getenv("PROCESS_CATEGORY_DEFAULT_TEMPLATE")
+	if templateName == "" {
+		templateName = DefaultCategoryTemplateName
+	}
+	tmpl, ok := model.GetCategoryTemplate(templateName)
+	if !ok {
+		panic("Unknown PROCESS_CATEGORY_DEFAULT_TEMPLATE while initializing the category service: " + templateName)
+	}
+	defaultTemplate = tmpl
+
+	eventPublisher, err = newEventPublisher(os.Getenv("PROCESS_CATEGORY_EVENT_PUBLISHER"), os.Getenv("PROCESS_CATEGORY_EVENT_FILE"))
+	if err != nil {
+		panic("Unable to setup the category event publisher: " + err.Error())
+	}
+	setEventOutbox(os.Getenv("PROCESS_CATEGORY_EVENT_OUTBOX") == "true")
 }
 
 //CategoryService - The service interface for working with categories.
@@ -31,36 +66,74 @@ func (t *CategoryService) GetCategoryModel(ctx context.Context, categoryModelID
 	catModel.ID = categoryModelID
 	catModel, err := categoryRepo.SelectOne(ctx, catModel)
 	if err != nil {
-		return nil, fmt.Errorf("Service Get Model Failed with: %v", err)
+		return nil, fmt.Errorf("Service Get Model Failed with: %w", err)
 	}
-	//First time user, initialize the base model
+	//First time user, initialize the base model from the deployment default template
 	if catModel.ID == "" && categoryModelID == MyLifeCategoryUserModelID {
+		catModel.CategoryRoot = *defaultTemplate.NewCategoryRoot()
 		catModel.ID = MyLifeCategoryUserModelID
-		catModel.Name = "Life Categories"
-		//Future: make this more flexible for user types
-		personalCat := model.NewCategory("Life")
-		catModel.AddChild(*personalCat)
-		workCat := model.NewCategory("Work")
-		catModel.AddChild(*workCat)
-
-		err = categoryRepo.Insert(ctx, catModel)
+		catModel.TitlePolicy = defaultTitlePolicy
+
+		events := []model.CategoryEvent{newCategoryEvent(ctx, model.CategoryModelReplaced, catModel.ID)}
+		err = categoryRepo.Insert(ctx, catModel, events...)
+		if errors.Is(err, repository.ErrCategoryConflict) {
+			//created by a concurrent first request
+			return t.GetCategoryModel(ctx, categoryModelID)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("Could not initialize lifeapp user model with err: %v", err)
+			return nil, fmt.Errorf("Could not initialize lifeapp user model with err: %w", err)
 		}
+		publishCategoryEvents(ctx, events)
 
 	}
 	return &catModel, nil
 }
 
-//ReplaceCategoryModel - Expert use only, replaces the full model
+//ListCategoryModels - Returns one page of the user's models, pass the returned cursor to get the next page.  An invalid cursor returns an error wrapping repository.ErrInvalidCursor
+func (t *CategoryService) ListCategoryModels(ctx context.Context, limit int, cursor string) (*repository.CategoryPage, error) {
+	page, err := categoryRepo.SelectPage(ctx, repository.CategoryUserModel{}, limit, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("Service List Models Failed with: %w", err)
+	}
+	return &page, nil
+}
+
+//WithInvocationCache - attaches a per request model cache when PROCESS_CATEGORY_CACHE_SCOPE is invocation, returns ctx unchanged otherwise
+func (t *CategoryService) WithInvocationCache(ctx context.Context) context.Context {
+	return categoryCache.WithInvocationCache(ctx)
+}
+
+//ReplaceCategoryModel - Expert use only, replaces the full model.  The model's title policy is applied to the new tree.
+//A model with a version is only replaced while the stored model is at that version, otherwise the current model is replaced
 func (t *CategoryService) ReplaceCategoryModel(ctx context.Context, newUserModel *repository.CategoryUserModel) error {
 	if newUserModel.ID == "" {
 		return fmt.Errorf("Model id required for Replace")
 	}
-	err := categoryRepo.Update(ctx, *newUserModel)
+	if !newUserModel.TitlePolicy.Valid() {
+		return model.ErrInvalidTitlePolicy
+	}
+	current, err := categoryRepo.SelectForUpdate(ctx, *newUserModel)
+	if err != nil {
+		return fmt.Errorf("Category Model replace failed with: %w", err)
+	}
+	if newUserModel.Version != 0 && newUserModel.Version != current.Version {
+		return fmt.Errorf("Category Model replace failed with: %w", repository.ErrCategoryConflict)
+	}
+	newUserModel.Replaces(current)
+	err = newUserModel.ApplyTitlePolicy(newUserModel.TitlePolicy)
+	if err != nil {
+		return fmt.Errorf("Category Model replace failed with: %w", err)
+	}
+	events := []model.CategoryEvent{newCategoryEvent(ctx, model.CategoryModelReplaced, newUserModel.ID)}
+	err = checkCategoryLimits(*newUserModel)
+	if err != nil {
+		return fmt.Errorf("Category Model replace failed with: %w", err)
+	}
+	err = categoryRepo.Update(ctx, *newUserModel, events...)
 	if err != nil {
-		return fmt.Errorf("Category Model replace failed with: %v", err)
+		return fmt.Errorf("Category Model replace failed with: %w", err)
 	}
+	publishCategoryEvents(ctx, events)
 	return nil
 }
 
@@ -71,10 +144,12 @@ func (t *CategoryService) DeleteCategoryModel(ctx context.Context, userModelID s
 	}
 	delTemplate := repository.CategoryUserModel{}
 	delTemplate.ID = userModelID
-	err := categoryRepo.Delete(ctx, delTemplate)
+	events := []model.CategoryEvent{newCategoryEvent(ctx, model.CategoryModelDeleted, userModelID)}
+	err := categoryRepo.Delete(ctx, delTemplate, events...)
 	if err != nil {
-		return fmt.Errorf("Category Model delete failed with: %v", err)
+		return fmt.Errorf("Category Model delete failed with: %w", err)
 	}
+	publishCategoryEvents(ctx, events)
 	return nil
 }
 
@@ -82,19 +157,37 @@ func (t *CategoryService) DeleteCategoryModel(ctx context.Context, userModelID s
 func (t *CategoryService) UpdateCategory(ctx context.Context, categoryModelID string, updatedCategory model.Category) error {
 	catModel := repository.CategoryUserModel{}
 	catModel.ID = categoryModelID
-	model, err := categoryRepo.SelectOne(ctx, catModel)
+	userModel, err := categoryRepo.SelectForUpdate(ctx, catModel)
 	if err != nil {
-		return fmt.Errorf("Service Update Category  Failed with: %v", err)
+		return fmt.Errorf("Service Update Category  Failed with: %w", err)
 	}
-	if model.ID == "" {
+	if userModel.ID == "" {
 		return errors.New("Service Update Category Model not found")
 	}
-	catItem, _ := model.FindChildByID(updatedCategory.ID)
+	catItem, parent := userModel.FindChildByID(updatedCategory.ID)
+	if catItem.ID == "" {
+		return fmt.Errorf("Service Update Category %v Failed with: %w", updatedCategory.ID, model.ErrCategoryNotFound)
+	}
+	siblings, _ := userModel.SiblingsOf(categoryParentID(parent))
+	updatedCategory.Title, err = userModel.TitlePolicy.ResolveTitle(siblings, categoryParentID(parent), updatedCategory.Title, updatedCategory.ID)
+	if err != nil {
+		return fmt.Errorf("Service Update Category Failed with: %w", err)
+	}
+	event := newCategoryEvent(ctx, model.CategoryRenamed, categoryModelID)
+	event.CategoryID = updatedCategory.ID
+	event.OldTitle = catItem.Title
+	event.Title = updatedCategory.Title
 	catItem.Title = updatedCategory.Title
-	err = categoryRepo.Update(ctx, model)
+	events := []model.CategoryEvent{event}
+	err = checkCategoryLimits(userModel)
 	if err != nil {
-		return fmt.Errorf("Category Model update failed with: %v", err)
+		return fmt.Errorf("Category Model update failed with: %w", err)
 	}
+	err = categoryRepo.Update(ctx, userModel, events...)
+	if err != nil {
+		return fmt.Errorf("Category Model update failed with: %w", err)
+	}
+	publishCategoryEvents(ctx, events)
 	return nil
 }
 
@@ -102,22 +195,59 @@ func (t *CategoryService) UpdateCategory(ctx context.Context, categoryModelID st
 func (t *CategoryService) MoveCategory(ctx context.Context, categoryModelID string, newParentID string, categoryIDToMove string) error {
 	catModel := repository.CategoryUserModel{}
 	catModel.ID = categoryModelID
-	model, err := categoryRepo.SelectOne(ctx, catModel)
+	userModel, err := categoryRepo.SelectForUpdate(ctx, catModel)
 	if err != nil {
-		return fmt.Errorf("Service Update Category  Failed with: %v", err)
+		return fmt.Errorf("Service Update Category  Failed with: %w", err)
 	}
-	if model.ID == "" {
+	if userModel.ID == "" {
 		return errors.New("Service Update Category Model not found")
 	}
 	if categoryIDToMove == "" {
 		return errors.New("No category to move was selected")
 	}
-	catItem, _ := model.FindChildByID(newParentID)
-	model.Move(categoryIDToMove, catItem)
-	err = categoryRepo.Update(ctx, model)
+	moved, oldParent := userModel.FindChildByID(categoryIDToMove)
+	if moved.ID == "" {
+		return fmt.Errorf("Service Move Category %v Failed with: %w", categoryIDToMove, model.ErrCategoryNotFound)
+	}
+	//an empty parent is the root
+	catItem, _ := userModel.FindChildByID(newParentID)
+	if newParentID != "" && catItem.ID == "" {
+		return fmt.Errorf("Service Move Category parent %v Failed with: %w", newParentID, model.ErrCategoryNotFound)
+	}
+	if descendant, _ := moved.FindChildByID(newParentID); newParentID == categoryIDToMove || descendant.ID != "" {
+		return fmt.Errorf("Service Move Category %v under %v Failed with: %w", categoryIDToMove, newParentID, model.ErrCategoryMoveCycle)
+	}
+	event := newCategoryEvent(ctx, model.CategoryMoved, categoryModelID)
+	event.CategoryID = categoryIDToMove
+	event.Title = moved.Title
+	event.OldParentID = categoryParentID(oldParent)
+	event.ParentID = catItem.ID
+	events := []model.CategoryEvent{event}
+	//the title policy of the new siblings can rename the moved category, reported as a rename after the move
+	siblings, _ := userModel.SiblingsOf(catItem.ID)
+	title, err := userModel.TitlePolicy.ResolveTitle(siblings, catItem.ID, moved.Title, categoryIDToMove)
+	if err != nil {
+		return fmt.Errorf("Service Move Category Failed with: %w", err)
+	}
+	if title != moved.Title {
+		renamed := newCategoryEvent(ctx, model.CategoryRenamed, categoryModelID)
+		renamed.CategoryID = categoryIDToMove
+		renamed.ParentID = catItem.ID
+		renamed.OldTitle = moved.Title
+		renamed.Title = title
+		events = append(events, renamed)
+		moved.Title = title
+	}
+	userModel.Move(categoryIDToMove, catItem)
+	err = checkCategoryLimits(userModel)
+	if err != nil {
+		return fmt.Errorf("Category Model move failed with: %w", err)
+	}
+	err = categoryRepo.Update(ctx, userModel, events...)
 	if err != nil {
-		return fmt.Errorf("Category Model move failed with: %v", err)
+		return fmt.Errorf("Category Model move failed with: %w", err)
 	}
+	publishCategoryEvents(ctx, events)
 
 	return nil
 }
@@ -126,56 +256,247 @@ func (t *CategoryService) MoveCategory(ctx context.Context, categoryModelID stri
 func (t *CategoryService) AddCategory(ctx context.Context, categoryModelID string, newParentID string, newCategory model.Category) error {
 	catModel := repository.CategoryUserModel{}
 	catModel.ID = categoryModelID
-	model, err := categoryRepo.SelectOne(ctx, catModel)
+	userModel, err := categoryRepo.SelectForUpdate(ctx, catModel)
 	if err != nil {
-		return fmt.Errorf("Service Add Category  Failed with: %v", err)
+		return fmt.Errorf("Service Add Category  Failed with: %w", err)
 	}
-	if model.ID == "" {
+	if userModel.ID == "" {
 		return errors.New("Service Add Category Model not found")
 	}
 	if newCategory.ID == "" {
 		return errors.New("No new category id to add was selected")
 	}
-	catItem, _ := model.FindChildByID(newParentID)
+	catItem, _ := userModel.FindChildByID(newParentID)
+	siblings := catItem.Children
+	if catItem.ID == "" {
+		siblings = userModel.Children
+	}
+	newCategory.Title, err = userModel.TitlePolicy.ResolveTitle(siblings, catItem.ID, newCategory.Title, newCategory.ID)
+	if err == nil {
+		err = newCategory.ApplyTitlePolicy(userModel.TitlePolicy)
+	}
+	if err != nil {
+		return fmt.Errorf("Service Add Category Failed with: %w", err)
+	}
 	//If no parent was found, this is a root menu add
 	if catItem.ID == "" {
-		model.AddChild(newCategory)
+		userModel.AddChild(newCategory)
 	} else {
 		catItem.AddChild(newCategory)
 	}
 
-	err = categoryRepo.Update(ctx, model)
+	event := newCategoryEvent(ctx, model.CategoryAdded, categoryModelID)
+	event.CategoryID = newCategory.ID
+	event.ParentID = catItem.ID
+	event.Title = newCategory.Title
+	events := []model.CategoryEvent{event}
+	err = checkCategoryLimits(userModel)
+	if err != nil {
+		return fmt.Errorf("Category Model add failed with: %w", err)
+	}
+	err = categoryRepo.Update(ctx, userModel, events...)
 	if err != nil {
-		return fmt.Errorf("Category Model add failed with: %v", err)
+		return fmt.Errorf("Category Model add failed with: %w", err)
 	}
+	publishCategoryEvents(ctx, events)
 
 	return nil
 }
 
+//CopyTitlePrefix - prepended to the title of a copied category when renaming is requested
+const CopyTitlePrefix = "Copy of "
+
+//CopyCategory - duplicates a category and its children with new ids under the target parent.  The target model defaults to the source model,
+//an empty target parent copies to the root.  Both models must belong to the calling user.  Returns the new top level copy
+func (t *CategoryService) CopyCategory(ctx context.Context, categoryModelID string, categoryIDToCopy string, targetModelID string, targetParentID string, renameCopy bool) (*model.Category, error) {
+	catModel := repository.CategoryUserModel{}
+	catModel.ID = categoryModelID
+	sourceModel, err := categoryRepo.SelectForUpdate(ctx, catModel)
+	if err != nil {
+		return nil, fmt.Errorf("Service Copy Category  Failed with: %w", err)
+	}
+	if sourceModel.ID == "" {
+		return nil, errors.New("Service Copy Category Model not found")
+	}
+	if categoryIDToCopy == "" {
+		return nil, errors.New("No category to copy was selected")
+	}
+	catItem, _ := sourceModel.FindChildByID(categoryIDToCopy)
+	if catItem.ID == "" {
+		return nil, fmt.Errorf("Category to copy not found: %v", categoryIDToCopy)
+	}
+	copied := catItem.Clone(true)
+	if renameCopy {
+		copied.Title = CopyTitlePrefix + copied.Title
+	}
+
+	targetModel := sourceModel
+	if targetModelID != "" && targetModelID != categoryModelID {
+		catModel.ID = targetModelID
+		targetModel, err = categoryRepo.SelectForUpdate(ctx, catModel)
+		if err != nil {
+			return nil, fmt.Errorf("Service Copy Category  Failed with: %w", err)
+		}
+		if targetModel.ID == "" {
+			return nil, errors.New("Service Copy Category target Model not found")
+		}
+	}
+
+	siblings, ok := targetModel.SiblingsOf(targetParentID)
+	if !ok {
+		return nil, fmt.Errorf("Copy target parent not found: %v", targetParentID)
+	}
+	copied.Title, err = targetModel.TitlePolicy.ResolveTitle(siblings, targetParentID, copied.Title, copied.ID)
+	if err == nil {
+		err = copied.ApplyTitlePolicy(targetModel.TitlePolicy)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Service Copy Category Failed with: %w", err)
+	}
+
+	var added *model.Category
+	if targetParentID == "" {
+		added = targetModel.AddChild(*copied)
+	} else {
+		parent, _ := targetModel.FindChildByID(targetParentID)
+		added = parent.AddChild(*copied)
+	}
+
+	event := newCategoryEvent(ctx, model.CategoryAdded, targetModel.ID)
+	event.CategoryID = added.ID
+	event.ParentID = targetParentID
+	event.Title = added.Title
+	events := []model.CategoryEvent{event}
+	err = checkCategoryLimits(targetModel)
+	if err != nil {
+		return nil, fmt.Errorf("Category Model copy failed with: %w", err)
+	}
+	err = categoryRepo.Update(ctx, targetModel, events...)
+	if err != nil {
+		return nil, fmt.Errorf("Category Model copy failed with: %w", err)
+	}
+	publishCategoryEvents(ctx, events)
+	return added, nil
+}
+
 //DeleteCategory - removes a category from the tree
 func (t *CategoryService) DeleteCategory(ctx context.Context, categoryModelID string, categoryIDToDelete string) error {
 	catModel := repository.CategoryUserModel{}
 	catModel.ID = categoryModelID
-	model, err := categoryRepo.SelectOne(ctx, catModel)
+	userModel, err := categoryRepo.SelectForUpdate(ctx, catModel)
 	if err != nil {
-		return fmt.Errorf("Service Delete Category  Failed with: %v", err)
+		return fmt.Errorf("Service Delete Category  Failed with: %w", err)
 	}
-	if model.ID == "" {
+	if userModel.ID == "" {
 		return errors.New("Service Delete Category Model not found")
 	}
 	if categoryIDToDelete == "" {
 		return errors.New("No new category id to delete was selected")
 	}
-	_, catItem := model.FindChildByID(categoryIDToDelete)
-	catItem.RemoveChildByID(categoryIDToDelete)
-	err = categoryRepo.Update(ctx, model)
+	deleted, catItem := userModel.FindChildByID(categoryIDToDelete)
+	if deleted.ID == "" {
+		return fmt.Errorf("Service Delete Category %v Failed with: %w", categoryIDToDelete, model.ErrCategoryNotFound)
+	}
+	event := newCategoryEvent(ctx, model.CategoryDeleted, categoryModelID)
+	event.CategoryID = categoryIDToDelete
+	event.OldParentID = categoryParentID(catItem)
+	event.Title = deleted.Title
+	//the root handles top level categories, which have no parent
+	userModel.RemoveChildByID(categoryIDToDelete)
+	events := []model.CategoryEvent{event}
+	err = categoryRepo.Update(ctx, userModel, events...)
 	if err != nil {
-		return fmt.Errorf("Category Model delete failed with: %v", err)
+		return fmt.Errorf("Category Model delete failed with: %w", err)
 	}
+	publishCategoryEvents(ctx, events)
 
 	return nil
 }
 
+//ImportCategoryModel - creates a new model from an imported category tree.  The name defaults to the imported root name
+func (t *CategoryService) ImportCategoryModel(ctx context.Context, name string, imported *model.CategoryRoot) (*repository.CategoryUserModel, error) {
+	if name == "" {
+		name = imported.Name
+	}
+	catModel := repository.NewCategoryUserModel(name)
+	catModel.Children = imported.Children
+	catModel.TitlePolicy = defaultTitlePolicy
+	err := catModel.ApplyTitlePolicy(catModel.TitlePolicy)
+	if err != nil {
+		return nil, fmt.Errorf("Category Model import failed with: %w", err)
+	}
+	events := []model.CategoryEvent{newCategoryEvent(ctx, model.CategoryModelReplaced, catModel.ID)}
+	err = checkCategoryLimits(*catModel)
+	if err != nil {
+		return nil, fmt.Errorf("Category Model import failed with: %w", err)
+	}
+	err = categoryRepo.Insert(ctx, *catModel, events...)
+	if err != nil {
+		return nil, fmt.Errorf("Category Model import failed with: %w", err)
+	}
+	publishCategoryEvents(ctx, events)
+	return catModel, nil
+}
+
+//MergeCategoryModel - merges an imported category tree into an existing model, see model.CategoryRoot Merge for matching rules
+func (t *CategoryService) MergeCategoryModel(ctx context.Context, categoryModelID string, imported *model.CategoryRoot) error {
+	catModel := repository.CategoryUserModel{}
+	catModel.ID = categoryModelID
+	catModel, err := categoryRepo.SelectForUpdate(ctx, catModel)
+	if err != nil {
+		return fmt.Errorf("Service Merge Category Model Failed with: %w", err)
+	}
+	if catModel.ID == "" {
+		return errors.New("Service Merge Category Model not found")
+	}
+	catModel.Merge(imported)
+	err = catModel.ApplyTitlePolicy(catModel.TitlePolicy)
+	if err != nil {
+		return fmt.Errorf("Category Model merge failed with: %w", err)
+	}
+	events := []model.CategoryEvent{newCategoryEvent(ctx, model.CategoryModelReplaced, catModel.ID)}
+	err = checkCategoryLimits(catModel)
+	if err != nil {
+		return fmt.Errorf("Category Model merge failed with: %w", err)
+	}
+	err = categoryRepo.Update(ctx, catModel, events...)
+	if err != nil {
+		return fmt.Errorf("Category Model merge failed with: %w", err)
+	}
+	publishCategoryEvents(ctx, events)
+	return nil
+}
+
+//CreateCategoryModelFromTemplate - creates a new model seeded from the named template.  The name defaults to the template title
+func (t *CategoryService) CreateCategoryModelFromTemplate(ctx context.Context, templateName string, name string) (*repository.CategoryUserModel, error) {
+	tmpl, ok := model.GetCategoryTemplate(templateName)
+	if !ok {
+		return nil, fmt.Errorf("Category template not found: %v", templateName)
+	}
+	catModel := repository.NewCategoryUserModel(name)
+	root := tmpl.NewCategoryRoot()
+	if name == "" {
+		catModel.Name = root.Name
+	}
+	catModel.Children = root.Children
+	catModel.TitlePolicy = defaultTitlePolicy
+	err := catModel.ApplyTitlePolicy(catModel.TitlePolicy)
+	if err != nil {
+		return nil, fmt.Errorf("Category Model create from template failed with: %w", err)
+	}
+	events := []model.CategoryEvent{newCategoryEvent(ctx, model.CategoryModelReplaced, catModel.ID)}
+	err = checkCategoryLimits(*catModel)
+	if err != nil {
+		return nil, fmt.Errorf("Category Model create from template failed with: %w", err)
+	}
+	err = categoryRepo.Insert(ctx, *catModel, events...)
+	if err != nil {
+		return nil, fmt.Errorf("Category Model create from template failed with: %w", err)
+	}
+	publishCategoryEvents(ctx, events)
+	return catModel, nil
+}
+
 //NewCategoryService - returns a service interface for the category user model domain
 func NewCategoryService() *CategoryService {
 	return &CategoryService{}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/suared/core-apiuser/repository"
+)
+
+//ListAuditRecords - one page of the model's audit log between from and to, newest first.  Zero times leave the range open, pass the returned cursor for the next page.
+//An invalid cursor returns an error wrapping repository.ErrInvalidCursor
+func (t *CategoryService) ListAuditRecords(ctx context.Context, categoryModelID string, from time.Time, to time.Time, limit int, cursor string) (*repository.CategoryAuditPage, error) {
+	page, err := categoryRepo.SelectAudit(ctx, categoryModelID, from, to, limit, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("Service List Audit Failed with: %w", err)
+	}
+	return &page, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+
+	"github.com/suared/core/security"
+)
+
+func TestCategoryAudit(t *testing.T) {
+	ctx := security.SetupTestAuthFromContext(context.TODO(), 1)
+	svc := NewCategoryService()
+
+	catModel, err := svc.CreateCategoryModelFromTemplate(ctx, "student", "")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+	defer svc.DeleteCategoryModel(ctx, catModel.ID)
+	start := time.Now()
+
+	err = svc.AddCategory(ctx, catModel.ID, "", model.Category{ID: "audit-test-1", Title: "Sports"})
+	if err != nil {
+		t.Fatalf("Add failed with: %v", err)
+	}
+	err = svc.UpdateCategory(ctx, catModel.ID, model.Category{ID: "audit-test-1", Title: "Games"})
+	if err != nil {
+		t.Fatalf("Update failed with: %v", err)
+	}
+
+	page, err := svc.ListAuditRecords(ctx, catModel.ID, time.Time{}, time.Time{}, 0, "")
+	if err != nil || len(page.Records) != 3 {
+		t.Fatalf("Expected create, add and rename records, received: %+v, %v", page, err)
+	}
+	renamed := page.Records[0]
+	if renamed.Type != model.CategoryRenamed || renamed.OldTitle != "Sports" || renamed.Title != "Games" || renamed.ActorID != security.GetAuth(ctx).GetUser() {
+		t.Errorf("Expected the rename first with the old and new titles, received: %+v", renamed)
+	}
+
+	//time range and pages
+	page, err = svc.ListAuditRecords(ctx, catModel.ID, start, time.Time{}, 1, "")
+	if err != nil || len(page.Records) != 1 || page.Cursor == "" || page.Records[0].Type != model.CategoryRenamed {
+		t.Fatalf("Expected the first page of changes since the model was created, received: %+v, %v", page, err)
+	}
+	page, err = svc.ListAuditRecords(ctx, catModel.ID, start, time.Time{}, 1, page.Cursor)
+	if err != nil || len(page.Records) != 1 || page.Records[0].Type != model.CategoryAdded {
+		t.Errorf("Expected the add on the second page, received: %+v, %v", page, err)
+	}
+	page, err = svc.ListAuditRecords(ctx, catModel.ID, time.Time{}, start, 0, "")
+	if err != nil || len(page.Records) != 1 {
+		t.Errorf("Expected only the create before the changes, received: %+v, %v", page, err)
+	}
+	if _, err = svc.ListAuditRecords(ctx, catModel.ID, time.Time{}, time.Time{}, 0, "not a cursor"); !errors.Is(err, repository.ErrInvalidCursor) {
+		t.Errorf("Expected an invalid cursor, received: %v", err)
+	}
+
+	//users without a grant cannot read the audit log
+	_, err = svc.ListAuditRecords(svc.WithCategoryOwner(security.SetupTestAuthFromContext(context.TODO(), 2), security.GetAuth(ctx).GetUser()), catModel.ID, time.Time{}, time.Time{}, 0, "")
+	if !errors.Is(err, repository.ErrForbidden) {
+		t.Errorf("Expected forbidden without a grant, received: %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+	"github.com/suared/core/security"
+)
+
+//EventPublisher - Delivers category change events to downstream consumers.  Publish is called after the change is stored,
+//a failed publish is retried by RelayOutbox when PROCESS_CATEGORY_EVENT_OUTBOX is true
+type EventPublisher interface {
+	Publish(ctx context.Context, events []model.CategoryEvent) error
+}
+
+//Publisher names, set with PROCESS_CATEGORY_EVENT_PUBLISHER
+const (
+	EventPublisherNone   = "none"
+	EventPublisherMemory = "memory"
+	EventPublisherJSONL  = "jsonl"
+)
+
+//eventPublisher - nil when events are not published
+var eventPublisher EventPublisher
+
+//eventOutboxEnabled - store events with the change so they survive a failed publish
+var eventOutboxEnabled bool
+
+//outboxRelayGrace - outbox events younger than this are left to the request publishing them, set with PROCESS_CATEGORY_RELAY_GRACE
+var outboxRelayGrace = time.Minute
+
+//outboxRelayBatch - events published together by the relay
+const outboxRelayBatch = 100
+
+func init() {
+	if grace, err := time.ParseDuration(os.Getenv("PROCESS_CATEGORY_RELAY_GRACE")); err == nil && grace >= 0 {
+		outboxRelayGrace = grace
+	}
+}
+
+//MemoryEventLimit - events kept by a MemoryEventPublisher, the oldest are dropped first
+const MemoryEventLimit = 1000
+
+//MemoryEventPublisher - Keeps the most recently published events in process, for tests and local development
+type MemoryEventPublisher struct {
+	mutex  sync.Mutex
+	events []model.CategoryEvent
+}
+
+//Publish - appends the events, dropping the oldest past MemoryEventLimit
+func (publisher *MemoryEventPublisher) Publish(ctx context.Context, events []model.CategoryEvent) error {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+	publisher.events = append(publisher.events, events...)
+	if dropped := len(publisher.events) - MemoryEventLimit; dropped > 0 {
+		publisher.events = append([]model.CategoryEvent{}, publisher.events[dropped:]...)
+	}
+	return nil
+}
+
+//Events - returns a copy of the events kept
+func (publisher *MemoryEventPublisher) Events() []model.CategoryEvent {
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+	return append([]model.CategoryEvent{}, publisher.events...)
+}
+
+//JSONLEventPublisher - Appends events to a local file, one JSON object per line
+type JSONLEventPublisher struct {
+	mutex sync.Mutex
+	path  string
+}
+
+//NewJSONLEventPublisher - the file is created on first publish if needed
+func NewJSONLEventPublisher(path string) *JSONLEventPublisher {
+	return &JSONLEventPublisher{path: path}
+}
+
+//Publish - appends one line per event, the events are written with a single write so lines from concurrent publishes are not interleaved
+func (publisher *JSONLEventPublisher) Publish(ctx context.Context, events []model.CategoryEvent) error {
+	var lines []byte
+	for i := range events {
+		line, err := json.Marshal(events[i])
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+
+	publisher.mutex.Lock()
+	defer publisher.mutex.Unlock()
+	file, err := os.OpenFile(publisher.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Unable to open event file: %v", err)
+	}
+	_, err = file.Write(lines)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("Unable to write event file: %v", err)
+	}
+	return file.Close()
+}
+
+//newEventPublisher - returns the publisher for the configured name, nil for none
+func newEventPublisher(name string, path string) (EventPublisher, error) {
+	switch name {
+	case "", EventPublisherNone:
+		return nil, nil
+	case EventPublisherMemory:
+		return &MemoryEventPublisher{}, nil
+	case EventPublisherJSONL:
+		if path == "" {
+			return nil, fmt.Errorf("PROCESS_CATEGORY_EVENT_FILE is required for the %v publisher", EventPublisherJSONL)
+		}
+		return NewJSONLEventPublisher(path), nil
+	}
+	return nil, fmt.Errorf("Unknown PROCESS_CATEGORY_EVENT_PUBLISHER: %v", name)
+}
+
+//SetEventPublisher - replaces the configured publisher, nil stops publishing
+func (t *CategoryService) SetEventPublisher(publisher EventPublisher) {
+	eventPublisher = publisher
+}
+
+//setEventOutbox - the repository stores events in the outbox with the change, they are removed once published
+func setEventOutbox(enabled bool) {
+	eventOutboxEnabled = enabled
+	categoryCache.SetOutbox(enabled)
+}
+
+//newCategoryEvent - an event for the model the context works with, see repository.CategoryOwner
+func newCategoryEvent(ctx context.Context, eventType model.CategoryEventType, modelID string) model.CategoryEvent {
+	event := model.NewCategoryEvent(eventType, repository.CategoryOwner(ctx), modelID)
+	event.ActorID = security.GetAuth(ctx).GetUser()
+	return event
+}
+
+//categoryParentID - FindChildByID returns a nil parent for top level categories, events use an empty id for the root
+func categoryParentID(parent *model.Category) string {
+	if parent == nil {
+		return ""
+	}
+	return parent.ID
+}
+
+//publishCategoryEvents - called after the change is stored.  Failures are logged vs. returned as the change itself succeeded, the outbox keeps the events for the relay
+func publishCategoryEvents(ctx context.Context, events []model.CategoryEvent) {
+	if len(events) == 0 {
+		return
+	}
+	//live subscribers in this process and registered webhooks are always told, independent of the configured publisher
+	categoryEventHub.Publish(ctx, events)
+	deliverCategoryWebhooks(ctx, requestWebhookStore{}, events)
+	if eventPublisher != nil {
+		err := eventPublisher.Publish(ctx, events)
+		if err != nil {
+			log.Printf("Unable to publish category events, outbox enabled: %v, received: %v", eventOutboxEnabled, err)
+			return
+		}
+	}
+	if eventOutboxEnabled {
+		err := categoryRepo.DeleteOutbox(ctx, events)
+		if err != nil {
+			//may be published again by a relay, consumers use the event id to ignore repeats
+			log.Printf("Unable to clear published category events from the outbox: %v", err)
+		}
+	}
+}
+
+//relayCategoryEvents - sends events read back from the outbox the way publishCategoryEvents does, then clears them with remove
+func relayCategoryEvents(ctx context.Context, webhooks categoryWebhookStore, events []model.CategoryEvent, remove func(ctx context.Context, events []model.CategoryEvent) error) error {
+	categoryEventHub.Publish(ctx, events)
+	deliverCategoryWebhooks(ctx, webhooks, events)
+	if eventPublisher != nil {
+		err := eventPublisher.Publish(ctx, events)
+		if err != nil {
+			return fmt.Errorf("publish failed with: %v", err)
+		}
+	}
+	err := remove(ctx, events)
+	if err != nil {
+		return fmt.Errorf("clear failed with: %v", err)
+	}
+	return nil
+}
+
+//RelayCategoryEvents - publishes the calling user's events left in the outbox by a failed publish.  Returns the number published
+func (t *CategoryService) RelayCategoryEvents(ctx context.Context) (int, error) {
+	if !eventOutboxEnabled {
+		return 0, nil
+	}
+	events, err := categoryRepo.SelectOutbox(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("Service Relay Events Failed with: %v", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+	err = relayCategoryEvents(ctx, requestWebhookStore{}, events, categoryRepo.DeleteOutbox)
+	if err != nil {
+		return 0, fmt.Errorf("Service Relay Events %v", err)
+	}
+	return len(events), nil
+}
+
+//RelayOutbox - publishes every user's events left in the outbox for longer than PROCESS_CATEGORY_RELAY_GRACE, e.g. by a failed publish or a
+//process that stopped before publishing, and operator changes.  Returns the number published.  Run by the relay Lambda and the web api worker
+func (t *CategoryService) RelayOutbox(ctx context.Context) (int, error) {
+	if !eventOutboxEnabled {
+		return 0, nil
+	}
+	relayed := 0
+	var batch []model.CategoryEvent
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := relayCategoryEvents(ctx, categoryOperator, batch, categoryOperator.DeleteOutbox)
+		if err != nil {
+			return err
+		}
+		relayed += len(batch)
+		batch = nil
+		return nil
+	}
+	//the scan returns each user's events together, oldest first, so they are published in order per user
+	err := categoryOperator.ScanOutbox(ctx, time.Now().Add(-outboxRelayGrace), func(event model.CategoryEvent) error {
+		if len(batch) > 0 && (batch[0].UserID != event.UserID || len(batch) >= outboxRelayBatch) {
+			err := flush()
+			if err != nil {
+				return err
+			}
+		}
+		batch = append(batch, event)
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return relayed, fmt.Errorf("Service Relay Outbox %v", err)
+	}
+	return relayed, nil
+}
+
+//RunOutboxRelay - calls RelayOutbox and RetryWebhookDeliveries every interval until the context is done, for the long running web api
+func (t *CategoryService) RunOutboxRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			relayed, err := t.RelayOutbox(ctx)
+			if err != nil {
+				log.Printf("Category outbox relay failed after %v events: %v", relayed, err)
+			}
+			attempted, err := t.RetryWebhookDeliveries(ctx)
+			if err != nil {
+				log.Printf("Category webhook retries failed after %v deliveries: %v", attempted, err)
+			}
+		}
+	}
+}
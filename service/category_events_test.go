@@ -0,0 +1,246 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/suared/core-apiuser/model"
+
+	"github.com/suared/core/security"
+)
+
+type failingEventPublisher struct{}
+
+func (publisher *failingEventPublisher) Publish(ctx context.Context, events []model.CategoryEvent) error {
+	return errors.New("publish failed")
+}
+
+func TestCategoryEvents(t *testing.T) {
+	ctx := context.TODO()
+	ctx = security.SetupTestAuthFromContext(ctx, 1)
+
+	svc := NewCategoryService()
+	savedPublisher, savedOutbox := eventPublisher, eventOutboxEnabled
+	defer func() {
+		eventPublisher = savedPublisher
+		setEventOutbox(savedOutbox)
+	}()
+	setEventOutbox(true)
+
+	memory := &MemoryEventPublisher{}
+	svc.SetEventPublisher(memory)
+
+	catModel, err := svc.CreateCategoryModelFromTemplate(ctx, "student", "")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+	classes, _ := catModel.FindChildByName("Classes")
+	svc.AddCategory(ctx, catModel.ID, "", model.Category{ID: "events-test-1", Title: "Sports"})
+	svc.UpdateCategory(ctx, catModel.ID, model.Category{ID: "events-test-1", Title: "Athletics"})
+	svc.MoveCategory(ctx, catModel.ID, classes.ID, "events-test-1")
+	svc.DeleteCategory(ctx, catModel.ID, "events-test-1")
+
+	expected := []model.CategoryEventType{model.CategoryModelReplaced, model.CategoryAdded, model.CategoryRenamed, model.CategoryMoved, model.CategoryDeleted}
+	events := memory.Events()
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %v events, received: %+v", len(expected), events)
+	}
+	for i := range expected {
+		if events[i].Type != expected[i] || events[i].ModelID != catModel.ID || events[i].UserID == "" {
+			t.Errorf("Unexpected event %v: %+v", i, events[i])
+		}
+	}
+	if events[2].OldTitle != "Sports" || events[2].Title != "Athletics" {
+		t.Errorf("Expected rename titles, received: %+v", events[2])
+	}
+	if events[3].OldParentID != "" || events[3].ParentID != classes.ID {
+		t.Errorf("Expected move from the root to Classes, received: %+v", events[3])
+	}
+
+	//published events are cleared from the outbox
+	relayed, err := svc.RelayCategoryEvents(ctx)
+	if err != nil || relayed != 0 {
+		t.Errorf("Expected empty outbox, received: %v, %v", relayed, err)
+	}
+
+	//a failed publish leaves the event for the relay
+	svc.SetEventPublisher(&failingEventPublisher{})
+	err = svc.DeleteCategoryModel(ctx, catModel.ID)
+	if err != nil {
+		t.Errorf("Delete User Model failed, err: %v", err)
+	}
+	svc.SetEventPublisher(memory)
+	relayed, err = svc.RelayCategoryEvents(ctx)
+	if err != nil || relayed != 1 {
+		t.Errorf("Expected 1 relayed event, received: %v, %v", relayed, err)
+	}
+	events = memory.Events()
+	if events[len(events)-1].Type != model.CategoryModelDeleted {
+		t.Errorf("Expected relayed model deleted event, received: %+v", events[len(events)-1])
+	}
+}
+
+func TestCategoryOutboxRelay(t *testing.T) {
+	ctx := security.SetupTestAuthFromContext(context.TODO(), 1)
+
+	svc := NewCategoryService()
+	savedPublisher, savedOutbox, savedGrace := eventPublisher, eventOutboxEnabled, outboxRelayGrace
+	defer func() {
+		eventPublisher = savedPublisher
+		setEventOutbox(savedOutbox)
+		outboxRelayGrace = savedGrace
+	}()
+	setEventOutbox(true)
+
+	//the failed publishes leave the events in the outbox
+	svc.SetEventPublisher(&failingEventPublisher{})
+	catModel, err := svc.CreateCategoryModelFromTemplate(ctx, "student", "")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+	defer svc.DeleteCategoryModel(ctx, catModel.ID)
+	err = svc.AddCategory(ctx, catModel.ID, "", model.Category{ID: "relay-test-1", Title: "Sports"})
+	if err != nil {
+		t.Fatalf("Add category failed with: %v", err)
+	}
+
+	//events younger than the grace are left to the request publishing them
+	memory := &MemoryEventPublisher{}
+	svc.SetEventPublisher(memory)
+	outboxRelayGrace = time.Hour
+	_, err = svc.RelayOutbox(ctx)
+	if err != nil {
+		t.Fatalf("Relay failed with: %v", err)
+	}
+	for _, event := range memory.Events() {
+		if event.ModelID == catModel.ID {
+			t.Errorf("Expected events inside the grace to be left, received: %+v", event)
+		}
+	}
+
+	outboxRelayGrace = 0
+	relayed, err := svc.RelayOutbox(ctx)
+	if err != nil || relayed < 2 {
+		t.Fatalf("Expected the outbox to be relayed, received: %v, %v", relayed, err)
+	}
+	var types []model.CategoryEventType
+	for _, event := range memory.Events() {
+		if event.ModelID == catModel.ID {
+			types = append(types, event.Type)
+		}
+	}
+	if len(types) != 2 || types[0] != model.CategoryModelReplaced || types[1] != model.CategoryAdded {
+		t.Errorf("Expected the model's events in order, received: %v", types)
+	}
+
+	//the relayed events are cleared
+	remaining, err := categoryRepo.SelectOutbox(ctx)
+	if err != nil {
+		t.Fatalf("Select outbox failed with: %v", err)
+	}
+	for _, event := range remaining {
+		if event.ModelID == catModel.ID {
+			t.Errorf("Expected the relayed event to be cleared, received: %+v", event)
+		}
+	}
+}
+
+func TestJSONLEventPublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	publisher := NewJSONLEventPublisher(path)
+	events := []model.CategoryEvent{
+		model.NewCategoryEvent(model.CategoryAdded, "testuser1", "model1"),
+		model.NewCategoryEvent(model.CategoryDeleted, "testuser1", "model1"),
+	}
+	for i := range events {
+		err := publisher.Publish(context.TODO(), events[i:i+1])
+		if err != nil {
+			t.Fatalf("Publish failed with: %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Unable to open event file: %v", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	var read []model.CategoryEvent
+	for scanner.Scan() {
+		event := model.CategoryEvent{}
+		err = json.Unmarshal(scanner.Bytes(), &event)
+		if err != nil {
+			t.Fatalf("Invalid event line: %v", err)
+		}
+		read = append(read, event)
+	}
+	if len(read) != 2 || read[0].ID != events[0].ID || read[1].Type != model.CategoryDeleted {
+		t.Errorf("Unexpected events read back: %+v", read)
+	}
+}
+
+func TestCategoryEventHub(t *testing.T) {
+	hub := NewCategoryEventHub()
+	first := model.NewCategoryEvent(model.CategoryAdded, "testuser1", "model1")
+	second := model.NewCategoryEvent(model.CategoryRenamed, "testuser1", "model1")
+	hub.Publish(context.TODO(), []model.CategoryEvent{first, second})
+
+	//resume after the first event replays the second
+	missed, resumed, events, cancel := hub.Subscribe("testuser1", "model1", first.ID)
+	if !resumed || len(missed) != 1 || missed[0].ID != second.ID {
+		t.Errorf("Expected resume with the second event, received: %v, %+v", resumed, missed)
+	}
+
+	//other users' and models' events are not delivered
+	hub.Publish(context.TODO(), []model.CategoryEvent{model.NewCategoryEvent(model.CategoryAdded, "testuser2", "model1")})
+	third := model.NewCategoryEvent(model.CategoryDeleted, "testuser1", "model1")
+	hub.Publish(context.TODO(), []model.CategoryEvent{third})
+	if received := <-events; received.ID != third.ID {
+		t.Errorf("Expected the third event live, received: %+v", received)
+	}
+	cancel()
+	if _, open := <-events; open {
+		t.Errorf("Expected the channel closed on cancel")
+	}
+
+	//an unknown last event id asks the subscriber to reload
+	_, resumed, _, cancel = hub.Subscribe("testuser1", "model1", "unknown")
+	cancel()
+	if resumed {
+		t.Errorf("Expected no resume for an unbuffered event id")
+	}
+
+	//a subscriber that falls behind is closed so it reconnects
+	_, _, events, cancel = hub.Subscribe("testuser1", "model1", "")
+	defer cancel()
+	for i := 0; i <= hubSubscriberQueue; i++ {
+		hub.Publish(context.TODO(), []model.CategoryEvent{model.NewCategoryEvent(model.CategoryAdded, "testuser1", "model1")})
+	}
+	count := 0
+	for range events {
+		count++
+	}
+	if count != hubSubscriberQueue {
+		t.Errorf("Expected %v queued events before close, received: %v", hubSubscriberQueue, count)
+	}
+}
+
+func TestMemoryEventPublisherLimit(t *testing.T) {
+	publisher := &MemoryEventPublisher{}
+	for i := 0; i < MemoryEventLimit+5; i++ {
+		event := model.NewCategoryEvent(model.CategoryAdded, "testuser1", "model1")
+		event.CategoryID = strconv.Itoa(i)
+		publisher.Publish(context.TODO(), []model.CategoryEvent{event})
+	}
+	events := publisher.Events()
+	if len(events) != MemoryEventLimit || events[0].CategoryID != "5" || events[len(events)-1].CategoryID != strconv.Itoa(MemoryEventLimit+4) {
+		t.Errorf("Expected the most recent %v events, received: %v from %v", MemoryEventLimit, len(events), events[0].CategoryID)
+	}
+}
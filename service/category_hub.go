@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+//hubBufferSize - recent events kept per model for Last-Event-ID resume
+const hubBufferSize = 100
+
+//hubMaxModels - models with buffered events kept in process, the least recently changed without subscribers are dropped first
+const hubMaxModels = 1000
+
+//hubSubscriberQueue - events queued per subscriber, a subscriber that falls further behind is closed so it reconnects and resumes
+const hubSubscriberQueue = 32
+
+type hubModel struct {
+	recent      []model.CategoryEvent
+	subscribers map[chan model.CategoryEvent]struct{}
+	updated     time.Time
+}
+
+//CategoryEventHub - In process fan out of category events to live subscribers (e.g. SSE) with a short replay buffer.
+//Only sees changes made through this process, another instance or Lambda needs a shared publisher
+type CategoryEventHub struct {
+	mutex  sync.Mutex
+	models map[string]*hubModel
+}
+
+//categoryEventHub - every event published by the service is sent here in addition to the configured publisher
+var categoryEventHub = NewCategoryEventHub()
+
+//NewCategoryEventHub - an empty hub
+func NewCategoryEventHub() *CategoryEventHub {
+	return &CategoryEventHub{models: make(map[string]*hubModel)}
+}
+
+func hubKey(userID string, modelID string) string {
+	return userID + "/" + modelID
+}
+
+//Publish - buffers the events and sends them to the model's subscribers, implements EventPublisher
+func (hub *CategoryEventHub) Publish(ctx context.Context, events []model.CategoryEvent) error {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+	for _, event := range events {
+		key := hubKey(event.UserID, event.ModelID)
+		entry, ok := hub.models[key]
+		if !ok {
+			hub.evict()
+			entry = &hubModel{subscribers: make(map[chan model.CategoryEvent]struct{})}
+			hub.models[key] = entry
+		}
+		entry.updated = time.Now()
+		entry.recent = append(entry.recent, event)
+		if len(entry.recent) > hubBufferSize {
+			entry.recent = entry.recent[len(entry.recent)-hubBufferSize:]
+		}
+		for subscriber := range entry.subscribers {
+			select {
+			case subscriber <- event:
+			default:
+				//too far behind, closing makes the client reconnect and resume from its last event
+				delete(entry.subscribers, subscriber)
+				close(subscriber)
+			}
+		}
+	}
+	return nil
+}
+
+//evict - drops the least recently changed model without subscribers once the hub is full, called with the lock held
+func (hub *CategoryEventHub) evict() {
+	if len(hub.models) < hubMaxModels {
+		return
+	}
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range hub.models {
+		if len(entry.subscribers) == 0 && (oldestKey == "" || entry.updated.Before(oldest)) {
+			oldestKey, oldest = key, entry.updated
+		}
+	}
+	if oldestKey != "" {
+		delete(hub.models, oldestKey)
+	}
+}
+
+//Subscribe - returns the events after lastEventID followed by a channel of new events, call cancel when done.
+//resumed is false when lastEventID is set but no longer buffered, the subscriber should reload the model.  The channel is closed if the subscriber falls behind
+func (hub *CategoryEventHub) Subscribe(userID string, modelID string, lastEventID string) (missed []model.CategoryEvent, resumed bool, events <-chan model.CategoryEvent, cancel func()) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+	key := hubKey(userID, modelID)
+	entry, ok := hub.models[key]
+	if !ok {
+		hub.evict()
+		entry = &hubModel{subscribers: make(map[chan model.CategoryEvent]struct{}), updated: time.Now()}
+		hub.models[key] = entry
+	}
+
+	resumed = lastEventID == ""
+	if !resumed {
+		for i := range entry.recent {
+			if entry.recent[i].ID == lastEventID {
+				missed = append(missed, entry.recent[i+1:]...)
+				resumed = true
+				break
+			}
+		}
+	}
+
+	subscriber := make(chan model.CategoryEvent, hubSubscriberQueue)
+	entry.subscribers[subscriber] = struct{}{}
+	cancel = func() {
+		hub.mutex.Lock()
+		defer hub.mutex.Unlock()
+		if _, ok := entry.subscribers[subscriber]; ok {
+			delete(entry.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+	return missed, resumed, subscriber, cancel
+}
+
+//SubscribeCategoryEvents - live events for one of the calling user's models or a model shared with them, see CategoryEventHub Subscribe.
+//The caller is expected to have read the model first so access has been checked
+func (t *CategoryService) SubscribeCategoryEvents(ctx context.Context, modelID string, lastEventID string) ([]model.CategoryEvent, bool, <-chan model.CategoryEvent, func()) {
+	return categoryEventHub.Subscribe(repository.CategoryOwner(ctx), modelID, lastEventID)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/suared/core-apiuser/repository"
+)
+
+//CategoryIdempotentResponse - a stored response replayed for a retried change
+type CategoryIdempotentResponse struct {
+	Status int
+	Header map[string]string
+	Body   []byte
+}
+
+//CategoryIdempotentChange - a claimed Idempotency-Key.  Replay is the first response when the key was already used for the same request,
+//otherwise the change should be made and finished with CompleteIdempotentChange or AbandonIdempotentChange
+type CategoryIdempotentChange struct {
+	Replay      *CategoryIdempotentResponse
+	reservation *repository.CategoryIdempotencyDAO
+}
+
+//BeginIdempotentChange - Claims the calling user's Idempotency-Key for the request, errors wrap repository.ErrIdempotencyKeyReused,
+//ErrIdempotencyInProgress or ErrInvalidIdempotencyKey
+func (t *CategoryService) BeginIdempotentChange(ctx context.Context, key string, requestHash string) (*CategoryIdempotentChange, error) {
+	record, err := categoryRepo.ReserveIdempotencyKey(ctx, key, requestHash)
+	if err != nil {
+		return nil, fmt.Errorf("Service Idempotency Key Failed with: %w", err)
+	}
+	if !record.Completed() {
+		return &CategoryIdempotentChange{reservation: record}, nil
+	}
+	return &CategoryIdempotentChange{Replay: &CategoryIdempotentResponse{Status: record.Status, Header: record.Header, Body: record.Body}}, nil
+}
+
+//CompleteIdempotentChange - stores the response so retries with the key replay it.  Fails with repository.ErrIdempotencyLeaseLost when the
+//change took longer than the lease and a retry took the key over
+func (t *CategoryService) CompleteIdempotentChange(ctx context.Context, change *CategoryIdempotentChange, response CategoryIdempotentResponse) error {
+	err := categoryRepo.SaveIdempotencyResult(ctx, change.reservation, response.Status, response.Header, response.Body)
+	if err != nil {
+		return fmt.Errorf("Service Idempotency Save Failed with: %w", err)
+	}
+	return nil
+}
+
+//AbandonIdempotentChange - releases the key so a retry makes the change again
+func (t *CategoryService) AbandonIdempotentChange(ctx context.Context, key string) error {
+	err := categoryRepo.DeleteIdempotencyKey(ctx, key)
+	if err != nil {
+		return fmt.Errorf("Service Idempotency Release Failed with: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/suared/core-apiuser/repository"
+	"github.com/suared/core/security"
+	"github.com/suared/core/uuid"
+)
+
+func TestCategoryIdempotentChange(t *testing.T) {
+	ctx := security.SetupTestAuthFromContext(context.TODO(), 1)
+	svc := NewCategoryService()
+	key := "test-" + uuid.NewUUID()
+	defer svc.AbandonIdempotentChange(ctx, key)
+
+	change, err := svc.BeginIdempotentChange(ctx, key, "hash-1")
+	if err != nil || change.Replay != nil {
+		t.Fatalf("Expected a new key to be reserved, received: %+v, %v", change, err)
+	}
+	if _, err = svc.BeginIdempotentChange(ctx, key, "hash-1"); !errors.Is(err, repository.ErrIdempotencyInProgress) {
+		t.Errorf("Expected the key to be in progress, received: %v", err)
+	}
+
+	response := CategoryIdempotentResponse{Status: http.StatusCreated, Header: map[string]string{"Location": "/Lifeapp/Categories/test"}, Body: []byte("created")}
+	err = svc.CompleteIdempotentChange(ctx, change, response)
+	if err != nil {
+		t.Fatalf("Complete failed with: %v", err)
+	}
+	replayed, err := svc.BeginIdempotentChange(ctx, key, "hash-1")
+	if err != nil || replayed.Replay == nil {
+		t.Fatalf("Expected the stored response to replay, received: %+v, %v", replayed, err)
+	}
+	if replay := replayed.Replay; replay.Status != http.StatusCreated || replay.Header["Location"] != "/Lifeapp/Categories/test" || string(replay.Body) != "created" {
+		t.Errorf("Expected the stored response to replay, received: %+v", replay)
+	}
+	if _, err = svc.BeginIdempotentChange(ctx, key, "hash-2"); !errors.Is(err, repository.ErrIdempotencyKeyReused) {
+		t.Errorf("Expected a different request to be refused, received: %v", err)
+	}
+
+	//keys are per user
+	other := security.SetupTestAuthFromContext(context.TODO(), 2)
+	defer svc.AbandonIdempotentChange(other, key)
+	if change, err = svc.BeginIdempotentChange(other, key, "hash-2"); err != nil || change.Replay != nil {
+		t.Errorf("Expected another user's key to be separate, received: %+v, %v", change, err)
+	}
+
+	//an abandoned key makes the change again
+	err = svc.AbandonIdempotentChange(ctx, key)
+	if err != nil {
+		t.Fatalf("Abandon failed with: %v", err)
+	}
+	if change, err = svc.BeginIdempotentChange(ctx, key, "hash-2"); err != nil || change.Replay != nil {
+		t.Errorf("Expected a released key to be reserved again, received: %+v, %v", change, err)
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+//categoryLimits - the limits for the model, its own limits within the deployment limits
+func categoryLimits(userModel repository.CategoryUserModel) model.CategoryLimits {
+	return userModel.Limits.Within(categoryCache.Limits())
+}
+
+//checkCategoryLimits - called before a changed model is stored, returns a *model.CategoryLimitError when the tree is over a limit
+func checkCategoryLimits(userModel repository.CategoryUserModel) error {
+	return userModel.CheckLimits(categoryLimits(userModel))
+}
+
+//GetCategoryLimits - the limits that apply to the model
+func (t *CategoryService) GetCategoryLimits(ctx context.Context, categoryModelID string) (model.CategoryLimits, error) {
+	catModel := repository.CategoryUserModel{}
+	catModel.ID = categoryModelID
+	catModel, err := categoryRepo.SelectOne(ctx, catModel)
+	if err != nil {
+		return model.CategoryLimits{}, fmt.Errorf("Service Get Limits Failed with: %w", err)
+	}
+	if catModel.ID == "" {
+		return model.CategoryLimits{}, errors.New("Service Get Limits Model not found")
+	}
+	return categoryLimits(catModel), nil
+}
+
+//SetCategoryLimits - sets the model's own limits, they can only tighten the deployment limits.  Refused with a *model.CategoryLimitError
+//when the model is already over the new limits
+func (t *CategoryService) SetCategoryLimits(ctx context.Context, categoryModelID string, limits model.CategoryLimits) error {
+	if !limits.Valid() {
+		return errors.New("Category limits must not be negative")
+	}
+	catModel := repository.CategoryUserModel{}
+	catModel.ID = categoryModelID
+	catModel, err := categoryRepo.SelectForUpdate(ctx, catModel)
+	if err != nil {
+		return fmt.Errorf("Service Set Limits Failed with: %w", err)
+	}
+	if catModel.ID == "" {
+		return errors.New("Service Set Limits Model not found")
+	}
+	catModel.Limits = limits
+	err = checkCategoryLimits(catModel)
+	if err != nil {
+		return fmt.Errorf("Service Set Limits Failed with: %w", err)
+	}
+	events := []model.CategoryEvent{newCategoryEvent(ctx, model.CategoryModelReplaced, catModel.ID)}
+	err = categoryRepo.Update(ctx, catModel, events...)
+	if err != nil {
+		return fmt.Errorf("Category Model limits update failed with: %w", err)
+	}
+	publishCategoryEvents(ctx, events)
+	return nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/suared/core-apiuser/model"
+
+	"github.com/suared/core/security"
+)
+
+func TestCategoryLimits(t *testing.T) {
+	ctx := security.SetupTestAuthFromContext(context.TODO(), 1)
+	svc := NewCategoryService()
+
+	catModel, err := svc.CreateCategoryModelFromTemplate(ctx, "student", "")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+	defer svc.DeleteCategoryModel(ctx, catModel.ID)
+	classes, _ := catModel.FindChildByName("Classes")
+
+	var limitErr *model.CategoryLimitError
+	err = svc.AddCategory(ctx, catModel.ID, "", model.Category{ID: "limits-test-1", Title: strings.Repeat("x", categoryCache.Limits().MaxTitleLength+1)})
+	if !errors.As(err, &limitErr) || limitErr.Limit != model.LimitTitleLength {
+		t.Errorf("Expected the deployment title length limit, received: %v", err)
+	}
+
+	//a model's own limits are tighter than the deployment limits
+	err = svc.SetCategoryLimits(ctx, catModel.ID, model.CategoryLimits{MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("Set limits failed with: %v", err)
+	}
+	limits, err := svc.GetCategoryLimits(ctx, catModel.ID)
+	if err != nil || limits.MaxDepth != 2 || limits.MaxChildren != categoryCache.Limits().MaxChildren {
+		t.Errorf("Expected the model depth within the deployment limits, received: %+v, %v", limits, err)
+	}
+	err = svc.AddCategory(ctx, catModel.ID, classes.ID, model.Category{ID: "limits-test-2", Title: "Math"})
+	if err != nil {
+		t.Fatalf("Add failed with: %v", err)
+	}
+	err = svc.AddCategory(ctx, catModel.ID, "limits-test-2", model.Category{ID: "limits-test-3", Title: "Algebra"})
+	if !errors.As(err, &limitErr) || limitErr.Limit != model.LimitDepth {
+		t.Errorf("Expected the model depth limit, received: %v", err)
+	}
+	//limits the model is already over are refused
+	if err = svc.SetCategoryLimits(ctx, catModel.ID, model.CategoryLimits{MaxDepth: 1}); !errors.As(err, &limitErr) {
+		t.Errorf("Expected limits below the current tree to be refused, received: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+//CategoryModelSearchResult - a search result with the model it was found in, single models are searched with model.CategoryRoot.Search
+type CategoryModelSearchResult struct {
+	ModelID   string
+	ModelName string
+	model.CategorySearchResult
+}
+
+//SearchAllCategoryModels - categories matching the query across all of the calling user's models, best matches first
+func (t *CategoryService) SearchAllCategoryModels(ctx context.Context, query string, opts model.CategorySearchOptions) ([]CategoryModelSearchResult, error) {
+	var results []CategoryModelSearchResult
+	cursor := ""
+	for {
+		page, err := categoryRepo.SelectPage(ctx, repository.CategoryUserModel{}, repository.MaxPageLimit, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("Service Search All Failed with: %w", err)
+		}
+		for i := range page.Models {
+			for _, result := range page.Models[i].Search(query, opts) {
+				results = append(results, CategoryModelSearchResult{ModelID: page.Models[i].ID, ModelName: page.Models[i].Name, CategorySearchResult: result})
+			}
+		}
+		cursor = page.Cursor
+		if cursor == "" {
+			break
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].RanksBefore(results[j].CategorySearchResult)
+	})
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results, nil
+}
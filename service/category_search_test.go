@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/suared/core-apiuser/model"
+
+	"github.com/suared/core/security"
+)
+
+func TestSearchAllCategoryModels(t *testing.T) {
+	ctx := security.SetupTestAuthFromContext(context.TODO(), 1)
+	svc := NewCategoryService()
+
+	first, err := svc.CreateCategoryModelFromTemplate(ctx, "student", "")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+	defer svc.DeleteCategoryModel(ctx, first.ID)
+	second, err := svc.CreateCategoryModelFromTemplate(ctx, "student", "")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+	defer svc.DeleteCategoryModel(ctx, second.ID)
+	err = svc.AddCategory(ctx, second.ID, "", model.Category{ID: "search-test-1", Title: "Xylophone Lessons"})
+	if err != nil {
+		t.Fatalf("Add failed with: %v", err)
+	}
+
+	results, err := svc.SearchAllCategoryModels(ctx, "xylophon", model.CategorySearchOptions{})
+	if err != nil || len(results) != 1 || results[0].ModelID != second.ID || results[0].Match != model.MatchPrefix {
+		t.Fatalf("Expected the prefix match in the second model, received: %+v, %v", results, err)
+	}
+	results, err = svc.SearchAllCategoryModels(ctx, "Classes", model.CategorySearchOptions{Match: model.MatchExact})
+	found := map[string]bool{}
+	for _, result := range results {
+		found[result.ModelID] = true
+	}
+	if err != nil || !found[first.ID] || !found[second.ID] {
+		t.Errorf("Expected matches from both models, received: %+v, %v", results, err)
+	}
+}
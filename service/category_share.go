@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/suared/core-apiuser/repository"
+)
+
+//WithCategoryOwner - model calls made with the returned context work on the owner's model, the caller needs a grant from the owner.  An empty owner returns ctx unchanged
+func (t *CategoryService) WithCategoryOwner(ctx context.Context, ownerID string) context.Context {
+	return repository.WithCategoryOwner(ctx, ownerID)
+}
+
+//CreateInvite - an invite to the model with the role (editor or viewer), send the invite Token to the invitee.  Only the owner can invite
+func (t *CategoryService) CreateInvite(ctx context.Context, categoryModelID string, role string, ttl time.Duration) (*repository.CategoryInviteDAO, error) {
+	shareRole, err := repository.ParseShareRole(role)
+	if err != nil {
+		return nil, err
+	}
+	catModel := repository.CategoryUserModel{}
+	catModel.ID = categoryModelID
+	catModel, err = categoryRepo.SelectOne(ctx, catModel)
+	if err != nil {
+		return nil, fmt.Errorf("Service Create Invite Failed with: %w", err)
+	}
+	if catModel.ID == "" {
+		return nil, errors.New("Service Create Invite Model not found")
+	}
+	invite, err := categoryRepo.InsertInvite(ctx, categoryModelID, shareRole, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("Service Create Invite Failed with: %w", err)
+	}
+	return invite, nil
+}
+
+//AcceptInvite - gives the calling user the invite's role on the owner's model.  Invites can be accepted once
+func (t *CategoryService) AcceptInvite(ctx context.Context, token string) (*repository.CategorySharedDAO, error) {
+	shared, err := categoryRepo.AcceptInvite(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("Service Accept Invite Failed with: %w", err)
+	}
+	return shared, nil
+}
+
+//ListShares - the users the model is shared with, owner only
+func (t *CategoryService) ListShares(ctx context.Context, categoryModelID string) ([]*repository.CategoryGrantDAO, error) {
+	grants, err := categoryRepo.SelectGrants(ctx, categoryModelID)
+	if err != nil {
+		return nil, fmt.Errorf("Service List Shares Failed with: %w", err)
+	}
+	return grants, nil
+}
+
+//ListSharedModels - the models other users have shared with the calling user
+func (t *CategoryService) ListSharedModels(ctx context.Context) ([]*repository.CategorySharedDAO, error) {
+	shares, err := categoryRepo.SelectShared(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Service List Shared Models Failed with: %w", err)
+	}
+	return shares, nil
+}
+
+//RemoveShare - the owner removes a user's access, or a user leaves a model shared with them
+func (t *CategoryService) RemoveShare(ctx context.Context, categoryModelID string, granteeID string) error {
+	err := categoryRepo.DeleteGrant(ctx, categoryModelID, granteeID)
+	if err != nil {
+		return fmt.Errorf("Service Remove Share Failed with: %w", err)
+	}
+	return nil
+}
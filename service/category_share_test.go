@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+
+	"github.com/suared/core/security"
+)
+
+func TestCategorySharing(t *testing.T) {
+	ownerCtx := security.SetupTestAuthFromContext(context.TODO(), 1)
+	editorCtx := security.SetupTestAuthFromContext(context.TODO(), 2)
+	viewerCtx := security.SetupTestAuthFromContext(context.TODO(), 3)
+	svc := NewCategoryService()
+
+	catModel, err := svc.CreateCategoryModelFromTemplate(ownerCtx, "student", "")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+	defer svc.DeleteCategoryModel(ownerCtx, catModel.ID)
+	ownerID := security.GetAuth(ownerCtx).GetUser()
+
+	//without a grant the owner's model is not visible
+	_, err = svc.GetCategoryModel(svc.WithCategoryOwner(editorCtx, ownerID), catModel.ID)
+	if !errors.Is(err, repository.ErrForbidden) {
+		t.Errorf("Expected forbidden before the invite is accepted, received: %v", err)
+	}
+	if _, err = svc.CreateInvite(ownerCtx, catModel.ID, "owner", 0); !errors.Is(err, repository.ErrInvalidRole) {
+		t.Errorf("Expected the owner role to be rejected, received: %v", err)
+	}
+
+	editorInvite, err := svc.CreateInvite(ownerCtx, catModel.ID, "editor", 0)
+	if err != nil {
+		t.Fatalf("Create invite failed with: %v", err)
+	}
+	viewerInvite, err := svc.CreateInvite(ownerCtx, catModel.ID, "viewer", 0)
+	if err != nil {
+		t.Fatalf("Create invite failed with: %v", err)
+	}
+	//invites can only be created by the owner
+	if _, err = svc.CreateInvite(svc.WithCategoryOwner(editorCtx, ownerID), catModel.ID, "viewer", 0); !errors.Is(err, repository.ErrForbidden) {
+		t.Errorf("Expected forbidden invite by a non owner, received: %v", err)
+	}
+
+	shared, err := svc.AcceptInvite(editorCtx, editorInvite.Token())
+	if err != nil || shared.OwnerID != ownerID || shared.Role != repository.RoleEditor {
+		t.Fatalf("Accept invite failed with: %+v, %v", shared, err)
+	}
+	if _, err = svc.AcceptInvite(viewerCtx, editorInvite.Token()); !errors.Is(err, repository.ErrInvalidInvite) {
+		t.Errorf("Expected a used invite to be rejected, received: %v", err)
+	}
+	if _, err = svc.AcceptInvite(viewerCtx, viewerInvite.Token()); err != nil {
+		t.Fatalf("Accept invite failed with: %v", err)
+	}
+
+	sharedModels, err := svc.ListSharedModels(editorCtx)
+	if err != nil || len(sharedModels) != 1 || sharedModels[0].ModelID != catModel.ID {
+		t.Errorf("Expected the model in the editor's shared list, received: %+v, %v", sharedModels, err)
+	}
+	grants, err := svc.ListShares(ownerCtx, catModel.ID)
+	if err != nil || len(grants) != 2 {
+		t.Errorf("Expected 2 shares, received: %+v, %v", grants, err)
+	}
+
+	//editors change categories in the owner's model
+	editorCtx = svc.WithCategoryOwner(editorCtx, ownerID)
+	err = svc.AddCategory(editorCtx, catModel.ID, "", model.Category{ID: "share-test-1", Title: "Chores"})
+	if err != nil {
+		t.Errorf("Editor add failed with: %v", err)
+	}
+	ownerModel, _ := svc.GetCategoryModel(ownerCtx, catModel.ID)
+	if added, _ := ownerModel.FindChildByID("share-test-1"); added.ID == "" {
+		t.Errorf("Expected the editor's category in the owner's model")
+	}
+	if err = svc.DeleteCategoryModel(editorCtx, catModel.ID); !errors.Is(err, repository.ErrForbidden) {
+		t.Errorf("Expected forbidden model delete by an editor, received: %v", err)
+	}
+
+	//viewers read but cannot change
+	viewerCtx = svc.WithCategoryOwner(viewerCtx, ownerID)
+	viewed, err := svc.GetCategoryModel(viewerCtx, catModel.ID)
+	if err != nil || viewed.ID != catModel.ID {
+		t.Errorf("Viewer read failed with: %v", err)
+	}
+	err = svc.UpdateCategory(viewerCtx, catModel.ID, model.Category{ID: "share-test-1", Title: "Housework"})
+	if !errors.Is(err, repository.ErrForbidden) {
+		t.Errorf("Expected forbidden viewer edit, received: %v", err)
+	}
+
+	//removing the share takes effect right away
+	err = svc.RemoveShare(ownerCtx, catModel.ID, security.GetAuth(viewerCtx).GetUser())
+	if err != nil {
+		t.Errorf("Remove share failed with: %v", err)
+	}
+	if _, err = svc.GetCategoryModel(viewerCtx, catModel.ID); !errors.Is(err, repository.ErrForbidden) {
+		t.Errorf("Expected forbidden after the share was removed, received: %v", err)
+	}
+}
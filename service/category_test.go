@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
 
 	_ "github.com/suared/core/infra"
 	"github.com/suared/core/security"
@@ -141,6 +143,147 @@ func TestSimpleCategoryServiceLifeCycle(t *testing.T) {
 	}
 }
 
+func TestCategoryModelFromTemplate(t *testing.T) {
+	ctx := context.TODO()
+	ctx = security.SetupTestAuthFromContext(ctx, 1)
+
+	svc := NewCategoryService()
+
+	catModel, err := svc.CreateCategoryModelFromTemplate(ctx, "student", "")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+	if catModel.Name != "Student" {
+		t.Errorf("Expected template title as the model name, received: %v", catModel.Name)
+	}
+
+	catModel, err = svc.GetCategoryModel(ctx, catModel.ID)
+	if err != nil {
+		t.Errorf("Get Model failed for template model, err: %v", err)
+	}
+	exams, examsParent := catModel.FindChildByName("Exams")
+	if exams.ID == "" || examsParent.Title != "Classes" {
+		t.Errorf("Expected Exams under Classes, received: %v under %v", exams, examsParent)
+	}
+
+	_, err = svc.CreateCategoryModelFromTemplate(ctx, "does not exist", "")
+	if err == nil {
+		t.Error("Expected error for unknown template")
+	}
+
+	//Cleanup...
+	err = svc.DeleteCategoryModel(ctx, catModel.ID)
+	if err != nil {
+		t.Errorf("Delete User Model failed, err: %v", err)
+	}
+}
+
+func TestListCategoryModels(t *testing.T) {
+	ctx := context.TODO()
+	ctx = security.SetupTestAuthFromContext(ctx, 1)
+
+	svc := NewCategoryService()
+
+	var created []string
+	for i := 0; i < 3; i++ {
+		catModel, err := svc.CreateCategoryModelFromTemplate(ctx, "student", "")
+		if err != nil {
+			t.Fatalf("Create from template failed with: %v", err)
+		}
+		created = append(created, catModel.ID)
+	}
+
+	//walk every page, each created model is seen exactly once
+	seen := make(map[string]int)
+	cursor := ""
+	for pages := 0; pages < 50; pages++ {
+		page, err := svc.ListCategoryModels(ctx, 2, cursor)
+		if err != nil {
+			t.Fatalf("List models failed with: %v", err)
+		}
+		if len(page.Models) > 2 {
+			t.Errorf("Expected at most 2 models per page, received: %v", len(page.Models))
+		}
+		for i := range page.Models {
+			seen[page.Models[i].ID]++
+		}
+		cursor = page.Cursor
+		if cursor == "" {
+			break
+		}
+	}
+	for i := range created {
+		if seen[created[i]] != 1 {
+			t.Errorf("Expected model %v listed once, seen: %v", created[i], seen[created[i]])
+		}
+	}
+
+	_, err := svc.ListCategoryModels(ctx, 2, "not a cursor")
+	if !errors.Is(err, repository.ErrInvalidCursor) {
+		t.Errorf("Expected invalid cursor error, received: %v", err)
+	}
+
+	//Cleanup...
+	for i := range created {
+		err = svc.DeleteCategoryModel(ctx, created[i])
+		if err != nil {
+			t.Errorf("Delete User Model failed, err: %v", err)
+		}
+	}
+}
+
+func TestCopyCategory(t *testing.T) {
+	ctx := context.TODO()
+	ctx = security.SetupTestAuthFromContext(ctx, 1)
+
+	svc := NewCategoryService()
+
+	source, err := svc.CreateCategoryModelFromTemplate(ctx, "household budget", "Copy Source")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+	target, err := svc.CreateCategoryModelFromTemplate(ctx, "student", "Copy Target")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+
+	housing := source.GetChildByName("Housing")
+	food := source.GetChildByName("Food")
+
+	//same model with rename
+	copied, err := svc.CopyCategory(ctx, source.ID, housing.ID, "", food.ID, true)
+	if err != nil {
+		t.Errorf("Copy within model failed with: %v", err)
+	}
+	source, _ = svc.GetCategoryModel(ctx, source.ID)
+	copyCat, copyParent := source.FindChildByName("Copy of Housing")
+	if copyCat.ID != copied.ID || copyParent.Title != "Food" || len(copyCat.Children) != 3 {
+		t.Errorf("Expected Copy of Housing with 3 children under Food, received: %v under %v", copyCat, copyParent)
+	}
+	if copyCat.Children[0].ID == housing.Children[0].ID {
+		t.Error("Expected new ids for copied children")
+	}
+
+	//across models to the root
+	_, err = svc.CopyCategory(ctx, source.ID, housing.ID, target.ID, "", false)
+	if err != nil {
+		t.Errorf("Copy across models failed with: %v", err)
+	}
+	target, _ = svc.GetCategoryModel(ctx, target.ID)
+	if len(target.GetChildByName("Housing").Children) != 3 {
+		t.Errorf("Expected Housing copied to the target root, received: %v", target.Children)
+	}
+
+	_, err = svc.CopyCategory(ctx, source.ID, housing.ID, target.ID, "missing", false)
+	if err == nil {
+		t.Error("Expected error for a missing target parent")
+	}
+
+	//Cleanup...
+	svc.DeleteCategoryModel(ctx, source.ID)
+	svc.DeleteCategoryModel(ctx, target.ID)
+}
+
 //Leveraging this start from model test
 func getDisconnectedCategorySet() *model.Category {
 	/*
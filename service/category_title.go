@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+//defaultTitlePolicy - given to new models, set with PROCESS_CATEGORY_TITLE_POLICY.  Models created before it was set allow duplicates
+var defaultTitlePolicy model.CategoryTitlePolicy
+
+func init() {
+	var err error
+	defaultTitlePolicy, err = model.ParseCategoryTitlePolicy(os.Getenv("PROCESS_CATEGORY_TITLE_POLICY"))
+	if err != nil {
+		panic("Invalid PROCESS_CATEGORY_TITLE_POLICY while initializing the category service: " + err.Error())
+	}
+}
+
+//SetTitlePolicy - changes the model's sibling title policy.  Existing duplicates are suffixed by the suffix policy, the reject policy
+//is refused with a *model.DuplicateTitleError while the model still has duplicates
+func (t *CategoryService) SetTitlePolicy(ctx context.Context, categoryModelID string, policy model.CategoryTitlePolicy) error {
+	if !policy.Valid() {
+		return model.ErrInvalidTitlePolicy
+	}
+	catModel := repository.CategoryUserModel{}
+	catModel.ID = categoryModelID
+	catModel, err := categoryRepo.SelectForUpdate(ctx, catModel)
+	if err != nil {
+		return fmt.Errorf("Service Set Title Policy Failed with: %w", err)
+	}
+	if catModel.ID == "" {
+		return errors.New("Service Set Title Policy Model not found")
+	}
+	catModel.TitlePolicy = policy
+	err = catModel.ApplyTitlePolicy(policy)
+	if err != nil {
+		return fmt.Errorf("Service Set Title Policy Failed with: %w", err)
+	}
+	//suffixes can take a title over the length limit
+	err = checkCategoryLimits(catModel)
+	if err != nil {
+		return fmt.Errorf("Service Set Title Policy Failed with: %w", err)
+	}
+	events := []model.CategoryEvent{newCategoryEvent(ctx, model.CategoryModelReplaced, catModel.ID)}
+	err = categoryRepo.Update(ctx, catModel, events...)
+	if err != nil {
+		return fmt.Errorf("Category Model title policy update failed with: %w", err)
+	}
+	publishCategoryEvents(ctx, events)
+	return nil
+}
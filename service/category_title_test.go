@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/suared/core-apiuser/model"
+
+	"github.com/suared/core/security"
+)
+
+func TestCategoryTitlePolicy(t *testing.T) {
+	ctx := security.SetupTestAuthFromContext(context.TODO(), 1)
+	svc := NewCategoryService()
+
+	catModel, err := svc.CreateCategoryModelFromTemplate(ctx, "student", "")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+	defer svc.DeleteCategoryModel(ctx, catModel.ID)
+	classes, _ := catModel.FindChildByName("Classes")
+
+	err = svc.SetTitlePolicy(ctx, catModel.ID, model.CategoryTitlePolicy{Uniqueness: model.TitlesReject, IgnoreCase: true})
+	if err != nil {
+		t.Fatalf("Set title policy failed with: %v", err)
+	}
+	var duplicate *model.DuplicateTitleError
+	err = svc.AddCategory(ctx, catModel.ID, "", model.Category{ID: "title-test-1", Title: "classes"})
+	if !errors.As(err, &duplicate) || duplicate.ExistingID != classes.ID {
+		t.Errorf("Expected a duplicate title error on add, received: %v", err)
+	}
+	err = svc.AddCategory(ctx, catModel.ID, "", model.Category{ID: "title-test-1", Title: "Clubs"})
+	if err != nil {
+		t.Fatalf("Add failed with: %v", err)
+	}
+	if err = svc.UpdateCategory(ctx, catModel.ID, model.Category{ID: "title-test-1", Title: "CLASSES"}); !errors.As(err, &duplicate) {
+		t.Errorf("Expected a duplicate title error on update, received: %v", err)
+	}
+
+	//the suffix policy renames vs. refusing
+	err = svc.SetTitlePolicy(ctx, catModel.ID, model.CategoryTitlePolicy{Uniqueness: model.TitlesSuffix})
+	if err != nil {
+		t.Fatalf("Set title policy failed with: %v", err)
+	}
+	err = svc.AddCategory(ctx, catModel.ID, classes.ID, model.Category{ID: "title-test-2", Title: "Clubs"})
+	if err != nil {
+		t.Fatalf("Add failed with: %v", err)
+	}
+	err = svc.MoveCategory(ctx, catModel.ID, "", "title-test-2")
+	if err != nil {
+		t.Fatalf("Move failed with: %v", err)
+	}
+	stored, _ := svc.GetCategoryModel(ctx, catModel.ID)
+	if moved, _ := stored.FindChildByID("title-test-2"); moved.Title != "Clubs (2)" {
+		t.Errorf("Expected the moved category to be suffixed, received: %v", moved.Title)
+	}
+	if stored.TitlePolicy.Uniqueness != model.TitlesSuffix {
+		t.Errorf("Expected the stored policy, received: %+v", stored.TitlePolicy)
+	}
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/suared/core-apiuser/repository"
+)
+
+//CategoryUsageReport - what the model owner has stored against the per user quotas
+type CategoryUsageReport struct {
+	repository.CategoryUsage
+	Quota repository.CategoryQuota `json:"quota"`
+}
+
+//GetUsage - the models and storage used by the owner, see repository.CategoryQuota
+func (t *CategoryService) GetUsage(ctx context.Context) (*CategoryUsageReport, error) {
+	usage, err := categoryRepo.SelectUsage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Service Get Usage Failed with: %w", err)
+	}
+	return &CategoryUsageReport{CategoryUsage: usage, Quota: categoryCache.Quota()}, nil
+}
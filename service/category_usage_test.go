@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+
+	"github.com/suared/core/security"
+)
+
+func TestCategoryUsageQuota(t *testing.T) {
+	ctx := security.SetupTestAuthFromContext(context.TODO(), 1)
+	svc := NewCategoryService()
+
+	before, err := svc.GetUsage(ctx)
+	if err != nil {
+		t.Fatalf("Get usage failed with: %v", err)
+	}
+	quota := categoryCache.Quota()
+	defer categoryCache.SetQuota(quota)
+	categoryCache.SetQuota(repository.CategoryQuota{MaxModels: before.ModelCount + 1})
+
+	catModel, err := svc.CreateCategoryModelFromTemplate(ctx, "student", "")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+	defer svc.DeleteCategoryModel(ctx, catModel.ID)
+	after, err := svc.GetUsage(ctx)
+	if err != nil || after.ModelCount != before.ModelCount+1 || after.StorageBytes <= before.StorageBytes || after.Quota.MaxModels != before.ModelCount+1 {
+		t.Errorf("Expected the new model in the usage, before: %+v, after: %+v, %v", before, after, err)
+	}
+
+	_, err = svc.CreateCategoryModelFromTemplate(ctx, "student", "")
+	if !errors.Is(err, repository.ErrQuotaExceeded) {
+		t.Errorf("Expected the models quota to refuse another model, received: %v", err)
+	}
+	//changes to existing models stay within the models quota
+	err = svc.AddCategory(ctx, catModel.ID, "", model.Category{ID: "quota-test-1", Title: "Clubs"})
+	if err != nil {
+		t.Errorf("Expected an update within the quota, received: %v", err)
+	}
+}
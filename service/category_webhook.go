@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+	"github.com/suared/core-apiuser/webhook"
+	"github.com/suared/core/uuid"
+)
+
+//webhookDeliverer - attempts and backoff set with PROCESS_CATEGORY_WEBHOOK_MAX_ATTEMPTS and PROCESS_CATEGORY_WEBHOOK_BACKOFF
+var webhookDeliverer = webhook.NewDeliverer(5, time.Second)
+
+//webhookDeliveries - first attempts still in progress, see WaitWebhookDeliveries
+var webhookDeliveries sync.WaitGroup
+
+//deliveryLease - how long an attempt holds a delivery, longer than the client timeout.  A delivery left locked by a stopped process is
+//retried once it passes
+const deliveryLease = 30 * time.Second
+
+func init() {
+	if maxAttempts, err := strconv.Atoi(os.Getenv("PROCESS_CATEGORY_WEBHOOK_MAX_ATTEMPTS")); err == nil && maxAttempts > 0 {
+		webhookDeliverer.MaxAttempts = maxAttempts
+	}
+	if backoff, err := time.ParseDuration(os.Getenv("PROCESS_CATEGORY_WEBHOOK_BACKOFF")); err == nil && backoff > 0 {
+		webhookDeliverer.BaseBackoff = backoff
+	}
+}
+
+//CategoryWebhookPayload - the JSON body POSTed to a webhook, signed with the webhook secret in the webhook.SignatureHeader header
+type CategoryWebhookPayload struct {
+	DeliveryID string              `json:"deliveryID"`
+	WebhookID  string              `json:"webhookID"`
+	Event      model.CategoryEvent `json:"event"`
+}
+
+//categoryWebhookStore - the webhooks and delivery records of the events' owners.  Requests use the signed in user's access, the outbox relay
+//runs without a user and uses the operator
+type categoryWebhookStore interface {
+	SelectWebhooks(ctx context.Context, userID string, modelID string) ([]*repository.CategoryWebhookDAO, error)
+	SaveDelivery(ctx context.Context, delivery *repository.CategoryDeliveryDAO) error
+	InsertDelivery(ctx context.Context, delivery *repository.CategoryDeliveryDAO) error
+}
+
+//requestWebhookStore - authorized as the signed in user, who is the owner or an editor of the changed model
+type requestWebhookStore struct{}
+
+func (store requestWebhookStore) SelectWebhooks(ctx context.Context, userID string, modelID string) ([]*repository.CategoryWebhookDAO, error) {
+	return categoryRepo.SelectWebhooks(repository.WithCategoryOwner(ctx, userID), modelID)
+}
+
+func (store requestWebhookStore) SaveDelivery(ctx context.Context, delivery *repository.CategoryDeliveryDAO) error {
+	return categoryRepo.SaveDelivery(ctx, delivery)
+}
+
+func (store requestWebhookStore) InsertDelivery(ctx context.Context, delivery *repository.CategoryDeliveryDAO) error {
+	return categoryRepo.InsertDelivery(ctx, delivery)
+}
+
+//detachedContext - keeps the request values (e.g. the auth user) for deliveries that outlive the request, without its cancellation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (ctx detachedContext) Deadline() (time.Time, bool)       { return time.Time{}, false }
+func (ctx detachedContext) Done() <-chan struct{}             { return nil }
+func (ctx detachedContext) Err() error                        { return nil }
+func (ctx detachedContext) Value(key interface{}) interface{} { return ctx.parent.Value(key) }
+
+//AddWebhook - registers a webhook for one of the calling user's models.  The returned secret is used to verify signatures and is not returned again
+func (t *CategoryService) AddWebhook(ctx context.Context, categoryModelID string, url string) (*repository.CategoryWebhookDAO, error) {
+	err := webhook.ValidateURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("Service Add Webhook Failed with: %w", err)
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, fmt.Errorf("Service Add Webhook unable to create a secret: %w", err)
+	}
+	hook := &repository.CategoryWebhookDAO{
+		WebhookID: uuid.NewUUID(),
+		ModelID:   categoryModelID,
+		URL:       url,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}
+	err = categoryRepo.InsertWebhook(ctx, hook)
+	if err != nil {
+		return nil, fmt.Errorf("Service Add Webhook Failed with: %w", err)
+	}
+	return hook, nil
+}
+
+//ListWebhooks - the webhooks registered for the model, secrets are removed
+func (t *CategoryService) ListWebhooks(ctx context.Context, categoryModelID string) ([]*repository.CategoryWebhookDAO, error) {
+	hooks, err := categoryRepo.SelectWebhooks(ctx, categoryModelID)
+	if err != nil {
+		return nil, fmt.Errorf("Service List Webhooks Failed with: %w", err)
+	}
+	for _, hook := range hooks {
+		hook.Secret = ""
+	}
+	return hooks, nil
+}
+
+//DeleteWebhook - stops deliveries to the webhook, deliveries already recorded are kept
+func (t *CategoryService) DeleteWebhook(ctx context.Context, categoryModelID string, webhookID string) error {
+	err := categoryRepo.DeleteWebhook(ctx, categoryModelID, webhookID)
+	if err != nil {
+		return fmt.Errorf("Service Delete Webhook Failed with: %w", err)
+	}
+	return nil
+}
+
+//ListWebhookDeliveries - the most recent deliveries for the model, newest first.  Status dead marks the dead letters
+func (t *CategoryService) ListWebhookDeliveries(ctx context.Context, categoryModelID string, limit int) ([]*repository.CategoryDeliveryDAO, error) {
+	deliveries, err := categoryRepo.SelectDeliveries(ctx, categoryModelID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("Service List Webhook Deliveries Failed with: %w", err)
+	}
+	return deliveries, nil
+}
+
+//WaitWebhookDeliveries - blocks until the first attempts started so far have finished, failed deliveries are left pending for
+//RetryWebhookDeliveries.  Lambda calls it before returning as background work is frozen between invocations
+func (t *CategoryService) WaitWebhookDeliveries() {
+	webhookDeliveries.Wait()
+}
+
+//RetryWebhookDeliveries - makes the next attempt of every pending delivery that is due, including deliveries whose first attempt was
+//never made.  Run with the outbox relay, returns the number attempted
+func (t *CategoryService) RetryWebhookDeliveries(ctx context.Context) (int, error) {
+	var due []*repository.CategoryDeliveryDAO
+	err := categoryOperator.ScanDeliveries(ctx, time.Now(), func(delivery *repository.CategoryDeliveryDAO) error {
+		due = append(due, delivery)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("Service Retry Webhook Deliveries Failed with: %w", err)
+	}
+	attempted := 0
+	for _, delivery := range due {
+		err = categoryOperator.ClaimDelivery(ctx, delivery, time.Now().Add(deliveryLease))
+		if errors.Is(err, repository.ErrDeliveryClaimed) {
+			continue
+		}
+		if err != nil {
+			return attempted, fmt.Errorf("Service Retry Webhook Deliveries Failed with: %w", err)
+		}
+		hook, err := selectWebhook(ctx, delivery)
+		if err != nil {
+			return attempted, fmt.Errorf("Service Retry Webhook Deliveries Failed with: %w", err)
+		}
+		if hook == nil || delivery.Event == nil {
+			//the webhook was removed, or the delivery was recorded before events were kept with it
+			delivery.Status = repository.DeliveryDead
+			delivery.LastError = "Webhook or event no longer available"
+			delivery.LockUntil(time.Time{})
+			delivery.UpdatedAt = time.Now().UTC()
+			saveDelivery(ctx, categoryOperator, delivery)
+			continue
+		}
+		attemptCategoryWebhook(ctx, categoryOperator, hook, delivery)
+		attempted++
+	}
+	return attempted, nil
+}
+
+//selectWebhook - the delivery's webhook, nil if it was removed
+func selectWebhook(ctx context.Context, delivery *repository.CategoryDeliveryDAO) (*repository.CategoryWebhookDAO, error) {
+	hooks, err := categoryOperator.SelectWebhooks(ctx, delivery.UserID, delivery.ModelID)
+	if err != nil {
+		return nil, err
+	}
+	for _, hook := range hooks {
+		if hook.WebhookID == delivery.WebhookID {
+			return hook, nil
+		}
+	}
+	return nil, nil
+}
+
+//categoryDeliveryID - one delivery per event and webhook, so an event published again by the relay is not delivered twice.  Starts with
+//the time ordered event id so deliveries list newest first
+func categoryDeliveryID(eventID string, webhookID string) string {
+	return eventID + "." + webhookID
+}
+
+//deliverCategoryWebhooks - records a pending delivery per event and webhook of the changed model and makes the first attempt in the
+//background so it does not hold up the request.  Failed attempts are retried by RetryWebhookDeliveries
+func deliverCategoryWebhooks(ctx context.Context, store categoryWebhookStore, events []model.CategoryEvent) {
+	hooksByModel := make(map[string][]*repository.CategoryWebhookDAO)
+	for _, event := range events {
+		hooks, ok := hooksByModel[event.ModelID]
+		if !ok {
+			var err error
+			hooks, err = store.SelectWebhooks(ctx, event.UserID, event.ModelID)
+			if err != nil {
+				log.Printf("Unable to read webhooks for category model: %v, received: %v", event.ModelID, err)
+			}
+			hooksByModel[event.ModelID] = hooks
+		}
+		for _, hook := range hooks {
+			event := event
+			now := time.Now().UTC()
+			delivery := &repository.CategoryDeliveryDAO{
+				UserID:        hook.UserID,
+				DeliveryID:    categoryDeliveryID(event.ID, hook.WebhookID),
+				WebhookID:     hook.WebhookID,
+				ModelID:       hook.ModelID,
+				EventID:       event.ID,
+				EventType:     string(event.Type),
+				Status:        repository.DeliveryPending,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+				Event:         &event,
+				NextAttemptAt: now,
+			}
+			//held by the first attempt below
+			delivery.LockUntil(now.Add(deliveryLease))
+			err := store.InsertDelivery(ctx, delivery)
+			if errors.Is(err, repository.ErrDeliveryClaimed) {
+				continue
+			}
+			if err != nil {
+				//attempted anyway, the attempt saves the delivery again
+				log.Printf("Unable to record webhook delivery: %v, received: %v", delivery.DeliveryID, err)
+			}
+			webhookDeliveries.Add(1)
+			go func(hook *repository.CategoryWebhookDAO, delivery *repository.CategoryDeliveryDAO) {
+				defer webhookDeliveries.Done()
+				attemptCategoryWebhook(detachedContext{parent: ctx}, store, hook, delivery)
+			}(hook, delivery)
+		}
+	}
+}
+
+//attemptCategoryWebhook - makes one attempt of a delivery the caller holds, then records it delivered, pending with the next attempt time
+//or dead after the last attempt
+func attemptCategoryWebhook(ctx context.Context, store categoryWebhookStore, hook *repository.CategoryWebhookDAO, delivery *repository.CategoryDeliveryDAO) {
+	body, err := json.Marshal(CategoryWebhookPayload{DeliveryID: delivery.DeliveryID, WebhookID: hook.WebhookID, Event: *delivery.Event})
+	if err != nil {
+		log.Printf("Unable to encode webhook payload for event: %v, received: %v", delivery.EventID, err)
+		return
+	}
+	target := webhook.Target{ID: hook.WebhookID, URL: hook.URL, Secret: hook.Secret}
+	statusCode, err := webhookDeliverer.Attempt(ctx, target, delivery.EventType, delivery.DeliveryID, body)
+
+	delivery.Attempts++
+	delivery.StatusCode = statusCode
+	delivery.LastError = ""
+	delivery.LockUntil(time.Time{})
+	delivery.UpdatedAt = time.Now().UTC()
+	switch {
+	case err == nil:
+		delivery.Status = repository.DeliveryDelivered
+	case delivery.Attempts >= webhookDeliverer.MaxAttempts:
+		delivery.Status = repository.DeliveryDead
+		delivery.LastError = err.Error()
+		log.Printf("Webhook delivery: %v to webhook: %v failed after %v attempts: %v", delivery.DeliveryID, hook.WebhookID, delivery.Attempts, err)
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = delivery.UpdatedAt.Add(webhookDeliverer.RetryAfter(delivery.Attempts))
+	}
+	saveDelivery(ctx, store, delivery)
+}
+
+func saveDelivery(ctx context.Context, store categoryWebhookStore, delivery *repository.CategoryDeliveryDAO) {
+	err := store.SaveDelivery(ctx, delivery)
+	if err != nil {
+		log.Printf("Unable to record webhook delivery: %v, received: %v", delivery.DeliveryID, err)
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+	"github.com/suared/core-apiuser/webhook"
+
+	"github.com/suared/core/security"
+)
+
+func TestCategoryWebhooks(t *testing.T) {
+	ctx := context.TODO()
+	ctx = security.SetupTestAuthFromContext(ctx, 1)
+	svc := NewCategoryService()
+
+	savedAttempts, savedBackoff, savedClient := webhookDeliverer.MaxAttempts, webhookDeliverer.BaseBackoff, webhookDeliverer.Client
+	defer func() {
+		webhookDeliverer.MaxAttempts, webhookDeliverer.BaseBackoff, webhookDeliverer.Client = savedAttempts, savedBackoff, savedClient
+	}()
+	//the receivers are local
+	webhookDeliverer.MaxAttempts, webhookDeliverer.BaseBackoff, webhookDeliverer.Client = 2, 10*time.Millisecond, webhook.NewClient(true)
+
+	var mutex sync.Mutex
+	var received []CategoryWebhookPayload
+	var secret string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		if !webhook.Verify(secret, body, r.Header.Get(webhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		payload := CategoryWebhookPayload{}
+		json.Unmarshal(body, &payload)
+		received = append(received, payload)
+	}))
+	defer receiver.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	catModel, err := svc.CreateCategoryModelFromTemplate(ctx, "student", "")
+	if err != nil {
+		t.Fatalf("Create from template failed with: %v", err)
+	}
+	defer svc.DeleteCategoryModel(ctx, catModel.ID)
+
+	if _, err = svc.AddWebhook(ctx, catModel.ID, "ftp://example.com"); err == nil {
+		t.Errorf("Expected an invalid url error")
+	}
+	hook, err := svc.AddWebhook(ctx, catModel.ID, receiver.URL)
+	if err != nil || hook.Secret == "" {
+		t.Fatalf("Add webhook failed with: %v", err)
+	}
+	mutex.Lock()
+	secret = hook.Secret
+	mutex.Unlock()
+	deadHook, err := svc.AddWebhook(ctx, catModel.ID, failing.URL)
+	if err != nil {
+		t.Fatalf("Add webhook failed with: %v", err)
+	}
+
+	hooks, err := svc.ListWebhooks(ctx, catModel.ID)
+	if err != nil || len(hooks) != 2 || hooks[0].Secret != "" {
+		t.Errorf("Expected 2 webhooks without secrets, received: %+v, %v", hooks, err)
+	}
+
+	svc.AddCategory(ctx, catModel.ID, "", model.Category{ID: "webhook-test-1", Title: "Sports"})
+	svc.WaitWebhookDeliveries()
+
+	mutex.Lock()
+	if len(received) != 1 || received[0].Event.Type != model.CategoryAdded || received[0].Event.CategoryID != "webhook-test-1" || received[0].WebhookID != hook.WebhookID {
+		t.Errorf("Expected a signed CategoryAdded delivery, received: %+v", received)
+	}
+	mutex.Unlock()
+
+	//the failed delivery is left pending for the retry
+	checkDeliveries := func(failedStatus string, failedAttempts int) []*repository.CategoryDeliveryDAO {
+		deliveries, err := svc.ListWebhookDeliveries(ctx, catModel.ID, 10)
+		if err != nil || len(deliveries) != 2 {
+			t.Fatalf("Expected 2 deliveries, received: %+v, %v", deliveries, err)
+		}
+		for _, delivery := range deliveries {
+			switch delivery.WebhookID {
+			case hook.WebhookID:
+				if delivery.Status != repository.DeliveryDelivered || delivery.Attempts != 1 {
+					t.Errorf("Expected delivered on the first attempt, received: %+v", delivery)
+				}
+			case deadHook.WebhookID:
+				if delivery.Status != failedStatus || delivery.Attempts != failedAttempts || delivery.StatusCode != http.StatusInternalServerError {
+					t.Errorf("Expected %v after %v attempts, received: %+v", failedStatus, failedAttempts, delivery)
+				}
+			default:
+				t.Errorf("Unexpected delivery: %+v", delivery)
+			}
+		}
+		return deliveries
+	}
+	deliveries := checkDeliveries(repository.DeliveryPending, 1)
+
+	//a relayed event is not delivered again
+	deliverCategoryWebhooks(ctx, requestWebhookStore{}, []model.CategoryEvent{*deliveries[0].Event})
+	svc.WaitWebhookDeliveries()
+	mutex.Lock()
+	if len(received) != 1 {
+		t.Errorf("Expected the event to be delivered once, received: %+v", received)
+	}
+	mutex.Unlock()
+
+	//the retry is made once due, the last attempt makes the dead letter
+	time.Sleep(20 * time.Millisecond)
+	if _, err = svc.RetryWebhookDeliveries(ctx); err != nil {
+		t.Fatalf("Retry failed with: %v", err)
+	}
+	checkDeliveries(repository.DeliveryDead, 2)
+
+	for _, hook := range []*repository.CategoryWebhookDAO{hook, deadHook} {
+		err = svc.DeleteWebhook(ctx, catModel.ID, hook.WebhookID)
+		if err != nil {
+			t.Errorf("Delete webhook failed with: %v", err)
+		}
+	}
+	hooks, _ = svc.ListWebhooks(ctx, catModel.ID)
+	if len(hooks) != 0 {
+		t.Errorf("Expected no webhooks after delete, received: %+v", hooks)
+	}
+}
@@ -0,0 +1,180 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	awsDynamoDB "github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+//CategoryChange - A change to one category model from the table stream.  Old is nil for a new model and New is nil for a removed one
+type CategoryChange struct {
+	EventID   string
+	EventName string
+	UserID    string
+	ModelID   string
+	Old       *repository.CategoryUserModel
+	New       *repository.CategoryUserModel
+	Diffs     []model.CategoryDiff
+	//Chunked - the stream image only holds the first chunk of the model, Old, New and Diffs are empty.  The other chunks are separate items
+	//written in the same transaction, their records are skipped as they cannot be matched to this one reliably and the stream function has
+	//no table access to read them.  Handlers that need the model reload it, comparing its version with Version
+	Chunked bool
+	//Version - the model version the change wrote, 0 when the model was removed or written before versions were stored
+	Version int64
+}
+
+//CategoryChangeHandler - Called for every model change.  An error fails the batch so the stream retries it, handlers should be idempotent on EventID
+type CategoryChangeHandler func(ctx context.Context, change CategoryChange) error
+
+var handlersMutex sync.RWMutex
+var handlers = make(map[string]CategoryChangeHandler)
+
+//RegisterCategoryChangeHandler - Adds or replaces the named handler, handlers run in name order
+func RegisterCategoryChangeHandler(name string, handler CategoryChangeHandler) {
+	handlersMutex.Lock()
+	defer handlersMutex.Unlock()
+	handlers[name] = handler
+}
+
+//UnregisterCategoryChangeHandler - Removes the named handler
+func UnregisterCategoryChangeHandler(name string) {
+	handlersMutex.Lock()
+	defer handlersMutex.Unlock()
+	delete(handlers, name)
+}
+
+func registeredHandlers() []CategoryChangeHandler {
+	handlersMutex.RLock()
+	defer handlersMutex.RUnlock()
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ordered := make([]CategoryChangeHandler, 0, len(names))
+	for _, name := range names {
+		ordered = append(ordered, handlers[name])
+	}
+	return ordered
+}
+
+//LogCategoryChange - a handler that logs a summary of each change
+func LogCategoryChange(ctx context.Context, change CategoryChange) error {
+	log.Printf("Category stream %v for user: %v, model: %v, diffs: %v, chunked: %v", change.EventName, change.UserID, change.ModelID, len(change.Diffs), change.Chunked)
+	return nil
+}
+
+//imageDAO - decodes a stream image through the repository's dao, so stored fields such as ModelVersion and the title policy and limits of
+//retired encodings are read like the repository reads them.  nil if there is no image
+func imageDAO(image map[string]events.DynamoDBAttributeValue) (*repository.CategoryDAO, error) {
+	if len(image) == 0 {
+		return nil, nil
+	}
+	//stream images and the sdk's attribute values share the Dynamo JSON format
+	data, err := json.Marshal(image)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read stream image: %v", err)
+	}
+	item := map[string]*awsDynamoDB.AttributeValue{}
+	err = json.Unmarshal(data, &item)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read stream image: %v", err)
+	}
+	dao, err := repository.CategoryDAOFromItem(item)
+	if err != nil {
+		return nil, err
+	}
+	if decodeErr := dao.DecodeError(); decodeErr != nil {
+		return nil, decodeErr
+	}
+	return dao, nil
+}
+
+//categoryChange - converts a stream record, ok is false for items that are not models (chunks, outbox, quarantine)
+func categoryChange(record events.DynamoDBEventRecord) (CategoryChange, bool, error) {
+	sortKey := record.Change.Keys["CategorySortKey"]
+	if sortKey.DataType() != events.DataTypeString || !repository.IsModelSortKey(sortKey.String()) {
+		return CategoryChange{}, false, nil
+	}
+	change := CategoryChange{EventID: record.EventID, EventName: record.EventName, ModelID: sortKey.String()}
+
+	oldDAO, err := imageDAO(record.Change.OldImage)
+	if err != nil {
+		return change, true, err
+	}
+	newDAO, err := imageDAO(record.Change.NewImage)
+	if err != nil {
+		return change, true, err
+	}
+
+	var oldRoot, newRoot *model.CategoryRoot
+	if oldDAO != nil {
+		change.UserID = oldDAO.UserID
+		change.Chunked = oldDAO.ChunkCount > 0
+		if !change.Chunked {
+			change.Old = &oldDAO.CategoryUserModel
+			oldRoot = &oldDAO.CategoryRoot
+		}
+	}
+	if newDAO != nil {
+		change.UserID = newDAO.UserID
+		change.Version = newDAO.ModelVersion
+		change.Chunked = change.Chunked || newDAO.ChunkCount > 0
+		if newDAO.ChunkCount == 0 {
+			change.New = &newDAO.CategoryUserModel
+			newRoot = &newDAO.CategoryRoot
+		}
+	}
+	if !change.Chunked {
+		change.Diffs = model.DiffCategoryRoots(oldRoot, newRoot)
+	}
+	return change, true, nil
+}
+
+//HandleCategoryStream - The Lambda entry point for the category table stream.  The table must stream NEW_AND_OLD_IMAGES for diffs.
+//Records that cannot be decoded are logged and skipped so one bad item does not block the shard, handler errors fail the batch
+func HandleCategoryStream(ctx context.Context, event events.DynamoDBEvent) error {
+	registered := registeredHandlers()
+	for _, record := range event.Records {
+		change, ok, err := categoryChange(record)
+		if !ok {
+			continue
+		}
+		if err != nil {
+			log.Printf("Skipping category stream record: %v, received: %v", record.EventID, err)
+			continue
+		}
+		for _, handler := range registered {
+			err = handler(ctx, change)
+			if err != nil {
+				return fmt.Errorf("Category stream handler failed on record: %v, received: %v", record.EventID, err)
+			}
+		}
+	}
+	return nil
+}
+
+//ReplayCategoryStream - Runs a recorded stream event (the Lambda payload JSON) through the registered handlers, for local testing
+func ReplayCategoryStream(ctx context.Context, reader io.Reader) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	event := events.DynamoDBEvent{}
+	err = json.Unmarshal(data, &event)
+	if err != nil {
+		return fmt.Errorf("Unable to read recorded stream event: %v", err)
+	}
+	return HandleCategoryStream(ctx, event)
+}
@@ -0,0 +1,97 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/suared/core-apiuser/model"
+	"github.com/suared/core-apiuser/repository"
+)
+
+func TestReplayCategoryStream(t *testing.T) {
+	var changes []CategoryChange
+	RegisterCategoryChangeHandler("test", func(ctx context.Context, change CategoryChange) error {
+		changes = append(changes, change)
+		return nil
+	})
+	defer UnregisterCategoryChangeHandler("test")
+
+	file, err := os.Open("testdata/category_stream_event.json")
+	if err != nil {
+		t.Fatalf("Unable to open recorded event: %v", err)
+	}
+	defer file.Close()
+	err = ReplayCategoryStream(context.TODO(), file)
+	if err != nil {
+		t.Fatalf("Replay failed with: %v", err)
+	}
+
+	//the outbox item and the corrupt image are skipped
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 model changes, received: %+v", changes)
+	}
+	created, modified, removed := changes[0], changes[1], changes[2]
+	if created.EventName != "INSERT" || created.Old != nil || created.New.Name != "Stream Test" || created.UserID != "testuser1" || len(created.Diffs) != 2 {
+		t.Errorf("Unexpected insert change: %+v", created)
+	}
+	expected := []model.CategoryDiff{
+		{Type: model.CategoryRenamed, CategoryID: "personal", Title: "Home", OldTitle: "Personal"},
+		{Type: model.CategoryAdded, CategoryID: "meetings", ParentID: "work", Title: "Meetings"},
+	}
+	if len(modified.Diffs) != len(expected) {
+		t.Fatalf("Expected %v diffs on modify, received: %+v", len(expected), modified.Diffs)
+	}
+	for i := range expected {
+		if modified.Diffs[i] != expected[i] {
+			t.Errorf("Diff %v expected: %+v, received: %+v", i, expected[i], modified.Diffs[i])
+		}
+	}
+	if removed.EventName != "REMOVE" || removed.New != nil || removed.ModelID != "stream-model-1" || len(removed.Diffs) != 2 || removed.Diffs[0].Type != model.CategoryDeleted {
+		t.Errorf("Unexpected remove change: %+v", removed)
+	}
+
+	//handler errors fail the batch so the stream retries it
+	RegisterCategoryChangeHandler("test", func(ctx context.Context, change CategoryChange) error {
+		return errors.New("handler failed")
+	})
+	file.Seek(0, 0)
+	if err = ReplayCategoryStream(context.TODO(), file); err == nil {
+		t.Errorf("Expected handler error to fail the replay")
+	}
+}
+
+func TestCategoryChangeImage(t *testing.T) {
+	userModel := repository.NewCategoryUserModel("Versioned")
+	data, err := repository.EncodeCategoryUserModel(*userModel, repository.EncodingGzipJSON)
+	if err != nil {
+		t.Fatalf("Encode failed with: %v", err)
+	}
+	image := map[string]events.DynamoDBAttributeValue{
+		"CategoryHashKey":       events.NewStringAttribute("category_testuser1"),
+		"CategorySortKey":       events.NewStringAttribute(userModel.ID),
+		"UserID":                events.NewStringAttribute("testuser1"),
+		"CategoryUserModelData": events.NewBinaryAttribute(data),
+		"ModelVersion":          events.NewNumberAttribute("7"),
+	}
+	record := events.DynamoDBEventRecord{EventID: "1", EventName: "MODIFY", Change: events.DynamoDBStreamRecord{
+		Keys:     map[string]events.DynamoDBAttributeValue{"CategorySortKey": events.NewStringAttribute(userModel.ID)},
+		NewImage: image,
+	}}
+
+	//decoded like the repository reads the item, including the stored version
+	change, ok, err := categoryChange(record)
+	if err != nil || !ok || change.New == nil || change.New.Name != "Versioned" || change.New.Version != 7 || change.Version != 7 {
+		t.Errorf("Expected the model at version 7, received: %+v, %v", change, err)
+	}
+
+	//chunked models are reported without the partial model
+	image["ChunkCount"] = events.NewNumberAttribute("2")
+	change, _, err = categoryChange(record)
+	if err != nil || !change.Chunked || change.New != nil || change.Version != 7 {
+		t.Errorf("Expected a chunked change at version 7, received: %+v, %v", change, err)
+	}
+}
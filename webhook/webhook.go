@@ -0,0 +1,240 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+//Headers sent with every delivery
+const (
+	//SignatureHeader - "sha256=" followed by the hex HMAC-SHA256 of the body with the webhook secret
+	SignatureHeader = "X-Category-Signature"
+	//EventHeader - the event type
+	EventHeader = "X-Category-Event"
+	//DeliveryHeader - the delivery id, the same on every retry so receivers can ignore repeats
+	DeliveryHeader = "X-Category-Delivery"
+)
+
+//ErrInvalidURL - returned by ValidateURL
+var ErrInvalidURL = errors.New("Webhook url must be an absolute http or https url")
+
+//ErrBlockedAddress - returned by a delivery to a loopback, private, link local or other non public address
+var ErrBlockedAddress = errors.New("Webhook address is not public")
+
+//blockedNetworks - addresses that reach the api's own host or network vs. the internet.  IPv4 mapped IPv6 addresses are checked as IPv4
+var blockedNetworks = parseNetworks(
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12", "192.0.0.0/24", "192.168.0.0/16",
+	"198.18.0.0/15", "224.0.0.0/4", "240.0.0.0/4",
+	"::/128", "::1/128", "64:ff9b::/96", "fc00::/7", "fe80::/10", "ff00::/8",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i := range cidrs {
+		_, network, err := net.ParseCIDR(cidrs[i])
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+//publicIP - false for the blocked networks
+func publicIP(ip net.IP) bool {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		ip = ipv4
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+//NewClient - an http client for deliveries.  The address is checked when connecting, after the host name is resolved, so a name
+//resolving to a private address, at registration or later, is refused.  allowPrivate is for tests against local receivers only.
+//Redirects are not followed, proxies from the environment are not used as the proxy would make the connection
+func NewClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || (!allowPrivate && !publicIP(ip)) {
+				return fmt.Errorf("%w: %v", ErrBlockedAddress, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+//Target - where a delivery is sent
+type Target struct {
+	ID     string
+	URL    string
+	Secret string
+}
+
+//Result - the outcome of a delivery after all attempts
+type Result struct {
+	Attempts   int
+	StatusCode int
+	Err        error
+}
+
+//Delivered - true if the receiver accepted the delivery with a 2xx response
+func (result Result) Delivered() bool {
+	return result.Err == nil
+}
+
+//Deliverer - POSTs signed payloads, retrying with exponential backoff
+type Deliverer struct {
+	Client      *http.Client
+	MaxAttempts int
+	//BaseBackoff - the wait after the first failure, doubled after each further failure up to MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	//OnAttempt - optional, called after each attempt with the attempt number (1 based), status code (0 if no response) and error
+	OnAttempt func(attempt int, statusCode int, err error)
+}
+
+//NewDeliverer - defaults suitable for the web api, deliveries are only made to public addresses, see NewClient
+func NewDeliverer(maxAttempts int, baseBackoff time.Duration) *Deliverer {
+	return &Deliverer{
+		Client:      NewClient(false),
+		MaxAttempts: maxAttempts,
+		BaseBackoff: baseBackoff,
+		MaxBackoff:  5 * time.Minute,
+	}
+}
+
+//Sign - returns the signature header value for the body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+//Verify - checks a signature header value in constant time, for receivers
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+//NewSecret - a random signing secret
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+//ValidateURL - webhook urls must be absolute http(s) urls
+func ValidateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, rawURL)
+	}
+	return nil
+}
+
+//backoff - the wait before the given attempt (2 based)
+func (deliverer *Deliverer) backoff(attempt int) time.Duration {
+	wait := deliverer.BaseBackoff
+	for i := 2; i < attempt && wait < deliverer.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if deliverer.MaxBackoff > 0 && wait > deliverer.MaxBackoff {
+		wait = deliverer.MaxBackoff
+	}
+	return wait
+}
+
+//RetryAfter - the wait after the given failed attempt (1 based), for callers that schedule retries themselves with Attempt
+func (deliverer *Deliverer) RetryAfter(attempt int) time.Duration {
+	return deliverer.backoff(attempt + 1)
+}
+
+//Attempt - POSTs the body once, returns the status code (0 if no response) and an error unless the receiver returned 2xx
+func (deliverer *Deliverer) Attempt(ctx context.Context, target Target, eventType string, deliveryID string, body []byte) (int, error) {
+	return deliverer.post(ctx, target, eventType, deliveryID, body)
+}
+
+//Deliver - POSTs the body until the receiver returns 2xx, MaxAttempts is reached or ctx is done
+func (deliverer *Deliverer) Deliver(ctx context.Context, target Target, eventType string, deliveryID string, body []byte) Result {
+	result := Result{}
+	for attempt := 1; attempt <= deliverer.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+				return result
+			case <-time.After(deliverer.backoff(attempt)):
+			}
+		}
+		result.Attempts = attempt
+		result.StatusCode, result.Err = deliverer.post(ctx, target, eventType, deliveryID, body)
+		if deliverer.OnAttempt != nil {
+			deliverer.OnAttempt(attempt, result.StatusCode, result.Err)
+		}
+		if result.Err == nil {
+			return result
+		}
+	}
+	return result
+}
+
+func (deliverer *Deliverer) post(ctx context.Context, target Target, eventType string, deliveryID string, body []byte) (int, error) {
+	request, err := http.NewRequest(http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SignatureHeader, Sign(target.Secret, body))
+	request.Header.Set(EventHeader, eventType)
+	request.Header.Set(DeliveryHeader, deliveryID)
+
+	response, err := deliverer.Client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	//drain so the connection can be reused
+	io.Copy(ioutil.Discard, io.LimitReader(response.Body, 64*1024))
+	response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("Webhook receiver returned %v", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
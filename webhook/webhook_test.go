@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeliver(t *testing.T) {
+	var mutex sync.Mutex
+	var calls int
+	var failFirst int
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mutex.Lock()
+		calls++
+		call := calls
+		mutex.Unlock()
+		if !Verify("secret1", body, r.Header.Get(SignatureHeader)) || r.Header.Get(DeliveryHeader) != "delivery1" || r.Header.Get(EventHeader) != "CategoryAdded" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if call <= failFirst {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	deliverer := NewDeliverer(3, time.Millisecond)
+	deliverer.Client = NewClient(true)
+	var attempts []int
+	deliverer.OnAttempt = func(attempt int, statusCode int, err error) {
+		attempts = append(attempts, statusCode)
+	}
+	target := Target{ID: "webhook1", URL: receiver.URL, Secret: "secret1"}
+	body := []byte(`{"type":"CategoryAdded"}`)
+
+	//retried until accepted
+	failFirst = 2
+	result := deliverer.Deliver(context.TODO(), target, "CategoryAdded", "delivery1", body)
+	if !result.Delivered() || result.Attempts != 3 || result.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected delivery on the third attempt, received: %+v", result)
+	}
+	if len(attempts) != 3 || attempts[0] != http.StatusInternalServerError {
+		t.Errorf("Unexpected attempts: %v", attempts)
+	}
+
+	//gives up after MaxAttempts
+	calls, failFirst = 0, 10
+	result = deliverer.Deliver(context.TODO(), target, "CategoryAdded", "delivery1", body)
+	if result.Delivered() || result.Attempts != 3 || calls != 3 {
+		t.Errorf("Expected failure after 3 attempts, received: %+v, calls: %v", result, calls)
+	}
+
+	//a wrong secret is rejected by the receiver
+	calls, failFirst = 0, 0
+	target.Secret = "wrong"
+	result = deliverer.Deliver(context.TODO(), target, "CategoryAdded", "delivery1", body)
+	if result.Delivered() || result.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected signature rejection, received: %+v", result)
+	}
+}
+
+func TestBlockedAddress(t *testing.T) {
+	var calls int
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer receiver.Close()
+	_, port, _ := net.SplitHostPort(receiver.Listener.Addr().String())
+
+	//the address is checked after the name is resolved
+	deliverer := NewDeliverer(1, time.Millisecond)
+	for _, rawURL := range []string{receiver.URL, "http://localhost:" + port} {
+		result := deliverer.Deliver(context.TODO(), Target{ID: "webhook1", URL: rawURL, Secret: "secret1"}, "CategoryAdded", "delivery1", []byte(`{}`))
+		if !errors.Is(result.Err, ErrBlockedAddress) || calls != 0 {
+			t.Errorf("Expected %v to be blocked, received: %+v, calls: %v", rawURL, result, calls)
+		}
+	}
+
+	for address, public := range map[string]bool{
+		"8.8.8.8": true, "2001:4860:4860::8888": true,
+		"127.0.0.1": false, "10.1.2.3": false, "172.16.0.1": false, "192.168.1.1": false, "169.254.169.254": false, "100.64.0.1": false,
+		"0.0.0.0": false, "::1": false, "::": false, "fe80::1": false, "fd00::1": false, "::ffff:127.0.0.1": false, "::ffff:10.0.0.1": false,
+	} {
+		if publicIP(net.ParseIP(address)) != public {
+			t.Errorf("Expected %v public: %v", address, public)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	deliverer := &Deliverer{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i := range expected {
+		if wait := deliverer.backoff(i + 2); wait != expected[i] {
+			t.Errorf("Attempt %v expected wait %v, received: %v", i+2, expected[i], wait)
+		}
+		if wait := deliverer.RetryAfter(i + 1); wait != expected[i] {
+			t.Errorf("Retry after attempt %v expected wait %v, received: %v", i+1, expected[i], wait)
+		}
+	}
+	if ValidateURL("ftp://example.com") == nil || ValidateURL("/relative") == nil || ValidateURL("https://example.com/hook") != nil {
+		t.Errorf("Unexpected url validation")
+	}
+}